@@ -0,0 +1,43 @@
+package archive
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalStore(t *testing.T) {
+	dir := t.TempDir()
+	store := NewLocalStore(dir)
+	ctx := context.Background()
+	key := "date=2024-05-01/source=AWS/events-1-2.ndjson.gz"
+
+	t.Run("Put and get", func(t *testing.T) {
+		require.NoError(t, store.Put(ctx, key, []byte("first")))
+		require.NoError(t, store.Put(ctx, key, []byte("second")))
+
+		data, err := store.Get(ctx, key)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("second"), data)
+
+		// No temporary file is left behind
+		entries, err := os.ReadDir(filepath.Join(dir, "date=2024-05-01", "source=AWS"))
+		require.NoError(t, err)
+		assert.Len(t, entries, 1)
+	})
+
+	t.Run("Missing key", func(t *testing.T) {
+		_, err := store.Get(ctx, "missing.ndjson.gz")
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("Key outside of the directory", func(t *testing.T) {
+		assert.ErrorContains(t, store.Put(ctx, "../escape", []byte("data")), "invalid archive key")
+		_, err := store.Get(ctx, "/etc/passwd")
+		assert.ErrorContains(t, err, "invalid archive key")
+	})
+}
@@ -0,0 +1,103 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"path"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// S3Config is S3 archive store related configuration
+type S3Config struct {
+	Bucket string
+	Prefix string // prefix of the object keys, e.g. events/
+	Region string
+
+	// Overrides for S3-compatible services such as MinIO
+	Endpoint        string // custom endpoint of the S3 API, the AWS endpoint of the region if empty
+	AccessKeyID     string // static credentials, the default AWS credential chain if empty
+	SecretAccessKey string
+	SessionToken    string
+	PathStyle       bool // addresses buckets as <endpoint>/<bucket> rather than <bucket>.<endpoint>
+}
+
+// S3API is the subset of the S3 client used by S3Store
+type S3API interface {
+	PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+}
+
+// S3Store is an implementation of the domain.ArchiveStore interface backed by an S3 bucket
+type S3Store struct {
+	client S3API
+	conf   S3Config
+}
+
+// NewS3Store loads the AWS configuration and creates a new S3Store instance
+func NewS3Store(ctx context.Context, conf S3Config) (*S3Store, error) {
+	opts := []func(*config.LoadOptions) error{
+		config.WithDefaultRegion(conf.Region),
+	}
+	if conf.AccessKeyID != "" {
+		opts = append(opts, config.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(conf.AccessKeyID, conf.SecretAccessKey, conf.SessionToken),
+		))
+	}
+	awsCfg, err := config.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	client := s3.NewFromConfig(awsCfg, func(o *s3.Options) {
+		if conf.Endpoint != "" {
+			o.BaseEndpoint = aws.String(conf.Endpoint)
+		}
+		o.UsePathStyle = conf.PathStyle
+	})
+	return NewS3StoreWithClient(client, conf), nil
+}
+
+// NewS3StoreWithClient creates a new S3Store instance on top of the given S3 client
+func NewS3StoreWithClient(client S3API, conf S3Config) *S3Store {
+	return &S3Store{
+		client: client,
+		conf:   conf,
+	}
+}
+
+// Put uploads the data as the object of the key
+func (s *S3Store) Put(ctx context.Context, key string, data []byte) error {
+	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:        aws.String(s.conf.Bucket),
+		Key:           aws.String(s.objectKey(key)),
+		Body:          bytes.NewReader(data),
+		ContentLength: aws.Int64(int64(len(data))),
+	})
+	return err
+}
+
+// Get downloads the object of the key
+func (s *S3Store) Get(ctx context.Context, key string) ([]byte, error) {
+	output, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.conf.Bucket),
+		Key:    aws.String(s.objectKey(key)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer output.Body.Close()
+	return io.ReadAll(output.Body)
+}
+
+// objectKey returns the key of the object of the archive key, under the configured prefix
+func (s *S3Store) objectKey(key string) string {
+	if s.conf.Prefix == "" {
+		return key
+	}
+	return path.Join(s.conf.Prefix, key)
+}
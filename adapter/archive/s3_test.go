@@ -0,0 +1,52 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeS3Client keeps the uploaded objects in memory by bucket and key
+type fakeS3Client struct {
+	objects map[string][]byte
+}
+
+func (f *fakeS3Client) PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	data, err := io.ReadAll(params.Body)
+	if err != nil {
+		return nil, err
+	}
+	f.objects[*params.Bucket+"/"+*params.Key] = data
+	return &s3.PutObjectOutput{}, nil
+}
+
+func (f *fakeS3Client) GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	data, ok := f.objects[*params.Bucket+"/"+*params.Key]
+	if !ok {
+		return nil, &types.NoSuchKey{}
+	}
+	return &s3.GetObjectOutput{Body: io.NopCloser(bytes.NewReader(data))}, nil
+}
+
+func TestS3Store(t *testing.T) {
+	client := &fakeS3Client{objects: map[string][]byte{}}
+	store := NewS3StoreWithClient(client, S3Config{Bucket: "archives", Prefix: "events/"})
+	ctx := context.Background()
+
+	require.NoError(t, store.Put(ctx, "date=2024-05-01/source=AWS/events-1-2.ndjson.gz", []byte("data")))
+	assert.Contains(t, client.objects, "archives/events/date=2024-05-01/source=AWS/events-1-2.ndjson.gz")
+
+	data, err := store.Get(ctx, "date=2024-05-01/source=AWS/events-1-2.ndjson.gz")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("data"), data)
+
+	var noSuchKey *types.NoSuchKey
+	_, err = store.Get(ctx, "missing")
+	assert.ErrorAs(t, err, &noSuchKey)
+}
@@ -0,0 +1,138 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HTTPError is returned when a notification service rejects a request
+type HTTPError struct {
+	Service    string
+	StatusCode int
+	Body       string
+	RetryAfter time.Duration // delay requested by the service before retrying a rate limited request
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("%s responded %d: %s", e.Service, e.StatusCode, e.Body)
+}
+
+// Retryable reports whether the request may be accepted later, i.e. it was rate limited or the service failed
+func (e *HTTPError) Retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= http.StatusInternalServerError
+}
+
+// postJSON posts the JSON body to the URL of the service once, with the additional headers
+func postJSON(ctx context.Context, client *http.Client, service, url string, header http.Header, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if resp.StatusCode/100 == 2 {
+		return nil
+	}
+
+	httpErr := &HTTPError{Service: service, StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(respBody))}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 && resp.StatusCode == http.StatusTooManyRequests {
+		httpErr.RetryAfter = time.Duration(seconds) * time.Second
+	}
+	return httpErr
+}
+
+// retrier retries the failed requests to a notification service with an exponential backoff, and the rate
+// limited ones after the delay requested by the service, during which the other requests wait as well.
+// Errors with a Retryable method reporting false are not retried.
+type retrier struct {
+	maxRetries int           // maximum number of retries of a failed request
+	retryDelay time.Duration // delay before the first retry, doubled for each further retry
+
+	mu      sync.Mutex
+	retryAt time.Time // end of the rate limit requested by the service
+}
+
+// do runs the request until it succeeds, fails for good or the retries are exhausted
+func (r *retrier) do(ctx context.Context, request func(ctx context.Context) error) error {
+	for attempt := 0; ; attempt++ {
+		if err := r.waitRateLimit(ctx); err != nil {
+			return err
+		}
+		err := request(ctx)
+		if err == nil {
+			return nil
+		}
+
+		var retryable interface{ Retryable() bool }
+		if errors.As(err, &retryable) && !retryable.Retryable() {
+			return err
+		}
+		if attempt >= r.maxRetries {
+			return fmt.Errorf("giving up after %d attempt(s): %w", attempt+1, err)
+		}
+
+		delay := r.backoff(attempt)
+		var httpErr *HTTPError
+		if errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusTooManyRequests {
+			delay = r.retryDelay
+			if httpErr.RetryAfter > 0 {
+				delay = httpErr.RetryAfter
+			}
+			r.setRetryAt(time.Now().Add(delay))
+		}
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// backoff returns the delay before the given retry, doubling the retry delay for each previous retry
+func (r *retrier) backoff(retry int) time.Duration {
+	backoff := float64(r.retryDelay) * math.Pow(2, float64(retry))
+	return time.Duration(min(backoff, math.MaxInt64))
+}
+
+// setRetryAt holds back the requests to the service until the given time
+func (r *retrier) setRetryAt(retryAt time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if retryAt.After(r.retryAt) {
+		r.retryAt = retryAt
+	}
+}
+
+// waitRateLimit waits for the end of the rate limit of the service, if any
+func (r *retrier) waitRateLimit(ctx context.Context) error {
+	r.mu.Lock()
+	delay := time.Until(r.retryAt)
+	r.mu.Unlock()
+	if delay <= 0 {
+		return nil
+	}
+	select {
+	case <-time.After(delay):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,347 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+
+	"github.com/cvzm/go-web-project/domain"
+)
+
+// Names of the email templates, text ones defining the subject as "subject"
+const (
+	eventTextTemplate  = "event.txt.tmpl"
+	eventHTMLTemplate  = "event.html.tmpl"
+	digestTextTemplate = "digest.txt.tmpl"
+	digestHTMLTemplate = "digest.html.tmpl"
+)
+
+//go:embed templates/*.tmpl
+var defaultEmailTemplates embed.FS
+
+// emailTemplateFuncs are the functions available to the email templates
+var emailTemplateFuncs = map[string]any{
+	"join": strings.Join,
+	"title": func(s string) string {
+		runes := []rune(s)
+		if len(runes) > 0 {
+			runes[0] = unicode.ToUpper(runes[0])
+		}
+		return string(runes)
+	},
+}
+
+// SMTPConfig is SMTP server related configuration
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string // no authentication if empty
+	Password string
+	StartTLS bool          // require upgrading the connection with STARTTLS
+	Timeout  time.Duration // timeout of a whole SMTP session, none if zero
+}
+
+// EmailConfig is email channel related configuration
+type EmailConfig struct {
+	Name         string
+	SMTP         SMTPConfig
+	From         string
+	To           []string
+	TemplatesDir string        // directory of templates replacing the default ones of the same name, if set
+	MaxRetries   int           // maximum number of retries of a failed delivery
+	RetryDelay   time.Duration // delay before the first retry, doubled for each further retry
+}
+
+// emailTemplate is a pair of text and HTML templates of an email, the subject being defined by the text one
+type emailTemplate struct {
+	text *template.Template
+	html *htmltemplate.Template
+}
+
+// EmailChannel is an implementation of the domain.NotificationChannel and domain.DigestSender interfaces sending
+// multipart emails, with a plain text and an HTML part, over SMTP. Deliveries failing with a transient error are
+// retried with an exponential backoff.
+type EmailChannel struct {
+	conf    EmailConfig
+	from    *mail.Address
+	to      []*mail.Address
+	event   emailTemplate
+	digest  emailTemplate
+	retrier *retrier
+}
+
+// NewEmailChannel creates a new EmailChannel instance, loading its templates
+func NewEmailChannel(conf EmailConfig) (*EmailChannel, error) {
+	from, err := mail.ParseAddress(conf.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sender address %q: %w", conf.From, err)
+	}
+	to := make([]*mail.Address, 0, len(conf.To))
+	for _, recipient := range conf.To {
+		address, err := mail.ParseAddress(recipient)
+		if err != nil {
+			return nil, fmt.Errorf("invalid recipient address %q: %w", recipient, err)
+		}
+		to = append(to, address)
+	}
+	event, err := loadEmailTemplate(conf.TemplatesDir, eventTextTemplate, eventHTMLTemplate)
+	if err != nil {
+		return nil, err
+	}
+	digest, err := loadEmailTemplate(conf.TemplatesDir, digestTextTemplate, digestHTMLTemplate)
+	if err != nil {
+		return nil, err
+	}
+	return &EmailChannel{
+		conf:    conf,
+		from:    from,
+		to:      to,
+		event:   event,
+		digest:  digest,
+		retrier: &retrier{maxRetries: conf.MaxRetries, retryDelay: conf.RetryDelay},
+	}, nil
+}
+
+// Name returns the name of the channel
+func (c *EmailChannel) Name() string {
+	return c.conf.Name
+}
+
+// Send emails the notification of the event
+func (c *EmailChannel) Send(ctx context.Context, event domain.Event) error {
+	return c.send(ctx, c.event, event)
+}
+
+// SendDigest emails the digest
+func (c *EmailChannel) SendDigest(ctx context.Context, digest domain.Digest) error {
+	return c.send(ctx, c.digest, digest)
+}
+
+// message returns the email of the template executed with the data, with the given date and message ID
+func (c *EmailChannel) message(tmpl emailTemplate, data any, date time.Time, messageID string) ([]byte, error) {
+	var subject, text, html bytes.Buffer
+	if err := tmpl.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return nil, fmt.Errorf("executing email subject template: %w", err)
+	}
+	if err := tmpl.text.Execute(&text, data); err != nil {
+		return nil, fmt.Errorf("executing email text template: %w", err)
+	}
+	if err := tmpl.html.Execute(&html, data); err != nil {
+		return nil, fmt.Errorf("executing email HTML template: %w", err)
+	}
+
+	var message bytes.Buffer
+	parts := multipart.NewWriter(&message)
+	header := textproto.MIMEHeader{}
+	recipients := make([]string, 0, len(c.to))
+	for _, address := range c.to {
+		recipients = append(recipients, address.String())
+	}
+	header.Set("From", c.from.String())
+	header.Set("To", strings.Join(recipients, ", "))
+	header.Set("Subject", mime.QEncoding.Encode("utf-8", strings.TrimSpace(subject.String())))
+	header.Set("Date", date.Format(time.RFC1123Z))
+	header.Set("Message-ID", messageID)
+	header.Set("MIME-Version", "1.0")
+	header.Set("Content-Type", "multipart/alternative; boundary="+parts.Boundary())
+	for _, name := range []string{"From", "To", "Subject", "Date", "Message-ID", "MIME-Version", "Content-Type"} {
+		fmt.Fprintf(&message, "%s: %s\r\n", name, header.Get(name))
+	}
+	message.WriteString("\r\n")
+
+	for _, part := range []struct {
+		contentType string
+		body        []byte
+	}{{"text/plain", text.Bytes()}, {"text/html", html.Bytes()}} {
+		writer, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType + "; charset=utf-8"},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		encoder := quotedprintable.NewWriter(writer)
+		if _, err := encoder.Write(part.body); err != nil {
+			return nil, err
+		}
+		if err := encoder.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+	return message.Bytes(), nil
+}
+
+// send emails the template executed with the data, retrying transient failures
+func (c *EmailChannel) send(ctx context.Context, tmpl emailTemplate, data any) error {
+	messageID, err := c.messageID()
+	if err != nil {
+		return err
+	}
+	message, err := c.message(tmpl, data, time.Now(), messageID)
+	if err != nil {
+		return err
+	}
+	return c.retrier.do(ctx, func(ctx context.Context) error {
+		return c.deliver(ctx, message)
+	})
+}
+
+// deliver sends the message in an SMTP session, upgraded with STARTTLS and authenticated as configured
+func (c *EmailChannel) deliver(ctx context.Context, message []byte) error {
+	if c.conf.SMTP.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.conf.SMTP.Timeout)
+		defer cancel()
+	}
+	dialer := net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(c.conf.SMTP.Host, strconv.Itoa(c.conf.SMTP.Port)))
+	if err != nil {
+		return err
+	}
+	// The SMTP client has no context support, closing the connection interrupts it
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	client, err := smtp.NewClient(conn, c.conf.SMTP.Host)
+	if err != nil {
+		conn.Close()
+		return smtpError(err)
+	}
+	defer client.Close()
+
+	if c.conf.SMTP.StartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return &SMTPError{Code: 0, Message: "server does not support STARTTLS"}
+		}
+		if err := client.StartTLS(&tls.Config{ServerName: c.conf.SMTP.Host}); err != nil {
+			return smtpError(err)
+		}
+	}
+	if c.conf.SMTP.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", c.conf.SMTP.Username, c.conf.SMTP.Password, c.conf.SMTP.Host)); err != nil {
+			return smtpError(err)
+		}
+	}
+	if err := client.Mail(c.from.Address); err != nil {
+		return smtpError(err)
+	}
+	for _, to := range c.to {
+		if err := client.Rcpt(to.Address); err != nil {
+			return smtpError(err)
+		}
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return smtpError(err)
+	}
+	if _, err := writer.Write(message); err != nil {
+		return smtpError(err)
+	}
+	if err := writer.Close(); err != nil {
+		return smtpError(err)
+	}
+	// The message is accepted, a failing QUIT must not have it sent again
+	client.Quit()
+	return nil
+}
+
+// messageID returns a unique message ID in the domain of the sender address
+func (c *EmailChannel) messageID() (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	host := c.from.Address[strings.LastIndex(c.from.Address, "@")+1:]
+	return fmt.Sprintf("<%s@%s>", hex.EncodeToString(random), host), nil
+}
+
+// SMTPError is returned when the SMTP server rejects a command
+type SMTPError struct {
+	Code    int // SMTP reply code, 0 if the server lacks a required extension
+	Message string
+}
+
+func (e *SMTPError) Error() string {
+	if e.Code == 0 {
+		return "smtp: " + e.Message
+	}
+	return fmt.Sprintf("smtp server responded %d: %s", e.Code, e.Message)
+}
+
+// Retryable reports whether the command may be accepted later, i.e. the server replied with a transient error
+func (e *SMTPError) Retryable() bool {
+	return e.Code/100 == 4
+}
+
+// smtpError converts the replies of the SMTP server to SMTPError, returning the other errors as is
+func smtpError(err error) error {
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) {
+		return &SMTPError{Code: protoErr.Code, Message: protoErr.Msg}
+	}
+	return err
+}
+
+// loadEmailTemplate parses the text and HTML templates of the given names, from the templates directory if present
+// there, and from the default ones otherwise
+func loadEmailTemplate(dir, textName, htmlName string) (emailTemplate, error) {
+	read := func(name string) (string, error) {
+		if dir != "" {
+			data, err := os.ReadFile(filepath.Join(dir, name))
+			if err == nil {
+				return string(data), nil
+			}
+			if !errors.Is(err, fs.ErrNotExist) {
+				return "", err
+			}
+		}
+		data, err := defaultEmailTemplates.ReadFile("templates/" + name)
+		return string(data), err
+	}
+
+	text, err := read(textName)
+	if err != nil {
+		return emailTemplate{}, err
+	}
+	textTmpl, err := template.New(textName).Funcs(emailTemplateFuncs).Parse(text)
+	if err != nil {
+		return emailTemplate{}, fmt.Errorf("invalid email template %s: %w", textName, err)
+	}
+	if textTmpl.Lookup("subject") == nil {
+		return emailTemplate{}, fmt.Errorf("invalid email template %s: no subject template defined", textName)
+	}
+
+	html, err := read(htmlName)
+	if err != nil {
+		return emailTemplate{}, err
+	}
+	htmlTmpl, err := htmltemplate.New(htmlName).Funcs(emailTemplateFuncs).Parse(html)
+	if err != nil {
+		return emailTemplate{}, fmt.Errorf("invalid email template %s: %w", htmlName, err)
+	}
+	return emailTemplate{text: textTmpl, html: htmlTmpl}, nil
+}
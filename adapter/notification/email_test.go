@@ -0,0 +1,290 @@
+package notification
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cvzm/go-web-project/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// smtpSink is a local SMTP server recording the received messages
+type smtpSink struct {
+	listener    net.Listener
+	mailReplies []string // replies to the MAIL commands in turn, then 250
+
+	mu       sync.Mutex
+	mails    int
+	messages []string
+	rcpts    []string
+}
+
+// newSMTPSink starts an SMTP sink replying to the MAIL commands with the given replies in turn
+func newSMTPSink(t *testing.T, mailReplies ...string) *smtpSink {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	sink := &smtpSink{listener: listener, mailReplies: mailReplies}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go sink.serve(conn)
+		}
+	}()
+	return sink
+}
+
+func (s *smtpSink) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *smtpSink) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+	reply("220 localhost ESMTP sink")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(strings.Fields(line + " x")[0])
+		switch command {
+		case "EHLO", "HELO":
+			reply("250-localhost")
+			reply("250 8BITMIME")
+		case "MAIL":
+			s.mu.Lock()
+			s.mails++
+			response := "250 OK"
+			if s.mails <= len(s.mailReplies) {
+				response = s.mailReplies[s.mails-1]
+			}
+			s.mu.Unlock()
+			reply(response)
+		case "RCPT":
+			s.mu.Lock()
+			s.rcpts = append(s.rcpts, strings.TrimSpace(line))
+			s.mu.Unlock()
+			reply("250 OK")
+		case "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				line, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(line, "."))
+			}
+			s.mu.Lock()
+			s.messages = append(s.messages, data.String())
+			s.mu.Unlock()
+			reply("250 OK queued")
+		case "RSET", "NOOP":
+			reply("250 OK")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+func (s *smtpSink) received() ([]string, []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string{}, s.messages...), append([]string{}, s.rcpts...)
+}
+
+func newTestEmailChannel(t *testing.T, port int, templatesDir string) *EmailChannel {
+	t.Helper()
+	channel, err := NewEmailChannel(EmailConfig{
+		Name:         "email",
+		SMTP:         SMTPConfig{Host: "127.0.0.1", Port: port, Timeout: 5 * time.Second},
+		From:         "Cloud events <events@example.com>",
+		To:           []string{"ops@example.com", "oncall@example.com"},
+		TemplatesDir: templatesDir,
+		MaxRetries:   2,
+		RetryDelay:   time.Millisecond,
+	})
+	require.NoError(t, err)
+	return channel
+}
+
+// parseEmail parses the message, returning its headers and its text and HTML parts
+func parseEmail(t *testing.T, raw string) (mail.Header, string, string) {
+	t.Helper()
+	message, err := mail.ReadMessage(strings.NewReader(raw))
+	require.NoError(t, err)
+	mediaType, params, err := mime.ParseMediaType(message.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	require.Equal(t, "multipart/alternative", mediaType)
+
+	parts := map[string]string{}
+	reader := multipart.NewReader(message.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		body, err := io.ReadAll(part)
+		require.NoError(t, err)
+		contentType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		parts[contentType] = string(body)
+	}
+	return message.Header, parts["text/plain"], parts["text/html"]
+}
+
+func TestEmailChannel_Send(t *testing.T) {
+	t.Run("Sends a multipart email to the recipients", func(t *testing.T) {
+		sink := newSMTPSink(t)
+		channel := newTestEmailChannel(t, sink.port(), "")
+
+		require.NoError(t, channel.Send(context.Background(), testEvent))
+
+		messages, rcpts := sink.received()
+		require.Len(t, messages, 1)
+		assert.Equal(t, []string{"RCPT TO:<ops@example.com>", "RCPT TO:<oncall@example.com>"}, rcpts)
+		header, text, html := parseEmail(t, messages[0])
+		assert.Equal(t, "[AWS] EC2_STOPPED on i-1 and 1 more", header.Get("Subject"))
+		assert.Equal(t, `"Cloud events" <events@example.com>`, header.Get("From"))
+		assert.Equal(t, "<ops@example.com>, <oncall@example.com>", header.Get("To"))
+		assert.True(t, strings.HasSuffix(header.Get("Message-ID"), "@example.com>"))
+		assert.Contains(t, text, "Instance stopped")
+		assert.Contains(t, text, "Severity:  error")
+		assert.Contains(t, text, "Resources: i-1, i-2")
+		assert.Contains(t, text, "Event:     #42")
+		assert.Contains(t, html, "<h2>AWS EC2_STOPPED</h2>")
+	})
+
+	t.Run("Escapes the HTML part", func(t *testing.T) {
+		sink := newSMTPSink(t)
+		channel := newTestEmailChannel(t, sink.port(), "")
+		event := testEvent
+		event.Description = "<script>alert(1)</script>"
+
+		require.NoError(t, channel.Send(context.Background(), event))
+
+		messages, _ := sink.received()
+		_, _, html := parseEmail(t, messages[0])
+		assert.NotContains(t, html, "<script>")
+		assert.Contains(t, html, "&lt;script&gt;")
+	})
+
+	t.Run("Retries transient failures", func(t *testing.T) {
+		sink := newSMTPSink(t, "451 Try again later")
+		channel := newTestEmailChannel(t, sink.port(), "")
+
+		require.NoError(t, channel.Send(context.Background(), testEvent))
+
+		messages, _ := sink.received()
+		assert.Len(t, messages, 1)
+	})
+
+	t.Run("Gives up on permanent failures", func(t *testing.T) {
+		sink := newSMTPSink(t, "550 Sender rejected", "250 OK")
+		channel := newTestEmailChannel(t, sink.port(), "")
+
+		err := channel.Send(context.Background(), testEvent)
+
+		var smtpErr *SMTPError
+		require.ErrorAs(t, err, &smtpErr)
+		assert.Equal(t, 550, smtpErr.Code)
+		assert.False(t, smtpErr.Retryable())
+		messages, _ := sink.received()
+		assert.Empty(t, messages)
+	})
+
+	t.Run("Requires STARTTLS when configured", func(t *testing.T) {
+		sink := newSMTPSink(t)
+		channel := newTestEmailChannel(t, sink.port(), "")
+		channel.conf.SMTP.StartTLS = true
+
+		err := channel.Send(context.Background(), testEvent)
+		assert.EqualError(t, err, "smtp: server does not support STARTTLS")
+	})
+}
+
+func TestEmailChannel_SendDigest(t *testing.T) {
+	sink := newSMTPSink(t)
+	channel := newTestEmailChannel(t, sink.port(), "")
+	from := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	digest := domain.Digest{
+		Period: domain.DigestHourly,
+		From:   from,
+		To:     from.Add(time.Hour),
+		Total:  5,
+		Sources: []domain.DigestSource{{
+			Source: domain.SourceAWS,
+			Total:  5,
+			Counts: []domain.EventCount{
+				{Source: domain.SourceAWS, EventType: "EC2_STARTED", Count: 3},
+				{Source: domain.SourceAWS, EventType: "EC2_STOPPED", Count: 2},
+			},
+			Notable: []domain.Event{testEvent},
+		}},
+	}
+
+	require.NoError(t, channel.SendDigest(context.Background(), digest))
+
+	messages, _ := sink.received()
+	require.Len(t, messages, 1)
+	header, text, html := parseEmail(t, messages[0])
+	assert.Equal(t, "Hourly event digest: 5 event(s) from 2024-05-01 12:00 UTC", header.Get("Subject"))
+	assert.Contains(t, text, "AWS: 5 event(s)")
+	assert.Contains(t, text, "  EC2_STARTED: 3\r\n")
+	assert.Contains(t, text, "  - #42 EC2_STOPPED on i-1, i-2 at 12:00:00: Instance stopped")
+	assert.Contains(t, html, "<h3>AWS: 5 event(s)</h3>")
+}
+
+func TestNewEmailChannel(t *testing.T) {
+	t.Run("Templates directory overrides the default templates", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, eventTextTemplate),
+			[]byte(`{{define "subject"}}ALERT {{.EventType}}{{end}}{{.Description}}`), 0o600))
+		sink := newSMTPSink(t)
+		channel := newTestEmailChannel(t, sink.port(), dir)
+
+		require.NoError(t, channel.Send(context.Background(), testEvent))
+
+		messages, _ := sink.received()
+		header, text, html := parseEmail(t, messages[0])
+		assert.Equal(t, "ALERT EC2_STOPPED", header.Get("Subject"))
+		assert.Equal(t, "Instance stopped", text)
+		assert.Contains(t, html, "<h2>AWS EC2_STOPPED</h2>")
+	})
+
+	t.Run("Template without subject", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, digestTextTemplate), []byte(`{{.Total}}`), 0o600))
+		_, err := NewEmailChannel(EmailConfig{From: "events@example.com", TemplatesDir: dir})
+		assert.EqualError(t, err, "invalid email template digest.txt.tmpl: no subject template defined")
+	})
+
+	t.Run("Invalid address", func(t *testing.T) {
+		_, err := NewEmailChannel(EmailConfig{From: "events@example.com", To: []string{"ops"}})
+		assert.ErrorContains(t, err, `invalid recipient address "ops"`)
+	})
+}
@@ -0,0 +1,99 @@
+package notification
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cvzm/go-web-project/domain"
+)
+
+// maxIncidentKeyLength is the maximum length of a deduplication key, the PagerDuty limit being the lowest
+const maxIncidentKeyLength = 255
+
+// IncidentConfig is incident management channel related configuration
+type IncidentConfig struct {
+	Name       string
+	BaseURL    string            // base URL of the API, that of the service if empty
+	Key        string            // PagerDuty integration key or Opsgenie API key
+	Severity   string            // PagerDuty severity or Opsgenie priority of the incidents of events without severity
+	Recoveries map[string]string // event types resolving incidents, to the event type of the incidents they resolve
+	MaxRetries int               // maximum number of retries of a failed request
+	RetryDelay time.Duration     // delay before the first retry, doubled for each further retry
+}
+
+// incidentAction is the triggering or the resolution of the incident of an event on a resource
+type incidentAction struct {
+	Resolve  bool
+	Key      string // deduplication key of the incident
+	Resource string // affected resource, empty if the event has none
+}
+
+// actions returns the incident actions of the event: the triggering of an incident per affected resource,
+// or the resolution of those of the triggering event type for a recovery event
+func (c IncidentConfig) actions(event domain.Event) []incidentAction {
+	eventType, resolve := c.Recoveries[event.EventType]
+	if !resolve {
+		eventType = event.EventType
+	}
+	resources := []string(event.AffectedResources)
+	if len(resources) == 0 {
+		resources = []string{""}
+	}
+
+	actions := make([]incidentAction, 0, len(resources))
+	for _, resource := range resources {
+		actions = append(actions, incidentAction{
+			Resolve:  resolve,
+			Key:      incidentKey(event.Source, eventType, resource),
+			Resource: resource,
+		})
+	}
+	return actions
+}
+
+// incidentKey returns the deduplication key of the incidents of the event type on the resource,
+// hashed when too long for the services
+func incidentKey(source domain.EventSource, eventType, resource string) string {
+	key := fmt.Sprintf("%s/%s/%s", source, eventType, resource)
+	if len(key) <= maxIncidentKeyLength {
+		return key
+	}
+	sum := sha256.Sum256([]byte(key))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// incidentSummary returns the summary of the incident of the event on the resource, truncated to the maximum length
+func incidentSummary(event domain.Event, resource string, maxLength int) string {
+	summary := fmt.Sprintf("%s %s", event.Source, event.EventType)
+	if resource != "" {
+		summary += " on " + resource
+	}
+	if event.Description != "" {
+		summary += ": " + event.Description
+	}
+	return truncate(summary, maxLength)
+}
+
+// truncate truncates the text to the maximum number of characters, ending it with an ellipsis
+func truncate(text string, maxLength int) string {
+	runes := []rune(text)
+	if len(runes) <= maxLength {
+		return text
+	}
+	return string(append(runes[:maxLength-3], []rune("...")...))
+}
+
+// sendActions sends the incident actions of the event, carrying on after a failed action
+func sendActions(ctx context.Context, conf IncidentConfig, event domain.Event, send func(ctx context.Context, action incidentAction) error) error {
+	var errs []error
+	for _, action := range conf.actions(event) {
+		if err := send(ctx, action); err != nil {
+			errs = append(errs, fmt.Errorf("incident %s: %w", action.Key, err))
+		}
+	}
+	return errors.Join(errs...)
+}
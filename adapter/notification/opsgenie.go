@@ -0,0 +1,123 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/cvzm/go-web-project/domain"
+)
+
+// DefaultOpsgenieBaseURL is the base URL of the Opsgenie API
+const DefaultOpsgenieBaseURL = "https://api.opsgenie.com"
+
+// Maximum lengths of the fields of an Opsgenie alert
+const (
+	maxOpsgenieMessageLength     = 130
+	maxOpsgenieDescriptionLength = 15000
+)
+
+// opsgeniePriorities maps the event severities to Opsgenie priorities
+var opsgeniePriorities = map[domain.Severity]string{
+	domain.SeverityCritical: "P1",
+	domain.SeverityError:    "P2",
+	domain.SeverityWarning:  "P3",
+	domain.SeverityInfo:     "P5",
+}
+
+// OpsgenieAlert is an alert created with the Opsgenie Alerts API
+type OpsgenieAlert struct {
+	Message     string            `json:"message"`
+	Alias       string            `json:"alias"` // deduplication key of the alert
+	Description string            `json:"description,omitempty"`
+	Source      string            `json:"source"`
+	Entity      string            `json:"entity,omitempty"`
+	Priority    string            `json:"priority"` // P1 to P5
+	Tags        []string          `json:"tags,omitempty"`
+	Details     map[string]string `json:"details,omitempty"`
+}
+
+// OpsgenieClose is the request closing an Opsgenie alert
+type OpsgenieClose struct {
+	Source string `json:"source"`
+	Note   string `json:"note,omitempty"`
+}
+
+// OpsgenieChannel is an implementation of the domain.NotificationChannel interface creating Opsgenie alerts,
+// one per affected resource, and closing them on the matching recovery events.
+// Failed requests are retried like the Slack ones.
+type OpsgenieChannel struct {
+	conf    IncidentConfig
+	client  *http.Client
+	retrier *retrier
+}
+
+// NewOpsgenieChannel creates a new OpsgenieChannel instance sending with the HTTP client
+func NewOpsgenieChannel(conf IncidentConfig, client *http.Client) *OpsgenieChannel {
+	if conf.BaseURL == "" {
+		conf.BaseURL = DefaultOpsgenieBaseURL
+	}
+	return &OpsgenieChannel{
+		conf:    conf,
+		client:  client,
+		retrier: &retrier{maxRetries: conf.MaxRetries, retryDelay: conf.RetryDelay},
+	}
+}
+
+// Name returns the name of the channel
+func (c *OpsgenieChannel) Name() string {
+	return c.conf.Name
+}
+
+// Send creates or closes the alerts of the event
+func (c *OpsgenieChannel) Send(ctx context.Context, event domain.Event) error {
+	header := http.Header{"Authorization": []string{"GenieKey " + c.conf.Key}}
+	baseURL := strings.TrimSuffix(c.conf.BaseURL, "/")
+	return sendActions(ctx, c.conf, event, func(ctx context.Context, action incidentAction) error {
+		requestURL := baseURL + "/v2/alerts"
+		var request any = c.alert(event, action)
+		if action.Resolve {
+			requestURL = fmt.Sprintf("%s/v2/alerts/%s/close?identifierType=alias", baseURL, url.PathEscape(action.Key))
+			request = OpsgenieClose{
+				Source: string(event.Source),
+				Note:   fmt.Sprintf("Closed by event %d of type %s", event.ID, event.EventType),
+			}
+		}
+		body, err := json.Marshal(request)
+		if err != nil {
+			return err
+		}
+		return c.retrier.do(ctx, func(ctx context.Context) error {
+			return postJSON(ctx, c.client, "opsgenie", requestURL, header, body)
+		})
+	})
+}
+
+// alert returns the Opsgenie alert of the incident action of the event
+func (c *OpsgenieChannel) alert(event domain.Event, action incidentAction) OpsgenieAlert {
+	details := map[string]string{"event_id": fmt.Sprint(event.ID)}
+	if action.Resource != "" {
+		details["resource"] = action.Resource
+	}
+	return OpsgenieAlert{
+		Message:     incidentSummary(event, action.Resource, maxOpsgenieMessageLength),
+		Alias:       action.Key,
+		Description: truncate(event.Description, maxOpsgenieDescriptionLength),
+		Source:      string(event.Source),
+		Entity:      action.Resource,
+		Priority:    c.priority(event),
+		Tags:        []string{string(event.Source), event.EventType},
+		Details:     details,
+	}
+}
+
+// priority returns the Opsgenie priority of the severity of the event, or the configured one if the event has none
+func (c *OpsgenieChannel) priority(event domain.Event) string {
+	if priority, ok := opsgeniePriorities[event.Severity]; ok {
+		return priority
+	}
+	return c.conf.Severity
+}
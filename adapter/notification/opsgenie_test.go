@@ -0,0 +1,112 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// opsgenieRequest is a request received by the Opsgenie stub
+type opsgenieRequest struct {
+	URI           string
+	Authorization string
+	Body          map[string]any
+}
+
+// newTestOpsgenieServer starts an Alerts API stub recording the requests, answering 202
+func newTestOpsgenieServer(t *testing.T) (*httptest.Server, func() []opsgenieRequest) {
+	t.Helper()
+	var mu sync.Mutex
+	requests := []opsgenieRequest{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		request := opsgenieRequest{URI: r.URL.RequestURI(), Authorization: r.Header.Get("Authorization")}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request.Body))
+		requests = append(requests, request)
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(`{"result":"Request will be processed"}`))
+	}))
+	t.Cleanup(server.Close)
+	return server, func() []opsgenieRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]opsgenieRequest{}, requests...)
+	}
+}
+
+func newTestOpsgenieChannel(baseURL string) *OpsgenieChannel {
+	conf := newTestIncidentConfig(baseURL)
+	conf.Name = "opsgenie"
+	conf.Key = "api-key"
+	conf.Severity = "P1"
+	return NewOpsgenieChannel(conf, http.DefaultClient)
+}
+
+func TestOpsgenieChannel_Send(t *testing.T) {
+	t.Run("Creates an alert per affected resource", func(t *testing.T) {
+		server, requests := newTestOpsgenieServer(t)
+		channel := newTestOpsgenieChannel(server.URL)
+
+		require.NoError(t, channel.Send(context.Background(), testEvent))
+
+		received := requests()
+		require.Len(t, received, 2)
+		assert.Equal(t, "/v2/alerts", received[0].URI)
+		assert.Equal(t, "GenieKey api-key", received[0].Authorization)
+		assert.Equal(t, "AWS EC2_STOPPED on i-1: Instance stopped", received[0].Body["message"])
+		assert.Equal(t, "AWS/EC2_STOPPED/i-1", received[0].Body["alias"])
+		assert.Equal(t, "i-1", received[0].Body["entity"])
+		assert.Equal(t, "P2", received[0].Body["priority"])
+		assert.Equal(t, []any{"AWS", "EC2_STOPPED"}, received[0].Body["tags"])
+		assert.Equal(t, map[string]any{"event_id": "42", "resource": "i-1"}, received[0].Body["details"])
+		assert.Equal(t, "AWS/EC2_STOPPED/i-2", received[1].Body["alias"])
+	})
+
+	t.Run("Closes the alerts of a recovery event", func(t *testing.T) {
+		server, requests := newTestOpsgenieServer(t)
+		channel := newTestOpsgenieChannel(server.URL)
+		recovery := testEvent
+		recovery.EventType = "EC2_STARTED"
+		recovery.AffectedResources = []string{"arn:aws:ec2:us-east-1:123:instance/i-1"}
+
+		require.NoError(t, channel.Send(context.Background(), recovery))
+
+		received := requests()
+		require.Len(t, received, 1)
+		assert.Equal(t, "/v2/alerts/AWS%2FEC2_STOPPED%2Farn:aws:ec2:us-east-1:123:instance%2Fi-1/close?identifierType=alias", received[0].URI)
+		assert.Equal(t, "Closed by event 42 of type EC2_STARTED", received[0].Body["note"])
+	})
+
+	t.Run("Falls back to the configured priority", func(t *testing.T) {
+		server, requests := newTestOpsgenieServer(t)
+		channel := newTestOpsgenieChannel(server.URL)
+		event := testEvent
+		event.Severity = ""
+
+		require.NoError(t, channel.Send(context.Background(), event))
+
+		assert.Equal(t, "P1", requests()[0].Body["priority"])
+	})
+
+	t.Run("Truncates the message", func(t *testing.T) {
+		server, requests := newTestOpsgenieServer(t)
+		channel := newTestOpsgenieChannel(server.URL + "/")
+		event := testEvent
+		event.AffectedResources = nil
+		event.Description = strings.Repeat("x", 200)
+
+		require.NoError(t, channel.Send(context.Background(), event))
+
+		message := requests()[0].Body["message"].(string)
+		assert.Len(t, message, maxOpsgenieMessageLength)
+		assert.True(t, strings.HasSuffix(message, "..."))
+	})
+}
@@ -0,0 +1,116 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cvzm/go-web-project/domain"
+)
+
+// DefaultPagerDutyBaseURL is the base URL of the PagerDuty Events API
+const DefaultPagerDutyBaseURL = "https://events.pagerduty.com"
+
+// maxPagerDutySummaryLength is the maximum length of the summary of a PagerDuty event
+const maxPagerDutySummaryLength = 1024
+
+// PagerDutyEvent is an event of the PagerDuty Events API v2
+type PagerDutyEvent struct {
+	RoutingKey  string            `json:"routing_key"`
+	EventAction string            `json:"event_action"` // trigger or resolve
+	DedupKey    string            `json:"dedup_key"`
+	Payload     *PagerDutyPayload `json:"payload,omitempty"` // only for trigger events
+}
+
+// PagerDutyPayload describes the alert of a PagerDuty trigger event
+type PagerDutyPayload struct {
+	Summary       string         `json:"summary"`
+	Source        string         `json:"source"`
+	Severity      string         `json:"severity"` // critical, error, warning or info
+	Timestamp     string         `json:"timestamp,omitempty"`
+	Component     string         `json:"component,omitempty"`
+	Class         string         `json:"class,omitempty"`
+	CustomDetails map[string]any `json:"custom_details,omitempty"`
+}
+
+// PagerDutyChannel is an implementation of the domain.NotificationChannel interface triggering PagerDuty incidents
+// with the Events API v2, one per affected resource, and resolving them on the matching recovery events.
+// Failed requests are retried like the Slack ones.
+type PagerDutyChannel struct {
+	conf    IncidentConfig
+	client  *http.Client
+	retrier *retrier
+}
+
+// NewPagerDutyChannel creates a new PagerDutyChannel instance sending with the HTTP client
+func NewPagerDutyChannel(conf IncidentConfig, client *http.Client) *PagerDutyChannel {
+	if conf.BaseURL == "" {
+		conf.BaseURL = DefaultPagerDutyBaseURL
+	}
+	return &PagerDutyChannel{
+		conf:    conf,
+		client:  client,
+		retrier: &retrier{maxRetries: conf.MaxRetries, retryDelay: conf.RetryDelay},
+	}
+}
+
+// Name returns the name of the channel
+func (c *PagerDutyChannel) Name() string {
+	return c.conf.Name
+}
+
+// Send triggers or resolves the incidents of the event
+func (c *PagerDutyChannel) Send(ctx context.Context, event domain.Event) error {
+	url := strings.TrimSuffix(c.conf.BaseURL, "/") + "/v2/enqueue"
+	return sendActions(ctx, c.conf, event, func(ctx context.Context, action incidentAction) error {
+		body, err := json.Marshal(c.event(event, action))
+		if err != nil {
+			return err
+		}
+		return c.retrier.do(ctx, func(ctx context.Context) error {
+			return postJSON(ctx, c.client, "pagerduty", url, nil, body)
+		})
+	})
+}
+
+// event returns the PagerDuty event of the incident action of the event
+func (c *PagerDutyChannel) event(event domain.Event, action incidentAction) PagerDutyEvent {
+	if action.Resolve {
+		return PagerDutyEvent{RoutingKey: c.conf.Key, EventAction: "resolve", DedupKey: action.Key}
+	}
+
+	source := action.Resource
+	if source == "" {
+		source = string(event.Source)
+	}
+	return PagerDutyEvent{
+		RoutingKey:  c.conf.Key,
+		EventAction: "trigger",
+		DedupKey:    action.Key,
+		Payload: &PagerDutyPayload{
+			Summary:   incidentSummary(event, action.Resource, maxPagerDutySummaryLength),
+			Source:    source,
+			Severity:  c.severity(event),
+			Timestamp: event.CreatedAt.UTC().Format(time.RFC3339),
+			Component: action.Resource,
+			Class:     event.EventType,
+			CustomDetails: map[string]any{
+				"event_id":           event.ID,
+				"cloud_source":       event.Source,
+				"description":        event.Description,
+				"affected_resources": event.AffectedResources,
+			},
+		},
+	}
+}
+
+// severity returns the PagerDuty severity of the event, which shares the names of the event severities, or the
+// configured one if the event has none
+func (c *PagerDutyChannel) severity(event domain.Event) string {
+	if event.Severity.Valid() {
+		return string(event.Severity)
+	}
+	return c.conf.Severity
+}
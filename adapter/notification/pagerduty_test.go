@@ -0,0 +1,152 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cvzm/go-web-project/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestPagerDutyServer starts an Events API stub recording the events, answering with the given status codes
+// in turn, then 202
+func newTestPagerDutyServer(t *testing.T, statuses ...int) (*httptest.Server, func() []PagerDutyEvent) {
+	t.Helper()
+	var mu sync.Mutex
+	events := []PagerDutyEvent{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.URL.Path != "/v2/enqueue" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var event PagerDutyEvent
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&event))
+		events = append(events, event)
+		if len(events) <= len(statuses) {
+			w.WriteHeader(statuses[len(events)-1])
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(`{"status":"success"}`))
+	}))
+	t.Cleanup(server.Close)
+	return server, func() []PagerDutyEvent {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]PagerDutyEvent{}, events...)
+	}
+}
+
+func newTestIncidentConfig(baseURL string) IncidentConfig {
+	return IncidentConfig{
+		Name:       "pagerduty",
+		BaseURL:    baseURL,
+		Key:        "routing-key",
+		Severity:   "critical",
+		Recoveries: map[string]string{"EC2_STARTED": "EC2_STOPPED"},
+		MaxRetries: 2,
+		RetryDelay: time.Millisecond,
+	}
+}
+
+func TestPagerDutyChannel_Send(t *testing.T) {
+	t.Run("Triggers an incident per affected resource", func(t *testing.T) {
+		server, events := newTestPagerDutyServer(t)
+		channel := NewPagerDutyChannel(newTestIncidentConfig(server.URL), http.DefaultClient)
+
+		require.NoError(t, channel.Send(context.Background(), testEvent))
+
+		sent := events()
+		require.Len(t, sent, 2)
+		assert.Equal(t, "routing-key", sent[0].RoutingKey)
+		assert.Equal(t, "trigger", sent[0].EventAction)
+		assert.Equal(t, "AWS/EC2_STOPPED/i-1", sent[0].DedupKey)
+		assert.Equal(t, "AWS/EC2_STOPPED/i-2", sent[1].DedupKey)
+		require.NotNil(t, sent[0].Payload)
+		assert.Equal(t, "AWS EC2_STOPPED on i-1: Instance stopped", sent[0].Payload.Summary)
+		assert.Equal(t, "i-1", sent[0].Payload.Source)
+		assert.Equal(t, "error", sent[0].Payload.Severity)
+		assert.Equal(t, "2024-05-01T12:00:00Z", sent[0].Payload.Timestamp)
+		assert.Equal(t, "EC2_STOPPED", sent[0].Payload.Class)
+		assert.EqualValues(t, 42, sent[0].Payload.CustomDetails["event_id"])
+	})
+
+	t.Run("Resolves the incidents of a recovery event", func(t *testing.T) {
+		server, events := newTestPagerDutyServer(t)
+		channel := NewPagerDutyChannel(newTestIncidentConfig(server.URL), http.DefaultClient)
+		recovery := testEvent
+		recovery.EventType = "EC2_STARTED"
+		recovery.AffectedResources = []string{"i-1"}
+
+		require.NoError(t, channel.Send(context.Background(), recovery))
+
+		assert.Equal(t, []PagerDutyEvent{{RoutingKey: "routing-key", EventAction: "resolve", DedupKey: "AWS/EC2_STOPPED/i-1"}}, events())
+	})
+
+	t.Run("Falls back to the configured severity", func(t *testing.T) {
+		server, events := newTestPagerDutyServer(t)
+		channel := NewPagerDutyChannel(newTestIncidentConfig(server.URL), http.DefaultClient)
+		event := testEvent
+		event.Severity = ""
+
+		require.NoError(t, channel.Send(context.Background(), event))
+
+		assert.Equal(t, "critical", events()[0].Payload.Severity)
+	})
+
+	t.Run("Event without affected resource", func(t *testing.T) {
+		server, events := newTestPagerDutyServer(t)
+		channel := NewPagerDutyChannel(newTestIncidentConfig(server.URL), http.DefaultClient)
+		event := testEvent
+		event.AffectedResources = nil
+
+		require.NoError(t, channel.Send(context.Background(), event))
+
+		sent := events()
+		require.Len(t, sent, 1)
+		assert.Equal(t, "AWS/EC2_STOPPED/", sent[0].DedupKey)
+		assert.Equal(t, "AWS", sent[0].Payload.Source)
+	})
+
+	t.Run("Retries failed requests", func(t *testing.T) {
+		server, events := newTestPagerDutyServer(t, http.StatusInternalServerError)
+		channel := NewPagerDutyChannel(newTestIncidentConfig(server.URL), http.DefaultClient)
+		event := testEvent
+		event.AffectedResources = []string{"i-1"}
+
+		require.NoError(t, channel.Send(context.Background(), event))
+
+		assert.Len(t, events(), 2)
+	})
+
+	t.Run("Carries on after a rejected request", func(t *testing.T) {
+		server, events := newTestPagerDutyServer(t, http.StatusBadRequest)
+		channel := NewPagerDutyChannel(newTestIncidentConfig(server.URL), http.DefaultClient)
+
+		err := channel.Send(context.Background(), testEvent)
+
+		var httpErr *HTTPError
+		require.ErrorAs(t, err, &httpErr)
+		assert.Equal(t, http.StatusBadRequest, httpErr.StatusCode)
+		assert.Contains(t, err.Error(), "AWS/EC2_STOPPED/i-1")
+		assert.Len(t, events(), 2)
+	})
+}
+
+func TestIncidentKey(t *testing.T) {
+	assert.Equal(t, "GCP/VM_DELETED/vm-1", incidentKey(domain.SourceGCP, "VM_DELETED", "vm-1"))
+
+	key := incidentKey(domain.SourceAWS, "EC2_STOPPED", strings.Repeat("r", 300))
+	assert.True(t, strings.HasPrefix(key, "sha256:"))
+	assert.LessOrEqual(t, len(key), maxIncidentKeyLength)
+	assert.Equal(t, key, incidentKey(domain.SourceAWS, "EC2_STOPPED", strings.Repeat("r", 300)))
+}
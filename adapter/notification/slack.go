@@ -0,0 +1,134 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/cvzm/go-web-project/domain"
+)
+
+// DefaultSlackTemplate is the template of the message text, executed with the domain.Event
+const DefaultSlackTemplate = "*{{.EventType}}* from {{.Source}}{{with .Description}}\n{{.}}{{end}}"
+
+// maxSlackHeaderLength is the maximum length of the text of a Block Kit header block
+const maxSlackHeaderLength = 150
+
+// SlackConfig is Slack channel related configuration
+type SlackConfig struct {
+	Name       string
+	WebhookURL string        // incoming webhook of the channel
+	Template   string        // text/template of the message text, DefaultSlackTemplate if empty
+	MaxRetries int           // maximum number of retries of a failed delivery
+	RetryDelay time.Duration // delay before the first retry, doubled for each further retry
+}
+
+// SlackMessage is a Block Kit message posted to an incoming webhook
+type SlackMessage struct {
+	Text   string       `json:"text"` // fallback for notifications and clients unable to render blocks
+	Blocks []SlackBlock `json:"blocks"`
+}
+
+// SlackBlock is a Block Kit layout block
+type SlackBlock struct {
+	Type     string      `json:"type"`
+	Text     *SlackText  `json:"text,omitempty"`
+	Fields   []SlackText `json:"fields,omitempty"`
+	Elements []SlackText `json:"elements,omitempty"`
+}
+
+// SlackText is a Block Kit text object
+type SlackText struct {
+	Type string `json:"type"` // plain_text or mrkdwn
+	Text string `json:"text"`
+}
+
+// SlackChannel is an implementation of the domain.NotificationChannel interface posting to a Slack incoming webhook.
+// Failed deliveries are retried with an exponential backoff, and rate limited ones after the delay requested by
+// Slack, during which the other deliveries to the channel wait as well.
+type SlackChannel struct {
+	conf     SlackConfig
+	client   *http.Client
+	template *template.Template
+	retrier  *retrier
+}
+
+// NewSlackChannel creates a new SlackChannel instance posting with the HTTP client
+func NewSlackChannel(conf SlackConfig, client *http.Client) (*SlackChannel, error) {
+	text := conf.Template
+	if text == "" {
+		text = DefaultSlackTemplate
+	}
+	tmpl, err := template.New(conf.Name).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template of Slack channel %q: %w", conf.Name, err)
+	}
+	return &SlackChannel{
+		conf:     conf,
+		client:   client,
+		template: tmpl,
+		retrier:  &retrier{maxRetries: conf.MaxRetries, retryDelay: conf.RetryDelay},
+	}, nil
+}
+
+// Name returns the name of the channel
+func (c *SlackChannel) Name() string {
+	return c.conf.Name
+}
+
+// Send posts the message of the event, retrying failed and rate limited deliveries
+func (c *SlackChannel) Send(ctx context.Context, event domain.Event) error {
+	message, err := c.Message(event)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	return c.retrier.do(ctx, func(ctx context.Context) error {
+		return postJSON(ctx, c.client, "slack webhook", c.conf.WebhookURL, nil, body)
+	})
+}
+
+// Message returns the Block Kit message of the event
+func (c *SlackChannel) Message(event domain.Event) (SlackMessage, error) {
+	var text strings.Builder
+	if err := c.template.Execute(&text, event); err != nil {
+		return SlackMessage{}, fmt.Errorf("executing template of Slack channel %q: %w", c.conf.Name, err)
+	}
+
+	header := []rune(fmt.Sprintf("%s %s", event.Source, event.EventType))
+	if len(header) > maxSlackHeaderLength {
+		header = append(header[:maxSlackHeaderLength-3], []rune("...")...)
+	}
+	resources := "-"
+	if len(event.AffectedResources) > 0 {
+		resources = strings.Join(event.AffectedResources, ", ")
+	}
+
+	return SlackMessage{
+		Text: text.String(),
+		Blocks: []SlackBlock{
+			{Type: "header", Text: &SlackText{Type: "plain_text", Text: string(header)}},
+			{Type: "section", Text: &SlackText{Type: "mrkdwn", Text: text.String()}},
+			{Type: "section", Fields: []SlackText{
+				{Type: "mrkdwn", Text: "*Source*\n" + string(event.Source)},
+				{Type: "mrkdwn", Text: "*Type*\n" + event.EventType},
+				{Type: "mrkdwn", Text: "*Severity*\n" + string(event.Severity)},
+				{Type: "mrkdwn", Text: "*Resources*\n" + resources},
+				{Type: "mrkdwn", Text: "*Time*\n" + event.CreatedAt.UTC().Format(time.RFC3339)},
+			}},
+			{Type: "context", Elements: []SlackText{
+				{Type: "mrkdwn", Text: fmt.Sprintf("Event #%d", event.ID)},
+			}},
+		},
+	}, nil
+}
+
+// SlackError is returned when Slack rejects a message
+type SlackError = HTTPError
@@ -0,0 +1,151 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cvzm/go-web-project/domain"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testEvent = domain.Event{
+	ID:                42,
+	Source:            domain.SourceAWS,
+	EventType:         "EC2_STOPPED",
+	Description:       "Instance stopped",
+	AffectedResources: pq.StringArray{"i-1", "i-2"},
+	Severity:          domain.SeverityError,
+	CreatedAt:         time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+}
+
+// newTestSlackServer starts a webhook server answering with the given status codes in turn, then 200
+func newTestSlackServer(t *testing.T, statuses ...int) (*httptest.Server, *atomic.Int32, chan SlackMessage) {
+	t.Helper()
+	var calls atomic.Int32
+	messages := make(chan SlackMessage, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		call := int(calls.Add(1))
+		var message SlackMessage
+		if err := json.NewDecoder(r.Body).Decode(&message); err == nil {
+			messages <- message
+		}
+		if call <= len(statuses) {
+			if statuses[call-1] == http.StatusTooManyRequests {
+				w.Header().Set("Retry-After", "1")
+			}
+			w.WriteHeader(statuses[call-1])
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls, messages
+}
+
+func newTestSlackChannel(t *testing.T, url, tmpl string) *SlackChannel {
+	t.Helper()
+	channel, err := NewSlackChannel(SlackConfig{
+		Name:       "ops",
+		WebhookURL: url,
+		Template:   tmpl,
+		MaxRetries: 2,
+		RetryDelay: time.Millisecond,
+	}, http.DefaultClient)
+	require.NoError(t, err)
+	return channel
+}
+
+func TestSlackChannel_Message(t *testing.T) {
+	t.Run("Default template", func(t *testing.T) {
+		channel := newTestSlackChannel(t, "http://localhost", "")
+
+		message, err := channel.Message(testEvent)
+		require.NoError(t, err)
+		assert.Equal(t, "*EC2_STOPPED* from AWS\nInstance stopped", message.Text)
+		require.Len(t, message.Blocks, 4)
+		assert.Equal(t, "header", message.Blocks[0].Type)
+		assert.Equal(t, "AWS EC2_STOPPED", message.Blocks[0].Text.Text)
+		assert.Equal(t, "*Severity*\nerror", message.Blocks[2].Fields[2].Text)
+		assert.Equal(t, "*Resources*\ni-1, i-2", message.Blocks[2].Fields[3].Text)
+		assert.Equal(t, "*Time*\n2024-05-01T12:00:00Z", message.Blocks[2].Fields[4].Text)
+		assert.Equal(t, "Event #42", message.Blocks[3].Elements[0].Text)
+	})
+
+	t.Run("Custom template", func(t *testing.T) {
+		channel := newTestSlackChannel(t, "http://localhost", ":rotating_light: {{.EventType}} on {{index .AffectedResources 0}}")
+
+		message, err := channel.Message(testEvent)
+		require.NoError(t, err)
+		assert.Equal(t, ":rotating_light: EC2_STOPPED on i-1", message.Text)
+	})
+
+	t.Run("Invalid template", func(t *testing.T) {
+		_, err := NewSlackChannel(SlackConfig{Name: "ops", Template: "{{.EventType"}, http.DefaultClient)
+		assert.ErrorContains(t, err, `invalid template of Slack channel "ops"`)
+	})
+}
+
+func TestSlackChannel_Send(t *testing.T) {
+	t.Run("Posts the message", func(t *testing.T) {
+		server, calls, messages := newTestSlackServer(t)
+		channel := newTestSlackChannel(t, server.URL, "")
+
+		require.NoError(t, channel.Send(context.Background(), testEvent))
+		assert.Equal(t, int32(1), calls.Load())
+		message := <-messages
+		assert.Equal(t, "*EC2_STOPPED* from AWS\nInstance stopped", message.Text)
+	})
+
+	t.Run("Retries server errors", func(t *testing.T) {
+		server, calls, _ := newTestSlackServer(t, http.StatusInternalServerError, http.StatusBadGateway)
+		channel := newTestSlackChannel(t, server.URL, "")
+
+		require.NoError(t, channel.Send(context.Background(), testEvent))
+		assert.Equal(t, int32(3), calls.Load())
+	})
+
+	t.Run("Gives up after the last retry", func(t *testing.T) {
+		server, calls, _ := newTestSlackServer(t, http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError)
+		channel := newTestSlackChannel(t, server.URL, "")
+
+		err := channel.Send(context.Background(), testEvent)
+		assert.ErrorContains(t, err, "giving up after 3 attempt(s)")
+		assert.Equal(t, int32(3), calls.Load())
+	})
+
+	t.Run("Does not retry rejected messages", func(t *testing.T) {
+		server, calls, _ := newTestSlackServer(t, http.StatusBadRequest)
+		channel := newTestSlackChannel(t, server.URL, "")
+
+		var slackErr *SlackError
+		require.ErrorAs(t, channel.Send(context.Background(), testEvent), &slackErr)
+		assert.Equal(t, http.StatusBadRequest, slackErr.StatusCode)
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("Waits for the end of the rate limit", func(t *testing.T) {
+		server, calls, _ := newTestSlackServer(t, http.StatusTooManyRequests)
+		channel := newTestSlackChannel(t, server.URL, "")
+
+		start := time.Now()
+		require.NoError(t, channel.Send(context.Background(), testEvent))
+		assert.GreaterOrEqual(t, time.Since(start), time.Second)
+		assert.Equal(t, int32(2), calls.Load())
+	})
+
+	t.Run("Stops retrying once cancelled", func(t *testing.T) {
+		server, _, _ := newTestSlackServer(t, http.StatusTooManyRequests)
+		channel := newTestSlackChannel(t, server.URL, "")
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, channel.Send(ctx, testEvent), context.DeadlineExceeded)
+	})
+}
@@ -0,0 +1,56 @@
+package publisher
+
+import (
+	"context"
+
+	"github.com/cvzm/go-web-project/domain"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// KafkaConfig is Kafka publisher related configuration
+type KafkaConfig struct {
+	Brokers []string
+	Topic   string
+}
+
+// KafkaPublisher is an implementation of the domain.OutboxPublisher interface producing to a Kafka topic.
+// Records are keyed by aggregate, so that the messages of an aggregate land on the same partition in order.
+type KafkaPublisher struct {
+	client *kgo.Client
+}
+
+// NewKafkaPublisher creates a new KafkaPublisher instance producing to the configured topic
+func NewKafkaPublisher(conf KafkaConfig, opts ...kgo.Opt) (*KafkaPublisher, error) {
+	opts = append([]kgo.Opt{
+		kgo.SeedBrokers(conf.Brokers...),
+		kgo.DefaultProduceTopic(conf.Topic),
+		kgo.RequiredAcks(kgo.AllISRAcks()),
+	}, opts...)
+	client, err := kgo.NewClient(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &KafkaPublisher{client: client}, nil
+}
+
+// Publish produces the message and waits for its acknowledgement
+func (p *KafkaPublisher) Publish(ctx context.Context, message domain.OutboxMessage) error {
+	record := &kgo.Record{
+		Key:   []byte(aggregateKey(message)),
+		Value: message.Payload,
+		Headers: []kgo.RecordHeader{
+			{Key: HeaderMessageID, Value: []byte(messageID(message))},
+			{Key: HeaderEventType, Value: []byte(message.EventType)},
+			{Key: HeaderAggregateType, Value: []byte(message.AggregateType)},
+			{Key: HeaderAggregateID, Value: []byte(message.AggregateID)},
+		},
+	}
+	return p.client.ProduceSync(ctx, record).FirstErr()
+}
+
+// Close flushes and closes the Kafka client
+func (p *KafkaPublisher) Close() error {
+	p.client.Close()
+	return nil
+}
@@ -0,0 +1,55 @@
+package publisher
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cvzm/go-web-project/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kfake"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+func TestKafkaPublisher(t *testing.T) {
+	cluster, err := kfake.NewCluster(kfake.NumBrokers(1), kfake.SeedTopics(3, "events"))
+	require.NoError(t, err)
+	t.Cleanup(cluster.Close)
+	brokers := cluster.ListenAddrs()
+
+	publisher, err := NewKafkaPublisher(KafkaConfig{Brokers: brokers, Topic: "events"})
+	require.NoError(t, err)
+	defer publisher.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	for id := uint(1); id <= 3; id++ {
+		require.NoError(t, publisher.Publish(ctx, domain.OutboxMessage{
+			ID:            id,
+			AggregateType: domain.AggregateEvent,
+			AggregateID:   "42",
+			EventType:     domain.OutboxEventCreated,
+			Payload:       []byte(`{"id":42}`),
+		}))
+	}
+
+	consumer, err := kgo.NewClient(kgo.SeedBrokers(brokers...), kgo.ConsumeTopics("events"), kgo.ConsumeResetOffset(kgo.NewOffset().AtStart()))
+	require.NoError(t, err)
+	defer consumer.Close()
+
+	var records []*kgo.Record
+	for len(records) < 3 && ctx.Err() == nil {
+		fetches := consumer.PollFetches(ctx)
+		records = append(records, fetches.Records()...)
+	}
+	require.Len(t, records, 3)
+	for i, record := range records {
+		// Messages of an aggregate share a key, hence a partition, and keep their order
+		assert.Equal(t, "event:42", string(record.Key))
+		assert.Equal(t, records[0].Partition, record.Partition)
+		assert.Equal(t, `{"id":42}`, string(record.Value))
+		assert.Contains(t, record.Headers, kgo.RecordHeader{Key: HeaderMessageID, Value: []byte{byte('1' + i)}})
+		assert.Contains(t, record.Headers, kgo.RecordHeader{Key: HeaderEventType, Value: []byte(domain.OutboxEventCreated)})
+	}
+}
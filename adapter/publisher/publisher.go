@@ -0,0 +1,25 @@
+package publisher
+
+import (
+	"strconv"
+
+	"github.com/cvzm/go-web-project/domain"
+)
+
+// Names of the metadata published along with the payload of a message, as Kafka headers or SNS message attributes
+const (
+	HeaderMessageID     = "message_id" // ID of the outbox message, for downstream consumers to deduplicate redeliveries
+	HeaderEventType     = "event_type"
+	HeaderAggregateType = "aggregate_type"
+	HeaderAggregateID   = "aggregate_id"
+)
+
+// messageID returns the ID of the outbox message as published
+func messageID(message domain.OutboxMessage) string {
+	return strconv.FormatUint(uint64(message.ID), 10)
+}
+
+// aggregateKey returns the key identifying the aggregate of the message, which orders its messages
+func aggregateKey(message domain.OutboxMessage) string {
+	return message.AggregateType + ":" + message.AggregateID
+}
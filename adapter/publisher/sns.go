@@ -0,0 +1,101 @@
+package publisher
+
+import (
+	"context"
+	"strings"
+
+	"github.com/cvzm/go-web-project/domain"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sns/types"
+)
+
+// SNSConfig is SNS publisher related configuration
+type SNSConfig struct {
+	TopicARN string // FIFO topics, whose name ends with .fifo, get the messages of an aggregate in order
+	Region   string
+
+	// Overrides for local emulators such as LocalStack
+	Endpoint        string // custom endpoint of the SNS API, the AWS endpoint of the region if empty
+	AccessKeyID     string // static credentials, the default AWS credential chain if empty
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// SNSAPI is the subset of the SNS client used by SNSPublisher
+type SNSAPI interface {
+	Publish(ctx context.Context, params *sns.PublishInput, optFns ...func(*sns.Options)) (*sns.PublishOutput, error)
+}
+
+// SNSPublisher is an implementation of the domain.OutboxPublisher interface publishing to an SNS topic
+type SNSPublisher struct {
+	client SNSAPI
+	conf   SNSConfig
+}
+
+// NewSNSPublisher loads the AWS configuration and creates a new SNSPublisher instance
+func NewSNSPublisher(ctx context.Context, conf SNSConfig) (*SNSPublisher, error) {
+	opts := []func(*config.LoadOptions) error{
+		config.WithDefaultRegion(conf.Region),
+	}
+	if conf.AccessKeyID != "" {
+		opts = append(opts, config.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(conf.AccessKeyID, conf.SecretAccessKey, conf.SessionToken),
+		))
+	}
+	awsCfg, err := config.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	client := sns.NewFromConfig(awsCfg, func(o *sns.Options) {
+		if conf.Endpoint != "" {
+			o.BaseEndpoint = aws.String(conf.Endpoint)
+		}
+	})
+	return NewSNSPublisherWithClient(client, conf), nil
+}
+
+// NewSNSPublisherWithClient creates a new SNSPublisher instance on top of the given SNS client
+func NewSNSPublisherWithClient(client SNSAPI, conf SNSConfig) *SNSPublisher {
+	return &SNSPublisher{
+		client: client,
+		conf:   conf,
+	}
+}
+
+// Publish publishes the payload of the message, with its metadata as message attributes
+func (p *SNSPublisher) Publish(ctx context.Context, message domain.OutboxMessage) error {
+	input := &sns.PublishInput{
+		TopicArn: aws.String(p.conf.TopicARN),
+		Message:  aws.String(string(message.Payload)),
+		MessageAttributes: map[string]types.MessageAttributeValue{
+			HeaderMessageID:     stringAttribute(messageID(message)),
+			HeaderEventType:     stringAttribute(message.EventType),
+			HeaderAggregateType: stringAttribute(message.AggregateType),
+			HeaderAggregateID:   stringAttribute(message.AggregateID),
+		},
+	}
+	if strings.HasSuffix(p.conf.TopicARN, ".fifo") {
+		input.MessageGroupId = aws.String(aggregateKey(message))
+		input.MessageDeduplicationId = aws.String(messageID(message))
+	}
+	_, err := p.client.Publish(ctx, input)
+	return err
+}
+
+// Close is a no-op, the SNS client holds no resources
+func (p *SNSPublisher) Close() error {
+	return nil
+}
+
+// stringAttribute returns an SNS message attribute of type String
+func stringAttribute(value string) types.MessageAttributeValue {
+	return types.MessageAttributeValue{
+		DataType:    aws.String("String"),
+		StringValue: aws.String(value),
+	}
+}
@@ -0,0 +1,55 @@
+package publisher
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/cvzm/go-web-project/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSNSClient records the published inputs
+type fakeSNSClient struct {
+	inputs []*sns.PublishInput
+}
+
+func (f *fakeSNSClient) Publish(ctx context.Context, params *sns.PublishInput, optFns ...func(*sns.Options)) (*sns.PublishOutput, error) {
+	f.inputs = append(f.inputs, params)
+	return &sns.PublishOutput{}, nil
+}
+
+func TestSNSPublisher(t *testing.T) {
+	message := domain.OutboxMessage{
+		ID:            7,
+		AggregateType: domain.AggregateEvent,
+		AggregateID:   "42",
+		EventType:     domain.OutboxEventCreated,
+		Payload:       []byte(`{"id":42}`),
+	}
+
+	t.Run("Standard topic", func(t *testing.T) {
+		client := &fakeSNSClient{}
+		publisher := NewSNSPublisherWithClient(client, SNSConfig{TopicARN: "arn:aws:sns:us-west-1:000000000000:events"})
+
+		require.NoError(t, publisher.Publish(context.Background(), message))
+		require.Len(t, client.inputs, 1)
+		input := client.inputs[0]
+		assert.Equal(t, "arn:aws:sns:us-west-1:000000000000:events", *input.TopicArn)
+		assert.Equal(t, `{"id":42}`, *input.Message)
+		assert.Equal(t, "7", *input.MessageAttributes[HeaderMessageID].StringValue)
+		assert.Equal(t, domain.OutboxEventCreated, *input.MessageAttributes[HeaderEventType].StringValue)
+		assert.Nil(t, input.MessageGroupId)
+	})
+
+	t.Run("FIFO topic", func(t *testing.T) {
+		client := &fakeSNSClient{}
+		publisher := NewSNSPublisherWithClient(client, SNSConfig{TopicARN: "arn:aws:sns:us-west-1:000000000000:events.fifo"})
+
+		require.NoError(t, publisher.Publish(context.Background(), message))
+		require.Len(t, client.inputs, 1)
+		assert.Equal(t, "event:42", *client.inputs[0].MessageGroupId)
+		assert.Equal(t, "7", *client.inputs[0].MessageDeduplicationId)
+	})
+}
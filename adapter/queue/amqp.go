@@ -0,0 +1,326 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/cvzm/go-web-project/domain"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// AMQPConfig is AMQP 0-9-1 (RabbitMQ) related configuration
+type AMQPConfig struct {
+	URL                 string
+	Queue               string        // durable queue consumed by the source
+	Exchange            string        // exchange the queue is bound to, no binding if empty
+	ExchangeType        string        // type of the exchange, e.g. topic
+	BindingKeys         []string      // routing keys binding the queue to the exchange
+	DeadLetterExchange  string        // exchange receiving rejected messages, no dead-lettering if empty
+	Prefetch            int           // maximum number of unacked messages delivered to the source
+	ReconnectMinBackoff time.Duration // delay before the first reconnection attempt
+	ReconnectMaxBackoff time.Duration // maximum delay between reconnection attempts
+}
+
+// AMQPChannel is the subset of an AMQP channel used by AMQPSource
+type AMQPChannel interface {
+	Qos(prefetchCount, prefetchSize int, global bool) error
+	ExchangeDeclare(name, kind string, durable, autoDelete, internal, noWait bool, args amqp.Table) error
+	QueueDeclare(name string, durable, autoDelete, exclusive, noWait bool, args amqp.Table) (amqp.Queue, error)
+	QueueBind(name, key, exchange string, noWait bool, args amqp.Table) error
+	Consume(queue, consumer string, autoAck, exclusive, noLocal, noWait bool, args amqp.Table) (<-chan amqp.Delivery, error)
+	Close() error
+}
+
+// AMQPConnection is the subset of an AMQP connection used by AMQPSource
+type AMQPConnection interface {
+	Channel() (AMQPChannel, error)
+	Close() error
+}
+
+// AMQPDialer opens a connection to the broker
+type AMQPDialer func(url string) (AMQPConnection, error)
+
+// amqpConnection adapts *amqp.Connection to the AMQPConnection interface
+type amqpConnection struct {
+	*amqp.Connection
+}
+
+// Channel opens a new channel on the connection
+func (c amqpConnection) Channel() (AMQPChannel, error) {
+	return c.Connection.Channel()
+}
+
+// DialAMQP connects to a RabbitMQ broker
+func DialAMQP(url string) (AMQPConnection, error) {
+	conn, err := amqp.Dial(url)
+	if err != nil {
+		return nil, err
+	}
+	return amqpConnection{conn}, nil
+}
+
+// AMQPSource is an implementation of the domain.MessageSource interface backed by an AMQP queue.
+// Messages are acked manually, and the source reconnects with backoff when the broker goes away.
+type AMQPSource struct {
+	conf AMQPConfig
+	dial AMQPDialer
+
+	mu         sync.Mutex
+	conn       AMQPConnection
+	channel    AMQPChannel
+	deliveries <-chan amqp.Delivery
+	generation uint64 // incremented on every connection, delivery tags being scoped to their channel
+}
+
+// amqpHandle is the handle of a message: its delivery and the generation of the channel it was delivered on
+type amqpHandle struct {
+	delivery   amqp.Delivery
+	generation uint64
+}
+
+// Default reconnection backoff bounds
+const (
+	defaultAMQPReconnectMinBackoff = time.Second
+	defaultAMQPReconnectMaxBackoff = 30 * time.Second
+)
+
+// NewAMQPSource connects to the broker, declares the topology and starts consuming
+func NewAMQPSource(conf AMQPConfig, dial AMQPDialer) (*AMQPSource, error) {
+	if conf.ReconnectMinBackoff <= 0 {
+		conf.ReconnectMinBackoff = defaultAMQPReconnectMinBackoff
+	}
+	if conf.ReconnectMaxBackoff < conf.ReconnectMinBackoff {
+		conf.ReconnectMaxBackoff = max(defaultAMQPReconnectMaxBackoff, conf.ReconnectMinBackoff)
+	}
+	source := &AMQPSource{
+		conf: conf,
+		dial: dial,
+	}
+	if err := source.connect(); err != nil {
+		return nil, err
+	}
+	return source, nil
+}
+
+// Receive waits for a delivery, then returns it along with the deliveries already buffered
+func (s *AMQPSource) Receive(ctx context.Context) ([]domain.Message, error) {
+	s.mu.Lock()
+	deliveries, generation := s.deliveries, s.generation
+	s.mu.Unlock()
+
+	var messages []domain.Message
+	select {
+	case delivery, ok := <-deliveries:
+		if !ok {
+			// The broker closed the channel, nothing is received until reconnected
+			return nil, s.reconnect(ctx)
+		}
+		messages = append(messages, toAMQPMessage(delivery, generation))
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	for len(messages) < s.conf.Prefetch {
+		select {
+		case delivery, ok := <-deliveries:
+			if !ok {
+				return messages, nil
+			}
+			messages = append(messages, toAMQPMessage(delivery, generation))
+		default:
+			return messages, nil
+		}
+	}
+	return messages, nil
+}
+
+// Ack acknowledges the delivery
+func (s *AMQPSource) Ack(ctx context.Context, message domain.Message) error {
+	handle, err := amqpMessageHandle(message)
+	if err != nil {
+		return err
+	}
+	return handle.delivery.Ack(false)
+}
+
+// Nack requeues the delivery once the delay has elapsed. Until then, it stays unacked
+// and counts towards the prefetch limit.
+func (s *AMQPSource) Nack(ctx context.Context, message domain.Message, delay time.Duration) error {
+	handle, err := amqpMessageHandle(message)
+	if err != nil {
+		return err
+	}
+	if delay <= 0 {
+		return handle.delivery.Nack(false, true)
+	}
+	time.AfterFunc(delay, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		// The broker already requeued the deliveries of a closed channel, and the tag may designate another
+		// delivery of the new one
+		if s.generation != handle.generation {
+			return
+		}
+		if err := handle.delivery.Nack(false, true); err != nil {
+			log.Printf("Error requeuing AMQP message %q: %v", message.ID, err)
+		}
+	})
+	return nil
+}
+
+// Reject rejects the delivery without requeuing it, so the broker routes it to the dead-letter exchange
+func (s *AMQPSource) Reject(ctx context.Context, message domain.Message) error {
+	handle, err := amqpMessageHandle(message)
+	if err != nil {
+		return err
+	}
+	return handle.delivery.Reject(false)
+}
+
+// Extend is a no-op, AMQP deliveries are not leased
+func (s *AMQPSource) Extend(ctx context.Context, message domain.Message, lease time.Duration) error {
+	return nil
+}
+
+// Close closes the channel and the connection
+func (s *AMQPSource) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closeConnection()
+}
+
+// connect opens a connection and a channel, declares the topology and starts consuming
+func (s *AMQPSource) connect() error {
+	conn, err := s.dial(s.conf.URL)
+	if err != nil {
+		return err
+	}
+	channel, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	deliveries, err := s.setupChannel(channel)
+	if err != nil {
+		channel.Close()
+		conn.Close()
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.conn = conn
+	s.channel = channel
+	s.deliveries = deliveries
+	s.generation++
+	return nil
+}
+
+// setupChannel declares the exchange, queue and bindings, and starts consuming the queue.
+// The arguments of an existing queue can't be changed: declaring it with another dead-letter exchange
+// than it was created with fails with PRECONDITION_FAILED, and the queue must be deleted or migrated first.
+func (s *AMQPSource) setupChannel(channel AMQPChannel) (<-chan amqp.Delivery, error) {
+	if err := channel.Qos(s.conf.Prefetch, 0, false); err != nil {
+		return nil, err
+	}
+
+	var args amqp.Table
+	if s.conf.DeadLetterExchange != "" {
+		args = amqp.Table{"x-dead-letter-exchange": s.conf.DeadLetterExchange}
+	}
+	if _, err := channel.QueueDeclare(s.conf.Queue, true, false, false, false, args); err != nil {
+		var amqpErr *amqp.Error
+		if errors.As(err, &amqpErr) && amqpErr.Code == amqp.PreconditionFailed {
+			return nil, fmt.Errorf("declaring queue %q, whose dead-letter exchange differs from %q: %w", s.conf.Queue, s.conf.DeadLetterExchange, err)
+		}
+		return nil, err
+	}
+
+	if s.conf.Exchange != "" {
+		if err := channel.ExchangeDeclare(s.conf.Exchange, s.conf.ExchangeType, true, false, false, false, nil); err != nil {
+			return nil, err
+		}
+		for _, key := range s.conf.BindingKeys {
+			if err := channel.QueueBind(s.conf.Queue, key, s.conf.Exchange, false, nil); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return channel.Consume(s.conf.Queue, "", false, false, false, false, nil)
+}
+
+// reconnect closes the broken connection and connects again with exponential backoff,
+// until reconnected or the context is done
+func (s *AMQPSource) reconnect(ctx context.Context) error {
+	s.mu.Lock()
+	s.closeConnection()
+	s.mu.Unlock()
+
+	backoff := s.conf.ReconnectMinBackoff
+	for {
+		err := s.connect()
+		if err == nil {
+			log.Printf("Reconnected to AMQP broker")
+			return nil
+		}
+		log.Printf("Error reconnecting to AMQP broker, retrying in %s: %v", backoff, err)
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		backoff = min(backoff*2, s.conf.ReconnectMaxBackoff)
+	}
+}
+
+// closeConnection closes the current channel and connection, if any
+func (s *AMQPSource) closeConnection() error {
+	var errs []error
+	if s.channel != nil {
+		errs = append(errs, ignoreClosed(s.channel.Close()))
+		s.channel = nil
+	}
+	if s.conn != nil {
+		errs = append(errs, ignoreClosed(s.conn.Close()))
+		s.conn = nil
+	}
+	return errors.Join(errs...)
+}
+
+// toAMQPMessage converts an AMQP delivery of the channel generation into a domain.Message
+func toAMQPMessage(delivery amqp.Delivery, generation uint64) domain.Message {
+	id := delivery.MessageId
+	if id == "" {
+		id = fmt.Sprintf("delivery-%d", delivery.DeliveryTag)
+	}
+	return domain.Message{
+		ID:              id,
+		Body:            delivery.Body,
+		DeduplicationID: delivery.MessageId,
+		Handle:          amqpHandle{delivery: delivery, generation: generation},
+	}
+}
+
+// amqpMessageHandle returns the handle of the AMQP delivery backing the message
+func amqpMessageHandle(message domain.Message) (amqpHandle, error) {
+	handle, ok := message.Handle.(amqpHandle)
+	if !ok {
+		return amqpHandle{}, fmt.Errorf("message %q is not an AMQP delivery", message.ID)
+	}
+	return handle, nil
+}
+
+// ignoreClosed ignores the error returned when closing an already closed channel or connection
+func ignoreClosed(err error) error {
+	if errors.Is(err, amqp.ErrClosed) {
+		return nil
+	}
+	return err
+}
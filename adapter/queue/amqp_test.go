@@ -0,0 +1,236 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAcknowledger records the acknowledgements of deliveries
+type fakeAcknowledger struct {
+	mu       sync.Mutex
+	acked    []uint64
+	requeued []uint64
+	rejected []uint64
+}
+
+func (f *fakeAcknowledger) Ack(tag uint64, multiple bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.acked = append(f.acked, tag)
+	return nil
+}
+
+func (f *fakeAcknowledger) Nack(tag uint64, multiple bool, requeue bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requeued = append(f.requeued, tag)
+	return nil
+}
+
+// requeuedTags returns the tags of the requeued deliveries
+func (f *fakeAcknowledger) requeuedTags() []uint64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]uint64(nil), f.requeued...)
+}
+
+func (f *fakeAcknowledger) Reject(tag uint64, requeue bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rejected = append(f.rejected, tag)
+	return nil
+}
+
+// fakeAMQPChannel records the declared topology and delivers the messages pushed to it
+type fakeAMQPChannel struct {
+	prefetch   int
+	declareErr error
+	queueArgs  amqp.Table
+	bindings   []string
+	deliveries chan amqp.Delivery
+}
+
+func (f *fakeAMQPChannel) Qos(prefetchCount, prefetchSize int, global bool) error {
+	f.prefetch = prefetchCount
+	return nil
+}
+
+func (f *fakeAMQPChannel) ExchangeDeclare(name, kind string, durable, autoDelete, internal, noWait bool, args amqp.Table) error {
+	return nil
+}
+
+func (f *fakeAMQPChannel) QueueDeclare(name string, durable, autoDelete, exclusive, noWait bool, args amqp.Table) (amqp.Queue, error) {
+	f.queueArgs = args
+	return amqp.Queue{Name: name}, f.declareErr
+}
+
+func (f *fakeAMQPChannel) QueueBind(name, key, exchange string, noWait bool, args amqp.Table) error {
+	f.bindings = append(f.bindings, exchange+":"+key)
+	return nil
+}
+
+func (f *fakeAMQPChannel) Consume(queue, consumer string, autoAck, exclusive, noLocal, noWait bool, args amqp.Table) (<-chan amqp.Delivery, error) {
+	return f.deliveries, nil
+}
+
+func (f *fakeAMQPChannel) Close() error {
+	return nil
+}
+
+// fakeAMQPConnection hands out a single channel
+type fakeAMQPConnection struct {
+	channel *fakeAMQPChannel
+}
+
+func (f *fakeAMQPConnection) Channel() (AMQPChannel, error) {
+	return f.channel, nil
+}
+
+func (f *fakeAMQPConnection) Close() error {
+	return nil
+}
+
+// fakeAMQPBroker dials connections to fresh channels, failing the configured number of dials
+type fakeAMQPBroker struct {
+	mu       sync.Mutex
+	channels []*fakeAMQPChannel
+	failures int
+}
+
+func (f *fakeAMQPBroker) dial(url string) (AMQPConnection, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.failures > 0 {
+		f.failures--
+		return nil, errors.New("connection refused")
+	}
+	channel := &fakeAMQPChannel{deliveries: make(chan amqp.Delivery, 10)}
+	f.channels = append(f.channels, channel)
+	return &fakeAMQPConnection{channel: channel}, nil
+}
+
+func (f *fakeAMQPBroker) channel(i int) *fakeAMQPChannel {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.channels[i]
+}
+
+func newTestAMQPSource(t *testing.T, broker *fakeAMQPBroker) *AMQPSource {
+	t.Helper()
+	source, err := NewAMQPSource(AMQPConfig{
+		Queue:               "events",
+		Exchange:            "cloud",
+		ExchangeType:        "topic",
+		BindingKeys:         []string{"aws.#", "gcp.#"},
+		DeadLetterExchange:  "cloud-dead",
+		Prefetch:            2,
+		ReconnectMinBackoff: time.Millisecond,
+		ReconnectMaxBackoff: 4 * time.Millisecond,
+	}, broker.dial)
+	require.NoError(t, err)
+	return source
+}
+
+func TestAMQPSource_Topology(t *testing.T) {
+	broker := &fakeAMQPBroker{}
+	newTestAMQPSource(t, broker)
+
+	channel := broker.channel(0)
+	assert.Equal(t, 2, channel.prefetch)
+	assert.Equal(t, amqp.Table{"x-dead-letter-exchange": "cloud-dead"}, channel.queueArgs)
+	assert.Equal(t, []string{"cloud:aws.#", "cloud:gcp.#"}, channel.bindings)
+}
+
+func TestAMQPSource_ReceiveAndAcknowledge(t *testing.T) {
+	broker := &fakeAMQPBroker{}
+	source := newTestAMQPSource(t, broker)
+	acknowledger := &fakeAcknowledger{}
+	ctx := context.Background()
+
+	channel := broker.channel(0)
+	for tag := uint64(1); tag <= 3; tag++ {
+		channel.deliveries <- amqp.Delivery{Acknowledger: acknowledger, DeliveryTag: tag, Body: []byte("body")}
+	}
+
+	// A batch is capped by the prefetch limit
+	messages, err := source.Receive(ctx)
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+	assert.Equal(t, "delivery-1", messages[0].ID)
+
+	assert.NoError(t, source.Ack(ctx, messages[0]))
+	assert.NoError(t, source.Reject(ctx, messages[1]))
+
+	messages, err = source.Receive(ctx)
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	assert.NoError(t, source.Nack(ctx, messages[0], 0))
+
+	assert.Equal(t, []uint64{1}, acknowledger.acked)
+	assert.Equal(t, []uint64{2}, acknowledger.rejected)
+	assert.Equal(t, []uint64{3}, acknowledger.requeued)
+}
+
+func TestAMQPSource_ReconnectsWithBackoff(t *testing.T) {
+	broker := &fakeAMQPBroker{}
+	source := newTestAMQPSource(t, broker)
+	ctx := context.Background()
+
+	// The broker restarts and refuses the first reconnection attempts
+	broker.failures = 2
+	close(broker.channel(0).deliveries)
+
+	messages, err := source.Receive(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, messages)
+
+	broker.channel(1).deliveries <- amqp.Delivery{Acknowledger: &fakeAcknowledger{}, MessageId: "after-restart"}
+	messages, err = source.Receive(ctx)
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	assert.Equal(t, "after-restart", messages[0].ID)
+}
+
+func TestAMQPSource_DelayedNack(t *testing.T) {
+	broker := &fakeAMQPBroker{}
+	source := newTestAMQPSource(t, broker)
+	acknowledger := &fakeAcknowledger{}
+	ctx := context.Background()
+
+	broker.channel(0).deliveries <- amqp.Delivery{Acknowledger: acknowledger, DeliveryTag: 1}
+	messages, err := source.Receive(ctx)
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+
+	assert.NoError(t, source.Nack(ctx, messages[0], 10*time.Millisecond))
+	assert.Empty(t, acknowledger.requeuedTags())
+	assert.Eventually(t, func() bool { return len(acknowledger.requeuedTags()) == 1 }, time.Second, 5*time.Millisecond)
+
+	// A delivery of a channel closed before its delay elapses is not nacked, its tag being meaningless
+	broker.channel(0).deliveries <- amqp.Delivery{Acknowledger: acknowledger, DeliveryTag: 2}
+	messages, err = source.Receive(ctx)
+	require.NoError(t, err)
+	assert.NoError(t, source.Nack(ctx, messages[0], 20*time.Millisecond))
+
+	close(broker.channel(0).deliveries)
+	_, err = source.Receive(ctx)
+	require.NoError(t, err)
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, []uint64{1}, acknowledger.requeuedTags())
+}
+
+func TestAMQPSource_QueueArgumentsMismatch(t *testing.T) {
+	_, err := NewAMQPSource(AMQPConfig{Queue: "events", DeadLetterExchange: "cloud-dead", Prefetch: 1}, func(url string) (AMQPConnection, error) {
+		return &fakeAMQPConnection{channel: &fakeAMQPChannel{
+			declareErr: &amqp.Error{Code: amqp.PreconditionFailed, Reason: "PRECONDITION_FAILED - inequivalent arg 'x-dead-letter-exchange'"},
+		}}, nil
+	})
+	assert.ErrorContains(t, err, `declaring queue "events", whose dead-letter exchange differs from "cloud-dead"`)
+}
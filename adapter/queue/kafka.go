@@ -0,0 +1,158 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cvzm/go-web-project/domain"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// KafkaConfig is Kafka related configuration
+type KafkaConfig struct {
+	Brokers        []string
+	Topics         []string
+	GroupID        string // consumer group sharing the partitions of the topics
+	MaxPollRecords int    // maximum number of records returned by a single receive
+}
+
+// KafkaSource is an implementation of the domain.MessageSource interface backed by a Kafka consumer group.
+// Offsets are committed explicitly when a message is acked, and records of a partition share a GroupID
+// so that they are processed in order.
+type KafkaSource struct {
+	client         *kgo.Client
+	maxPollRecords int
+
+	mu      sync.Mutex
+	rewinds []kafkaRewind
+}
+
+// kafkaRewind is a pending request to consume a partition again from a nacked record
+type kafkaRewind struct {
+	record *kgo.Record
+	delay  time.Duration
+}
+
+// NewKafkaSource creates a new KafkaSource instance joining the configured consumer group
+func NewKafkaSource(conf KafkaConfig, opts ...kgo.Opt) (*KafkaSource, error) {
+	opts = append([]kgo.Opt{
+		kgo.SeedBrokers(conf.Brokers...),
+		kgo.ConsumerGroup(conf.GroupID),
+		kgo.ConsumeTopics(conf.Topics...),
+		kgo.DisableAutoCommit(),
+		kgo.ConsumeResetOffset(kgo.NewOffset().AtStart()),
+	}, opts...)
+	client, err := kgo.NewClient(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &KafkaSource{
+		client:         client,
+		maxPollRecords: conf.MaxPollRecords,
+	}, nil
+}
+
+// Receive polls the next records from the assigned partitions
+func (s *KafkaSource) Receive(ctx context.Context) ([]domain.Message, error) {
+	s.applyRewinds()
+
+	fetches := s.client.PollRecords(ctx, s.maxPollRecords)
+	if fetches.IsClientClosed() {
+		return nil, kgo.ErrClientClosed
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	var errs []error
+	fetches.EachError(func(topic string, partition int32, err error) {
+		errs = append(errs, fmt.Errorf("fetch %s/%d: %w", topic, partition, err))
+	})
+
+	messages := make([]domain.Message, 0, fetches.NumRecords())
+	fetches.EachRecord(func(record *kgo.Record) {
+		messages = append(messages, domain.Message{
+			ID:      fmt.Sprintf("%s/%d/%d", record.Topic, record.Partition, record.Offset),
+			Body:    record.Value,
+			GroupID: fmt.Sprintf("%s/%d", record.Topic, record.Partition),
+			Handle:  record,
+		})
+	})
+	if len(messages) == 0 {
+		return nil, errors.Join(errs...)
+	}
+	return messages, nil
+}
+
+// Ack commits the offset following the record
+func (s *KafkaSource) Ack(ctx context.Context, message domain.Message) error {
+	record, err := kafkaRecord(message)
+	if err != nil {
+		return err
+	}
+	return s.client.CommitRecords(ctx, record)
+}
+
+// Nack rewinds the partition to the record so it is consumed again after the delay.
+// The rewind is applied on the next Receive, once the current batch has been processed.
+func (s *KafkaSource) Nack(ctx context.Context, message domain.Message, delay time.Duration) error {
+	record, err := kafkaRecord(message)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rewinds = append(s.rewinds, kafkaRewind{record: record, delay: delay})
+	return nil
+}
+
+// Reject commits the offset following the record, so the partition moves past it
+func (s *KafkaSource) Reject(ctx context.Context, message domain.Message) error {
+	return s.Ack(ctx, message)
+}
+
+// Extend is a no-op, Kafka records are not leased
+func (s *KafkaSource) Extend(ctx context.Context, message domain.Message, lease time.Duration) error {
+	return nil
+}
+
+// Close leaves the consumer group and closes the client
+func (s *KafkaSource) Close() error {
+	s.client.Close()
+	return nil
+}
+
+// applyRewinds seeks nacked partitions back to their failed record, pausing them for the nack delay
+func (s *KafkaSource) applyRewinds() {
+	s.mu.Lock()
+	rewinds := s.rewinds
+	s.rewinds = nil
+	s.mu.Unlock()
+
+	for _, rewind := range rewinds {
+		record := rewind.record
+		partitions := map[string][]int32{record.Topic: {record.Partition}}
+		if rewind.delay > 0 {
+			s.client.PauseFetchPartitions(partitions)
+			time.AfterFunc(rewind.delay, func() {
+				s.client.ResumeFetchPartitions(partitions)
+			})
+		}
+		s.client.SetOffsets(map[string]map[int32]kgo.EpochOffset{
+			record.Topic: {record.Partition: {Epoch: record.LeaderEpoch, Offset: record.Offset}},
+		})
+	}
+}
+
+// kafkaRecord returns the Kafka record backing the message
+func kafkaRecord(message domain.Message) (*kgo.Record, error) {
+	record, ok := message.Handle.(*kgo.Record)
+	if !ok {
+		return nil, fmt.Errorf("message %q is not a Kafka record", message.ID)
+	}
+	return record, nil
+}
@@ -0,0 +1,100 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kfake"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+const testTopic = "events"
+
+// newFakeKafka starts an in-process Kafka cluster and returns its broker addresses
+func newFakeKafka(t *testing.T) []string {
+	t.Helper()
+	cluster, err := kfake.NewCluster(kfake.NumBrokers(1), kfake.SeedTopics(1, testTopic))
+	require.NoError(t, err)
+	t.Cleanup(cluster.Close)
+	return cluster.ListenAddrs()
+}
+
+// produce writes the values to the test topic
+func produce(t *testing.T, brokers []string, values ...string) {
+	t.Helper()
+	client, err := kgo.NewClient(kgo.SeedBrokers(brokers...), kgo.DefaultProduceTopic(testTopic))
+	require.NoError(t, err)
+	defer client.Close()
+
+	for _, value := range values {
+		require.NoError(t, client.ProduceSync(context.Background(), kgo.StringRecord(value)).FirstErr())
+	}
+}
+
+// receiveValues receives a batch of messages and returns their bodies
+func receiveValues(t *testing.T, source *KafkaSource) []string {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	messages, err := source.Receive(ctx)
+	require.NoError(t, err)
+
+	values := make([]string, 0, len(messages))
+	for _, message := range messages {
+		assert.Equal(t, testTopic+"/0", message.GroupID)
+		values = append(values, string(message.Body))
+	}
+	return values
+}
+
+func newTestKafkaSource(t *testing.T, brokers []string) *KafkaSource {
+	t.Helper()
+	source, err := NewKafkaSource(KafkaConfig{
+		Brokers:        brokers,
+		Topics:         []string{testTopic},
+		GroupID:        "event-consumers",
+		MaxPollRecords: 10,
+	})
+	require.NoError(t, err)
+	return source
+}
+
+func TestKafkaSource_AckCommitsOffset(t *testing.T) {
+	brokers := newFakeKafka(t)
+	produce(t, brokers, "first", "second")
+
+	source := newTestKafkaSource(t, brokers)
+	ctx := context.Background()
+
+	messages, err := source.Receive(ctx)
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+	assert.NoError(t, source.Ack(ctx, messages[0]))
+	assert.NoError(t, source.Close())
+
+	// A new member of the group resumes after the committed offset
+	source = newTestKafkaSource(t, brokers)
+	defer source.Close()
+	assert.Equal(t, []string{"second"}, receiveValues(t, source))
+}
+
+func TestKafkaSource_NackRedelivers(t *testing.T) {
+	brokers := newFakeKafka(t)
+	produce(t, brokers, "first", "second")
+
+	source := newTestKafkaSource(t, brokers)
+	defer source.Close()
+	ctx := context.Background()
+
+	messages, err := source.Receive(ctx)
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+	assert.NoError(t, source.Ack(ctx, messages[0]))
+	assert.NoError(t, source.Nack(ctx, messages[1], 0))
+
+	assert.Equal(t, []string{"second"}, receiveValues(t, source))
+}
@@ -0,0 +1,171 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/cvzm/go-web-project/domain"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// NATSConfig is NATS JetStream related configuration
+type NATSConfig struct {
+	URL           string
+	Stream        string        // stream holding the events
+	Subject       string        // subject filter of the consumer, all stream subjects if empty
+	Durable       string        // name of the durable pull consumer
+	MaxMessages   int           // maximum number of messages returned by a single receive
+	MaxWait       time.Duration // maximum duration a receive waits for messages
+	AckWait       time.Duration // duration after which unacknowledged messages are redelivered
+	MaxDeliveries int           // maximum number of deliveries of a message, unlimited if zero
+}
+
+// NATSSource is an implementation of the domain.MessageSource interface backed by
+// a durable JetStream pull consumer. Messages carry no GroupID: JetStream delivers the next
+// messages of a subject while a nacked one awaits its redelivery, so there is no per-subject ordering.
+type NATSSource struct {
+	conn     *nats.Conn
+	consumer jetstream.Consumer
+	conf     NATSConfig
+}
+
+// NewNATSSource connects to NATS and creates or updates the durable consumer
+func NewNATSSource(ctx context.Context, conf NATSConfig) (*NATSSource, error) {
+	conn, err := nats.Connect(conf.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	consumerConfig := jetstream.ConsumerConfig{
+		Durable:       conf.Durable,
+		FilterSubject: conf.Subject,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		AckWait:       conf.AckWait,
+		MaxDeliver:    conf.MaxDeliveries,
+	}
+	if consumerConfig.MaxDeliver == 0 {
+		consumerConfig.MaxDeliver = -1
+	}
+	consumer, err := js.CreateOrUpdateConsumer(ctx, conf.Stream, consumerConfig)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return &NATSSource{
+		conn:     conn,
+		consumer: consumer,
+		conf:     conf,
+	}, nil
+}
+
+// Receive pulls the next batch of messages from the consumer
+func (s *NATSSource) Receive(ctx context.Context) ([]domain.Message, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var opts []jetstream.FetchOpt
+	if s.conf.MaxWait > 0 {
+		opts = append(opts, jetstream.FetchMaxWait(s.conf.MaxWait))
+	}
+	batch, err := s.consumer.Fetch(s.conf.MaxMessages, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	var messages []domain.Message
+	for {
+		select {
+		case <-ctx.Done():
+			// The pull request can't be cancelled, the messages it already delivered are redelivered right
+			// away rather than after the ack wait
+			for _, message := range messages {
+				if err := message.Handle.(jetstream.Msg).Nak(); err != nil {
+					log.Printf("Error requeuing NATS message %q: %v", message.ID, err)
+				}
+			}
+			return nil, ctx.Err()
+		case msg, ok := <-batch.Messages():
+			if !ok {
+				return messages, batch.Error()
+			}
+			messages = append(messages, natsMessage(msg))
+		}
+	}
+}
+
+// natsMessage converts the JetStream message, identified by its stream sequence
+func natsMessage(msg jetstream.Msg) domain.Message {
+	id := msg.Subject()
+	if metadata, err := msg.Metadata(); err == nil {
+		id = fmt.Sprintf("%s/%d", metadata.Stream, metadata.Sequence.Stream)
+	}
+	return domain.Message{
+		ID:              id,
+		Body:            msg.Data(),
+		DeduplicationID: msg.Headers().Get(jetstream.MsgIDHeader),
+		Handle:          msg,
+	}
+}
+
+// Ack acknowledges the message once it is persisted
+func (s *NATSSource) Ack(ctx context.Context, message domain.Message) error {
+	msg, err := jetStreamMsg(message)
+	if err != nil {
+		return err
+	}
+	return msg.Ack()
+}
+
+// Nack asks the server to redeliver the message after the delay. The next messages of its subject
+// are delivered in the meantime.
+func (s *NATSSource) Nack(ctx context.Context, message domain.Message, delay time.Duration) error {
+	msg, err := jetStreamMsg(message)
+	if err != nil {
+		return err
+	}
+	return msg.NakWithDelay(delay)
+}
+
+// Reject terminates the message, so it is never redelivered
+func (s *NATSSource) Reject(ctx context.Context, message domain.Message) error {
+	msg, err := jetStreamMsg(message)
+	if err != nil {
+		return err
+	}
+	return msg.Term()
+}
+
+// Extend resets the ack wait of the message, the lease is the configured ack wait
+func (s *NATSSource) Extend(ctx context.Context, message domain.Message, lease time.Duration) error {
+	msg, err := jetStreamMsg(message)
+	if err != nil {
+		return err
+	}
+	return msg.InProgress()
+}
+
+// Close drains and closes the NATS connection
+func (s *NATSSource) Close() error {
+	return s.conn.Drain()
+}
+
+// jetStreamMsg returns the JetStream message backing the message
+func jetStreamMsg(message domain.Message) (jetstream.Msg, error) {
+	msg, ok := message.Handle.(jetstream.Msg)
+	if !ok {
+		return nil, fmt.Errorf("message %q is not a JetStream message", message.ID)
+	}
+	return msg, nil
+}
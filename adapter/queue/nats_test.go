@@ -0,0 +1,138 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newEmbeddedNATS starts an in-process NATS server with an "EVENTS" stream and returns its URL
+func newEmbeddedNATS(t *testing.T) string {
+	t.Helper()
+	srv, err := server.NewServer(&server.Options{
+		Port:      server.RANDOM_PORT,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+	})
+	require.NoError(t, err)
+	go srv.Start()
+	t.Cleanup(srv.Shutdown)
+	require.True(t, srv.ReadyForConnections(5*time.Second))
+
+	conn, err := nats.Connect(srv.ClientURL())
+	require.NoError(t, err)
+	defer conn.Close()
+	js, err := jetstream.New(conn)
+	require.NoError(t, err)
+	_, err = js.CreateStream(context.Background(), jetstream.StreamConfig{
+		Name:     "EVENTS",
+		Subjects: []string{"events.>"},
+	})
+	require.NoError(t, err)
+
+	return srv.ClientURL()
+}
+
+// publish writes the message to JetStream with a message ID
+func publish(t *testing.T, url, subject, msgID, data string) {
+	t.Helper()
+	conn, err := nats.Connect(url)
+	require.NoError(t, err)
+	defer conn.Close()
+	js, err := jetstream.New(conn)
+	require.NoError(t, err)
+	_, err = js.Publish(context.Background(), subject, []byte(data), jetstream.WithMsgID(msgID))
+	require.NoError(t, err)
+}
+
+func newTestNATSSource(t *testing.T, url string) *NATSSource {
+	t.Helper()
+	source, err := NewNATSSource(context.Background(), NATSConfig{
+		URL:         url,
+		Stream:      "EVENTS",
+		Subject:     "events.aws",
+		Durable:     "event-consumer",
+		MaxMessages: 10,
+		MaxWait:     200 * time.Millisecond,
+		AckWait:     time.Minute,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { source.Close() })
+	return source
+}
+
+func TestNATSSource_Receive(t *testing.T) {
+	url := newEmbeddedNATS(t)
+	publish(t, url, "events.aws", "msg-1", "first")
+	publish(t, url, "events.gcp", "msg-2", "filtered out")
+
+	source := newTestNATSSource(t, url)
+	messages, err := source.Receive(context.Background())
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	assert.Equal(t, "EVENTS/1", messages[0].ID)
+	assert.Equal(t, []byte("first"), messages[0].Body)
+	assert.Empty(t, messages[0].GroupID)
+	assert.Equal(t, "msg-1", messages[0].DeduplicationID)
+}
+
+func TestNATSSource_ReceiveCancelled(t *testing.T) {
+	url := newEmbeddedNATS(t)
+	publish(t, url, "events.aws", "msg-1", "first")
+	source, err := NewNATSSource(context.Background(), NATSConfig{
+		URL:         url,
+		Stream:      "EVENTS",
+		Subject:     "events.aws",
+		Durable:     "event-consumer",
+		MaxMessages: 10,
+		MaxWait:     time.Minute,
+		AckWait:     time.Minute,
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(200*time.Millisecond, cancel)
+
+	// Receive returns once the context is cancelled rather than after the max wait
+	start := time.Now()
+	_, err = source.Receive(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, time.Since(start), 5*time.Second)
+	require.NoError(t, source.Close())
+
+	// The message it received is redelivered right away rather than after the ack wait
+	messages, err := newTestNATSSource(t, url).Receive(context.Background())
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	assert.Equal(t, []byte("first"), messages[0].Body)
+}
+
+func TestNATSSource_AckNackReject(t *testing.T) {
+	url := newEmbeddedNATS(t)
+	publish(t, url, "events.aws", "msg-1", "acked")
+	publish(t, url, "events.aws", "msg-2", "nacked")
+	publish(t, url, "events.aws", "msg-3", "rejected")
+
+	source := newTestNATSSource(t, url)
+	ctx := context.Background()
+
+	messages, err := source.Receive(ctx)
+	require.NoError(t, err)
+	require.Len(t, messages, 3)
+	assert.NoError(t, source.Ack(ctx, messages[0]))
+	assert.NoError(t, source.Extend(ctx, messages[1], time.Minute))
+	assert.NoError(t, source.Nack(ctx, messages[1], 0))
+	assert.NoError(t, source.Reject(ctx, messages[2]))
+
+	// Only the nacked message is redelivered
+	messages, err = source.Receive(ctx)
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	assert.Equal(t, []byte("nacked"), messages[0].Body)
+}
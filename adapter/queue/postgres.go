@@ -0,0 +1,281 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/cvzm/go-web-project/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// QueueMessageStatus represents the state of a message in the Postgres work queue
+type QueueMessageStatus string
+
+// Constants defining the states of a queue message
+const (
+	QueueMessagePending QueueMessageStatus = "pending" // waiting to be processed, or leased if AvailableAt is in the future
+	QueueMessageDead    QueueMessageStatus = "dead"    // rejected, or out of attempts
+)
+
+// ErrLeaseLost is returned when a message was leased again or dead-lettered since it was received
+var ErrLeaseLost = errors.New("lease lost")
+
+// QueueMessage struct defines a message of the Postgres work queue
+type QueueMessage struct {
+	ID              uint               `gorm:"primaryKey"`
+	Queue           string             `gorm:"type:varchar(100);not null;index:idx_queue_messages_dequeue,priority:1;uniqueIndex:idx_queue_messages_live_dedup,priority:1,where:status <> 'dead';index:idx_queue_messages_group,priority:1"`
+	Body            string             `gorm:"type:text;not null"`
+	GroupID         string             `gorm:"type:varchar(255);index:idx_queue_messages_group,priority:2"`
+	DeduplicationID *string            `gorm:"type:varchar(255);uniqueIndex:idx_queue_messages_live_dedup,priority:2"`
+	Status          QueueMessageStatus `gorm:"type:varchar(20);not null;index:idx_queue_messages_dequeue,priority:2"`
+	Attempts        int                `gorm:"not null;default:0"`
+	AvailableAt     time.Time          `gorm:"not null;index:idx_queue_messages_dequeue,priority:3"`
+	LastError       string             `gorm:"type:text"`
+	CreatedAt       time.Time          `gorm:"autoCreateTime"`
+	UpdatedAt       time.Time          `gorm:"autoUpdateTime"`
+}
+
+// TableName returns the name of the queue messages table
+func (QueueMessage) TableName() string {
+	return "queue_messages"
+}
+
+// PostgresQueueConfig is Postgres work queue related configuration
+type PostgresQueueConfig struct {
+	Queue             string        // name of the queue, several queues can share the table
+	BatchSize         int           // maximum number of messages returned by a single receive
+	VisibilityTimeout time.Duration // duration received messages stay leased to the consumer
+	PollInterval      time.Duration // pause after a receive that found no message
+	MaxAttempts       int           // maximum number of attempts before dead-lettering, unlimited if zero
+	MaxBackoff        time.Duration // maximum delay between two attempts
+}
+
+// PostgresSource is an implementation of the domain.MessageSource interface backed by a Postgres table.
+// Messages are dequeued with FOR UPDATE SKIP LOCKED and leased until acked, nacked or expired. A lease is
+// identified by the number of attempts of the message, so that a consumer whose lease expired can't settle
+// the message leased by another one.
+type PostgresSource struct {
+	db   *gorm.DB
+	conf PostgresQueueConfig
+}
+
+// NewPostgresSource creates a new PostgresSource instance
+func NewPostgresSource(db *gorm.DB, conf PostgresQueueConfig) *PostgresSource {
+	return &PostgresSource{
+		db:   db,
+		conf: conf,
+	}
+}
+
+// Enqueue adds a message to the queue. Messages with the deduplication ID of a message
+// still pending are ignored.
+func (s *PostgresSource) Enqueue(ctx context.Context, body []byte, groupID, deduplicationID string) error {
+	message := &QueueMessage{
+		Queue:       s.conf.Queue,
+		Body:        string(body),
+		GroupID:     groupID,
+		Status:      QueueMessagePending,
+		AvailableAt: time.Now(),
+	}
+	if deduplicationID != "" {
+		message.DeduplicationID = &deduplicationID
+	}
+	return s.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(message).Error
+}
+
+// Receive leases the next available messages, waiting for the poll interval if there is none
+func (s *PostgresSource) Receive(ctx context.Context) ([]domain.Message, error) {
+	rows, err := s.dequeue(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		select {
+		case <-time.After(s.conf.PollInterval):
+		case <-ctx.Done():
+		}
+		return nil, nil
+	}
+
+	messages := make([]domain.Message, 0, len(rows))
+	for _, row := range rows {
+		message := domain.Message{
+			ID:      strconv.FormatUint(uint64(row.ID), 10),
+			Body:    []byte(row.Body),
+			GroupID: row.GroupID,
+			Handle:  row,
+		}
+		if row.DeduplicationID != nil {
+			message.DeduplicationID = *row.DeduplicationID
+		}
+		messages = append(messages, message)
+	}
+	return messages, nil
+}
+
+// Ack deletes the processed message
+func (s *PostgresSource) Ack(ctx context.Context, message domain.Message) error {
+	row, err := queueMessage(message)
+	if err != nil {
+		return err
+	}
+	result := s.db.WithContext(ctx).Where("id = ? AND attempts = ?", row.ID, row.Attempts).Delete(&QueueMessage{})
+	return leaseResult(row, result)
+}
+
+// Nack makes the message available again after an exponential backoff based on the delay
+// and its number of attempts, or dead-letters it once out of attempts
+func (s *PostgresSource) Nack(ctx context.Context, message domain.Message, delay time.Duration) error {
+	row, err := queueMessage(message)
+	if err != nil {
+		return err
+	}
+	if s.conf.MaxAttempts > 0 && row.Attempts >= s.conf.MaxAttempts {
+		return s.markDead(ctx, row, fmt.Sprintf("out of attempts after %d attempt(s)", row.Attempts))
+	}
+	return s.update(ctx, row, map[string]any{
+		"available_at": time.Now().Add(s.backoff(delay, row.Attempts)),
+	})
+}
+
+// Reject dead-letters the message
+func (s *PostgresSource) Reject(ctx context.Context, message domain.Message) error {
+	row, err := queueMessage(message)
+	if err != nil {
+		return err
+	}
+	return s.markDead(ctx, row, "rejected")
+}
+
+// Extend renews the lease of the message
+func (s *PostgresSource) Extend(ctx context.Context, message domain.Message, lease time.Duration) error {
+	row, err := queueMessage(message)
+	if err != nil {
+		return err
+	}
+	return s.update(ctx, row, map[string]any{
+		"available_at": time.Now().Add(lease),
+	})
+}
+
+// Close is a no-op, the database connection is owned by the application
+func (s *PostgresSource) Close() error {
+	return nil
+}
+
+// Replay makes dead messages pending again, with their attempts reset. Dead messages whose
+// deduplication ID was enqueued again since are left dead.
+func (s *PostgresSource) Replay(ctx context.Context, limit int) (int, error) {
+	dead := s.db.Model(&QueueMessage{}).Select("id").
+		Where("queue = ? AND status = ?", s.conf.Queue, QueueMessageDead).
+		Where("deduplication_id IS NULL OR NOT EXISTS (SELECT 1 FROM queue_messages live WHERE live.queue = queue_messages.queue "+
+			"AND live.deduplication_id = queue_messages.deduplication_id AND live.status <> ?)", QueueMessageDead).
+		Order("id")
+	if limit > 0 {
+		dead = dead.Limit(limit)
+	}
+
+	result := s.db.WithContext(ctx).Model(&QueueMessage{}).Where("id IN (?)", dead).Updates(map[string]any{
+		"status":       QueueMessagePending,
+		"attempts":     0,
+		"available_at": time.Now(),
+		"last_error":   "",
+	})
+	return int(result.RowsAffected), result.Error
+}
+
+// dequeue locks the next available messages, skipping those locked by other consumers,
+// and leases them by pushing back their availability. Only the first pending message of
+// a group is dequeued, so that the messages of a group are processed in order across
+// consumers. Messages whose lease expired without being acked or nacked for their last
+// attempt are dead-lettered first.
+func (s *PostgresSource) dequeue(ctx context.Context) ([]QueueMessage, error) {
+	var rows []QueueMessage
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if s.conf.MaxAttempts > 0 {
+			err := tx.Model(&QueueMessage{}).
+				Where("queue = ? AND status = ? AND available_at <= ? AND attempts >= ?", s.conf.Queue, QueueMessagePending, now, s.conf.MaxAttempts).
+				Updates(map[string]any{
+					"status":     QueueMessageDead,
+					"last_error": "lease expired on last attempt",
+				}).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("queue = ? AND status = ? AND available_at <= ?", s.conf.Queue, QueueMessagePending, now).
+			Where("group_id IS NULL OR group_id = '' OR NOT EXISTS (SELECT 1 FROM queue_messages earlier WHERE earlier.queue = queue_messages.queue "+
+				"AND earlier.group_id = queue_messages.group_id AND earlier.status = ? AND earlier.id < queue_messages.id)", QueueMessagePending).
+			Order("id").
+			Limit(s.conf.BatchSize).
+			Find(&rows).Error
+		if err != nil || len(rows) == 0 {
+			return err
+		}
+
+		ids := make([]uint, len(rows))
+		for i := range rows {
+			ids[i] = rows[i].ID
+			rows[i].Attempts++
+		}
+		return tx.Model(&QueueMessage{}).Where("id IN ?", ids).Updates(map[string]any{
+			"attempts":     gorm.Expr("attempts + 1"),
+			"available_at": now.Add(s.conf.VisibilityTimeout),
+		}).Error
+	})
+	return rows, err
+}
+
+// backoff returns the delay before the next attempt, doubling the base delay for each previous attempt
+func (s *PostgresSource) backoff(delay time.Duration, attempts int) time.Duration {
+	backoff := float64(delay) * math.Pow(2, float64(max(attempts-1, 0)))
+	if s.conf.MaxBackoff > 0 && backoff > float64(s.conf.MaxBackoff) {
+		return s.conf.MaxBackoff
+	}
+	return time.Duration(min(backoff, math.MaxInt64))
+}
+
+// markDead moves the message to the dead state
+func (s *PostgresSource) markDead(ctx context.Context, row QueueMessage, reason string) error {
+	return s.update(ctx, row, map[string]any{
+		"status":     QueueMessageDead,
+		"last_error": reason,
+	})
+}
+
+// update updates the columns of the message, as long as it is still leased for the same attempt
+func (s *PostgresSource) update(ctx context.Context, row QueueMessage, columns map[string]any) error {
+	result := s.db.WithContext(ctx).Model(&QueueMessage{}).
+		Where("id = ? AND attempts = ? AND status = ?", row.ID, row.Attempts, QueueMessagePending).
+		Updates(columns)
+	return leaseResult(row, result)
+}
+
+// leaseResult returns ErrLeaseLost if the statement scoped to the lease of the message affected no row
+func leaseResult(row QueueMessage, result *gorm.DB) error {
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("message %d: %w", row.ID, ErrLeaseLost)
+	}
+	return nil
+}
+
+// queueMessage returns the queue row backing the message
+func queueMessage(message domain.Message) (QueueMessage, error) {
+	row, ok := message.Handle.(QueueMessage)
+	if !ok {
+		return QueueMessage{}, fmt.Errorf("message %q is not a Postgres queue message", message.ID)
+	}
+	return row, nil
+}
@@ -0,0 +1,157 @@
+package queue
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/cvzm/go-web-project/adapter/storage"
+	"github.com/cvzm/go-web-project/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestPostgresSource(t *testing.T) (*PostgresSource, sqlmock.Sqlmock) {
+	t.Helper()
+	gormDB, mock := storage.GetMockDB(t)
+	source := NewPostgresSource(gormDB, PostgresQueueConfig{
+		Queue:             "events",
+		BatchSize:         10,
+		VisibilityTimeout: time.Minute,
+		PollInterval:      time.Millisecond,
+		MaxAttempts:       3,
+		MaxBackoff:        time.Hour,
+	})
+	return source, mock
+}
+
+func TestPostgresSource_Enqueue(t *testing.T) {
+	source, mock := newTestPostgresSource(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "queue_messages"`)+".*"+regexp.QuoteMeta(`ON CONFLICT DO NOTHING`)).
+		WithArgs("events", "body", "group", "dedup", QueueMessagePending, 0, sqlmock.AnyArg(), "", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	err := source.Enqueue(context.Background(), []byte("body"), "group", "dedup")
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresSource_Receive(t *testing.T) {
+	source, mock := newTestPostgresSource(t)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "queue_messages" SET "last_error"=$1,"status"=$2,"updated_at"=$3 WHERE queue = $4 AND status = $5 AND available_at <= $6 AND attempts >= $7`)).
+		WithArgs("lease expired on last attempt", QueueMessageDead, sqlmock.AnyArg(), "events", QueueMessagePending, sqlmock.AnyArg(), 3).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "queue_messages" WHERE (queue = $1 AND status = $2 AND available_at <= $3) AND (group_id IS NULL OR group_id = '' `+
+		`OR NOT EXISTS (SELECT 1 FROM queue_messages earlier WHERE earlier.queue = queue_messages.queue AND earlier.group_id = queue_messages.group_id `+
+		`AND earlier.status = $4 AND earlier.id < queue_messages.id)) ORDER BY id LIMIT $5 FOR UPDATE SKIP LOCKED`)).
+		WithArgs("events", QueueMessagePending, sqlmock.AnyArg(), QueueMessagePending, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "queue", "body", "group_id", "deduplication_id", "status", "attempts"}).
+			AddRow(7, "events", "body", "group", "dedup", QueueMessagePending, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "queue_messages" SET "attempts"=attempts + 1,"available_at"=$1,"updated_at"=$2 WHERE id IN ($3)`)).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	messages, err := source.Receive(context.Background())
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	assert.Equal(t, "7", messages[0].ID)
+	assert.Equal(t, []byte("body"), messages[0].Body)
+	assert.Equal(t, "group", messages[0].GroupID)
+	assert.Equal(t, "dedup", messages[0].DeduplicationID)
+	assert.Equal(t, 2, messages[0].Handle.(QueueMessage).Attempts)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresSource_Ack(t *testing.T) {
+	t.Run("Deletes the message", func(t *testing.T) {
+		source, mock := newTestPostgresSource(t)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "queue_messages" WHERE id = $1 AND attempts = $2`)).
+			WithArgs(7, 2).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := source.Ack(context.Background(), domain.Message{ID: "7", Handle: QueueMessage{ID: 7, Attempts: 2}})
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Lease lost", func(t *testing.T) {
+		source, mock := newTestPostgresSource(t)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "queue_messages" WHERE id = $1 AND attempts = $2`)).
+			WithArgs(7, 2).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		err := source.Ack(context.Background(), domain.Message{ID: "7", Handle: QueueMessage{ID: 7, Attempts: 2}})
+		assert.ErrorIs(t, err, ErrLeaseLost)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPostgresSource_Nack(t *testing.T) {
+	t.Run("Retry later", func(t *testing.T) {
+		source, mock := newTestPostgresSource(t)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "queue_messages" SET "available_at"=$1,"updated_at"=$2 WHERE id = $3 AND attempts = $4 AND status = $5`)).
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), 7, 2, QueueMessagePending).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := source.Nack(context.Background(), domain.Message{ID: "7", Handle: QueueMessage{ID: 7, Attempts: 2}}, time.Second)
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Out of attempts", func(t *testing.T) {
+		source, mock := newTestPostgresSource(t)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "queue_messages" SET "last_error"=$1,"status"=$2,"updated_at"=$3 WHERE id = $4 AND attempts = $5 AND status = $6`)).
+			WithArgs("out of attempts after 3 attempt(s)", QueueMessageDead, sqlmock.AnyArg(), 7, 3, QueueMessagePending).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := source.Nack(context.Background(), domain.Message{ID: "7", Handle: QueueMessage{ID: 7, Attempts: 3}}, time.Second)
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPostgresSource_Replay(t *testing.T) {
+	source, mock := newTestPostgresSource(t)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "queue_messages" SET "attempts"=$1,"available_at"=$2,"last_error"=$3,"status"=$4,"updated_at"=$5 `+
+		`WHERE id IN (SELECT "id" FROM "queue_messages" WHERE (queue = $6 AND status = $7) AND (deduplication_id IS NULL OR NOT EXISTS `+
+		`(SELECT 1 FROM queue_messages live WHERE live.queue = queue_messages.queue AND live.deduplication_id = queue_messages.deduplication_id `+
+		`AND live.status <> $8)) ORDER BY id LIMIT $9)`)).
+		WithArgs(0, sqlmock.AnyArg(), "", QueueMessagePending, sqlmock.AnyArg(), "events", QueueMessageDead, QueueMessageDead, 5).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	replayed, err := source.Replay(context.Background(), 5)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, replayed)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresSource_Backoff(t *testing.T) {
+	source, _ := newTestPostgresSource(t)
+
+	assert.Equal(t, time.Second, source.backoff(time.Second, 1))
+	assert.Equal(t, 4*time.Second, source.backoff(time.Second, 3))
+	assert.Equal(t, time.Hour, source.backoff(time.Second, 100))
+}
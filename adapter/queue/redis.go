@@ -0,0 +1,273 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cvzm/go-web-project/domain"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Fields of the stream entries
+const (
+	RedisBodyField          = "body"           // field holding the message body
+	RedisOriginalIDField    = "original_id"    // dead-letter field holding the ID of the original entry
+	RedisDeliveryCountField = "delivery_count" // dead-letter field holding the number of deliveries
+)
+
+// RedisConfig is Redis Streams related configuration
+type RedisConfig struct {
+	Addr             string
+	Password         string
+	DB               int
+	Stream           string        // stream holding the events
+	Group            string        // consumer group sharing the stream entries
+	Consumer         string        // name of this consumer within the group
+	DeadLetterStream string        // stream receiving entries that exceeded MaxDeliveries or were rejected
+	Count            int64         // maximum number of entries returned by a single receive
+	Block            time.Duration // maximum duration a receive waits for new entries
+	ClaimMinIdle     time.Duration // idle time after which pending entries are reclaimed
+	MaxDeliveries    int64         // maximum number of deliveries before dead-lettering, unlimited if zero
+}
+
+// RedisSource is an implementation of the domain.MessageSource interface backed by a Redis Streams consumer group.
+// Entries that are not acked stay pending, and are reclaimed with XAUTOCLAIM once idle for ClaimMinIdle.
+type RedisSource struct {
+	client redis.UniversalClient
+	conf   RedisConfig
+
+	mu          sync.Mutex
+	claimCursor string
+}
+
+// NewRedisSource connects to Redis and creates the consumer group if needed
+func NewRedisSource(ctx context.Context, conf RedisConfig) (*RedisSource, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     conf.Addr,
+		Password: conf.Password,
+		DB:       conf.DB,
+	})
+	source, err := NewRedisSourceWithClient(ctx, client, conf)
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+	return source, nil
+}
+
+// NewRedisSourceWithClient creates a new RedisSource instance on top of the given Redis client
+func NewRedisSourceWithClient(ctx context.Context, client redis.UniversalClient, conf RedisConfig) (*RedisSource, error) {
+	// Consume the entries already in the stream when the group is created
+	err := client.XGroupCreateMkStream(ctx, conf.Stream, conf.Group, "0").Err()
+	if err != nil && !isBusyGroup(err) {
+		return nil, err
+	}
+
+	return &RedisSource{
+		client:      client,
+		conf:        conf,
+		claimCursor: "0-0",
+	}, nil
+}
+
+// Receive reclaims stale pending entries, then reads new entries of the stream
+func (s *RedisSource) Receive(ctx context.Context) ([]domain.Message, error) {
+	entries, err := s.reclaim(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(entries)) < s.conf.Count {
+		streams, err := s.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    s.conf.Group,
+			Consumer: s.conf.Consumer,
+			Streams:  []string{s.conf.Stream, ">"},
+			Count:    s.conf.Count - int64(len(entries)),
+			Block:    s.conf.Block,
+		}).Result()
+		// Reclaimed entries are still returned if reading new entries fails
+		if err != nil && !errors.Is(err, redis.Nil) && len(entries) == 0 {
+			return nil, err
+		}
+		for _, stream := range streams {
+			entries = append(entries, stream.Messages...)
+		}
+	}
+
+	messages := make([]domain.Message, 0, len(entries))
+	for _, entry := range entries {
+		body, _ := entry.Values[RedisBodyField].(string)
+		messages = append(messages, domain.Message{
+			ID:     entry.ID,
+			Body:   []byte(body),
+			Handle: entry,
+		})
+	}
+	return messages, nil
+}
+
+// Ack acknowledges the entry, removing it from the pending entries list
+func (s *RedisSource) Ack(ctx context.Context, message domain.Message) error {
+	return s.client.XAck(ctx, s.conf.Stream, s.conf.Group, message.ID).Err()
+}
+
+// Nack leaves the entry pending. Redis Streams have no redelivery delay, so the entry
+// is redelivered once reclaimed after ClaimMinIdle, whatever the delay.
+func (s *RedisSource) Nack(ctx context.Context, message domain.Message, delay time.Duration) error {
+	return nil
+}
+
+// Reject moves the entry to the dead-letter stream
+func (s *RedisSource) Reject(ctx context.Context, message domain.Message) error {
+	entry, ok := message.Handle.(redis.XMessage)
+	if !ok {
+		return fmt.Errorf("message %q is not a Redis stream entry", message.ID)
+	}
+	return s.deadLetter(ctx, entry, 0)
+}
+
+// Extend resets the idle time of the entry, so it is not reclaimed while being processed
+func (s *RedisSource) Extend(ctx context.Context, message domain.Message, lease time.Duration) error {
+	return s.client.XClaimJustID(ctx, &redis.XClaimArgs{
+		Stream:   s.conf.Stream,
+		Group:    s.conf.Group,
+		Consumer: s.conf.Consumer,
+		Messages: []string{message.ID},
+	}).Err()
+}
+
+// Close closes the Redis client
+func (s *RedisSource) Close() error {
+	return s.client.Close()
+}
+
+// Replay moves entries of the dead-letter stream back to the stream
+func (s *RedisSource) Replay(ctx context.Context, limit int) (int, error) {
+	var entries []redis.XMessage
+	var err error
+	if limit > 0 {
+		entries, err = s.client.XRangeN(ctx, s.conf.DeadLetterStream, "-", "+", int64(limit)).Result()
+	} else {
+		entries, err = s.client.XRange(ctx, s.conf.DeadLetterStream, "-", "+").Result()
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	for i, entry := range entries {
+		values := make(map[string]any, len(entry.Values))
+		for field, value := range entry.Values {
+			if field != RedisOriginalIDField && field != RedisDeliveryCountField {
+				values[field] = value
+			}
+		}
+
+		_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.XAdd(ctx, &redis.XAddArgs{Stream: s.conf.Stream, Values: values})
+			pipe.XDel(ctx, s.conf.DeadLetterStream, entry.ID)
+			return nil
+		})
+		if err != nil {
+			return i, err
+		}
+	}
+	return len(entries), nil
+}
+
+// reclaim claims entries pending for longer than ClaimMinIdle, and dead-letters those
+// delivered more than MaxDeliveries times
+func (s *RedisSource) reclaim(ctx context.Context) ([]redis.XMessage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	claimed, next, err := s.client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+		Stream:   s.conf.Stream,
+		Group:    s.conf.Group,
+		Consumer: s.conf.Consumer,
+		MinIdle:  s.conf.ClaimMinIdle,
+		Start:    s.claimCursor,
+		Count:    s.conf.Count,
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+	s.claimCursor = next
+	if len(claimed) == 0 || s.conf.MaxDeliveries == 0 {
+		return claimed, nil
+	}
+
+	counts, err := s.deliveryCounts(ctx, claimed)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := claimed[:0]
+	for _, entry := range claimed {
+		if count := counts[entry.ID]; count > s.conf.MaxDeliveries {
+			if err := s.deadLetter(ctx, entry, count); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// deliveryCounts returns the number of times each pending entry was delivered
+func (s *RedisSource) deliveryCounts(ctx context.Context, entries []redis.XMessage) (map[string]int64, error) {
+	cmds := make([]*redis.XPendingExtCmd, len(entries))
+	_, err := s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, entry := range entries {
+			cmds[i] = pipe.XPendingExt(ctx, &redis.XPendingExtArgs{
+				Stream: s.conf.Stream,
+				Group:  s.conf.Group,
+				Start:  entry.ID,
+				End:    entry.ID,
+				Count:  1,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(entries))
+	for _, cmd := range cmds {
+		for _, pending := range cmd.Val() {
+			counts[pending.ID] = pending.RetryCount
+		}
+	}
+	return counts, nil
+}
+
+// deadLetter atomically copies the entry to the dead-letter stream and acknowledges it
+func (s *RedisSource) deadLetter(ctx context.Context, entry redis.XMessage, deliveries int64) error {
+	values := make(map[string]any, len(entry.Values)+2)
+	for field, value := range entry.Values {
+		values[field] = value
+	}
+	values[RedisOriginalIDField] = entry.ID
+	if deliveries > 0 {
+		values[RedisDeliveryCountField] = strconv.FormatInt(deliveries, 10)
+	}
+
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.XAdd(ctx, &redis.XAddArgs{Stream: s.conf.DeadLetterStream, Values: values})
+		pipe.XAck(ctx, s.conf.Stream, s.conf.Group, entry.ID)
+		return nil
+	})
+	return err
+}
+
+// isBusyGroup reports whether the error is caused by an already existing consumer group
+func isBusyGroup(err error) bool {
+	return err != nil && strings.HasPrefix(err.Error(), "BUSYGROUP")
+}
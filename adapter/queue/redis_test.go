@@ -0,0 +1,154 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestRedisSource creates a RedisSource on top of an in-process Redis server
+func newTestRedisSource(t *testing.T, maxDeliveries int64) (*RedisSource, *miniredis.Miniredis, *redis.Client) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	source, err := NewRedisSource(context.Background(), RedisConfig{
+		Addr:             server.Addr(),
+		Stream:           "events",
+		Group:            "event-consumers",
+		Consumer:         "consumer-1",
+		DeadLetterStream: "events-dead",
+		Count:            10,
+		Block:            10 * time.Millisecond,
+		ClaimMinIdle:     time.Minute,
+		MaxDeliveries:    maxDeliveries,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { source.Close() })
+	return source, server, client
+}
+
+// addEntry appends an entry with the given body to the stream
+func addEntry(t *testing.T, client *redis.Client, stream, body string) string {
+	t.Helper()
+	id, err := client.XAdd(context.Background(), &redis.XAddArgs{
+		Stream: stream,
+		Values: map[string]any{RedisBodyField: body},
+	}).Result()
+	require.NoError(t, err)
+	return id
+}
+
+func TestRedisSource_ReceiveAndAck(t *testing.T) {
+	source, _, client := newTestRedisSource(t, 0)
+	ctx := context.Background()
+	id := addEntry(t, client, "events", "first")
+
+	messages, err := source.Receive(ctx)
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	assert.Equal(t, id, messages[0].ID)
+	assert.Equal(t, []byte("first"), messages[0].Body)
+
+	assert.NoError(t, source.Ack(ctx, messages[0]))
+	pending, err := client.XPending(ctx, "events", "event-consumers").Result()
+	require.NoError(t, err)
+	assert.Zero(t, pending.Count)
+}
+
+func TestRedisSource_ReclaimsStaleEntries(t *testing.T) {
+	source, server, client := newTestRedisSource(t, 0)
+	ctx := context.Background()
+	addEntry(t, client, "events", "first")
+
+	messages, err := source.Receive(ctx)
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	assert.NoError(t, source.Nack(ctx, messages[0], 0))
+
+	// The entry is not reclaimed before being idle for ClaimMinIdle
+	messages, err = source.Receive(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, messages)
+
+	server.SetTime(time.Now().Add(2 * time.Minute))
+	messages, err = source.Receive(ctx)
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	assert.Equal(t, []byte("first"), messages[0].Body)
+}
+
+func TestRedisSource_DeadLettersAfterMaxDeliveries(t *testing.T) {
+	source, server, client := newTestRedisSource(t, 1)
+	ctx := context.Background()
+	id := addEntry(t, client, "events", "poison")
+
+	messages, err := source.Receive(ctx)
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+
+	server.SetTime(time.Now().Add(2 * time.Minute))
+	messages, err = source.Receive(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, messages)
+
+	dead, err := client.XRange(ctx, "events-dead", "-", "+").Result()
+	require.NoError(t, err)
+	require.Len(t, dead, 1)
+	assert.Equal(t, "poison", dead[0].Values[RedisBodyField])
+	assert.Equal(t, id, dead[0].Values[RedisOriginalIDField])
+	assert.Equal(t, "2", dead[0].Values[RedisDeliveryCountField])
+}
+
+func TestRedisSource_Reject(t *testing.T) {
+	source, _, client := newTestRedisSource(t, 0)
+	ctx := context.Background()
+	addEntry(t, client, "events", "malformed")
+
+	messages, err := source.Receive(ctx)
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	assert.NoError(t, source.Extend(ctx, messages[0], time.Minute))
+	assert.NoError(t, source.Reject(ctx, messages[0]))
+
+	dead, err := client.XLen(ctx, "events-dead").Result()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), dead)
+	pending, err := client.XPending(ctx, "events", "event-consumers").Result()
+	require.NoError(t, err)
+	assert.Zero(t, pending.Count)
+}
+
+func TestRedisSource_Replay(t *testing.T) {
+	source, _, client := newTestRedisSource(t, 0)
+	ctx := context.Background()
+	addEntry(t, client, "events", "first")
+	addEntry(t, client, "events", "second")
+
+	messages, err := source.Receive(ctx)
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+	for _, message := range messages {
+		require.NoError(t, source.Reject(ctx, message))
+	}
+
+	replayed, err := source.Replay(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, replayed)
+
+	messages, err = source.Receive(ctx)
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	assert.Equal(t, []byte("first"), messages[0].Body)
+	assert.NotContains(t, messages[0].Handle.(redis.XMessage).Values, RedisOriginalIDField)
+
+	dead, err := client.XLen(ctx, "events-dead").Result()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), dead)
+}
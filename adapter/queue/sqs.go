@@ -0,0 +1,216 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/cvzm/go-web-project/domain"
+)
+
+// SQSConfig is SQS related configuration
+type SQSConfig struct {
+	QueueURL            string
+	Region              string
+	MaxNumberOfMessages int32  // maximum number of messages returned by a single receive
+	WaitTimeSeconds     int32  // long polling duration of a receive
+	VisibilityTimeout   int32  // duration received messages stay hidden from other consumers
+	FIFO                bool   // whether the queue is a FIFO queue
+	DeadLetterQueueURL  string // queue rejected messages are moved to, of the same type as the queue; dropped if empty
+
+	// Overrides for SQS-compatible services such as ElasticMQ or LocalStack
+	Endpoint          string // custom endpoint of the SQS API, the AWS endpoint of the region if empty
+	AccessKeyID       string // static credentials, the default AWS credential chain if empty
+	SecretAccessKey   string
+	SessionToken      string
+	PathStyleQueueURL bool // rewrites the queue URL to <endpoint>/<account>/<queue>
+}
+
+// SQSAPI is the subset of the SQS client used by SQSSource
+type SQSAPI interface {
+	ReceiveMessage(ctx context.Context, params *sqs.ReceiveMessageInput, optFns ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error)
+	DeleteMessage(ctx context.Context, params *sqs.DeleteMessageInput, optFns ...func(*sqs.Options)) (*sqs.DeleteMessageOutput, error)
+	SendMessage(ctx context.Context, params *sqs.SendMessageInput, optFns ...func(*sqs.Options)) (*sqs.SendMessageOutput, error)
+	ChangeMessageVisibility(ctx context.Context, params *sqs.ChangeMessageVisibilityInput, optFns ...func(*sqs.Options)) (*sqs.ChangeMessageVisibilityOutput, error)
+}
+
+// SQSSource is an implementation of the domain.MessageSource interface backed by an AWS SQS queue
+type SQSSource struct {
+	client SQSAPI
+	conf   SQSConfig
+}
+
+// NewSQSSource loads the AWS configuration and creates a new SQSSource instance
+func NewSQSSource(ctx context.Context, conf SQSConfig) (*SQSSource, error) {
+	opts := []func(*config.LoadOptions) error{
+		config.WithDefaultRegion(conf.Region),
+		config.WithRetryer(func() aws.Retryer {
+			return retry.AddWithMaxBackoffDelay(retry.AddWithMaxAttempts(retry.NewStandard(), 10), 1*time.Minute)
+		}),
+	}
+	if conf.AccessKeyID != "" {
+		opts = append(opts, config.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(conf.AccessKeyID, conf.SecretAccessKey, conf.SessionToken),
+		))
+	}
+	awsCfg, err := config.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if conf.PathStyleQueueURL {
+		if conf.QueueURL, err = pathStyleQueueURL(conf.Endpoint, conf.QueueURL); err != nil {
+			return nil, err
+		}
+		if conf.DeadLetterQueueURL != "" {
+			if conf.DeadLetterQueueURL, err = pathStyleQueueURL(conf.Endpoint, conf.DeadLetterQueueURL); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	client := sqs.NewFromConfig(awsCfg, func(o *sqs.Options) {
+		if conf.Endpoint != "" {
+			o.BaseEndpoint = aws.String(conf.Endpoint)
+		}
+	})
+	return NewSQSSourceWithClient(client, conf), nil
+}
+
+// NewSQSSourceWithClient creates a new SQSSource instance on top of the given SQS client
+func NewSQSSourceWithClient(client SQSAPI, conf SQSConfig) *SQSSource {
+	return &SQSSource{
+		client: client,
+		conf:   conf,
+	}
+}
+
+// Receive retrieves messages from the SQS queue
+func (s *SQSSource) Receive(ctx context.Context) ([]domain.Message, error) {
+	result, err := s.client.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
+		QueueUrl:            aws.String(s.conf.QueueURL),
+		MaxNumberOfMessages: s.conf.MaxNumberOfMessages,
+		WaitTimeSeconds:     s.conf.WaitTimeSeconds,
+		VisibilityTimeout:   s.conf.VisibilityTimeout,
+		MessageSystemAttributeNames: []types.MessageSystemAttributeName{
+			types.MessageSystemAttributeNameMessageGroupId,
+			types.MessageSystemAttributeNameMessageDeduplicationId,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]domain.Message, 0, len(result.Messages))
+	for _, message := range result.Messages {
+		messages = append(messages, s.toMessage(message))
+	}
+	return messages, nil
+}
+
+// Ack deletes a processed message from the SQS queue
+func (s *SQSSource) Ack(ctx context.Context, message domain.Message) error {
+	_, err := s.client.DeleteMessage(ctx, &sqs.DeleteMessageInput{
+		QueueUrl:      aws.String(s.conf.QueueURL),
+		ReceiptHandle: aws.String(receiptHandle(message)),
+	})
+	return err
+}
+
+// Nack makes the message visible again once the delay has elapsed
+func (s *SQSSource) Nack(ctx context.Context, message domain.Message, delay time.Duration) error {
+	return s.changeVisibility(ctx, message, delay)
+}
+
+// Reject moves the message to the dead-letter queue if configured, and deletes it from the queue. Leaving it in
+// flight instead would get it redelivered after its successors in a FIFO group, or forever without a redrive policy.
+// FIFO queues require a dead-letter queue, messages of standard queues are dropped without one.
+func (s *SQSSource) Reject(ctx context.Context, message domain.Message) error {
+	if s.conf.DeadLetterQueueURL == "" {
+		log.Printf("Dropping SQS message %q without dead-letter queue: %s", message.ID, message.Body)
+	} else {
+		input := &sqs.SendMessageInput{
+			QueueUrl:    aws.String(s.conf.DeadLetterQueueURL),
+			MessageBody: aws.String(string(message.Body)),
+		}
+		if s.conf.FIFO {
+			deduplicationID := message.DeduplicationID
+			if deduplicationID == "" {
+				deduplicationID = message.ID
+			}
+			input.MessageGroupId = aws.String(message.GroupID)
+			input.MessageDeduplicationId = aws.String(deduplicationID)
+		}
+		if _, err := s.client.SendMessage(ctx, input); err != nil {
+			return fmt.Errorf("dead-lettering message %q: %w", message.ID, err)
+		}
+	}
+	return s.Ack(ctx, message)
+}
+
+// Extend postpones the moment the message becomes visible again
+func (s *SQSSource) Extend(ctx context.Context, message domain.Message, lease time.Duration) error {
+	return s.changeVisibility(ctx, message, lease)
+}
+
+// Close is a no-op, the SQS client holds no resources
+func (s *SQSSource) Close() error {
+	return nil
+}
+
+// changeVisibility sets the visibility timeout of an in-flight message
+func (s *SQSSource) changeVisibility(ctx context.Context, message domain.Message, timeout time.Duration) error {
+	_, err := s.client.ChangeMessageVisibility(ctx, &sqs.ChangeMessageVisibilityInput{
+		QueueUrl:          aws.String(s.conf.QueueURL),
+		ReceiptHandle:     aws.String(receiptHandle(message)),
+		VisibilityTimeout: int32(timeout / time.Second),
+	})
+	return err
+}
+
+// toMessage converts an SQS message into a domain.Message
+func (s *SQSSource) toMessage(message types.Message) domain.Message {
+	m := domain.Message{
+		ID:              aws.ToString(message.MessageId),
+		Body:            []byte(aws.ToString(message.Body)),
+		DeduplicationID: message.Attributes[string(types.MessageSystemAttributeNameMessageDeduplicationId)],
+		Handle:          aws.ToString(message.ReceiptHandle),
+	}
+	// Standard queues give no ordering guarantee, so their messages stay ungrouped
+	if s.conf.FIFO {
+		m.GroupID = message.Attributes[string(types.MessageSystemAttributeNameMessageGroupId)]
+	}
+	return m
+}
+
+// pathStyleQueueURL rebases the path of the queue URL, /<account>/<queue>, on the endpoint
+func pathStyleQueueURL(endpoint, queueURL string) (string, error) {
+	if endpoint == "" {
+		return "", fmt.Errorf("a path-style queue URL requires an SQS endpoint")
+	}
+	base, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+	queue, err := url.Parse(queueURL)
+	if err != nil {
+		return "", err
+	}
+	base.Path = strings.TrimSuffix(base.Path, "/") + queue.Path
+	return base.String(), nil
+}
+
+// receiptHandle returns the SQS receipt handle of the message
+func receiptHandle(message domain.Message) string {
+	handle, _ := message.Handle.(string)
+	return handle
+}
@@ -0,0 +1,124 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/cvzm/go-web-project/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeSQSClient returns pre-configured messages and records the calls made to it
+type fakeSQSClient struct {
+	messages   []types.Message
+	deleted    []string
+	sent       []*sqs.SendMessageInput
+	visibility map[string]int32
+}
+
+func (f *fakeSQSClient) ReceiveMessage(ctx context.Context, params *sqs.ReceiveMessageInput, optFns ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error) {
+	return &sqs.ReceiveMessageOutput{Messages: f.messages}, nil
+}
+
+func (f *fakeSQSClient) DeleteMessage(ctx context.Context, params *sqs.DeleteMessageInput, optFns ...func(*sqs.Options)) (*sqs.DeleteMessageOutput, error) {
+	f.deleted = append(f.deleted, *params.ReceiptHandle)
+	return &sqs.DeleteMessageOutput{}, nil
+}
+
+func (f *fakeSQSClient) SendMessage(ctx context.Context, params *sqs.SendMessageInput, optFns ...func(*sqs.Options)) (*sqs.SendMessageOutput, error) {
+	f.sent = append(f.sent, params)
+	return &sqs.SendMessageOutput{}, nil
+}
+
+func (f *fakeSQSClient) ChangeMessageVisibility(ctx context.Context, params *sqs.ChangeMessageVisibilityInput, optFns ...func(*sqs.Options)) (*sqs.ChangeMessageVisibilityOutput, error) {
+	f.visibility[*params.ReceiptHandle] = params.VisibilityTimeout
+	return &sqs.ChangeMessageVisibilityOutput{}, nil
+}
+
+func TestSQSSource_Receive(t *testing.T) {
+	client := &fakeSQSClient{
+		messages: []types.Message{{
+			MessageId:     aws.String("id-1"),
+			ReceiptHandle: aws.String("handle-1"),
+			Body:          aws.String("body"),
+			Attributes: map[string]string{
+				string(types.MessageSystemAttributeNameMessageGroupId):         "group",
+				string(types.MessageSystemAttributeNameMessageDeduplicationId): "dedup",
+			},
+		}},
+	}
+
+	t.Run("FIFO queue", func(t *testing.T) {
+		source := NewSQSSourceWithClient(client, SQSConfig{FIFO: true})
+		messages, err := source.Receive(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, []domain.Message{{
+			ID:              "id-1",
+			Body:            []byte("body"),
+			GroupID:         "group",
+			DeduplicationID: "dedup",
+			Handle:          "handle-1",
+		}}, messages)
+	})
+
+	t.Run("Standard queue", func(t *testing.T) {
+		source := NewSQSSourceWithClient(client, SQSConfig{})
+		messages, err := source.Receive(context.Background())
+		assert.NoError(t, err)
+		assert.Len(t, messages, 1)
+		assert.Empty(t, messages[0].GroupID)
+	})
+}
+
+func TestSQSSource_AckNackExtend(t *testing.T) {
+	client := &fakeSQSClient{visibility: map[string]int32{}}
+	source := NewSQSSourceWithClient(client, SQSConfig{})
+	ctx := context.Background()
+
+	assert.NoError(t, source.Ack(ctx, domain.Message{Handle: "acked"}))
+	assert.NoError(t, source.Nack(ctx, domain.Message{Handle: "nacked"}, 5*time.Second))
+	assert.NoError(t, source.Extend(ctx, domain.Message{Handle: "extended"}, time.Minute))
+
+	assert.Equal(t, []string{"acked"}, client.deleted)
+	assert.Equal(t, map[string]int32{"nacked": 5, "extended": 60}, client.visibility)
+}
+
+func TestSQSSource_Reject(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Deletes the message without dead-letter queue", func(t *testing.T) {
+		client := &fakeSQSClient{}
+		source := NewSQSSourceWithClient(client, SQSConfig{QueueURL: "events"})
+
+		assert.NoError(t, source.Reject(ctx, domain.Message{ID: "id-1", Handle: "rejected"}))
+		assert.Equal(t, []string{"rejected"}, client.deleted)
+		assert.Empty(t, client.sent)
+	})
+
+	t.Run("Moves the message of a FIFO queue to the dead-letter queue", func(t *testing.T) {
+		client := &fakeSQSClient{}
+		source := NewSQSSourceWithClient(client, SQSConfig{QueueURL: "events.fifo", DeadLetterQueueURL: "events-dead.fifo", FIFO: true})
+
+		assert.NoError(t, source.Reject(ctx, domain.Message{ID: "id-1", Body: []byte("not json"), GroupID: "group", Handle: "rejected"}))
+		assert.Equal(t, []*sqs.SendMessageInput{{
+			QueueUrl:               aws.String("events-dead.fifo"),
+			MessageBody:            aws.String("not json"),
+			MessageGroupId:         aws.String("group"),
+			MessageDeduplicationId: aws.String("id-1"),
+		}}, client.sent)
+		assert.Equal(t, []string{"rejected"}, client.deleted)
+	})
+}
+
+func TestPathStyleQueueURL(t *testing.T) {
+	queueURL, err := pathStyleQueueURL("http://localhost:9324/", "https://sqs.us-west-1.amazonaws.com/000000000000/events")
+	assert.NoError(t, err)
+	assert.Equal(t, "http://localhost:9324/000000000000/events", queueURL)
+
+	_, err = pathStyleQueueURL("", "https://sqs.us-west-1.amazonaws.com/000000000000/events")
+	assert.Error(t, err)
+}
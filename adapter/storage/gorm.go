@@ -1,13 +1,23 @@
 package storage
 
 import (
+	"context"
+	"time"
+
 	"gorm.io/gorm"
 )
 
+// Instance keys of the query timeout callbacks
+const (
+	queryTimeoutCancelKey  = "storage:query_timeout_cancel"
+	queryTimeoutContextKey = "storage:query_timeout_context"
+)
+
 // DBConfig is db related configuration
 type DBConfig struct {
-	MaxIdleConns int // maximum number of connections in the idle connection pool
-	MaxOpenConns int // maximum number of open connections to the database
+	MaxIdleConns int           // maximum number of connections in the idle connection pool
+	MaxOpenConns int           // maximum number of open connections to the database
+	QueryTimeout time.Duration // maximum duration of a single operation, unlimited if zero
 }
 
 // DBConnector is an interface that represents a database connector.
@@ -34,6 +44,12 @@ func NewDB(connector DBConnector, conf DBConfig) (*gorm.DB, error) {
 	db.SetMaxIdleConns(conf.MaxIdleConns)
 	db.SetMaxOpenConns(conf.MaxOpenConns)
 
+	if conf.QueryTimeout > 0 {
+		if err := registerQueryTimeout(gormDB, conf.QueryTimeout); err != nil {
+			return nil, err
+		}
+	}
+
 	// Check whether the connection is successful
 	if err := db.Ping(); err != nil {
 		return nil, err
@@ -41,3 +57,45 @@ func NewDB(connector DBConnector, conf DBConfig) (*gorm.DB, error) {
 
 	return gormDB, nil
 }
+
+// registerQueryTimeout bounds every create, query, update, delete and raw operation, including
+// its implicit transaction, to the timeout on top of the deadline of its own context
+func registerQueryTimeout(db *gorm.DB, timeout time.Duration) error {
+	before := func(tx *gorm.DB) {
+		ctx, cancel := context.WithTimeout(tx.Statement.Context, timeout)
+		tx.InstanceSet(queryTimeoutContextKey, tx.Statement.Context)
+		tx.InstanceSet(queryTimeoutCancelKey, cancel)
+		tx.Statement.Context = ctx
+	}
+	after := func(tx *gorm.DB) {
+		if cancel, ok := tx.InstanceGet(queryTimeoutCancelKey); ok {
+			cancel.(context.CancelFunc)()
+		}
+		// The statement may be reused, so it gets its own context back
+		if ctx, ok := tx.InstanceGet(queryTimeoutContextKey); ok {
+			tx.Statement.Context = ctx.(context.Context)
+		}
+	}
+
+	callbacks := db.Callback()
+	registrations := []struct {
+		name   string
+		before func(name string, fn func(*gorm.DB)) error
+		after  func(name string, fn func(*gorm.DB)) error
+	}{
+		{"create", callbacks.Create().Before("*").Register, callbacks.Create().After("*").Register},
+		{"query", callbacks.Query().Before("*").Register, callbacks.Query().After("*").Register},
+		{"update", callbacks.Update().Before("*").Register, callbacks.Update().After("*").Register},
+		{"delete", callbacks.Delete().Before("*").Register, callbacks.Delete().After("*").Register},
+		{"raw", callbacks.Raw().Before("*").Register, callbacks.Raw().After("*").Register},
+	}
+	for _, registration := range registrations {
+		if err := registration.before("storage:query_timeout_before_"+registration.name, before); err != nil {
+			return err
+		}
+		if err := registration.after("storage:query_timeout_after_"+registration.name, after); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -1,9 +1,13 @@
 package storage
 
 import (
+	"regexp"
 	"testing"
+	"time"
 
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewDB(t *testing.T) {
@@ -27,3 +31,22 @@ func TestNewDB(t *testing.T) {
 
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestNewDB_QueryTimeout(t *testing.T) {
+	db, mock := GetMockDB(t)
+	mock.ExpectPing()
+	gormDB, err := NewDB(&MockDBConnector{DB: db}, DBConfig{MaxIdleConns: 1, QueryTimeout: 10 * time.Millisecond})
+	require.NoError(t, err)
+
+	// Operations completing within the timeout are unaffected
+	mock.ExpectExec(regexp.QuoteMeta(`SELECT 1`)).WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.NoError(t, gormDB.Exec("SELECT 1").Error)
+
+	mock.ExpectExec(regexp.QuoteMeta(`SELECT 2`)).
+		WillDelayFor(time.Second).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	start := time.Now()
+	err = gormDB.Exec("SELECT 2").Error
+	assert.ErrorIs(t, err, sqlmock.ErrCancelled)
+	assert.Less(t, time.Since(start), time.Second)
+}
@@ -0,0 +1,24 @@
+package storage
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// WithTryAdvisoryLock runs fn while holding the Postgres session advisory lock lockID, e.g. so that
+// a job scheduled on every instance runs on a single one at a time. It reports false without
+// running fn if another session holds the lock.
+func WithTryAdvisoryLock(ctx context.Context, db *gorm.DB, lockID int64, fn func() error) (bool, error) {
+	locked := false
+	err := db.WithContext(ctx).Connection(func(conn *gorm.DB) error {
+		// Session level locks must be released on the connection that acquired them
+		conn = conn.Session(&gorm.Session{})
+		if err := conn.Raw("SELECT pg_try_advisory_lock(?)", lockID).Scan(&locked).Error; err != nil || !locked {
+			return err
+		}
+		defer conn.Exec("SELECT pg_advisory_unlock(?)", lockID)
+		return fn()
+	})
+	return locked, err
+}
@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithTryAdvisoryLock(t *testing.T) {
+	t.Run("Runs while holding the lock", func(t *testing.T) {
+		db, mock := GetMockDB(t)
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT pg_try_advisory_lock($1)`)).WithArgs(42).
+			WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(true))
+		mock.ExpectExec(regexp.QuoteMeta(`SELECT pg_advisory_unlock($1)`)).WithArgs(42).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		ran := false
+		locked, err := WithTryAdvisoryLock(context.Background(), db, 42, func() error {
+			ran = true
+			return assert.AnError
+		})
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.True(t, locked)
+		assert.True(t, ran)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Skips when the lock is held", func(t *testing.T) {
+		db, mock := GetMockDB(t)
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT pg_try_advisory_lock($1)`)).WithArgs(42).
+			WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(false))
+
+		locked, err := WithTryAdvisoryLock(context.Background(), db, 42, func() error {
+			t.Fatal("must not run without the lock")
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.False(t, locked)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
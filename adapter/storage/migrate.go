@@ -0,0 +1,356 @@
+package storage
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SchemaVersionTable is the name of the table recording the applied migrations
+const SchemaVersionTable = "schema_version"
+
+// migrationLockID is the key of the Postgres advisory lock held while migrating,
+// so that pods started concurrently don't race applying the same migrations
+const migrationLockID int64 = 4171062139
+
+// noTransactionDirective marks migrations that can't run inside a transaction,
+// e.g. CREATE INDEX CONCURRENTLY. Their statements are run one at a time.
+const noTransactionDirective = "-- migrate:no-transaction"
+
+// generateDirective marks the statements of no-transaction migrations returning the statements
+// to run, one per row, e.g. to index every partition of a table concurrently
+const generateDirective = "-- migrate:generate"
+
+// migrationFileRegexp matches migration file names, e.g. 0001_create_events.up.sql
+var migrationFileRegexp = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// ErrSchemaOutdated is returned when the database schema lacks migrations known to the application
+var ErrSchemaOutdated = errors.New("database schema is outdated")
+
+// Migration is a versioned schema change with the SQL applying and reverting it
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus is a known migration along with the time it was applied, nil if pending
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// schemaVersion struct defines a row of the schema version table
+type schemaVersion struct {
+	Version   int64     `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"type:varchar(255);not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+// TableName returns the name of the schema version table
+func (schemaVersion) TableName() string {
+	return SchemaVersionTable
+}
+
+// Migrations returns the migrations embedded in the application, ordered by version
+func Migrations() ([]Migration, error) {
+	fsys, err := fs.Sub(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	return LoadMigrations(fsys)
+}
+
+// LoadMigrations reads the <version>_<name>.up.sql and <version>_<name>.down.sql files
+// at the root of fsys and returns the migrations ordered by version
+func LoadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		match := migrationFileRegexp.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %q", entry.Name())
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %q: %w", entry.Name(), err)
+		}
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		} else if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration version %d is used by %q and %q", version, migration.Name, match[2])
+		}
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Migrator applies and reverts versioned migrations, recording them in the schema version table
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+// NewMigrator creates a new Migrator instance for the migrations, which must be ordered by version
+func NewMigrator(db *gorm.DB, migrations []Migration) *Migrator {
+	return &Migrator{
+		db:         db,
+		migrations: migrations,
+	}
+}
+
+// Up applies up to steps pending migrations in version order, all of them if steps is zero,
+// and returns the applied migrations
+func (m *Migrator) Up(ctx context.Context, steps int) ([]Migration, error) {
+	var applied []Migration
+	err := m.withLock(ctx, func(conn *gorm.DB) error {
+		versions, err := appliedVersions(conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			if steps > 0 && len(applied) == steps {
+				break
+			}
+			if _, ok := versions[migration.Version]; ok {
+				continue
+			}
+			if err := m.apply(conn, migration.Up, func(tx *gorm.DB) error {
+				return tx.Create(&schemaVersion{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now()}).Error
+			}); err != nil {
+				return fmt.Errorf("applying migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down reverts up to steps applied migrations in reverse version order, all of them if steps is zero,
+// and returns the reverted migrations
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var reverted []Migration
+	err := m.withLock(ctx, func(conn *gorm.DB) error {
+		versions, err := appliedVersions(conn)
+		if err != nil {
+			return err
+		}
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			migration := m.migrations[i]
+			if steps > 0 && len(reverted) == steps {
+				break
+			}
+			if _, ok := versions[migration.Version]; !ok {
+				continue
+			}
+			if migration.Down == "" {
+				return fmt.Errorf("migration %d_%s can't be reverted: no down file", migration.Version, migration.Name)
+			}
+			if err := m.apply(conn, migration.Down, func(tx *gorm.DB) error {
+				return tx.Delete(&schemaVersion{}, migration.Version).Error
+			}); err != nil {
+				return fmt.Errorf("reverting migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			reverted = append(reverted, migration)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Status returns the known migrations along with the time they were applied
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	versions, err := appliedVersions(m.db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := MigrationStatus{Migration: migration}
+		if appliedAt, ok := versions[migration.Version]; ok {
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Check returns ErrSchemaOutdated if some known migrations are not applied, without changing the schema.
+// Migrations applied by a newer release are tolerated, so that old instances keep running during a rollout.
+func (m *Migrator) Check(ctx context.Context) error {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	var pending []string
+	for _, status := range statuses {
+		if status.AppliedAt == nil {
+			pending = append(pending, fmt.Sprintf("%d_%s", status.Version, status.Name))
+		}
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%w: pending migrations %s", ErrSchemaOutdated, strings.Join(pending, ", "))
+	}
+	return nil
+}
+
+// withLock runs fn on a single connection holding the migration advisory lock,
+// creating the schema version table if it doesn't exist
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *gorm.DB) error) error {
+	return m.db.WithContext(ctx).Connection(func(conn *gorm.DB) error {
+		// A new session keeps the clauses of a statement from leaking into the next ones
+		conn = conn.Session(&gorm.Session{})
+		if err := conn.Exec("SELECT pg_advisory_lock(?)", migrationLockID).Error; err != nil {
+			return err
+		}
+		defer conn.Exec("SELECT pg_advisory_unlock(?)", migrationLockID)
+
+		err := conn.Exec(`CREATE TABLE IF NOT EXISTS ` + SchemaVersionTable + ` (
+			version bigint PRIMARY KEY,
+			name varchar(255) NOT NULL,
+			applied_at timestamptz NOT NULL
+		)`).Error
+		if err != nil {
+			return err
+		}
+		return fn(conn)
+	})
+}
+
+// apply runs the SQL of a migration and records it, in a single transaction
+// unless the migration opts out of it
+func (m *Migrator) apply(conn *gorm.DB, sql string, record func(tx *gorm.DB) error) error {
+	if strings.HasPrefix(strings.TrimSpace(sql), noTransactionDirective) {
+		// Several statements sent at once would run in an implicit transaction
+		for _, statement := range splitStatements(sql) {
+			if err := execStatement(conn, statement); err != nil {
+				return err
+			}
+		}
+		return record(conn)
+	}
+
+	return conn.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(sql).Error; err != nil {
+			return err
+		}
+		return record(tx)
+	})
+}
+
+// execStatement runs a statement of a no-transaction migration, or the statements it generates
+func execStatement(conn *gorm.DB, statement string) error {
+	generates := slices.ContainsFunc(strings.Split(statement, "\n"), func(line string) bool {
+		return strings.TrimSpace(line) == generateDirective
+	})
+	if !generates {
+		return conn.Exec(statement).Error
+	}
+
+	var generated []string
+	if err := conn.Raw(statement).Scan(&generated).Error; err != nil {
+		return err
+	}
+	for _, sql := range generated {
+		if err := conn.Exec(sql).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitStatements splits SQL into statements, each ending with a semicolon at the end of a line
+// outside of dollar-quoted bodies, along with the comments preceding it
+func splitStatements(sql string) []string {
+	var statements []string
+	var statement strings.Builder
+	quoted, blank := false, true
+	for _, line := range strings.SplitAfter(sql, "\n") {
+		statement.WriteString(line)
+		trimmed := strings.TrimSpace(line)
+		if strings.Count(line, "$$")%2 == 1 {
+			quoted = !quoted
+		}
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		blank = false
+		if !quoted && strings.HasSuffix(trimmed, ";") {
+			statements = append(statements, statement.String())
+			statement.Reset()
+			blank = true
+		}
+	}
+	if !blank {
+		statements = append(statements, statement.String())
+	}
+	return statements
+}
+
+// appliedVersions returns the application time of the applied migrations by version,
+// none if the schema version table doesn't exist yet
+func appliedVersions(db *gorm.DB) (map[int64]time.Time, error) {
+	var exists bool
+	if err := db.Raw("SELECT to_regclass(?) IS NOT NULL", SchemaVersionTable).Scan(&exists).Error; err != nil {
+		return nil, err
+	}
+	versions := map[int64]time.Time{}
+	if !exists {
+		return versions, nil
+	}
+
+	var rows []schemaVersion
+	if err := db.Order("version").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		versions[row.Version] = row.AppliedAt
+	}
+	return versions, nil
+}
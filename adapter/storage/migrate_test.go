@@ -0,0 +1,267 @@
+package storage
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testMigrations = []Migration{
+	{Version: 1, Name: "create_events", Up: "CREATE TABLE events ();", Down: "DROP TABLE events;"},
+	{Version: 2, Name: "index_events", Up: noTransactionDirective + "\nCREATE INDEX CONCURRENTLY idx ON events;", Down: "DROP INDEX idx;"},
+}
+
+// expectLock sets up the expectations of acquiring the migration lock and reading the applied versions
+func expectLock(mock sqlmock.Sqlmock, appliedVersions ...int64) {
+	mock.ExpectExec(regexp.QuoteMeta(`SELECT pg_advisory_lock($1)`)).WithArgs(migrationLockID).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta(`CREATE TABLE IF NOT EXISTS schema_version`)).WillReturnResult(sqlmock.NewResult(0, 0))
+	expectAppliedVersions(mock, true, appliedVersions...)
+}
+
+// expectAppliedVersions sets up the expectations of reading the applied versions
+func expectAppliedVersions(mock sqlmock.Sqlmock, tableExists bool, versions ...int64) {
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT to_regclass($1) IS NOT NULL`)).WithArgs(SchemaVersionTable).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(tableExists))
+	if !tableExists {
+		return
+	}
+	rows := sqlmock.NewRows([]string{"version", "name", "applied_at"})
+	for _, version := range versions {
+		rows.AddRow(version, "migration", time.Now())
+	}
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "schema_version" ORDER BY version`)).WillReturnRows(rows)
+}
+
+func expectUnlock(mock sqlmock.Sqlmock) {
+	mock.ExpectExec(regexp.QuoteMeta(`SELECT pg_advisory_unlock($1)`)).WithArgs(migrationLockID).WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+func TestLoadMigrations(t *testing.T) {
+	t.Run("Valid files", func(t *testing.T) {
+		migrations, err := LoadMigrations(fstest.MapFS{
+			"0002_index_events.up.sql":    {Data: []byte("CREATE INDEX")},
+			"0001_create_events.up.sql":   {Data: []byte("CREATE TABLE")},
+			"0001_create_events.down.sql": {Data: []byte("DROP TABLE")},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []Migration{
+			{Version: 1, Name: "create_events", Up: "CREATE TABLE", Down: "DROP TABLE"},
+			{Version: 2, Name: "index_events", Up: "CREATE INDEX"},
+		}, migrations)
+	})
+
+	t.Run("Invalid file name", func(t *testing.T) {
+		_, err := LoadMigrations(fstest.MapFS{"create_events.sql": {}})
+		assert.ErrorContains(t, err, "invalid migration file name")
+	})
+
+	t.Run("Duplicate version", func(t *testing.T) {
+		_, err := LoadMigrations(fstest.MapFS{
+			"0001_create_events.up.sql": {Data: []byte("CREATE TABLE")},
+			"0001_create_queue.up.sql":  {Data: []byte("CREATE TABLE")},
+		})
+		assert.ErrorContains(t, err, "migration version 1 is used by")
+	})
+
+	t.Run("Missing up file", func(t *testing.T) {
+		_, err := LoadMigrations(fstest.MapFS{"0001_create_events.down.sql": {Data: []byte("DROP TABLE")}})
+		assert.ErrorContains(t, err, "has no up file")
+	})
+}
+
+func TestMigrations(t *testing.T) {
+	migrations, err := Migrations()
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+	for i, migration := range migrations {
+		assert.Equal(t, int64(i+1), migration.Version, "migration versions must be contiguous")
+		assert.NotEmpty(t, migration.Down, "migration %d_%s must be reversible", migration.Version, migration.Name)
+	}
+}
+
+func TestMigrator_Up(t *testing.T) {
+	db, mock := GetMockDB(t)
+	migrator := NewMigrator(db, testMigrations)
+
+	expectLock(mock)
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`CREATE TABLE events ();`)).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "schema_version" ("version","name","applied_at") VALUES ($1,$2,$3)`)).
+		WithArgs(1, "create_events", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	// The second migration runs outside of a transaction
+	mock.ExpectExec(regexp.QuoteMeta(`CREATE INDEX CONCURRENTLY idx ON events;`)).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "schema_version"`)).
+		WithArgs(2, "index_events", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	expectUnlock(mock)
+
+	applied, err := migrator.Up(context.Background(), 0)
+	assert.NoError(t, err)
+	assert.Equal(t, testMigrations, applied)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMigrator_UpSteps(t *testing.T) {
+	db, mock := GetMockDB(t)
+	migrator := NewMigrator(db, append(testMigrations, Migration{Version: 3, Name: "create_queue", Up: "CREATE TABLE queue ();"}))
+
+	expectLock(mock, 1)
+	mock.ExpectExec(regexp.QuoteMeta(`CREATE INDEX CONCURRENTLY idx ON events;`)).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "schema_version"`)).
+		WithArgs(2, "index_events", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	expectUnlock(mock)
+
+	applied, err := migrator.Up(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, testMigrations[1:], applied)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMigrator_UpFailure(t *testing.T) {
+	db, mock := GetMockDB(t)
+	migrator := NewMigrator(db, testMigrations)
+
+	expectLock(mock)
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`CREATE TABLE events ();`)).WillReturnError(assert.AnError)
+	mock.ExpectRollback()
+	expectUnlock(mock)
+
+	applied, err := migrator.Up(context.Background(), 0)
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.ErrorContains(t, err, "applying migration 1_create_events")
+	assert.Empty(t, applied)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMigrator_UpGeneratedStatements(t *testing.T) {
+	db, mock := GetMockDB(t)
+	migrator := NewMigrator(db, []Migration{{Version: 1, Name: "index_partitions", Up: noTransactionDirective + `
+CREATE INDEX IF NOT EXISTS idx ON ONLY events (source);
+
+` + generateDirective + `
+SELECT format('CREATE INDEX CONCURRENTLY %I ON %I (source)', relname || '_idx', relname) FROM partitions;
+`}})
+
+	expectLock(mock)
+	// Each statement runs on its own, then those generated by the query
+	mock.ExpectExec(regexp.QuoteMeta(`CREATE INDEX IF NOT EXISTS idx ON ONLY events (source);`)).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT format('CREATE INDEX CONCURRENTLY %I ON %I (source)', relname || '_idx', relname) FROM partitions;`)).
+		WillReturnRows(sqlmock.NewRows([]string{"format"}).
+			AddRow("CREATE INDEX CONCURRENTLY events_2024_05_idx ON events_2024_05 (source)").
+			AddRow("CREATE INDEX CONCURRENTLY events_default_idx ON events_default (source)"))
+	mock.ExpectExec(regexp.QuoteMeta(`CREATE INDEX CONCURRENTLY events_2024_05_idx ON events_2024_05 (source)`)).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta(`CREATE INDEX CONCURRENTLY events_default_idx ON events_default (source)`)).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "schema_version"`)).
+		WithArgs(1, "index_partitions", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	expectUnlock(mock)
+
+	_, err := migrator.Up(context.Background(), 0)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSplitStatements(t *testing.T) {
+	statements := splitStatements(`-- migrate:no-transaction
+-- First statement
+CREATE TABLE t (
+    id bigint
+);
+DO $$
+BEGIN
+    PERFORM 1;
+END $$;
+SELECT 'a;b'; SELECT 2;
+-- Trailing comment
+`)
+	assert.Equal(t, []string{
+		"-- migrate:no-transaction\n-- First statement\nCREATE TABLE t (\n    id bigint\n);\n",
+		"DO $$\nBEGIN\n    PERFORM 1;\nEND $$;\n",
+		"SELECT 'a;b'; SELECT 2;\n",
+	}, statements)
+
+	assert.Equal(t, []string{"DROP INDEX idx"}, splitStatements("DROP INDEX idx"))
+}
+
+func TestMigrator_Down(t *testing.T) {
+	db, mock := GetMockDB(t)
+	migrator := NewMigrator(db, testMigrations)
+
+	expectLock(mock, 1, 2)
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`DROP INDEX idx;`)).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "schema_version" WHERE "schema_version"."version" = $1`)).
+		WithArgs(2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	expectUnlock(mock)
+
+	reverted, err := migrator.Down(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, testMigrations[1:], reverted)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMigrator_Status(t *testing.T) {
+	db, mock := GetMockDB(t)
+	migrator := NewMigrator(db, testMigrations)
+
+	expectAppliedVersions(mock, true, 1)
+
+	statuses, err := migrator.Status(context.Background())
+	require.NoError(t, err)
+	require.Len(t, statuses, 2)
+	assert.NotNil(t, statuses[0].AppliedAt)
+	assert.Nil(t, statuses[1].AppliedAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMigrator_Check(t *testing.T) {
+	t.Run("Up to date", func(t *testing.T) {
+		db, mock := GetMockDB(t)
+		migrator := NewMigrator(db, testMigrations)
+		// Versions applied by a newer release are tolerated
+		expectAppliedVersions(mock, true, 1, 2, 3)
+
+		assert.NoError(t, migrator.Check(context.Background()))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Pending migrations", func(t *testing.T) {
+		db, mock := GetMockDB(t)
+		migrator := NewMigrator(db, testMigrations)
+		expectAppliedVersions(mock, true, 1)
+
+		err := migrator.Check(context.Background())
+		assert.ErrorIs(t, err, ErrSchemaOutdated)
+		assert.ErrorContains(t, err, "2_index_events")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Uninitialized database", func(t *testing.T) {
+		db, mock := GetMockDB(t)
+		migrator := NewMigrator(db, testMigrations)
+		expectAppliedVersions(mock, false)
+
+		err := migrator.Check(context.Background())
+		assert.ErrorIs(t, err, ErrSchemaOutdated)
+		assert.ErrorContains(t, err, "1_create_events, 2_index_events")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
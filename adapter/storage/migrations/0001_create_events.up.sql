@@ -0,0 +1,10 @@
+-- Schema previously created by AutoMigrate, IF NOT EXISTS adopts existing databases
+CREATE TABLE IF NOT EXISTS events (
+    id                 bigserial PRIMARY KEY,
+    source             varchar(255) NOT NULL,
+    event_type         varchar(100) NOT NULL,
+    description        text,
+    affected_resources varchar(200)[],
+    created_at         timestamptz,
+    updated_at         timestamptz
+);
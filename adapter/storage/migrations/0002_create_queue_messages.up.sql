@@ -0,0 +1,17 @@
+-- Schema previously created by AutoMigrate, IF NOT EXISTS adopts existing databases
+CREATE TABLE IF NOT EXISTS queue_messages (
+    id               bigserial PRIMARY KEY,
+    queue            varchar(100) NOT NULL,
+    body             text NOT NULL,
+    group_id         varchar(255),
+    deduplication_id varchar(255),
+    status           varchar(20) NOT NULL,
+    attempts         bigint NOT NULL DEFAULT 0,
+    available_at     timestamptz NOT NULL,
+    last_error       text,
+    created_at       timestamptz,
+    updated_at       timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS idx_queue_messages_dequeue ON queue_messages (queue, status, available_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_queue_messages_dedup ON queue_messages (queue, deduplication_id);
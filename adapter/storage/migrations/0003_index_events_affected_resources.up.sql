@@ -0,0 +1,3 @@
+-- migrate:no-transaction
+-- Built concurrently so that writes to events are not blocked on large tables
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_events_affected_resources ON events USING GIN (affected_resources);
@@ -0,0 +1,26 @@
+-- Recreate events as a regular table, keeping the events of every partition
+ALTER SEQUENCE events_id_seq OWNED BY NONE;
+ALTER TABLE events RENAME TO events_partitioned;
+ALTER TABLE events_partitioned RENAME CONSTRAINT events_pkey TO events_partitioned_pkey;
+DROP INDEX IF EXISTS idx_events_affected_resources;
+DROP INDEX IF EXISTS idx_events_created_at;
+
+CREATE TABLE events (
+    id                 bigint PRIMARY KEY DEFAULT nextval('events_id_seq'),
+    source             varchar(255) NOT NULL,
+    event_type         varchar(100) NOT NULL,
+    description        text,
+    affected_resources varchar(200)[],
+    created_at         timestamptz,
+    updated_at         timestamptz
+);
+
+ALTER SEQUENCE events_id_seq OWNED BY events.id;
+CREATE INDEX idx_events_affected_resources ON events USING GIN (affected_resources);
+
+INSERT INTO events (id, source, event_type, description, affected_resources, created_at, updated_at)
+SELECT id, source, event_type, description, affected_resources, created_at, updated_at
+FROM events_partitioned;
+
+-- Dropping the partitioned table drops its partitions
+DROP TABLE events_partitioned;
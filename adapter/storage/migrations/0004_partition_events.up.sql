@@ -0,0 +1,63 @@
+-- migrate:no-transaction
+-- Recreate events as a table partitioned by month on created_at. The primary key of
+-- a partitioned table must include the partition key, so it becomes (id, created_at).
+-- The existing table is attached as the partition of the current month rather than copied,
+-- so that writes are only blocked while the tables are swapped. Its constraints and indexes
+-- are prepared beforehand without blocking writes, so that the attachment does not scan it.
+UPDATE events SET created_at = now() WHERE created_at IS NULL;
+
+ALTER TABLE events DROP CONSTRAINT IF EXISTS events_created_at_not_null;
+ALTER TABLE events ADD CONSTRAINT events_created_at_not_null CHECK (created_at IS NOT NULL) NOT VALID;
+ALTER TABLE events VALIDATE CONSTRAINT events_created_at_not_null;
+
+-- The partition of the current month holds every event dated before the next month
+ALTER TABLE events DROP CONSTRAINT IF EXISTS events_partition_bound;
+DO $$
+BEGIN
+    EXECUTE format('ALTER TABLE events ADD CONSTRAINT events_partition_bound CHECK (created_at < %L) NOT VALID',
+        (date_trunc('month', now() AT TIME ZONE 'UTC') + interval '1 month') AT TIME ZONE 'UTC');
+END $$;
+ALTER TABLE events VALIDATE CONSTRAINT events_partition_bound;
+
+-- Indexes left invalid by an interrupted build are dropped and built again
+DROP INDEX CONCURRENTLY IF EXISTS events_id_created_at_key;
+CREATE UNIQUE INDEX CONCURRENTLY events_id_created_at_key ON events (id, created_at);
+DROP INDEX CONCURRENTLY IF EXISTS events_created_at_idx;
+CREATE INDEX CONCURRENTLY events_created_at_idx ON events (created_at);
+
+-- Swapped in a single statement, so that it is atomic. The indexes of the partitioned table
+-- adopt the matching indexes of the attached table, the affected resources index built by
+-- 0003 being renamed for migration 0013 to attach it.
+DO $$
+DECLARE
+    partition_name text := 'events_' || to_char(now() AT TIME ZONE 'UTC', 'YYYY_MM');
+    bound timestamptz := (date_trunc('month', now() AT TIME ZONE 'UTC') + interval '1 month') AT TIME ZONE 'UTC';
+BEGIN
+    ALTER TABLE events ALTER COLUMN created_at SET NOT NULL;
+    ALTER TABLE events DROP CONSTRAINT events_pkey;
+    EXECUTE format('ALTER TABLE events ADD CONSTRAINT %I PRIMARY KEY USING INDEX events_id_created_at_key', partition_name || '_pkey');
+    ALTER SEQUENCE events_id_seq OWNED BY NONE;
+    EXECUTE format('ALTER TABLE events RENAME TO %I', partition_name);
+    EXECUTE format('ALTER INDEX IF EXISTS idx_events_affected_resources RENAME TO %I', partition_name || '_affected_resources_idx');
+
+    CREATE TABLE events (
+        id                 bigint NOT NULL DEFAULT nextval('events_id_seq'),
+        source             varchar(255) NOT NULL,
+        event_type         varchar(100) NOT NULL,
+        description        text,
+        affected_resources varchar(200)[],
+        created_at         timestamptz NOT NULL DEFAULT now(),
+        updated_at         timestamptz,
+        PRIMARY KEY (id, created_at)
+    ) PARTITION BY RANGE (created_at);
+
+    ALTER SEQUENCE events_id_seq OWNED BY events.id;
+    CREATE INDEX idx_events_created_at ON events (created_at);
+
+    EXECUTE format('ALTER TABLE events ATTACH PARTITION %I FOR VALUES FROM (MINVALUE) TO (%L)', partition_name, bound);
+    EXECUTE format('ALTER TABLE %I DROP CONSTRAINT events_created_at_not_null, DROP CONSTRAINT events_partition_bound', partition_name);
+
+    -- Catches events dated outside of the existing partitions, e.g. imported ones. Later months
+    -- are created by the partition maintenance job.
+    CREATE TABLE events_default PARTITION OF events DEFAULT;
+END $$;
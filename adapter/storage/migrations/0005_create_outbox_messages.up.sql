@@ -0,0 +1,17 @@
+-- Outbox of the messages to publish downstream, written in the transaction of the change they describe
+CREATE TABLE outbox_messages (
+    id             bigserial PRIMARY KEY,
+    aggregate_type varchar(100) NOT NULL,
+    aggregate_id   varchar(255) NOT NULL,
+    event_type     varchar(100) NOT NULL,
+    payload        jsonb NOT NULL,
+    attempts       integer NOT NULL DEFAULT 0,
+    available_at   timestamptz NOT NULL,
+    last_error     text,
+    created_at     timestamptz NOT NULL,
+    published_at   timestamptz
+);
+
+-- Pending messages by aggregate, to find the oldest pending message of each aggregate
+CREATE INDEX idx_outbox_messages_pending ON outbox_messages (aggregate_type, aggregate_id, id) WHERE published_at IS NULL;
+CREATE INDEX idx_outbox_messages_published_at ON outbox_messages (published_at) WHERE published_at IS NOT NULL;
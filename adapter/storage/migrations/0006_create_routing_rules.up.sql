@@ -0,0 +1,17 @@
+-- Rules routing the notifications of the events to notification channels, evaluated by priority
+CREATE TABLE routing_rules (
+    id                bigserial PRIMARY KEY,
+    name              varchar(255) NOT NULL,
+    priority          integer NOT NULL DEFAULT 0,
+    disabled          boolean NOT NULL DEFAULT false,
+    sources           varchar(255)[],
+    event_types       varchar(255)[],
+    resources         varchar(255)[],
+    description_regex text,
+    channels          varchar(255)[] NOT NULL,
+    stop              boolean NOT NULL DEFAULT false,
+    created_at        timestamptz NOT NULL,
+    updated_at        timestamptz NOT NULL
+);
+
+CREATE INDEX idx_routing_rules_priority ON routing_rules (priority, id);
@@ -0,0 +1,34 @@
+-- Subscriptions of internal services to the events, delivered to their endpoint
+CREATE TABLE webhook_subscriptions (
+    id                   bigserial PRIMARY KEY,
+    name                 varchar(255) NOT NULL,
+    url                  text NOT NULL,
+    secret               varchar(255) NOT NULL,
+    sources              varchar(255)[],
+    event_types          varchar(255)[],
+    disabled             boolean NOT NULL DEFAULT false,
+    disabled_reason      text,
+    consecutive_failures integer NOT NULL DEFAULT 0,
+    created_at           timestamptz NOT NULL,
+    updated_at           timestamptz NOT NULL
+);
+
+-- Deliveries of the events to the subscriptions, written in the transaction of the event
+CREATE TABLE webhook_deliveries (
+    id              bigserial PRIMARY KEY,
+    subscription_id bigint NOT NULL REFERENCES webhook_subscriptions (id) ON DELETE CASCADE,
+    event_id        bigint NOT NULL,
+    event_type      varchar(100) NOT NULL,
+    payload         jsonb NOT NULL,
+    status          varchar(20) NOT NULL,
+    attempts        integer NOT NULL DEFAULT 0,
+    next_attempt_at timestamptz NOT NULL,
+    response_status integer NOT NULL DEFAULT 0,
+    last_error      text,
+    delivered_at    timestamptz,
+    created_at      timestamptz NOT NULL,
+    updated_at      timestamptz NOT NULL
+);
+
+CREATE INDEX idx_webhook_deliveries_due ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX idx_webhook_deliveries_subscription ON webhook_deliveries (subscription_id, id);
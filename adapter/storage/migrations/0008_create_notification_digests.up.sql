@@ -0,0 +1,7 @@
+-- Digests sent, one per period, so that a single instance sends each digest
+CREATE TABLE notification_digests (
+    period       varchar(20) NOT NULL,
+    period_start timestamptz NOT NULL,
+    sent_at      timestamptz NOT NULL,
+    PRIMARY KEY (period, period_start)
+);
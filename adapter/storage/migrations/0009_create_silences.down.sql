@@ -0,0 +1,2 @@
+ALTER TABLE events DROP COLUMN IF EXISTS silenced;
+DROP TABLE IF EXISTS silences;
@@ -0,0 +1,22 @@
+-- Silences of the matching events, which are stored flagged as silenced without being notified.
+-- Silences with recurrence days are weekly maintenance windows.
+CREATE TABLE silences (
+    id                  bigserial PRIMARY KEY,
+    sources             varchar(255)[],
+    event_types         varchar(255)[],
+    resources           varchar(255)[],
+    starts_at           timestamptz NOT NULL,
+    ends_at             timestamptz,
+    recurrence_days     varchar(3)[],
+    recurrence_start    varchar(5),
+    recurrence_duration integer NOT NULL DEFAULT 0,
+    recurrence_timezone varchar(64),
+    created_by          varchar(255) NOT NULL,
+    comment             text,
+    created_at          timestamptz NOT NULL,
+    updated_at          timestamptz NOT NULL
+);
+
+CREATE INDEX idx_silences_ends_at ON silences (ends_at);
+
+ALTER TABLE events ADD COLUMN silenced boolean NOT NULL DEFAULT false;
@@ -0,0 +1,3 @@
+ALTER TABLE routing_rules DROP COLUMN IF EXISTS severities;
+DROP INDEX IF EXISTS idx_events_severity;
+ALTER TABLE events DROP COLUMN IF EXISTS severity;
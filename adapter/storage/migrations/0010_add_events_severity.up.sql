@@ -0,0 +1,6 @@
+-- Severity of the events, provider-native or classified; events saved before are considered informational
+ALTER TABLE events ADD COLUMN severity varchar(20) NOT NULL DEFAULT 'info';
+
+CREATE INDEX idx_events_severity ON events (severity, created_at);
+
+ALTER TABLE routing_rules ADD COLUMN severities varchar(20)[];
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS event_changes;
+DROP INDEX IF EXISTS idx_events_status;
+ALTER TABLE events DROP COLUMN IF EXISTS resolved_at;
+ALTER TABLE events DROP COLUMN IF EXISTS acknowledged_at;
+ALTER TABLE events DROP COLUMN IF EXISTS assignee;
+ALTER TABLE events DROP COLUMN IF EXISTS status;
@@ -0,0 +1,23 @@
+-- Lifecycle of the events; events saved before are considered open
+ALTER TABLE events ADD COLUMN status varchar(20) NOT NULL DEFAULT 'open';
+ALTER TABLE events ADD COLUMN assignee varchar(255);
+ALTER TABLE events ADD COLUMN acknowledged_at timestamptz;
+ALTER TABLE events ADD COLUMN resolved_at timestamptz;
+
+CREATE INDEX idx_events_status ON events (status, created_at);
+
+-- Audit history of the changes of the events. There is no foreign key, as events are partitioned.
+CREATE TABLE event_changes (
+    id            bigserial PRIMARY KEY,
+    event_id      bigint NOT NULL,
+    action        varchar(20) NOT NULL,
+    actor         varchar(255) NOT NULL,
+    from_status   varchar(20) NOT NULL,
+    to_status     varchar(20) NOT NULL,
+    from_assignee varchar(255),
+    to_assignee   varchar(255),
+    note          text,
+    created_at    timestamptz NOT NULL
+);
+
+CREATE INDEX idx_event_changes_event_id ON event_changes (event_id, id);
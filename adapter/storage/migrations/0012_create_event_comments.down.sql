@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS event_annotations;
+DROP TABLE IF EXISTS event_comments;
@@ -0,0 +1,27 @@
+-- Threaded comments of the events, replying to their parent comment if any.
+-- There are no foreign keys to the events, as they are partitioned.
+CREATE TABLE event_comments (
+    id         bigserial PRIMARY KEY,
+    event_id   bigint NOT NULL,
+    parent_id  bigint REFERENCES event_comments (id),
+    author     varchar(255) NOT NULL,
+    body       text NOT NULL,
+    created_at timestamptz NOT NULL,
+    updated_at timestamptz NOT NULL
+);
+
+CREATE INDEX idx_event_comments_event_id ON event_comments (event_id, id);
+
+-- Key-value annotations of the events, such as ticket IDs and runbook links, searchable by the event queries
+CREATE TABLE event_annotations (
+    id         bigserial PRIMARY KEY,
+    event_id   bigint NOT NULL,
+    key        varchar(63) NOT NULL,
+    value      varchar(1024) NOT NULL,
+    author     varchar(255) NOT NULL,
+    created_at timestamptz NOT NULL,
+    updated_at timestamptz NOT NULL,
+    UNIQUE (event_id, key)
+);
+
+CREATE INDEX idx_event_annotations_key_value ON event_annotations (key, value);
@@ -0,0 +1,2 @@
+-- Dropping the index of the partitioned table drops the indexes of its partitions
+DROP INDEX IF EXISTS idx_events_affected_resources;
@@ -0,0 +1,33 @@
+-- migrate:no-transaction
+-- Index the affected resources of each partition concurrently, so that writes to events are not blocked on
+-- large partitions, and attach them to the index of the partitioned table, valid once all are attached.
+-- Partitions created afterwards get their index from the partitioned table. Each step skips the partitions
+-- already done, so that an interrupted migration can run again.
+CREATE INDEX IF NOT EXISTS idx_events_affected_resources ON ONLY events USING GIN (affected_resources);
+
+-- Indexes left invalid by an interrupted build are built again
+-- migrate:generate
+SELECT format('DROP INDEX CONCURRENTLY %I', idx.relname)
+FROM pg_inherits
+JOIN pg_class part ON part.oid = pg_inherits.inhrelid
+JOIN pg_class idx ON idx.relname = part.relname || '_affected_resources_idx'
+    AND idx.relnamespace = part.relnamespace
+JOIN pg_index ON pg_index.indexrelid = idx.oid
+WHERE pg_inherits.inhparent = 'events'::regclass AND NOT pg_index.indisvalid;
+
+-- migrate:generate
+SELECT format('CREATE INDEX CONCURRENTLY IF NOT EXISTS %I ON %I USING GIN (affected_resources)',
+    part.relname || '_affected_resources_idx', part.relname)
+FROM pg_inherits
+JOIN pg_class part ON part.oid = pg_inherits.inhrelid
+WHERE pg_inherits.inhparent = 'events'::regclass
+ORDER BY part.relname;
+
+-- migrate:generate
+SELECT format('ALTER INDEX idx_events_affected_resources ATTACH PARTITION %I', idx.relname)
+FROM pg_inherits
+JOIN pg_class part ON part.oid = pg_inherits.inhrelid
+JOIN pg_class idx ON idx.relname = part.relname || '_affected_resources_idx'
+    AND idx.relnamespace = part.relnamespace
+WHERE pg_inherits.inhparent = 'events'::regclass AND NOT idx.relispartition
+ORDER BY part.relname;
@@ -0,0 +1,4 @@
+-- Fails if a dead message shares its deduplication ID with a pending one
+CREATE UNIQUE INDEX IF NOT EXISTS idx_queue_messages_dedup ON queue_messages (queue, deduplication_id);
+DROP INDEX IF EXISTS idx_queue_messages_live_dedup;
+DROP INDEX IF EXISTS idx_queue_messages_group;
@@ -0,0 +1,11 @@
+-- migrate:no-transaction
+-- Deduplicate against pending messages only, so that the deduplication ID of a dead message can be enqueued
+-- again, and index the groups for the dequeue to find the first pending message of each. Indexes left invalid
+-- by an interrupted build are dropped and built again.
+DROP INDEX CONCURRENTLY IF EXISTS idx_queue_messages_live_dedup;
+CREATE UNIQUE INDEX CONCURRENTLY idx_queue_messages_live_dedup ON queue_messages (queue, deduplication_id)
+    WHERE status <> 'dead';
+DROP INDEX CONCURRENTLY IF EXISTS idx_queue_messages_dedup;
+
+DROP INDEX CONCURRENTLY IF EXISTS idx_queue_messages_group;
+CREATE INDEX CONCURRENTLY idx_queue_messages_group ON queue_messages (queue, group_id, id);
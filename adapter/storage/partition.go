@@ -0,0 +1,203 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+// partitionNameLayout is the time layout of the suffix of monthly partition names, e.g. events_2024_05
+const partitionNameLayout = "2006_01"
+
+// Partition is a monthly partition of a table, holding the rows dated within [From, To)
+type Partition struct {
+	Name string
+	From time.Time
+	To   time.Time
+}
+
+// PartitionMaintenance reports the partitions changed by a maintenance run
+type PartitionMaintenance struct {
+	Created  []Partition
+	Detached []Partition
+	Dropped  []Partition
+	Purged   int64 // rows older than the retention period deleted from the default partition
+}
+
+// MonthlyPartitioner maintains the monthly partitions of a table partitioned by range
+// on a timestamp column. Partitions are named <table>_YYYY_MM and bounded in UTC.
+type MonthlyPartitioner struct {
+	db     *gorm.DB
+	table  string
+	column string
+	lockID int64
+}
+
+// NewMonthlyPartitioner creates a new MonthlyPartitioner instance for the table partitioned on column.
+// Maintenance runs hold the advisory lock lockID, so that concurrent instances skip them.
+func NewMonthlyPartitioner(db *gorm.DB, table, column string, lockID int64) *MonthlyPartitioner {
+	return &MonthlyPartitioner{
+		db:     db,
+		table:  table,
+		column: column,
+		lockID: lockID,
+	}
+}
+
+// Partitions returns the monthly partitions currently attached to the table, ordered by date.
+// The default partition, named <table>_default, is not included.
+func (p *MonthlyPartitioner) Partitions(ctx context.Context) ([]Partition, error) {
+	return p.partitions(p.db.WithContext(ctx))
+}
+
+// Maintain creates the partitions of the current month and of the given number of months ahead,
+// moving the rows of the default partition dated within them, then detaches the partitions entirely older than the retention period, if not zero, and drops them
+// unless detachOnly is set. Detached partitions are left as standalone tables, e.g. to be archived.
+// When dropping, rows of the default partition older than the retention period are deleted too.
+// It reports false without doing anything if another instance is already maintaining the table.
+func (p *MonthlyPartitioner) Maintain(ctx context.Context, now time.Time, ahead int, retention time.Duration, detachOnly bool) (PartitionMaintenance, bool, error) {
+	var result PartitionMaintenance
+	locked := false
+	err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Raw("SELECT pg_try_advisory_xact_lock(?)", p.lockID).Scan(&locked).Error; err != nil || !locked {
+			return err
+		}
+
+		partitions, err := p.partitions(tx)
+		if err != nil {
+			return err
+		}
+		existing := map[string]bool{}
+		for _, partition := range partitions {
+			existing[partition.Name] = true
+		}
+		defaultPartition := p.table + "_default"
+		var hasDefault bool
+		if err := tx.Raw("SELECT to_regclass(?) IS NOT NULL", defaultPartition).Scan(&hasDefault).Error; err != nil {
+			return err
+		}
+
+		month := startOfMonth(now)
+		for i := 0; i <= ahead; i++ {
+			partition := p.partition(month.AddDate(0, i, 0))
+			if existing[partition.Name] {
+				continue
+			}
+			if err := p.create(tx, partition, hasDefault); err != nil {
+				return fmt.Errorf("creating partition %s: %w", partition.Name, err)
+			}
+			result.Created = append(result.Created, partition)
+		}
+
+		if retention <= 0 {
+			return nil
+		}
+		cutoff := now.Add(-retention)
+		for _, partition := range partitions {
+			if partition.To.After(cutoff) {
+				break
+			}
+			sql := fmt.Sprintf("ALTER TABLE %s DETACH PARTITION %s", pq.QuoteIdentifier(p.table), pq.QuoteIdentifier(partition.Name))
+			if err := tx.Exec(sql).Error; err != nil {
+				return fmt.Errorf("detaching partition %s: %w", partition.Name, err)
+			}
+			result.Detached = append(result.Detached, partition)
+			if detachOnly {
+				continue
+			}
+			if err := tx.Exec("DROP TABLE " + pq.QuoteIdentifier(partition.Name)).Error; err != nil {
+				return fmt.Errorf("dropping partition %s: %w", partition.Name, err)
+			}
+			result.Dropped = append(result.Dropped, partition)
+		}
+
+		if !detachOnly && hasDefault {
+			// Rows of the default partition can't be detached with a month, so they are deleted instead
+			sql := fmt.Sprintf("DELETE FROM %s WHERE %s < ?", pq.QuoteIdentifier(defaultPartition), pq.QuoteIdentifier(p.column))
+			purge := tx.Exec(sql, cutoff)
+			if purge.Error != nil {
+				return fmt.Errorf("purging partition %s: %w", defaultPartition, purge.Error)
+			}
+			result.Purged = purge.RowsAffected
+		}
+		return nil
+	})
+	return result, locked, err
+}
+
+// create creates the partition. Postgres refuses to create a partition while the default partition holds
+// rows within its range, so the default partition is then detached while its rows are moved to the new one.
+func (p *MonthlyPartitioner) create(tx *gorm.DB, partition Partition, hasDefault bool) error {
+	table, column := pq.QuoteIdentifier(p.table), pq.QuoteIdentifier(p.column)
+	defaultPartition := pq.QuoteIdentifier(p.table + "_default")
+	var moving bool
+	if hasDefault {
+		sql := fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s WHERE %s >= ? AND %s < ?)", defaultPartition, column, column)
+		if err := tx.Raw(sql, partition.From, partition.To).Scan(&moving).Error; err != nil {
+			return err
+		}
+	}
+
+	if moving {
+		if err := tx.Exec(fmt.Sprintf("ALTER TABLE %s DETACH PARTITION %s", table, defaultPartition)).Error; err != nil {
+			return err
+		}
+	}
+	sql := fmt.Sprintf("CREATE TABLE %s PARTITION OF %s FOR VALUES FROM ('%s') TO ('%s')",
+		pq.QuoteIdentifier(partition.Name), table, partition.From.Format(time.RFC3339), partition.To.Format(time.RFC3339))
+	if err := tx.Exec(sql).Error; err != nil || !moving {
+		return err
+	}
+	sql = fmt.Sprintf("WITH moved AS (DELETE FROM %s WHERE %s >= ? AND %s < ? RETURNING *) INSERT INTO %s SELECT * FROM moved",
+		defaultPartition, column, column, pq.QuoteIdentifier(partition.Name))
+	if err := tx.Exec(sql, partition.From, partition.To).Error; err != nil {
+		return err
+	}
+	return tx.Exec(fmt.Sprintf("ALTER TABLE %s ATTACH PARTITION %s DEFAULT", table, defaultPartition)).Error
+}
+
+// partitions lists the monthly partitions attached to the table, ordered by date
+func (p *MonthlyPartitioner) partitions(db *gorm.DB) ([]Partition, error) {
+	var names []string
+	err := db.Raw(`SELECT child.relname FROM pg_inherits
+		JOIN pg_class parent ON parent.oid = pg_inherits.inhparent
+		JOIN pg_class child ON child.oid = pg_inherits.inhrelid
+		WHERE parent.relname = ?`, p.table).Scan(&names).Error
+	if err != nil {
+		return nil, err
+	}
+
+	partitions := []Partition{}
+	for _, name := range names {
+		month, err := time.Parse(partitionNameLayout, strings.TrimPrefix(name, p.table+"_"))
+		if err != nil {
+			// Not a monthly partition, e.g. the default partition
+			continue
+		}
+		partitions = append(partitions, p.partition(month))
+	}
+	sort.Slice(partitions, func(i, j int) bool {
+		return partitions[i].From.Before(partitions[j].From)
+	})
+	return partitions, nil
+}
+
+// partition returns the partition of the month starting at the given time
+func (p *MonthlyPartitioner) partition(month time.Time) Partition {
+	return Partition{
+		Name: p.table + "_" + month.Format(partitionNameLayout),
+		From: month,
+		To:   month.AddDate(0, 1, 0),
+	}
+}
+
+// startOfMonth returns the start of the month of t in UTC
+func startOfMonth(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,167 @@
+package storage
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPartitionLockID = 42
+
+func newTestPartitioner(t *testing.T) (*MonthlyPartitioner, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock := GetMockDB(t)
+	return NewMonthlyPartitioner(db, "events", "created_at", testPartitionLockID), mock
+}
+
+func expectPartitions(mock sqlmock.Sqlmock, names ...string) {
+	rows := sqlmock.NewRows([]string{"relname"})
+	for _, name := range names {
+		rows.AddRow(name)
+	}
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT child.relname FROM pg_inherits`)).WithArgs("events").WillReturnRows(rows)
+}
+
+func expectDefaultPartition(mock sqlmock.Sqlmock, exists bool) {
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT to_regclass($1) IS NOT NULL`)).WithArgs("events_default").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(exists))
+}
+
+func expectDefaultRows(mock sqlmock.Sqlmock, from, to time.Time, exist bool) {
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT EXISTS (SELECT 1 FROM "events_default" WHERE "created_at" >= $1 AND "created_at" < $2)`)).
+		WithArgs(from, to).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(exist))
+}
+
+func TestMonthlyPartitioner_Partitions(t *testing.T) {
+	partitioner, mock := newTestPartitioner(t)
+	expectPartitions(mock, "events_2024_06", "events_default", "events_2024_05")
+
+	partitions, err := partitioner.Partitions(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []Partition{
+		{Name: "events_2024_05", From: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{Name: "events_2024_06", From: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)},
+	}, partitions)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMonthlyPartitioner_Maintain(t *testing.T) {
+	now := time.Date(2024, 12, 15, 10, 0, 0, 0, time.UTC)
+
+	t.Run("Creates partitions ahead and drops expired ones", func(t *testing.T) {
+		partitioner, mock := newTestPartitioner(t)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT pg_try_advisory_xact_lock($1)`)).WithArgs(testPartitionLockID).
+			WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(true))
+		expectPartitions(mock, "events_2024_09", "events_2024_10", "events_2024_11", "events_2024_12", "events_default")
+		expectDefaultPartition(mock, true)
+		expectDefaultRows(mock, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC), false)
+		mock.ExpectExec(regexp.QuoteMeta(`CREATE TABLE "events_2025_01" PARTITION OF "events" FOR VALUES FROM ('2025-01-01T00:00:00Z') TO ('2025-02-01T00:00:00Z')`)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		// With a 60 days retention, the cutoff is 2024-10-16 so only September is entirely expired
+		mock.ExpectExec(regexp.QuoteMeta(`ALTER TABLE "events" DETACH PARTITION "events_2024_09"`)).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta(`DROP TABLE "events_2024_09"`)).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "events_default" WHERE "created_at" < $1`)).
+			WithArgs(now.Add(-60 * 24 * time.Hour)).
+			WillReturnResult(sqlmock.NewResult(0, 3))
+		mock.ExpectCommit()
+
+		result, locked, err := partitioner.Maintain(context.Background(), now, 1, 60*24*time.Hour, false)
+		require.NoError(t, err)
+		assert.True(t, locked)
+		require.Len(t, result.Created, 1)
+		assert.Equal(t, "events_2025_01", result.Created[0].Name)
+		require.Len(t, result.Detached, 1)
+		assert.Equal(t, "events_2024_09", result.Detached[0].Name)
+		assert.Equal(t, result.Detached, result.Dropped)
+		assert.Equal(t, int64(3), result.Purged)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Detaches only", func(t *testing.T) {
+		partitioner, mock := newTestPartitioner(t)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT pg_try_advisory_xact_lock($1)`)).WithArgs(testPartitionLockID).
+			WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(true))
+		expectPartitions(mock, "events_2024_09", "events_2024_12")
+		expectDefaultPartition(mock, false)
+		mock.ExpectExec(regexp.QuoteMeta(`ALTER TABLE "events" DETACH PARTITION "events_2024_09"`)).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		result, locked, err := partitioner.Maintain(context.Background(), now, 0, 60*24*time.Hour, true)
+		require.NoError(t, err)
+		assert.True(t, locked)
+		assert.Empty(t, result.Created)
+		assert.Len(t, result.Detached, 1)
+		assert.Empty(t, result.Dropped)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Moves the rows of the default partition to the created partition", func(t *testing.T) {
+		partitioner, mock := newTestPartitioner(t)
+		from, to := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT pg_try_advisory_xact_lock($1)`)).WithArgs(testPartitionLockID).
+			WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(true))
+		expectPartitions(mock, "events_2024_12", "events_default")
+		expectDefaultPartition(mock, true)
+		// An event dated next month was saved before its partition was created
+		expectDefaultRows(mock, from, to, true)
+		mock.ExpectExec(regexp.QuoteMeta(`ALTER TABLE "events" DETACH PARTITION "events_default"`)).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta(`CREATE TABLE "events_2025_01" PARTITION OF "events" FOR VALUES FROM ('2025-01-01T00:00:00Z') TO ('2025-02-01T00:00:00Z')`)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta(`WITH moved AS (DELETE FROM "events_default" WHERE "created_at" >= $1 AND "created_at" < $2 RETURNING *) `+
+			`INSERT INTO "events_2025_01" SELECT * FROM moved`)).
+			WithArgs(from, to).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta(`ALTER TABLE "events" ATTACH PARTITION "events_default" DEFAULT`)).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		result, locked, err := partitioner.Maintain(context.Background(), now, 1, 0, false)
+		require.NoError(t, err)
+		assert.True(t, locked)
+		require.Len(t, result.Created, 1)
+		assert.Equal(t, "events_2025_01", result.Created[0].Name)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Skips when another instance holds the lock", func(t *testing.T) {
+		partitioner, mock := newTestPartitioner(t)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT pg_try_advisory_xact_lock($1)`)).WithArgs(testPartitionLockID).
+			WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(false))
+		mock.ExpectCommit()
+
+		_, locked, err := partitioner.Maintain(context.Background(), now, 3, 0, false)
+		require.NoError(t, err)
+		assert.False(t, locked)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Rolls back on failure", func(t *testing.T) {
+		partitioner, mock := newTestPartitioner(t)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT pg_try_advisory_xact_lock($1)`)).WithArgs(testPartitionLockID).
+			WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(true))
+		expectPartitions(mock)
+		expectDefaultPartition(mock, false)
+		mock.ExpectExec(regexp.QuoteMeta(`CREATE TABLE "events_2024_12"`)).WillReturnError(assert.AnError)
+		mock.ExpectRollback()
+
+		_, _, err := partitioner.Maintain(context.Background(), now, 0, 0, false)
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.ErrorContains(t, err, "creating partition events_2024_12")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,74 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cvzm/go-web-project/domain"
+)
+
+// Headers of the webhook requests
+const (
+	HeaderDeliveryID = "X-Webhook-Delivery"  // ID of the delivery, a redelivery having a new one
+	HeaderEventID    = "X-Webhook-Event-ID"  // ID of the event, for subscribers to deduplicate on
+	HeaderEventType  = "X-Webhook-Event"     // type of the webhook, e.g. event.created
+	HeaderTimestamp  = "X-Webhook-Timestamp" // Unix time of the request, in seconds
+	HeaderSignature  = "X-Webhook-Signature" // sha256=<hex HMAC-SHA256 of "<timestamp>.<body>" keyed by the secret>
+)
+
+// Sign returns the signature of the body sent at the given Unix time with the secret of the subscription.
+// Subscribers compute it again to authenticate the request, and reject old timestamps to prevent replays.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// HTTPSender is an implementation of the domain.WebhookSender interface posting the signed payload of
+// the deliveries to the subscription endpoints
+type HTTPSender struct {
+	client *http.Client
+	now    func() time.Time
+}
+
+// NewHTTPSender creates a new HTTPSender instance posting with the HTTP client
+func NewHTTPSender(client *http.Client) *HTTPSender {
+	return &HTTPSender{client: client, now: time.Now}
+}
+
+// Send posts the delivery once, failing unless the subscriber responds with a 2xx status
+func (s *HTTPSender) Send(ctx context.Context, subscription domain.WebhookSubscription, delivery domain.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := s.now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderDeliveryID, strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set(HeaderEventID, strconv.FormatUint(uint64(delivery.EventID), 10))
+	req.Header.Set(HeaderEventType, delivery.EventType)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(subscription.Secret, timestamp, delivery.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if resp.StatusCode/100 != 2 {
+		return resp.StatusCode, fmt.Errorf("subscriber responded %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return resp.StatusCode, nil
+}
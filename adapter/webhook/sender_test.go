@@ -0,0 +1,72 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cvzm/go-web-project/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSign(t *testing.T) {
+	// Computed independently with: printf '1714564800.{"id":42}' | openssl dgst -sha256 -hmac secret
+	assert.Equal(t, "sha256=cf45f36cc3d5a264f5d46261a86c86e5c367915c0a153ba694abf83397002183", Sign("secret", 1714564800, []byte(`{"id":42}`)))
+	assert.NotEqual(t, Sign("secret", 1714564800, []byte(`{"id":42}`)), Sign("secret", 1714564801, []byte(`{"id":42}`)))
+	assert.NotEqual(t, Sign("secret", 1714564800, []byte(`{"id":42}`)), Sign("other", 1714564800, []byte(`{"id":42}`)))
+}
+
+func TestHTTPSender_Send(t *testing.T) {
+	subscription := domain.WebhookSubscription{ID: 3, Secret: "secret"}
+	delivery := domain.WebhookDelivery{ID: 7, EventID: 42, EventType: domain.OutboxEventCreated, Payload: []byte(`{"id":42}`)}
+
+	t.Run("Posts the signed payload", func(t *testing.T) {
+		var received *http.Request
+		var body []byte
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received = r
+			body, _ = io.ReadAll(r.Body)
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+
+		sender := NewHTTPSender(server.Client())
+		sender.now = func() time.Time { return time.Unix(1714564800, 0) }
+		subscription.URL = server.URL
+
+		status, err := sender.Send(context.Background(), subscription, delivery)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNoContent, status)
+		assert.Equal(t, `{"id":42}`, string(body))
+		assert.Equal(t, "application/json", received.Header.Get("Content-Type"))
+		assert.Equal(t, "7", received.Header.Get(HeaderDeliveryID))
+		assert.Equal(t, "42", received.Header.Get(HeaderEventID))
+		assert.Equal(t, domain.OutboxEventCreated, received.Header.Get(HeaderEventType))
+		assert.Equal(t, "1714564800", received.Header.Get(HeaderTimestamp))
+		assert.Equal(t, Sign("secret", 1714564800, body), received.Header.Get(HeaderSignature))
+	})
+
+	t.Run("Fails on error status", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+		subscription.URL = server.URL
+
+		status, err := NewHTTPSender(server.Client()).Send(context.Background(), subscription, delivery)
+		assert.Equal(t, http.StatusServiceUnavailable, status)
+		assert.EqualError(t, err, "subscriber responded 503: unavailable")
+	})
+
+	t.Run("Fails without response", func(t *testing.T) {
+		subscription.URL = "http://127.0.0.1:1"
+
+		status, err := NewHTTPSender(http.DefaultClient).Send(context.Background(), subscription, delivery)
+		assert.Zero(t, status)
+		assert.Error(t, err)
+	})
+}
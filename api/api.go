@@ -1,8 +1,12 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"net/http"
 
+	"github.com/cvzm/go-web-project/domain"
+
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 )
@@ -31,8 +35,8 @@ type StandardResponse struct {
 }
 
 type (
-	// RequestHandler is a function type for handling standard requests
-	RequestHandler[T any] func(T) (any, error)
+	// RequestHandler is a function type for handling standard requests, within the context of the request
+	RequestHandler[T any] func(context.Context, T) (any, error)
 )
 
 // HandleRequest processes standard requests
@@ -45,8 +49,30 @@ func HandleRequest[T any](c echo.Context, handler RequestHandler[T]) error {
 		})
 	}
 
-	// Call the handler function
-	result, err := handler(param)
+	// Call the handler function, which is cancelled if the client disconnects or the request times out
+	result, err := handler(c.Request().Context(), param)
+	var validationErr *domain.ValidationError
+	if errors.As(err, &validationErr) {
+		return c.JSON(http.StatusBadRequest, StandardResponse{
+			Message: validationErr.Message,
+		})
+	}
+	var conflictErr *domain.ConflictError
+	if errors.As(err, &conflictErr) {
+		return c.JSON(http.StatusConflict, StandardResponse{
+			Message: conflictErr.Message,
+		})
+	}
+	if errors.Is(err, domain.ErrNotFound) {
+		return c.JSON(http.StatusNotFound, StandardResponse{
+			Message: "Not found",
+		})
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return c.JSON(http.StatusServiceUnavailable, StandardResponse{
+			Message: "Request timed out",
+		})
+	}
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, StandardResponse{
 			Message: "Internal error",
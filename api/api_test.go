@@ -1,12 +1,15 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
 
+	"github.com/cvzm/go-web-project/domain"
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
 )
@@ -43,7 +46,7 @@ func TestHandleRequest(t *testing.T) {
 		Name string `json:"name"`
 	}
 
-	handler := func(param TestParam) (any, error) {
+	handler := func(ctx context.Context, param TestParam) (any, error) {
 		return map[string]string{"greeting": "Hello, " + param.Name}, nil
 	}
 
@@ -86,4 +89,74 @@ func TestHandleRequest(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, "Invalid request format", response.Message)
 	})
+	t.Run("Request timed out", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/test", strings.NewReader(`{"name":"John"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		err := HandleRequest(c, func(ctx context.Context, param TestParam) (any, error) {
+			return nil, fmt.Errorf("saving event: %w", context.DeadlineExceeded)
+		})
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+		var response StandardResponse
+		err = json.Unmarshal(rec.Body.Bytes(), &response)
+		assert.NoError(t, err)
+		assert.Equal(t, "Request timed out", response.Message)
+	})
+
+	t.Run("Invalid request content", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/test", strings.NewReader(`{"name":""}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		err := HandleRequest(c, func(ctx context.Context, param TestParam) (any, error) {
+			return nil, &domain.ValidationError{Message: "name is required"}
+		})
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+		var response StandardResponse
+		err = json.Unmarshal(rec.Body.Bytes(), &response)
+		assert.NoError(t, err)
+		assert.Equal(t, "name is required", response.Message)
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/test", strings.NewReader(`{"name":"John"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		err := HandleRequest(c, func(ctx context.Context, param TestParam) (any, error) {
+			return nil, fmt.Errorf("finding rule: %w", domain.ErrNotFound)
+		})
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("Conflict", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/test", strings.NewReader(`{"name":"John"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		err := HandleRequest(c, func(ctx context.Context, param TestParam) (any, error) {
+			return nil, fmt.Errorf("resolving event: %w", &domain.ConflictError{Message: "event 7 is resolved and cannot be resolved"})
+		})
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusConflict, rec.Code)
+
+		var response StandardResponse
+		err = json.Unmarshal(rec.Body.Bytes(), &response)
+		assert.NoError(t, err)
+		assert.Equal(t, "event 7 is resolved and cannot be resolved", response.Message)
+	})
 }
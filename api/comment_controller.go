@@ -0,0 +1,92 @@
+package api
+
+import (
+	"context"
+
+	"github.com/cvzm/go-web-project/domain"
+
+	"github.com/labstack/echo/v4"
+)
+
+// commentParam is a comment of an event, identified by the path of the request
+type commentParam struct {
+	EventID uint `param:"id" json:"-"`
+	ID      uint `param:"comment_id" json:"-"`
+	domain.EventComment
+}
+
+// annotationParam is an annotation of an event, identified by the path of the request
+type annotationParam struct {
+	EventID uint   `param:"id" json:"-"`
+	Key     string `param:"key" json:"-"`
+	domain.EventAnnotation
+}
+
+type CommentController struct {
+	commentUsecase domain.CommentUsecase
+}
+
+func NewCommentController(usecase domain.CommentUsecase) *CommentController {
+	return &CommentController{
+		commentUsecase: usecase,
+	}
+}
+
+// ListComments returns the threads of comments of an event
+func (c *CommentController) ListComments(ctx echo.Context) error {
+	return HandleRequest(ctx, func(reqCtx context.Context, param eventParam) (any, error) {
+		return c.commentUsecase.Comments(reqCtx, param.ID)
+	})
+}
+
+func (c *CommentController) CreateComment(ctx echo.Context) error {
+	return HandleRequest(ctx, func(reqCtx context.Context, param commentParam) (any, error) {
+		comment := param.EventComment
+		comment.ID = 0
+		if err := c.commentUsecase.AddComment(reqCtx, param.EventID, &comment); err != nil {
+			return nil, err
+		}
+		return comment, nil
+	})
+}
+
+// UpdateComment edits the body of a comment, its author and parent being kept
+func (c *CommentController) UpdateComment(ctx echo.Context) error {
+	return HandleRequest(ctx, func(reqCtx context.Context, param commentParam) (any, error) {
+		return c.commentUsecase.EditComment(reqCtx, param.EventID, param.ID, param.Body)
+	})
+}
+
+func (c *CommentController) ListAnnotations(ctx echo.Context) error {
+	return HandleRequest(ctx, func(reqCtx context.Context, param eventParam) (any, error) {
+		return c.commentUsecase.Annotations(reqCtx, param.ID)
+	})
+}
+
+// SetAnnotation sets the annotation of the key of the path, replacing its value if already set
+func (c *CommentController) SetAnnotation(ctx echo.Context) error {
+	return HandleRequest(ctx, func(reqCtx context.Context, param annotationParam) (any, error) {
+		annotation := param.EventAnnotation
+		annotation.ID = 0
+		annotation.Key = param.Key
+		if err := c.commentUsecase.Annotate(reqCtx, param.EventID, &annotation); err != nil {
+			return nil, err
+		}
+		return annotation, nil
+	})
+}
+
+func (c *CommentController) DeleteAnnotation(ctx echo.Context) error {
+	return HandleRequest(ctx, func(reqCtx context.Context, param annotationParam) (any, error) {
+		return nil, c.commentUsecase.RemoveAnnotation(reqCtx, param.EventID, param.Key)
+	})
+}
+
+func SetupCommentRoutes(e *echo.Echo, controller *CommentController) {
+	e.GET("/events/:id/comments", controller.ListComments)
+	e.POST("/events/:id/comments", controller.CreateComment)
+	e.PUT("/events/:id/comments/:comment_id", controller.UpdateComment)
+	e.GET("/events/:id/annotations", controller.ListAnnotations)
+	e.PUT("/events/:id/annotations/:key", controller.SetAnnotation)
+	e.DELETE("/events/:id/annotations/:key", controller.DeleteAnnotation)
+}
@@ -0,0 +1,178 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/cvzm/go-web-project/domain"
+	domain_mock "github.com/cvzm/go-web-project/domain/mock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommentController_ListComments(t *testing.T) {
+	e := echo.New()
+	mockUsecase := new(domain_mock.MockCommentUsecase)
+	controller := NewCommentController(mockUsecase)
+	parentID := uint(1)
+
+	mockUsecase.On("Comments", mock.Anything, uint(7)).Return([]domain.EventComment{{
+		ID: 1, EventID: 7, Author: "alice", Body: "Disk full",
+		Replies: []domain.EventComment{{ID: 2, EventID: 7, ParentID: &parentID, Author: "bob", Body: "Cleaned up the logs"}},
+	}}, nil).Once()
+
+	c, resp := newTestContext(http.MethodGet, "/events/7/comments", nil, e)
+	c.SetParamNames("id")
+	c.SetParamValues("7")
+
+	assert.NoError(t, controller.ListComments(c))
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	var response struct {
+		Data []domain.EventComment `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+	require.Len(t, response.Data, 1)
+	require.Len(t, response.Data[0].Replies, 1)
+	assert.Equal(t, "bob", response.Data[0].Replies[0].Author)
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestCommentController_CreateComment(t *testing.T) {
+	e := echo.New()
+
+	t.Run("Successfully create comment", func(t *testing.T) {
+		mockUsecase := new(domain_mock.MockCommentUsecase)
+		controller := NewCommentController(mockUsecase)
+
+		mockUsecase.On("AddComment", mock.Anything, uint(7), mock.MatchedBy(func(comment *domain.EventComment) bool {
+			return comment.ID == 0 && comment.ParentID != nil && *comment.ParentID == 1 && comment.Author == "bob" && comment.Body == "**Fixed**"
+		})).Run(func(args mock.Arguments) {
+			comment := args.Get(2).(*domain.EventComment)
+			comment.ID = 2
+			comment.EventID = 7
+		}).Return(nil).Once()
+
+		c, resp := newTestContext(http.MethodPost, "/events/7/comments", map[string]any{"id": 5, "parent_id": 1, "author": "bob", "body": "**Fixed**"}, e)
+		c.SetParamNames("id")
+		c.SetParamValues("7")
+
+		assert.NoError(t, controller.CreateComment(c))
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"id":2`)
+		assert.Contains(t, resp.Body.String(), `"event_id":7`)
+		mockUsecase.AssertExpectations(t)
+	})
+
+	t.Run("Invalid comment", func(t *testing.T) {
+		mockUsecase := new(domain_mock.MockCommentUsecase)
+		controller := NewCommentController(mockUsecase)
+
+		mockUsecase.On("AddComment", mock.Anything, uint(7), mock.Anything).Return(&domain.ValidationError{Message: "body is required"}).Once()
+
+		c, resp := newTestContext(http.MethodPost, "/events/7/comments", domain.EventComment{Author: "bob"}, e)
+		c.SetParamNames("id")
+		c.SetParamValues("7")
+
+		assert.NoError(t, controller.CreateComment(c))
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+		assert.Contains(t, resp.Body.String(), "body is required")
+	})
+}
+
+func TestCommentController_UpdateComment(t *testing.T) {
+	e := echo.New()
+	mockUsecase := new(domain_mock.MockCommentUsecase)
+	controller := NewCommentController(mockUsecase)
+
+	mockUsecase.On("EditComment", mock.Anything, uint(7), uint(2), "Rotated the logs").
+		Return(domain.EventComment{ID: 2, EventID: 7, Author: "bob", Body: "Rotated the logs"}, nil).Once()
+	mockUsecase.On("EditComment", mock.Anything, uint(7), uint(9), "Rotated the logs").
+		Return(domain.EventComment{}, domain.ErrNotFound).Once()
+
+	c, resp := newTestContext(http.MethodPut, "/events/7/comments/2", map[string]string{"body": "Rotated the logs"}, e)
+	c.SetParamNames("id", "comment_id")
+	c.SetParamValues("7", "2")
+	assert.NoError(t, controller.UpdateComment(c))
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	c, resp = newTestContext(http.MethodPut, "/events/7/comments/9", map[string]string{"body": "Rotated the logs"}, e)
+	c.SetParamNames("id", "comment_id")
+	c.SetParamValues("7", "9")
+	assert.NoError(t, controller.UpdateComment(c))
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestCommentController_Annotations(t *testing.T) {
+	e := echo.New()
+
+	t.Run("List annotations", func(t *testing.T) {
+		mockUsecase := new(domain_mock.MockCommentUsecase)
+		controller := NewCommentController(mockUsecase)
+
+		mockUsecase.On("Annotations", mock.Anything, uint(7)).
+			Return([]domain.EventAnnotation{{ID: 1, EventID: 7, Key: "jira", Value: "OPS-42", Author: "alice"}}, nil).Once()
+
+		c, resp := newTestContext(http.MethodGet, "/events/7/annotations", nil, e)
+		c.SetParamNames("id")
+		c.SetParamValues("7")
+
+		assert.NoError(t, controller.ListAnnotations(c))
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"value":"OPS-42"`)
+		mockUsecase.AssertExpectations(t)
+	})
+
+	t.Run("Set annotation of the key of the path", func(t *testing.T) {
+		mockUsecase := new(domain_mock.MockCommentUsecase)
+		controller := NewCommentController(mockUsecase)
+
+		mockUsecase.On("Annotate", mock.Anything, uint(7), &domain.EventAnnotation{Key: "jira", Value: "OPS-43", Author: "bob"}).Return(nil).Once()
+
+		c, resp := newTestContext(http.MethodPut, "/events/7/annotations/jira", map[string]string{"key": "other", "value": "OPS-43", "author": "bob"}, e)
+		c.SetParamNames("id", "key")
+		c.SetParamValues("7", "jira")
+
+		assert.NoError(t, controller.SetAnnotation(c))
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"key":"jira"`)
+		mockUsecase.AssertExpectations(t)
+	})
+
+	t.Run("Delete missing annotation", func(t *testing.T) {
+		mockUsecase := new(domain_mock.MockCommentUsecase)
+		controller := NewCommentController(mockUsecase)
+
+		mockUsecase.On("RemoveAnnotation", mock.Anything, uint(7), "jira").Return(domain.ErrNotFound).Once()
+
+		c, resp := newTestContext(http.MethodDelete, "/events/7/annotations/jira", nil, e)
+		c.SetParamNames("id", "key")
+		c.SetParamValues("7", "jira")
+
+		assert.NoError(t, controller.DeleteAnnotation(c))
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+		mockUsecase.AssertExpectations(t)
+	})
+}
+
+func TestSetupCommentRoutes(t *testing.T) {
+	e := echo.New()
+	SetupCommentRoutes(e, NewCommentController(new(domain_mock.MockCommentUsecase)))
+
+	routes := map[string]bool{}
+	for _, route := range e.Router().Routes() {
+		routes[route.Method+" "+route.Path] = true
+	}
+	assert.Equal(t, map[string]bool{
+		"GET /events/:id/comments":             true,
+		"POST /events/:id/comments":            true,
+		"PUT /events/:id/comments/:comment_id": true,
+		"GET /events/:id/annotations":          true,
+		"PUT /events/:id/annotations/:key":     true,
+		"DELETE /events/:id/annotations/:key":  true,
+	}, routes)
+}
@@ -1,34 +1,94 @@
 package api
 
 import (
+	"context"
+
 	"github.com/cvzm/go-web-project/domain"
 
 	"github.com/labstack/echo/v4"
 )
 
+// eventParam identifies an event by the path of the request
+type eventParam struct {
+	ID uint `param:"id" json:"-"`
+}
+
+// eventChangeParam is a change of an event, identified by the path of the request
+type eventChangeParam struct {
+	ID uint `param:"id" json:"-"`
+	domain.EventChangeRequest
+}
+
 type EventController struct {
-	eventUsecase domain.EventUsecase
+	eventUsecase  domain.EventUsecase
+	eventIngester domain.EventIngester
 }
 
-func NewEventController(usecase domain.EventUsecase) *EventController {
+func NewEventController(usecase domain.EventUsecase, ingester domain.EventIngester) *EventController {
 	return &EventController{
-		eventUsecase: usecase,
+		eventUsecase:  usecase,
+		eventIngester: ingester,
 	}
 }
 
 func (c *EventController) CreateAWSEvent(ctx echo.Context) error {
-	return HandleRequest(ctx, func(param domain.AWSEvent) (any, error) {
-		return nil, c.eventUsecase.Save(param)
+	return HandleRequest(ctx, func(reqCtx context.Context, param domain.AWSEvent) (any, error) {
+		return nil, c.eventIngester.Ingest(reqCtx, param)
 	})
 }
 
 func (c *EventController) CreateGCPEvent(ctx echo.Context) error {
-	return HandleRequest(ctx, func(param domain.GCPEvent) (any, error) {
-		return nil, c.eventUsecase.Save(param)
+	return HandleRequest(ctx, func(reqCtx context.Context, param domain.GCPEvent) (any, error) {
+		return nil, c.eventIngester.Ingest(reqCtx, param)
+	})
+}
+
+// ListEvents returns the events matching the query string, latest first
+func (c *EventController) ListEvents(ctx echo.Context) error {
+	return HandleRequest(ctx, func(reqCtx context.Context, param domain.EventQuery) (any, error) {
+		return c.eventUsecase.Query(reqCtx, param)
+	})
+}
+
+func (c *EventController) GetEvent(ctx echo.Context) error {
+	return HandleRequest(ctx, func(reqCtx context.Context, param eventParam) (any, error) {
+		return c.eventUsecase.Get(reqCtx, param.ID)
+	})
+}
+
+func (c *EventController) AcknowledgeEvent(ctx echo.Context) error {
+	return HandleRequest(ctx, func(reqCtx context.Context, param eventChangeParam) (any, error) {
+		return c.eventUsecase.Acknowledge(reqCtx, param.ID, param.EventChangeRequest)
+	})
+}
+
+func (c *EventController) ResolveEvent(ctx echo.Context) error {
+	return HandleRequest(ctx, func(reqCtx context.Context, param eventChangeParam) (any, error) {
+		return c.eventUsecase.Resolve(reqCtx, param.ID, param.EventChangeRequest)
+	})
+}
+
+// AssignEvent assigns an event to the assignee of the request, or unassigns it when there is none
+func (c *EventController) AssignEvent(ctx echo.Context) error {
+	return HandleRequest(ctx, func(reqCtx context.Context, param eventChangeParam) (any, error) {
+		return c.eventUsecase.Assign(reqCtx, param.ID, param.EventChangeRequest)
+	})
+}
+
+// EventHistory returns the changes of an event, oldest first
+func (c *EventController) EventHistory(ctx echo.Context) error {
+	return HandleRequest(ctx, func(reqCtx context.Context, param eventParam) (any, error) {
+		return c.eventUsecase.History(reqCtx, param.ID)
 	})
 }
 
 func SetupEventRoutes(e *echo.Echo, controller *EventController) {
+	e.GET("/events", controller.ListEvents)
+	e.GET("/events/:id", controller.GetEvent)
+	e.GET("/events/:id/history", controller.EventHistory)
+	e.POST("/events/:id/ack", controller.AcknowledgeEvent)
+	e.POST("/events/:id/resolve", controller.ResolveEvent)
+	e.POST("/events/:id/assign", controller.AssignEvent)
 	e.POST("/events/aws", controller.CreateAWSEvent)
 	e.POST("/events/gcp", controller.CreateGCPEvent)
 }
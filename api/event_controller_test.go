@@ -15,14 +15,16 @@ import (
 
 func TestNewEventController(t *testing.T) {
 	mockUsecase := new(domain_mock.MockEventUsecase)
-	controller := NewEventController(mockUsecase)
+	mockIngester := new(domain_mock.MockEventIngester)
+	controller := NewEventController(mockUsecase, mockIngester)
 	assert.NotNil(t, controller)
 	assert.Equal(t, mockUsecase, controller.eventUsecase)
+	assert.Equal(t, mockIngester, controller.eventIngester)
 }
 
 func TestEventController_CreateAWSEvent(t *testing.T) {
-	mockUsecase := new(domain_mock.MockEventUsecase)
-	controller := NewEventController(mockUsecase)
+	mockIngester := new(domain_mock.MockEventIngester)
+	controller := NewEventController(nil, mockIngester)
 	e := echo.New()
 
 	t.Run("Successfully create AWS event", func(t *testing.T) {
@@ -33,7 +35,7 @@ func TestEventController_CreateAWSEvent(t *testing.T) {
 			AWSTimestamp: time.Now(),
 		}
 
-		mockUsecase.On("Save", mock.AnythingOfType("domain.AWSEvent")).Return(nil).Once()
+		mockIngester.On("Ingest", mock.Anything, mock.AnythingOfType("domain.AWSEvent")).Return(nil).Once()
 
 		c, resp := newTestContext(http.MethodPost, "/events/aws", awsEvent, e)
 
@@ -41,7 +43,7 @@ func TestEventController_CreateAWSEvent(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, http.StatusOK, resp.Code)
 
-		mockUsecase.AssertExpectations(t)
+		mockIngester.AssertExpectations(t)
 	})
 
 	t.Run("Fail to create AWS event", func(t *testing.T) {
@@ -52,7 +54,7 @@ func TestEventController_CreateAWSEvent(t *testing.T) {
 			AWSTimestamp: time.Now(),
 		}
 
-		mockUsecase.On("Save", mock.AnythingOfType("domain.AWSEvent")).Return(errors.New("creation failed")).Once()
+		mockIngester.On("Ingest", mock.Anything, mock.AnythingOfType("domain.AWSEvent")).Return(errors.New("creation failed")).Once()
 
 		c, resp := newTestContext(http.MethodPost, "/events/aws", awsEvent, e)
 
@@ -60,13 +62,13 @@ func TestEventController_CreateAWSEvent(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, http.StatusInternalServerError, resp.Code)
 
-		mockUsecase.AssertExpectations(t)
+		mockIngester.AssertExpectations(t)
 	})
 }
 
 func TestEventController_CreateGCPEvent(t *testing.T) {
-	mockUsecase := new(domain_mock.MockEventUsecase)
-	controller := NewEventController(mockUsecase)
+	mockIngester := new(domain_mock.MockEventIngester)
+	controller := NewEventController(nil, mockIngester)
 	e := echo.New()
 
 	t.Run("Successfully create GCP event", func(t *testing.T) {
@@ -77,7 +79,7 @@ func TestEventController_CreateGCPEvent(t *testing.T) {
 			GCPTimestamp: time.Now(),
 		}
 
-		mockUsecase.On("Save", mock.AnythingOfType("domain.GCPEvent")).Return(nil).Once()
+		mockIngester.On("Ingest", mock.Anything, mock.AnythingOfType("domain.GCPEvent")).Return(nil).Once()
 
 		c, resp := newTestContext(http.MethodPost, "/events/gcp", gcpEvent, e)
 
@@ -85,7 +87,7 @@ func TestEventController_CreateGCPEvent(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, http.StatusOK, resp.Code)
 
-		mockUsecase.AssertExpectations(t)
+		mockIngester.AssertExpectations(t)
 	})
 
 	t.Run("Fail to create GCP event", func(t *testing.T) {
@@ -96,7 +98,7 @@ func TestEventController_CreateGCPEvent(t *testing.T) {
 			GCPTimestamp: time.Now(),
 		}
 
-		mockUsecase.On("Save", mock.AnythingOfType("domain.GCPEvent")).Return(errors.New("creation failed")).Once()
+		mockIngester.On("Ingest", mock.Anything, mock.AnythingOfType("domain.GCPEvent")).Return(errors.New("creation failed")).Once()
 
 		c, resp := newTestContext(http.MethodPost, "/events/gcp", gcpEvent, e)
 
@@ -104,35 +106,150 @@ func TestEventController_CreateGCPEvent(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, http.StatusInternalServerError, resp.Code)
 
+		mockIngester.AssertExpectations(t)
+	})
+}
+
+func TestEventController_ListEvents(t *testing.T) {
+	e := echo.New()
+
+	t.Run("Successfully query events", func(t *testing.T) {
+		mockUsecase := new(domain_mock.MockEventUsecase)
+		controller := NewEventController(mockUsecase, nil)
+		from := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+		events := []domain.Event{{ID: 7, Source: domain.SourceAWS, EventType: "EC2_STOPPED", Severity: domain.SeverityCritical}}
+
+		mockUsecase.On("Query", mock.Anything, mock.MatchedBy(func(query domain.EventQuery) bool {
+			return assert.ObjectsAreEqual([]domain.EventSource{domain.SourceAWS}, query.Sources) &&
+				assert.ObjectsAreEqual([]domain.Severity{domain.SeverityError, domain.SeverityCritical}, query.Severities) &&
+				query.Resource == "i-123" && assert.ObjectsAreEqual([]string{"jira:OPS-42"}, query.Annotations) && query.From != nil && query.From.Equal(from) && query.To == nil && query.Limit == 20
+		})).Return(events, nil).Once()
+
+		c, resp := newTestContext(http.MethodGet, "/events?source=AWS&severity=error&severity=critical&resource=i-123&annotation=jira:OPS-42&from=2024-05-01T12:00:00Z&limit=20", nil, e)
+
+		assert.NoError(t, controller.ListEvents(c))
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"severity":"critical"`)
 		mockUsecase.AssertExpectations(t)
 	})
+
+	t.Run("Invalid query", func(t *testing.T) {
+		mockUsecase := new(domain_mock.MockEventUsecase)
+		controller := NewEventController(mockUsecase, nil)
+
+		c, resp := newTestContext(http.MethodGet, "/events?from=yesterday", nil, e)
+
+		assert.NoError(t, controller.ListEvents(c))
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+		mockUsecase.AssertNotCalled(t, "Query", mock.Anything, mock.Anything)
+	})
 }
 
-func TestSetupEventRoutes(t *testing.T) {
+func TestEventController_GetEvent(t *testing.T) {
 	e := echo.New()
 	mockUsecase := new(domain_mock.MockEventUsecase)
-	controller := NewEventController(mockUsecase)
+	controller := NewEventController(mockUsecase, nil)
+
+	mockUsecase.On("Get", mock.Anything, uint(7)).Return(domain.Event{ID: 7, Status: domain.EventStatusOpen}, nil).Once()
+	mockUsecase.On("Get", mock.Anything, uint(9)).Return(domain.Event{}, domain.ErrNotFound).Once()
+
+	c, resp := newTestContext(http.MethodGet, "/events/7", nil, e)
+	c.SetParamNames("id")
+	c.SetParamValues("7")
+	assert.NoError(t, controller.GetEvent(c))
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), `"status":"open"`)
+
+	c, resp = newTestContext(http.MethodGet, "/events/9", nil, e)
+	c.SetParamNames("id")
+	c.SetParamValues("9")
+	assert.NoError(t, controller.GetEvent(c))
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+	mockUsecase.AssertExpectations(t)
+}
 
-	SetupEventRoutes(e, controller)
+func TestEventController_ChangeEvent(t *testing.T) {
+	e := echo.New()
+	request := domain.EventChangeRequest{Actor: "alice", Assignee: "bob", Note: "On it"}
+
+	tests := []struct {
+		name   string
+		method string
+		handle func(controller *EventController, c echo.Context) error
+	}{
+		{"Acknowledge", "Acknowledge", (*EventController).AcknowledgeEvent},
+		{"Resolve", "Resolve", (*EventController).ResolveEvent},
+		{"Assign", "Assign", (*EventController).AssignEvent},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockUsecase := new(domain_mock.MockEventUsecase)
+			controller := NewEventController(mockUsecase, nil)
+
+			mockUsecase.On(tc.method, mock.Anything, uint(7), request).Return(domain.Event{ID: 7, Assignee: "bob"}, nil).Once()
+
+			c, resp := newTestContext(http.MethodPost, "/events/7", request, e)
+			c.SetParamNames("id")
+			c.SetParamValues("7")
+
+			assert.NoError(t, tc.handle(controller, c))
+			assert.Equal(t, http.StatusOK, resp.Code)
+			assert.Contains(t, resp.Body.String(), `"assignee":"bob"`)
+			mockUsecase.AssertExpectations(t)
+		})
+	}
 
-	assert.NotNil(t, e.Router().Routes())
-	assert.Len(t, e.Router().Routes(), 2)
+	t.Run("Conflicting change", func(t *testing.T) {
+		mockUsecase := new(domain_mock.MockEventUsecase)
+		controller := NewEventController(mockUsecase, nil)
 
-	routes := e.Router().Routes()
-	awsRouteFound := false
-	gcpRouteFound := false
+		mockUsecase.On("Resolve", mock.Anything, uint(7), mock.Anything).
+			Return(domain.Event{}, &domain.ConflictError{Message: "event 7 is resolved and cannot be resolved"}).Once()
 
-	for _, route := range routes {
-		switch route.Path {
-		case "/events/aws":
-			assert.Equal(t, http.MethodPost, route.Method)
-			awsRouteFound = true
-		case "/events/gcp":
-			assert.Equal(t, http.MethodPost, route.Method)
-			gcpRouteFound = true
-		}
-	}
+		c, resp := newTestContext(http.MethodPost, "/events/7/resolve", request, e)
+		c.SetParamNames("id")
+		c.SetParamValues("7")
 
-	assert.True(t, awsRouteFound, "AWS route not found")
-	assert.True(t, gcpRouteFound, "GCP route not found")
+		assert.NoError(t, controller.ResolveEvent(c))
+		assert.Equal(t, http.StatusConflict, resp.Code)
+		assert.Contains(t, resp.Body.String(), "cannot be resolved")
+	})
+}
+
+func TestEventController_EventHistory(t *testing.T) {
+	e := echo.New()
+	mockUsecase := new(domain_mock.MockEventUsecase)
+	controller := NewEventController(mockUsecase, nil)
+	changes := []domain.EventChange{{ID: 1, EventID: 7, Action: domain.EventActionAcknowledge, Actor: "alice"}}
+
+	mockUsecase.On("History", mock.Anything, uint(7)).Return(changes, nil).Once()
+
+	c, resp := newTestContext(http.MethodGet, "/events/7/history", nil, e)
+	c.SetParamNames("id")
+	c.SetParamValues("7")
+
+	assert.NoError(t, controller.EventHistory(c))
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), `"action":"acknowledge"`)
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestSetupEventRoutes(t *testing.T) {
+	e := echo.New()
+	SetupEventRoutes(e, NewEventController(new(domain_mock.MockEventUsecase), nil))
+
+	routes := map[string]bool{}
+	for _, route := range e.Router().Routes() {
+		routes[route.Method+" "+route.Path] = true
+	}
+	assert.Equal(t, map[string]bool{
+		"GET /events":              true,
+		"GET /events/:id":          true,
+		"GET /events/:id/history":  true,
+		"POST /events/:id/ack":     true,
+		"POST /events/:id/resolve": true,
+		"POST /events/:id/assign":  true,
+		"POST /events/aws":         true,
+		"POST /events/gcp":         true,
+	}, routes)
 }
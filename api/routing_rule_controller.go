@@ -0,0 +1,80 @@
+package api
+
+import (
+	"context"
+
+	"github.com/cvzm/go-web-project/domain"
+
+	"github.com/labstack/echo/v4"
+)
+
+// routingRuleParam is a routing rule, identified by the path of the request
+type routingRuleParam struct {
+	ID uint `param:"id" json:"-"`
+	domain.RoutingRule
+}
+
+type RoutingRuleController struct {
+	routingUsecase domain.RoutingUsecase
+}
+
+func NewRoutingRuleController(usecase domain.RoutingUsecase) *RoutingRuleController {
+	return &RoutingRuleController{
+		routingUsecase: usecase,
+	}
+}
+
+func (c *RoutingRuleController) ListRules(ctx echo.Context) error {
+	return HandleRequest(ctx, func(reqCtx context.Context, param struct{}) (any, error) {
+		return c.routingUsecase.List(reqCtx)
+	})
+}
+
+func (c *RoutingRuleController) GetRule(ctx echo.Context) error {
+	return HandleRequest(ctx, func(reqCtx context.Context, param routingRuleParam) (any, error) {
+		return c.routingUsecase.Get(reqCtx, param.ID)
+	})
+}
+
+func (c *RoutingRuleController) CreateRule(ctx echo.Context) error {
+	return HandleRequest(ctx, func(reqCtx context.Context, param domain.RoutingRule) (any, error) {
+		param.ID = 0
+		if err := c.routingUsecase.Create(reqCtx, &param); err != nil {
+			return nil, err
+		}
+		return param, nil
+	})
+}
+
+func (c *RoutingRuleController) UpdateRule(ctx echo.Context) error {
+	return HandleRequest(ctx, func(reqCtx context.Context, param routingRuleParam) (any, error) {
+		rule := param.RoutingRule
+		rule.ID = param.ID
+		if err := c.routingUsecase.Update(reqCtx, &rule); err != nil {
+			return nil, err
+		}
+		return c.routingUsecase.Get(reqCtx, rule.ID)
+	})
+}
+
+func (c *RoutingRuleController) DeleteRule(ctx echo.Context) error {
+	return HandleRequest(ctx, func(reqCtx context.Context, param routingRuleParam) (any, error) {
+		return nil, c.routingUsecase.Delete(reqCtx, param.ID)
+	})
+}
+
+// TestRules returns the rules a sample event would match and the channels it would be notified to
+func (c *RoutingRuleController) TestRules(ctx echo.Context) error {
+	return HandleRequest(ctx, func(reqCtx context.Context, param domain.Event) (any, error) {
+		return c.routingUsecase.Test(reqCtx, param)
+	})
+}
+
+func SetupRoutingRuleRoutes(e *echo.Echo, controller *RoutingRuleController) {
+	e.GET("/routing-rules", controller.ListRules)
+	e.POST("/routing-rules", controller.CreateRule)
+	e.POST("/routing-rules/test", controller.TestRules)
+	e.GET("/routing-rules/:id", controller.GetRule)
+	e.PUT("/routing-rules/:id", controller.UpdateRule)
+	e.DELETE("/routing-rules/:id", controller.DeleteRule)
+}
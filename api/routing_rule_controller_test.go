@@ -0,0 +1,149 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/cvzm/go-web-project/domain"
+	domain_mock "github.com/cvzm/go-web-project/domain/mock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoutingRuleController_CreateRule(t *testing.T) {
+	e := echo.New()
+
+	t.Run("Successfully create rule", func(t *testing.T) {
+		mockUsecase := new(domain_mock.MockRoutingUsecase)
+		controller := NewRoutingRuleController(mockUsecase)
+		rule := domain.RoutingRule{Name: "IAM to security", EventTypes: []string{"IAM_*"}, Channels: []string{"security"}, Stop: true}
+
+		mockUsecase.On("Create", mock.Anything, mock.MatchedBy(func(r *domain.RoutingRule) bool {
+			return r.Name == rule.Name && r.Stop && r.Channels[0] == "security"
+		})).Run(func(args mock.Arguments) {
+			args.Get(1).(*domain.RoutingRule).ID = 3
+		}).Return(nil).Once()
+
+		c, resp := newTestContext(http.MethodPost, "/routing-rules", rule, e)
+
+		assert.NoError(t, controller.CreateRule(c))
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"id":3`)
+		mockUsecase.AssertExpectations(t)
+	})
+
+	t.Run("Invalid rule", func(t *testing.T) {
+		mockUsecase := new(domain_mock.MockRoutingUsecase)
+		controller := NewRoutingRuleController(mockUsecase)
+
+		mockUsecase.On("Create", mock.Anything, mock.Anything).Return(&domain.ValidationError{Message: `unknown channel "finance"`}).Once()
+
+		c, resp := newTestContext(http.MethodPost, "/routing-rules", domain.RoutingRule{Name: "rule", Channels: []string{"finance"}}, e)
+
+		assert.NoError(t, controller.CreateRule(c))
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+		assert.Contains(t, resp.Body.String(), `unknown channel \"finance\"`)
+	})
+}
+
+func TestRoutingRuleController_UpdateRule(t *testing.T) {
+	e := echo.New()
+
+	t.Run("Successfully update rule", func(t *testing.T) {
+		mockUsecase := new(domain_mock.MockRoutingUsecase)
+		controller := NewRoutingRuleController(mockUsecase)
+		rule := domain.RoutingRule{ID: 3, Name: "IAM to security", Channels: []string{"security"}}
+
+		mockUsecase.On("Update", mock.Anything, mock.MatchedBy(func(r *domain.RoutingRule) bool {
+			return r.ID == 3 && r.Name == rule.Name
+		})).Return(nil).Once()
+		mockUsecase.On("Get", mock.Anything, uint(3)).Return(rule, nil).Once()
+
+		c, resp := newTestContext(http.MethodPut, "/routing-rules/3", domain.RoutingRule{ID: 7, Name: rule.Name, Channels: rule.Channels}, e)
+		c.SetParamNames("id")
+		c.SetParamValues("3")
+
+		assert.NoError(t, controller.UpdateRule(c))
+		assert.Equal(t, http.StatusOK, resp.Code)
+		mockUsecase.AssertExpectations(t)
+	})
+
+	t.Run("Rule not found", func(t *testing.T) {
+		mockUsecase := new(domain_mock.MockRoutingUsecase)
+		controller := NewRoutingRuleController(mockUsecase)
+
+		mockUsecase.On("Update", mock.Anything, mock.Anything).Return(domain.ErrNotFound).Once()
+
+		c, resp := newTestContext(http.MethodPut, "/routing-rules/9", domain.RoutingRule{Name: "rule", Channels: []string{"infra"}}, e)
+		c.SetParamNames("id")
+		c.SetParamValues("9")
+
+		assert.NoError(t, controller.UpdateRule(c))
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+}
+
+func TestRoutingRuleController_DeleteRule(t *testing.T) {
+	e := echo.New()
+	mockUsecase := new(domain_mock.MockRoutingUsecase)
+	controller := NewRoutingRuleController(mockUsecase)
+
+	mockUsecase.On("Delete", mock.Anything, uint(3)).Return(nil).Once()
+
+	c, resp := newTestContext(http.MethodDelete, "/routing-rules/3", nil, e)
+	c.SetParamNames("id")
+	c.SetParamValues("3")
+
+	assert.NoError(t, controller.DeleteRule(c))
+	assert.Equal(t, http.StatusOK, resp.Code)
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestRoutingRuleController_TestRules(t *testing.T) {
+	e := echo.New()
+	mockUsecase := new(domain_mock.MockRoutingUsecase)
+	controller := NewRoutingRuleController(mockUsecase)
+	event := domain.Event{Source: domain.SourceAWS, EventType: "IAM_KEY_CREATED", AffectedResources: []string{"arn:aws:iam:prod-user"}}
+	result := domain.RoutingResult{
+		Rules:    []domain.RoutingRule{{ID: 3, Name: "IAM to security", Channels: []string{"security"}, Stop: true}},
+		Channels: []string{"security"},
+	}
+
+	mockUsecase.On("Test", mock.Anything, mock.MatchedBy(func(e domain.Event) bool {
+		return e.EventType == event.EventType && e.AffectedResources[0] == "arn:aws:iam:prod-user"
+	})).Return(result, nil).Once()
+
+	c, resp := newTestContext(http.MethodPost, "/routing-rules/test", event, e)
+
+	assert.NoError(t, controller.TestRules(c))
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	var response struct {
+		Data domain.RoutingResult `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+	assert.Equal(t, []string{"security"}, response.Data.Channels)
+	assert.Equal(t, "IAM to security", response.Data.Rules[0].Name)
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestSetupRoutingRuleRoutes(t *testing.T) {
+	e := echo.New()
+	SetupRoutingRuleRoutes(e, NewRoutingRuleController(new(domain_mock.MockRoutingUsecase)))
+
+	routes := map[string]bool{}
+	for _, route := range e.Router().Routes() {
+		routes[route.Method+" "+route.Path] = true
+	}
+	assert.Equal(t, map[string]bool{
+		"GET /routing-rules":        true,
+		"POST /routing-rules":       true,
+		"POST /routing-rules/test":  true,
+		"GET /routing-rules/:id":    true,
+		"PUT /routing-rules/:id":    true,
+		"DELETE /routing-rules/:id": true,
+	}, routes)
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"context"
+
+	"github.com/cvzm/go-web-project/domain"
+
+	"github.com/labstack/echo/v4"
+)
+
+// silenceParam is a silence, identified by the path of the request
+type silenceParam struct {
+	ID uint `param:"id" json:"-"`
+	domain.Silence
+}
+
+type SilenceController struct {
+	silenceUsecase domain.SilenceUsecase
+}
+
+func NewSilenceController(usecase domain.SilenceUsecase) *SilenceController {
+	return &SilenceController{
+		silenceUsecase: usecase,
+	}
+}
+
+func (c *SilenceController) ListSilences(ctx echo.Context) error {
+	return HandleRequest(ctx, func(reqCtx context.Context, param struct{}) (any, error) {
+		return c.silenceUsecase.List(reqCtx)
+	})
+}
+
+func (c *SilenceController) GetSilence(ctx echo.Context) error {
+	return HandleRequest(ctx, func(reqCtx context.Context, param silenceParam) (any, error) {
+		return c.silenceUsecase.Get(reqCtx, param.ID)
+	})
+}
+
+func (c *SilenceController) CreateSilence(ctx echo.Context) error {
+	return HandleRequest(ctx, func(reqCtx context.Context, param domain.Silence) (any, error) {
+		param.ID = 0
+		if err := c.silenceUsecase.Create(reqCtx, &param); err != nil {
+			return nil, err
+		}
+		return param, nil
+	})
+}
+
+func (c *SilenceController) UpdateSilence(ctx echo.Context) error {
+	return HandleRequest(ctx, func(reqCtx context.Context, param silenceParam) (any, error) {
+		silence := param.Silence
+		silence.ID = param.ID
+		if err := c.silenceUsecase.Update(reqCtx, &silence); err != nil {
+			return nil, err
+		}
+		return c.silenceUsecase.Get(reqCtx, silence.ID)
+	})
+}
+
+// DeleteSilence deletes a silence, e.g. to end it early. Events it silenced stay flagged as silenced.
+func (c *SilenceController) DeleteSilence(ctx echo.Context) error {
+	return HandleRequest(ctx, func(reqCtx context.Context, param silenceParam) (any, error) {
+		return nil, c.silenceUsecase.Delete(reqCtx, param.ID)
+	})
+}
+
+func SetupSilenceRoutes(e *echo.Echo, controller *SilenceController) {
+	e.GET("/silences", controller.ListSilences)
+	e.POST("/silences", controller.CreateSilence)
+	e.GET("/silences/:id", controller.GetSilence)
+	e.PUT("/silences/:id", controller.UpdateSilence)
+	e.DELETE("/silences/:id", controller.DeleteSilence)
+}
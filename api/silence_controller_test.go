@@ -0,0 +1,112 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/cvzm/go-web-project/domain"
+	domain_mock "github.com/cvzm/go-web-project/domain/mock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSilenceController_CreateSilence(t *testing.T) {
+	e := echo.New()
+	startsAt := time.Date(2024, 3, 1, 22, 0, 0, 0, time.UTC)
+
+	t.Run("Successfully create maintenance window", func(t *testing.T) {
+		mockUsecase := new(domain_mock.MockSilenceUsecase)
+		controller := NewSilenceController(mockUsecase)
+		body := map[string]any{
+			"resources":  []string{"arn:aws:rds:prod-*"},
+			"starts_at":  startsAt,
+			"recurrence": map[string]any{"days": []string{"SAT"}, "start": "23:00", "duration": 120, "timezone": "Europe/Paris"},
+			"created_by": "alice",
+			"comment":    "Weekly database maintenance",
+		}
+
+		mockUsecase.On("Create", mock.Anything, mock.MatchedBy(func(s *domain.Silence) bool {
+			return s.CreatedBy == "alice" && s.StartsAt.Equal(startsAt) && s.EndsAt == nil &&
+				s.Recurrence.Start == "23:00" && s.Recurrence.Duration == 120 && s.Resources[0] == "arn:aws:rds:prod-*"
+		})).Run(func(args mock.Arguments) {
+			args.Get(1).(*domain.Silence).ID = 5
+		}).Return(nil).Once()
+
+		c, resp := newTestContext(http.MethodPost, "/silences", body, e)
+
+		assert.NoError(t, controller.CreateSilence(c))
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"id":5`)
+		mockUsecase.AssertExpectations(t)
+	})
+
+	t.Run("Invalid silence", func(t *testing.T) {
+		mockUsecase := new(domain_mock.MockSilenceUsecase)
+		controller := NewSilenceController(mockUsecase)
+
+		mockUsecase.On("Create", mock.Anything, mock.Anything).Return(&domain.ValidationError{Message: "created_by is required"}).Once()
+
+		c, resp := newTestContext(http.MethodPost, "/silences", domain.Silence{Sources: []string{"AWS"}}, e)
+
+		assert.NoError(t, controller.CreateSilence(c))
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+		assert.Contains(t, resp.Body.String(), "created_by is required")
+	})
+}
+
+func TestSilenceController_ListSilences(t *testing.T) {
+	e := echo.New()
+	mockUsecase := new(domain_mock.MockSilenceUsecase)
+	controller := NewSilenceController(mockUsecase)
+
+	mockUsecase.On("List", mock.Anything).Return([]domain.Silence{{ID: 5, Sources: []string{"AWS"}, CreatedBy: "alice", Active: true}}, nil).Once()
+
+	c, resp := newTestContext(http.MethodGet, "/silences", nil, e)
+
+	assert.NoError(t, controller.ListSilences(c))
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	var response struct {
+		Data []domain.Silence `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+	require.Len(t, response.Data, 1)
+	assert.True(t, response.Data[0].Active)
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestSilenceController_UpdateSilence(t *testing.T) {
+	e := echo.New()
+	mockUsecase := new(domain_mock.MockSilenceUsecase)
+	controller := NewSilenceController(mockUsecase)
+
+	mockUsecase.On("Update", mock.Anything, mock.Anything).Return(domain.ErrNotFound).Once()
+
+	c, resp := newTestContext(http.MethodPut, "/silences/9", domain.Silence{Sources: []string{"AWS"}, CreatedBy: "alice"}, e)
+	c.SetParamNames("id")
+	c.SetParamValues("9")
+
+	assert.NoError(t, controller.UpdateSilence(c))
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+}
+
+func TestSetupSilenceRoutes(t *testing.T) {
+	e := echo.New()
+	SetupSilenceRoutes(e, NewSilenceController(new(domain_mock.MockSilenceUsecase)))
+
+	routes := map[string]bool{}
+	for _, route := range e.Router().Routes() {
+		routes[route.Method+" "+route.Path] = true
+	}
+	assert.Equal(t, map[string]bool{
+		"GET /silences":        true,
+		"POST /silences":       true,
+		"GET /silences/:id":    true,
+		"PUT /silences/:id":    true,
+		"DELETE /silences/:id": true,
+	}, routes)
+}
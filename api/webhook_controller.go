@@ -0,0 +1,100 @@
+package api
+
+import (
+	"context"
+
+	"github.com/cvzm/go-web-project/domain"
+
+	"github.com/labstack/echo/v4"
+)
+
+// webhookSubscriptionParam is a webhook subscription, identified by the path of the request
+type webhookSubscriptionParam struct {
+	ID uint `param:"id" json:"-"`
+	domain.WebhookSubscription
+}
+
+// webhookDeliveriesParam selects the deliveries of the subscription identified by the path of the request
+type webhookDeliveriesParam struct {
+	ID uint `param:"id"`
+	domain.WebhookDeliveryQuery
+}
+
+// webhookDeliveryParam identifies a delivery of a subscription by the path of the request
+type webhookDeliveryParam struct {
+	ID         uint `param:"id"`
+	DeliveryID uint `param:"delivery_id"`
+}
+
+type WebhookController struct {
+	webhookUsecase domain.WebhookUsecase
+}
+
+func NewWebhookController(usecase domain.WebhookUsecase) *WebhookController {
+	return &WebhookController{
+		webhookUsecase: usecase,
+	}
+}
+
+func (c *WebhookController) ListSubscriptions(ctx echo.Context) error {
+	return HandleRequest(ctx, func(reqCtx context.Context, param struct{}) (any, error) {
+		return c.webhookUsecase.List(reqCtx)
+	})
+}
+
+func (c *WebhookController) GetSubscription(ctx echo.Context) error {
+	return HandleRequest(ctx, func(reqCtx context.Context, param webhookSubscriptionParam) (any, error) {
+		return c.webhookUsecase.Get(reqCtx, param.ID)
+	})
+}
+
+// CreateSubscription creates the subscription, responding with its secret, which is never returned afterwards
+func (c *WebhookController) CreateSubscription(ctx echo.Context) error {
+	return HandleRequest(ctx, func(reqCtx context.Context, param domain.WebhookSubscription) (any, error) {
+		param.ID = 0
+		if err := c.webhookUsecase.Create(reqCtx, &param); err != nil {
+			return nil, err
+		}
+		return param, nil
+	})
+}
+
+func (c *WebhookController) UpdateSubscription(ctx echo.Context) error {
+	return HandleRequest(ctx, func(reqCtx context.Context, param webhookSubscriptionParam) (any, error) {
+		subscription := param.WebhookSubscription
+		subscription.ID = param.ID
+		if err := c.webhookUsecase.Update(reqCtx, &subscription); err != nil {
+			return nil, err
+		}
+		return c.webhookUsecase.Get(reqCtx, subscription.ID)
+	})
+}
+
+func (c *WebhookController) DeleteSubscription(ctx echo.Context) error {
+	return HandleRequest(ctx, func(reqCtx context.Context, param webhookSubscriptionParam) (any, error) {
+		return nil, c.webhookUsecase.Delete(reqCtx, param.ID)
+	})
+}
+
+// ListDeliveries returns the delivery log of the subscription, newest first, optionally by status
+func (c *WebhookController) ListDeliveries(ctx echo.Context) error {
+	return HandleRequest(ctx, func(reqCtx context.Context, param webhookDeliveriesParam) (any, error) {
+		return c.webhookUsecase.Deliveries(reqCtx, param.ID, param.WebhookDeliveryQuery)
+	})
+}
+
+func (c *WebhookController) Redeliver(ctx echo.Context) error {
+	return HandleRequest(ctx, func(reqCtx context.Context, param webhookDeliveryParam) (any, error) {
+		return c.webhookUsecase.Redeliver(reqCtx, param.ID, param.DeliveryID)
+	})
+}
+
+func SetupWebhookRoutes(e *echo.Echo, controller *WebhookController) {
+	e.GET("/webhooks", controller.ListSubscriptions)
+	e.POST("/webhooks", controller.CreateSubscription)
+	e.GET("/webhooks/:id", controller.GetSubscription)
+	e.PUT("/webhooks/:id", controller.UpdateSubscription)
+	e.DELETE("/webhooks/:id", controller.DeleteSubscription)
+	e.GET("/webhooks/:id/deliveries", controller.ListDeliveries)
+	e.POST("/webhooks/:id/deliveries/:delivery_id/redeliver", controller.Redeliver)
+}
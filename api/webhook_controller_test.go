@@ -0,0 +1,107 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/cvzm/go-web-project/domain"
+	domain_mock "github.com/cvzm/go-web-project/domain/mock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestWebhookController_CreateSubscription(t *testing.T) {
+	e := echo.New()
+	mockUsecase := new(domain_mock.MockWebhookUsecase)
+	controller := NewWebhookController(mockUsecase)
+
+	mockUsecase.On("Create", mock.Anything, mock.MatchedBy(func(s *domain.WebhookSubscription) bool {
+		return s.Name == "billing" && s.URL == "https://billing.internal/hooks" && s.EventTypes[0] == "EC2_*"
+	})).Run(func(args mock.Arguments) {
+		subscription := args.Get(1).(*domain.WebhookSubscription)
+		subscription.ID, subscription.Secret = 3, "whsec_1"
+	}).Return(nil).Once()
+
+	c, resp := newTestContext(http.MethodPost, "/webhooks", map[string]any{
+		"name": "billing", "url": "https://billing.internal/hooks", "event_types": []string{"EC2_*"},
+	}, e)
+
+	assert.NoError(t, controller.CreateSubscription(c))
+	assert.Equal(t, http.StatusOK, resp.Code)
+	// The secret is returned on creation only
+	assert.Contains(t, resp.Body.String(), `"secret":"whsec_1"`)
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestWebhookController_ListDeliveries(t *testing.T) {
+	e := echo.New()
+
+	t.Run("Filters by status", func(t *testing.T) {
+		mockUsecase := new(domain_mock.MockWebhookUsecase)
+		controller := NewWebhookController(mockUsecase)
+
+		mockUsecase.On("Deliveries", mock.Anything, uint(3), domain.WebhookDeliveryQuery{Status: domain.WebhookDeliveryFailed, Limit: 10}).
+			Return([]domain.WebhookDelivery{{ID: 7, SubscriptionID: 3, Status: domain.WebhookDeliveryFailed}}, nil).Once()
+
+		c, resp := newTestContext(http.MethodGet, "/webhooks/3/deliveries?status=failed&limit=10", nil, e)
+		c.SetParamNames("id")
+		c.SetParamValues("3")
+
+		assert.NoError(t, controller.ListDeliveries(c))
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"status":"failed"`)
+		mockUsecase.AssertExpectations(t)
+	})
+
+	t.Run("Subscription not found", func(t *testing.T) {
+		mockUsecase := new(domain_mock.MockWebhookUsecase)
+		controller := NewWebhookController(mockUsecase)
+
+		mockUsecase.On("Deliveries", mock.Anything, uint(9), domain.WebhookDeliveryQuery{}).Return([]domain.WebhookDelivery(nil), domain.ErrNotFound).Once()
+
+		c, resp := newTestContext(http.MethodGet, "/webhooks/9/deliveries", nil, e)
+		c.SetParamNames("id")
+		c.SetParamValues("9")
+
+		assert.NoError(t, controller.ListDeliveries(c))
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+}
+
+func TestWebhookController_Redeliver(t *testing.T) {
+	e := echo.New()
+	mockUsecase := new(domain_mock.MockWebhookUsecase)
+	controller := NewWebhookController(mockUsecase)
+
+	mockUsecase.On("Redeliver", mock.Anything, uint(3), uint(7)).
+		Return(domain.WebhookDelivery{ID: 8, SubscriptionID: 3, Status: domain.WebhookDeliveryPending}, nil).Once()
+
+	c, resp := newTestContext(http.MethodPost, "/webhooks/3/deliveries/7/redeliver", nil, e)
+	c.SetParamNames("id", "delivery_id")
+	c.SetParamValues("3", "7")
+
+	assert.NoError(t, controller.Redeliver(c))
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), `"id":8`)
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestSetupWebhookRoutes(t *testing.T) {
+	e := echo.New()
+	SetupWebhookRoutes(e, NewWebhookController(new(domain_mock.MockWebhookUsecase)))
+
+	routes := map[string]bool{}
+	for _, route := range e.Router().Routes() {
+		routes[route.Method+" "+route.Path] = true
+	}
+	assert.Equal(t, map[string]bool{
+		"GET /webhooks":                true,
+		"POST /webhooks":               true,
+		"GET /webhooks/:id":            true,
+		"PUT /webhooks/:id":            true,
+		"DELETE /webhooks/:id":         true,
+		"GET /webhooks/:id/deliveries": true,
+		"POST /webhooks/:id/deliveries/:delivery_id/redeliver": true,
+	}, routes)
+}
@@ -13,43 +13,119 @@ import (
 	"github.com/cvzm/go-web-project/domain"
 
 	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
 	"gorm.io/gorm"
 )
 
 // App struct represents the main application
 type App struct {
-	config      *Config
-	db          *gorm.DB
-	echo        *echo.Echo
-	sqsConsumer *SQSConsumer
-
-	eventController *api.EventController
+	config   *Config
+	db       *gorm.DB
+	echo     *echo.Echo
+	consumer *Consumer
+	source   domain.MessageSource
+	migrator *storage.Migrator
+
+	partitionMaintainer *PartitionMaintainer
+	eventArchiver       *EventArchiver
+	outboxRelay         *OutboxRelay
+	webhookDispatcher   *WebhookDispatcher
+	digestMailer        *DigestMailer
+	metricsServer       *MetricsServer
+	notifier            *Notifier
+
+	eventUsecase          domain.EventUsecase
+	archiveUsecase        domain.ArchiveUsecase
+	eventController       *api.EventController
+	commentController     *api.CommentController
+	routingRuleController *api.RoutingRuleController
+	silenceController     *api.SilenceController
+	webhookController     *api.WebhookController
 }
 
 // NewApp creates and returns a new App instance
-func NewApp(cfg *Config, db *gorm.DB, e *echo.Echo, sqsConsumer *SQSConsumer, eventController *api.EventController) *App {
+func NewApp(cfg *Config, db *gorm.DB, e *echo.Echo, consumer *Consumer, migrator *storage.Migrator, partitionMaintainer *PartitionMaintainer, eventArchiver *EventArchiver, outboxRelay *OutboxRelay, webhookDispatcher *WebhookDispatcher, digestMailer *DigestMailer, metricsServer *MetricsServer, notifier *Notifier, eventUsecase domain.EventUsecase, archiveUsecase domain.ArchiveUsecase, eventController *api.EventController, commentController *api.CommentController, routingRuleController *api.RoutingRuleController, silenceController *api.SilenceController, webhookController *api.WebhookController) *App {
 	return &App{
-		config:          cfg,
-		db:              db,
-		echo:            e,
-		sqsConsumer:     sqsConsumer,
-		eventController: eventController,
+		config:   cfg,
+		db:       db,
+		echo:     e,
+		consumer: consumer,
+		migrator: migrator,
+
+		partitionMaintainer: partitionMaintainer,
+		eventArchiver:       eventArchiver,
+		outboxRelay:         outboxRelay,
+		webhookDispatcher:   webhookDispatcher,
+		digestMailer:        digestMailer,
+		metricsServer:       metricsServer,
+		notifier:            notifier,
+
+		eventUsecase:          eventUsecase,
+		archiveUsecase:        archiveUsecase,
+		eventController:       eventController,
+		commentController:     commentController,
+		routingRuleController: routingRuleController,
+		silenceController:     silenceController,
+		webhookController:     webhookController,
 	}
 }
 
-// SetupAndRun sets up and runs the application
+// newMigrationApp creates an App instance only migrating the database schema, for the migrate command
+func newMigrationApp(cfg *Config, db *gorm.DB, migrator *storage.Migrator) *App {
+	return &App{config: cfg, db: db, migrator: migrator}
+}
+
+// newArchiveApp creates an App instance only archiving and restoring events, for the archive and restore commands
+func newArchiveApp(cfg *Config, db *gorm.DB, eventArchiver *EventArchiver, archiveUsecase domain.ArchiveUsecase) *App {
+	return &App{config: cfg, db: db, eventArchiver: eventArchiver, archiveUsecase: archiveUsecase}
+}
+
+// newImportApp creates an App instance only saving events, for the import command. The relay is not started,
+// it only closes the outbox publisher.
+func newImportApp(cfg *Config, db *gorm.DB, outboxRelay *OutboxRelay, eventUsecase domain.EventUsecase) *App {
+	return &App{config: cfg, db: db, outboxRelay: outboxRelay, eventUsecase: eventUsecase}
+}
+
+// newReplayApp creates an App instance only replaying dead-lettered messages, for the replay command
+func newReplayApp(cfg *Config, db *gorm.DB, source domain.MessageSource) *App {
+	return &App{config: cfg, db: db, source: source}
+}
+
+// SetupAndRun sets up and runs the parts of the application selected by the run mode
 func (a *App) SetupAndRun() error {
-	a.setupRoutes()
+	// The schema is migrated ahead of deploy with the migrate command, never at startup
+	if err := a.migrator.Check(context.Background()); err != nil {
+		return err
+	}
 
-	go a.startServer()
-	go a.sqsConsumer.Start()
+	go a.metricsServer.Start()
+	a.notifier.Start()
+	if a.config.RunsAPI() {
+		a.setupRoutes()
+		go a.startServer()
+	}
+	if a.config.RunsWorker() {
+		go a.consumer.Start()
+		go a.partitionMaintainer.Start()
+		go a.eventArchiver.Start()
+		go a.outboxRelay.Start()
+		go a.webhookDispatcher.Start()
+		go a.digestMailer.Start()
+	}
 
 	return a.gracefulShutdown()
 }
 
-// setupRoutes sets up all routes
+// setupRoutes sets up all routes, with the request timeout if configured
 func (a *App) setupRoutes() {
+	if timeout := time.Duration(a.config.APIRequestTimeout) * time.Second; timeout > 0 {
+		a.echo.Use(middleware.ContextTimeout(timeout))
+	}
 	api.SetupEventRoutes(a.echo, a.eventController)
+	api.SetupCommentRoutes(a.echo, a.commentController)
+	api.SetupRoutingRuleRoutes(a.echo, a.routingRuleController)
+	api.SetupSilenceRoutes(a.echo, a.silenceController)
+	api.SetupWebhookRoutes(a.echo, a.webhookController)
 }
 
 // startServer starts the server in the background
@@ -69,10 +145,82 @@ func (a *App) gracefulShutdown() error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	if err := a.echo.Shutdown(ctx); err != nil {
+	if a.config.RunsAPI() {
+		if err := a.echo.Shutdown(ctx); err != nil {
+			return err
+		}
+	}
+
+	if a.config.RunsWorker() {
+		if err := a.consumer.Stop(); err != nil {
+			return err
+		}
+	}
+	// Events are no longer saved, so the last notifications can be sent
+	a.notifier.Stop(ctx)
+	if a.config.RunsWorker() {
+		a.partitionMaintainer.Stop()
+		a.eventArchiver.Stop()
+		a.outboxRelay.Stop()
+		a.webhookDispatcher.Stop()
+		a.digestMailer.Stop()
+	}
+	if err := a.outboxRelay.Close(); err != nil {
 		return err
 	}
+	if err := a.metricsServer.Shutdown(ctx); err != nil {
+		return err
+	}
+
+	return a.closeDB()
+}
+
+// Migrator returns the migrator of the database schema
+func (a *App) Migrator() *storage.Migrator {
+	return a.migrator
+}
+
+// ReplayMessages requeues up to limit dead-lettered messages, all of them if limit is zero
+func (a *App) ReplayMessages(ctx context.Context, limit int) (int, error) {
+	if a.source == nil {
+		return 0, fmt.Errorf("replaying messages requires the worker run mode")
+	}
+	replayer, ok := a.source.(domain.MessageReplayer)
+	if !ok {
+		return 0, fmt.Errorf("queue driver %q does not support replaying messages", a.config.QueueDriver)
+	}
+	return replayer.Replay(ctx, limit)
+}
+
+// ArchiveEvents moves the events older than the given number of days, the configured archive age
+// if zero, to the archive store
+func (a *App) ArchiveEvents(ctx context.Context, days int) ([]domain.ArchiveManifest, error) {
+	if days == 0 {
+		days = a.config.ArchiveAfterDays
+	}
+	if days <= 0 {
+		return nil, fmt.Errorf("archiving events requires a positive age, set ARCHIVE_AFTER_DAYS or --older-than")
+	}
+	return a.eventArchiver.Archive(ctx, days)
+}
+
+// RestoreArchive loads back the events of the archive described by the manifest at the given key
+func (a *App) RestoreArchive(ctx context.Context, manifestKey string) (int, error) {
+	return a.archiveUsecase.Restore(ctx, manifestKey)
+}
 
+// Close releases the resources of an application that was not run, e.g. after a one-off command
+func (a *App) Close() error {
+	if a.source != nil {
+		if err := a.source.Close(); err != nil {
+			return err
+		}
+	}
+	if a.outboxRelay != nil {
+		if err := a.outboxRelay.Close(); err != nil {
+			return err
+		}
+	}
 	return a.closeDB()
 }
 
@@ -91,21 +239,16 @@ func initDatabase(cfg *Config) (*gorm.DB, error) {
 		DSN:        cfg.DBDSN,
 		ReplicaDSN: cfg.DBReplicaDSN,
 	}
-	db, err := storage.NewDB(connector, storage.DBConfig{})
-	if err != nil {
-		return nil, err
-	}
-
-	if err := db.AutoMigrate(getModelsToMigrate()...); err != nil {
-		return nil, err
-	}
-
-	return db, nil
+	return storage.NewDB(connector, storage.DBConfig{
+		QueryTimeout: time.Duration(cfg.DBQueryTimeout) * time.Second,
+	})
 }
 
-func getModelsToMigrate() []any {
-	return []any{
-		&domain.Event{},
-		// Add other models here
+// initMigrator initializes the migrator of the database schema with the embedded migrations
+func initMigrator(db *gorm.DB) (*storage.Migrator, error) {
+	migrations, err := storage.Migrations()
+	if err != nil {
+		return nil, err
 	}
+	return storage.NewMigrator(db, migrations), nil
 }
@@ -0,0 +1,92 @@
+package bootstrap
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/cvzm/go-web-project/adapter/archive"
+	"github.com/cvzm/go-web-project/adapter/storage"
+	"github.com/cvzm/go-web-project/domain"
+
+	"gorm.io/gorm"
+)
+
+// Supported archive stores
+const (
+	ArchiveStoreLocal = "local"
+	ArchiveStoreS3    = "s3"
+
+	eventArchiveLockID int64 = 4171062141 // advisory lock held while archiving events
+)
+
+// initArchiveStore initializes the archive store selected by the configuration, none if not configured
+func initArchiveStore(cfg *Config) (domain.ArchiveStore, error) {
+	switch cfg.ArchiveStore {
+	case ArchiveStoreLocal:
+		return archive.NewLocalStore(cfg.ArchiveDir), nil
+	case ArchiveStoreS3:
+		return archive.NewS3Store(context.Background(), archive.S3Config{
+			Bucket:          cfg.ArchiveS3Bucket,
+			Prefix:          cfg.ArchiveS3Prefix,
+			Region:          cfg.ArchiveS3Region,
+			Endpoint:        cfg.ArchiveS3Endpoint,
+			AccessKeyID:     cfg.ArchiveS3AccessKeyID,
+			SecretAccessKey: cfg.ArchiveS3SecretAccessKey,
+			SessionToken:    cfg.ArchiveS3SessionToken,
+			PathStyle:       cfg.ArchiveS3PathStyle,
+		})
+	}
+	return nil, nil
+}
+
+// EventArchiver periodically moves the events older than the archive age to the archive store
+type EventArchiver struct {
+	*job
+	db             *gorm.DB
+	config         *Config
+	archiveUsecase domain.ArchiveUsecase
+}
+
+// NewEventArchiver creates a new EventArchiver instance, running at every check interval once started
+// if archival is enabled
+func NewEventArchiver(db *gorm.DB, config *Config, archiveUsecase domain.ArchiveUsecase) *EventArchiver {
+	a := &EventArchiver{
+		db:             db,
+		config:         config,
+		archiveUsecase: archiveUsecase,
+	}
+	var interval time.Duration
+	if config.ArchiveAfterDays > 0 {
+		interval = time.Duration(config.ArchiveCheckInterval) * time.Second
+	}
+	a.job = newJob(interval, a.run)
+	return a
+}
+
+// Archive moves the events older than the given number of days to the archive store, on a single
+// instance at a time, and returns the written manifests
+func (a *EventArchiver) Archive(ctx context.Context, days int) ([]domain.ArchiveManifest, error) {
+	var manifests []domain.ArchiveManifest
+	before := time.Now().AddDate(0, 0, -days)
+	locked, err := storage.WithTryAdvisoryLock(ctx, a.db, eventArchiveLockID, func() error {
+		var err error
+		manifests, err = a.archiveUsecase.Archive(ctx, before)
+		return err
+	})
+	if err == nil && !locked {
+		log.Printf("Skipping event archival, already running on another instance")
+	}
+	return manifests, err
+}
+
+// run archives the events older than the configured archive age and logs the outcome
+func (a *EventArchiver) run(ctx context.Context) {
+	manifests, err := a.Archive(ctx, a.config.ArchiveAfterDays)
+	for _, manifest := range manifests {
+		log.Printf("Archived %d %s event(s) of %s to %s", manifest.Count, manifest.Source, manifest.Date, manifest.Key)
+	}
+	if err != nil {
+		log.Printf("Error archiving events: %v", err)
+	}
+}
@@ -1,28 +1,221 @@
 package bootstrap
 
 import (
+	"fmt"
+	"reflect"
+	"slices"
+
 	"github.com/go-playground/validator/v10"
 	"github.com/spf13/viper"
 )
 
 // Config struct defines the configuration items for the application
 type Config struct {
+	// Run mode: api serves HTTP requests, worker consumes the queue, all does both, by default
+	RunMode string `mapstructure:"RUN_MODE" validate:"oneof=api worker all"`
+
 	// Server configuration
-	ServerPort int `mapstructure:"SERVER_PORT" validate:"required"`
+	ServerPort        int   `mapstructure:"SERVER_PORT" mode:"api" validate:"required"`
+	APIRequestTimeout int32 `mapstructure:"API_REQUEST_TIMEOUT" mode:"api" validate:"min=0"`
 
 	// Database configuration
-	DBDSN        string `mapstructure:"DB_DSN" validate:"required"`
-	DBReplicaDSN string `mapstructure:"DB_REPLICA_DSN"`
+	DBDSN          string `mapstructure:"DB_DSN" validate:"required"`
+	DBReplicaDSN   string `mapstructure:"DB_REPLICA_DSN"`
+	DBQueryTimeout int32  `mapstructure:"DB_QUERY_TIMEOUT" validate:"min=0"`
+
+	// Events partitioning and retention configuration
+	EventPartitionsAhead        int    `mapstructure:"EVENT_PARTITIONS_AHEAD" validate:"min=0"`
+	EventPartitionCheckInterval int32  `mapstructure:"EVENT_PARTITION_CHECK_INTERVAL" validate:"min=0"`
+	EventRetentionDays          int    `mapstructure:"EVENT_RETENTION_DAYS" validate:"min=0"`
+	EventRetentionMode          string `mapstructure:"EVENT_RETENTION_MODE" validate:"required_unless=EventRetentionDays 0,omitempty,oneof=drop detach"`
+
+	// Events archival configuration
+	ArchiveAfterDays         int    `mapstructure:"ARCHIVE_AFTER_DAYS" validate:"min=0"`
+	ArchiveCheckInterval     int32  `mapstructure:"ARCHIVE_CHECK_INTERVAL" validate:"min=0"`
+	ArchiveStore             string `mapstructure:"ARCHIVE_STORE" validate:"required_unless=ArchiveAfterDays 0,omitempty,oneof=local s3"`
+	ArchiveDir               string `mapstructure:"ARCHIVE_DIR" validate:"required_if=ArchiveStore local"`
+	ArchiveS3Bucket          string `mapstructure:"ARCHIVE_S3_BUCKET" validate:"required_if=ArchiveStore s3"`
+	ArchiveS3Prefix          string `mapstructure:"ARCHIVE_S3_PREFIX"`
+	ArchiveS3Region          string `mapstructure:"ARCHIVE_S3_REGION" validate:"required_if=ArchiveStore s3"`
+	ArchiveS3Endpoint        string `mapstructure:"ARCHIVE_S3_ENDPOINT" validate:"omitempty,url"`
+	ArchiveS3AccessKeyID     string `mapstructure:"ARCHIVE_S3_ACCESS_KEY_ID"`
+	ArchiveS3SecretAccessKey string `mapstructure:"ARCHIVE_S3_SECRET_ACCESS_KEY" validate:"required_with=ArchiveS3AccessKeyID"`
+	ArchiveS3SessionToken    string `mapstructure:"ARCHIVE_S3_SESSION_TOKEN"`
+	ArchiveS3PathStyle       bool   `mapstructure:"ARCHIVE_S3_PATH_STYLE"`
+
+	// Event severity configuration
+	SeverityRulesFile string `mapstructure:"SEVERITY_RULES_FILE" validate:"omitempty,file"`
+	SeverityDefault   string `mapstructure:"SEVERITY_DEFAULT" validate:"omitempty,oneof=info warning error critical"`
+
+	// Outbox configuration
+	OutboxPublisher          string   `mapstructure:"OUTBOX_PUBLISHER" validate:"omitempty,oneof=kafka sns"`
+	OutboxPollInterval       int32    `mapstructure:"OUTBOX_POLL_INTERVAL" mode:"worker" validate:"min=0"`
+	OutboxBatchSize          int      `mapstructure:"OUTBOX_BATCH_SIZE" mode:"worker" validate:"required_with=OutboxPublisher,omitempty,min=1"`
+	OutboxRetryDelay         int32    `mapstructure:"OUTBOX_RETRY_DELAY" mode:"worker" validate:"min=0"`
+	OutboxMaxBackoff         int32    `mapstructure:"OUTBOX_MAX_BACKOFF" mode:"worker" validate:"min=0"`
+	OutboxRetentionDays      int      `mapstructure:"OUTBOX_RETENTION_DAYS" mode:"worker" validate:"min=0"`
+	OutboxKafkaBrokers       []string `mapstructure:"OUTBOX_KAFKA_BROKERS" validate:"required_if=OutboxPublisher kafka"`
+	OutboxKafkaTopic         string   `mapstructure:"OUTBOX_KAFKA_TOPIC" validate:"required_if=OutboxPublisher kafka"`
+	OutboxSNSTopicARN        string   `mapstructure:"OUTBOX_SNS_TOPIC_ARN" validate:"required_if=OutboxPublisher sns"`
+	OutboxSNSRegion          string   `mapstructure:"OUTBOX_SNS_REGION" validate:"required_if=OutboxPublisher sns"`
+	OutboxSNSEndpoint        string   `mapstructure:"OUTBOX_SNS_ENDPOINT" validate:"omitempty,url"`
+	OutboxSNSAccessKeyID     string   `mapstructure:"OUTBOX_SNS_ACCESS_KEY_ID"`
+	OutboxSNSSecretAccessKey string   `mapstructure:"OUTBOX_SNS_SECRET_ACCESS_KEY" validate:"required_with=OutboxSNSAccessKeyID"`
+	OutboxSNSSessionToken    string   `mapstructure:"OUTBOX_SNS_SESSION_TOKEN"`
+
+	// Webhook configuration
+	WebhookPollInterval          int32 `mapstructure:"WEBHOOK_POLL_INTERVAL" mode:"worker" validate:"min=0"`
+	WebhookBatchSize             int   `mapstructure:"WEBHOOK_BATCH_SIZE" mode:"worker" validate:"required_unless=WebhookPollInterval 0,omitempty,min=1"`
+	WebhookTimeout               int32 `mapstructure:"WEBHOOK_TIMEOUT" mode:"worker" validate:"min=0"`
+	WebhookMaxAttempts           int   `mapstructure:"WEBHOOK_MAX_ATTEMPTS" mode:"worker" validate:"required_unless=WebhookPollInterval 0,omitempty,min=1"`
+	WebhookRetryDelay            int32 `mapstructure:"WEBHOOK_RETRY_DELAY" mode:"worker" validate:"min=0"`
+	WebhookMaxBackoff            int32 `mapstructure:"WEBHOOK_MAX_BACKOFF" mode:"worker" validate:"min=0"`
+	WebhookDisableAfterFailures  int   `mapstructure:"WEBHOOK_DISABLE_AFTER_FAILURES" mode:"worker" validate:"min=0"`
+	WebhookDeliveryRetentionDays int   `mapstructure:"WEBHOOK_DELIVERY_RETENTION_DAYS" mode:"worker" validate:"min=0"`
+
+	// Metrics configuration
+	MetricsPort int `mapstructure:"METRICS_PORT" validate:"min=0"`
+
+	// Consumer configuration
+	QueueDriver                 string `mapstructure:"QUEUE_DRIVER" mode:"worker" validate:"oneof=sqs kafka nats redis amqp postgres"` // sqs by default
+	ConsumerRetryDelay          int32  `mapstructure:"CONSUMER_RETRY_DELAY" mode:"worker" validate:"min=0"`
+	ConsumerLease               int32  `mapstructure:"CONSUMER_LEASE" mode:"worker" validate:"min=0"`
+	ConsumerDeduplication       bool   `mapstructure:"CONSUMER_DEDUPLICATION" mode:"worker"`
+	ConsumerDeduplicationWindow int32  `mapstructure:"CONSUMER_DEDUPLICATION_WINDOW" mode:"worker" validate:"min=0"`
+	ConsumerMessageTimeout      int32  `mapstructure:"CONSUMER_MESSAGE_TIMEOUT" mode:"worker" validate:"min=0"`
 
 	// SQS configuration
-	SQSQueueURL            string `mapstructure:"SQS_QUEUE_URL" validate:"required,url"`
-	SQSRegion              string `mapstructure:"SQS_REGION" validate:"required"`
-	SQSMaxNumberOfMessages int32  `mapstructure:"SQS_MAX_NUMBER_OF_MESSAGES" validate:"required,min=1,max=10"`
-	SQSWaitTimeSeconds     int32  `mapstructure:"SQS_WAIT_TIME_SECONDS" validate:"required,min=0,max=20"`
-	SQSVisibilityTimeout   int32  `mapstructure:"SQS_VISIBILITY_TIMEOUT" validate:"required,min=0"`
+	SQSQueueURL            string `mapstructure:"SQS_QUEUE_URL" mode:"worker" validate:"required_if=QueueDriver sqs,omitempty,url"`
+	SQSRegion              string `mapstructure:"SQS_REGION" mode:"worker" validate:"required_if=QueueDriver sqs"`
+	SQSMaxNumberOfMessages int32  `mapstructure:"SQS_MAX_NUMBER_OF_MESSAGES" mode:"worker" validate:"required_if=QueueDriver sqs,omitempty,min=1,max=10"`
+	SQSWaitTimeSeconds     int32  `mapstructure:"SQS_WAIT_TIME_SECONDS" mode:"worker" validate:"required_if=QueueDriver sqs,omitempty,min=0,max=20"`
+	SQSVisibilityTimeout   int32  `mapstructure:"SQS_VISIBILITY_TIMEOUT" mode:"worker" validate:"required_if=QueueDriver sqs,omitempty,min=0"`
+	SQSFIFO                bool   `mapstructure:"SQS_FIFO" mode:"worker"`
+	SQSDeadLetterQueueURL  string `mapstructure:"SQS_DEAD_LETTER_QUEUE_URL" mode:"worker" validate:"required_if=SQSFIFO true,omitempty,url"`
+	SQSEndpoint            string `mapstructure:"SQS_ENDPOINT" mode:"worker" validate:"required_if=SQSPathStyleQueueURL true,omitempty,url"`
+	SQSAccessKeyID         string `mapstructure:"SQS_ACCESS_KEY_ID" mode:"worker"`
+	SQSSecretAccessKey     string `mapstructure:"SQS_SECRET_ACCESS_KEY" mode:"worker" validate:"required_with=SQSAccessKeyID"`
+	SQSSessionToken        string `mapstructure:"SQS_SESSION_TOKEN" mode:"worker"`
+	SQSPathStyleQueueURL   bool   `mapstructure:"SQS_PATH_STYLE_QUEUE_URL" mode:"worker"`
+
+	// Kafka configuration
+	KafkaBrokers        []string `mapstructure:"KAFKA_BROKERS" mode:"worker" validate:"required_if=QueueDriver kafka"`
+	KafkaTopics         []string `mapstructure:"KAFKA_TOPICS" mode:"worker" validate:"required_if=QueueDriver kafka"`
+	KafkaGroupID        string   `mapstructure:"KAFKA_GROUP_ID" mode:"worker" validate:"required_if=QueueDriver kafka"`
+	KafkaMaxPollRecords int      `mapstructure:"KAFKA_MAX_POLL_RECORDS" mode:"worker" validate:"min=0"`
+
+	// NATS JetStream configuration
+	NATSURL           string `mapstructure:"NATS_URL" mode:"worker" validate:"required_if=QueueDriver nats"`
+	NATSStream        string `mapstructure:"NATS_STREAM" mode:"worker" validate:"required_if=QueueDriver nats"`
+	NATSSubject       string `mapstructure:"NATS_SUBJECT" mode:"worker"`
+	NATSDurable       string `mapstructure:"NATS_DURABLE" mode:"worker" validate:"required_if=QueueDriver nats"`
+	NATSMaxMessages   int    `mapstructure:"NATS_MAX_MESSAGES" mode:"worker" validate:"required_if=QueueDriver nats,omitempty,min=1"`
+	NATSMaxWait       int32  `mapstructure:"NATS_MAX_WAIT" mode:"worker" validate:"min=0"`
+	NATSAckWait       int32  `mapstructure:"NATS_ACK_WAIT" mode:"worker" validate:"min=0"`
+	NATSMaxDeliveries int    `mapstructure:"NATS_MAX_DELIVERIES" mode:"worker" validate:"min=0"`
+
+	// Redis Streams configuration
+	RedisAddr             string `mapstructure:"REDIS_ADDR" mode:"worker" validate:"required_if=QueueDriver redis"`
+	RedisPassword         string `mapstructure:"REDIS_PASSWORD" mode:"worker"`
+	RedisDB               int    `mapstructure:"REDIS_DB" mode:"worker" validate:"min=0"`
+	RedisStream           string `mapstructure:"REDIS_STREAM" mode:"worker" validate:"required_if=QueueDriver redis"`
+	RedisGroup            string `mapstructure:"REDIS_GROUP" mode:"worker" validate:"required_if=QueueDriver redis"`
+	RedisConsumer         string `mapstructure:"REDIS_CONSUMER" mode:"worker"`
+	RedisDeadLetterStream string `mapstructure:"REDIS_DEAD_LETTER_STREAM" mode:"worker" validate:"required_if=QueueDriver redis"`
+	RedisCount            int64  `mapstructure:"REDIS_COUNT" mode:"worker" validate:"required_if=QueueDriver redis,omitempty,min=1"`
+	RedisBlock            int32  `mapstructure:"REDIS_BLOCK" mode:"worker" validate:"min=0"`
+	RedisClaimMinIdle     int32  `mapstructure:"REDIS_CLAIM_MIN_IDLE" mode:"worker" validate:"required_if=QueueDriver redis,omitempty,min=1"`
+	RedisMaxDeliveries    int64  `mapstructure:"REDIS_MAX_DELIVERIES" mode:"worker" validate:"min=0"`
+
+	// AMQP (RabbitMQ) configuration
+	AMQPURL                 string   `mapstructure:"AMQP_URL" mode:"worker" validate:"required_if=QueueDriver amqp"`
+	AMQPQueue               string   `mapstructure:"AMQP_QUEUE" mode:"worker" validate:"required_if=QueueDriver amqp"`
+	AMQPExchange            string   `mapstructure:"AMQP_EXCHANGE" mode:"worker"`
+	AMQPExchangeType        string   `mapstructure:"AMQP_EXCHANGE_TYPE" mode:"worker" validate:"required_with=AMQPExchange,omitempty,oneof=direct fanout topic headers"`
+	AMQPBindingKeys         []string `mapstructure:"AMQP_BINDING_KEYS" mode:"worker"`
+	AMQPDeadLetterExchange  string   `mapstructure:"AMQP_DEAD_LETTER_EXCHANGE" mode:"worker"`
+	AMQPPrefetch            int      `mapstructure:"AMQP_PREFETCH" mode:"worker" validate:"required_if=QueueDriver amqp,omitempty,min=1"`
+	AMQPReconnectMinBackoff int32    `mapstructure:"AMQP_RECONNECT_MIN_BACKOFF" mode:"worker" validate:"min=0"`
+	AMQPReconnectMaxBackoff int32    `mapstructure:"AMQP_RECONNECT_MAX_BACKOFF" mode:"worker" validate:"min=0"`
+
+	// Postgres work queue configuration
+	PGQueueName              string `mapstructure:"PG_QUEUE_NAME" validate:"required_if=QueueDriver postgres"` // also written to by the API
+	PGQueueBatchSize         int    `mapstructure:"PG_QUEUE_BATCH_SIZE" mode:"worker" validate:"required_if=QueueDriver postgres,omitempty,min=1"`
+	PGQueueVisibilityTimeout int32  `mapstructure:"PG_QUEUE_VISIBILITY_TIMEOUT" mode:"worker" validate:"required_if=QueueDriver postgres,omitempty,min=1"`
+	PGQueuePollInterval      int32  `mapstructure:"PG_QUEUE_POLL_INTERVAL" mode:"worker" validate:"min=0"`
+	PGQueueMaxAttempts       int    `mapstructure:"PG_QUEUE_MAX_ATTEMPTS" mode:"worker" validate:"min=0"`
+	PGQueueMaxBackoff        int32  `mapstructure:"PG_QUEUE_MAX_BACKOFF" mode:"worker" validate:"min=0"`
+
+	// Notification configuration
+	NotificationWorkers   int `mapstructure:"NOTIFICATION_WORKERS" validate:"required_with=SlackWebhook SlackChannelsFile PagerDutyRoutingKey OpsgenieAPIKey SMTPHost,omitempty,min=1"`
+	NotificationQueueSize int `mapstructure:"NOTIFICATION_QUEUE_SIZE" validate:"min=0"`
 
-	// External service configuration
-	SlackWebhook string `mapstructure:"SLACK_WEBHOOK"`
+	// Notification throttling configuration
+	NotificationGroupBy           []string `mapstructure:"NOTIFICATION_GROUP_BY" validate:"dive,oneof=source event_type resource"`
+	NotificationGroupWindow       int32    `mapstructure:"NOTIFICATION_GROUP_WINDOW" validate:"min=0"`
+	NotificationRateLimit         int      `mapstructure:"NOTIFICATION_RATE_LIMIT" validate:"min=0"`
+	NotificationRateInterval      int32    `mapstructure:"NOTIFICATION_RATE_INTERVAL" validate:"required_with=NotificationRateLimit NotificationChannelRateLimits,omitempty,min=1"`
+	NotificationChannelRateLimits []string `mapstructure:"NOTIFICATION_CHANNEL_RATE_LIMITS"`
+
+	// Notification routing rules configuration
+	RoutingRulesRefreshInterval int32 `mapstructure:"ROUTING_RULES_REFRESH_INTERVAL" validate:"min=0"`
+
+	// Silences configuration
+	SilencesRefreshInterval int32 `mapstructure:"SILENCES_REFRESH_INTERVAL" validate:"min=0"`
+
+	// Slack configuration
+	SlackWebhook      string   `mapstructure:"SLACK_WEBHOOK" validate:"omitempty,url"`
+	SlackSources      []string `mapstructure:"SLACK_SOURCES"`
+	SlackEventTypes   []string `mapstructure:"SLACK_EVENT_TYPES"`
+	SlackSeverities   []string `mapstructure:"SLACK_SEVERITIES" validate:"dive,oneof=info warning error critical"`
+	SlackTemplate     string   `mapstructure:"SLACK_TEMPLATE"`
+	SlackChannelsFile string   `mapstructure:"SLACK_CHANNELS_FILE" validate:"omitempty,file"`
+	SlackTimeout      int32    `mapstructure:"SLACK_TIMEOUT" validate:"min=0"`
+	SlackMaxRetries   int      `mapstructure:"SLACK_MAX_RETRIES" validate:"min=0"`
+	SlackRetryDelay   int32    `mapstructure:"SLACK_RETRY_DELAY" validate:"min=0"`
+
+	// Incident management configuration
+	IncidentRecoveryEventTypes []string `mapstructure:"INCIDENT_RECOVERY_EVENT_TYPES"`
+	IncidentTimeout            int32    `mapstructure:"INCIDENT_TIMEOUT" validate:"min=0"`
+	IncidentMaxRetries         int      `mapstructure:"INCIDENT_MAX_RETRIES" validate:"min=0"`
+	IncidentRetryDelay         int32    `mapstructure:"INCIDENT_RETRY_DELAY" validate:"min=0"`
+
+	// PagerDuty configuration
+	PagerDutyRoutingKey string   `mapstructure:"PAGERDUTY_ROUTING_KEY"`
+	PagerDutyBaseURL    string   `mapstructure:"PAGERDUTY_BASE_URL" validate:"required_with=PagerDutyRoutingKey,omitempty,url"`
+	PagerDutySeverity   string   `mapstructure:"PAGERDUTY_SEVERITY" validate:"required_with=PagerDutyRoutingKey,omitempty,oneof=critical error warning info"`
+	PagerDutySources    []string `mapstructure:"PAGERDUTY_SOURCES"`
+	PagerDutyEventTypes []string `mapstructure:"PAGERDUTY_EVENT_TYPES"`
+
+	// Opsgenie configuration
+	OpsgenieAPIKey     string   `mapstructure:"OPSGENIE_API_KEY"`
+	OpsgenieBaseURL    string   `mapstructure:"OPSGENIE_BASE_URL" validate:"required_with=OpsgenieAPIKey,omitempty,url"`
+	OpsgeniePriority   string   `mapstructure:"OPSGENIE_PRIORITY" validate:"required_with=OpsgenieAPIKey,omitempty,oneof=P1 P2 P3 P4 P5"`
+	OpsgenieSources    []string `mapstructure:"OPSGENIE_SOURCES"`
+	OpsgenieEventTypes []string `mapstructure:"OPSGENIE_EVENT_TYPES"`
+
+	// SMTP configuration
+	SMTPHost     string `mapstructure:"SMTP_HOST" validate:"required_with=EmailDigestPeriod"`
+	SMTPPort     int    `mapstructure:"SMTP_PORT" validate:"required_with=SMTPHost,omitempty,min=1,max=65535"`
+	SMTPUsername string `mapstructure:"SMTP_USERNAME"`
+	SMTPPassword string `mapstructure:"SMTP_PASSWORD"`
+	SMTPStartTLS bool   `mapstructure:"SMTP_STARTTLS"`
+	SMTPTimeout  int32  `mapstructure:"SMTP_TIMEOUT" validate:"min=0"`
+
+	// Email configuration
+	EmailFrom                    string   `mapstructure:"EMAIL_FROM" validate:"required_with=SMTPHost"`
+	EmailTo                      []string `mapstructure:"EMAIL_TO" validate:"required_with=SMTPHost"`
+	EmailSources                 []string `mapstructure:"EMAIL_SOURCES"`
+	EmailEventTypes              []string `mapstructure:"EMAIL_EVENT_TYPES"`
+	EmailSeverities              []string `mapstructure:"EMAIL_SEVERITIES" validate:"dive,oneof=info warning error critical"`
+	EmailTemplatesDir            string   `mapstructure:"EMAIL_TEMPLATES_DIR" validate:"omitempty,dir"`
+	EmailMaxRetries              int      `mapstructure:"EMAIL_MAX_RETRIES" validate:"min=0"`
+	EmailRetryDelay              int32    `mapstructure:"EMAIL_RETRY_DELAY" validate:"min=0"`
+	EmailDigestPeriod            string   `mapstructure:"EMAIL_DIGEST_PERIOD" validate:"omitempty,oneof=hourly daily"`
+	EmailDigestCheckInterval     int32    `mapstructure:"EMAIL_DIGEST_CHECK_INTERVAL" mode:"worker" validate:"required_with=EmailDigestPeriod,omitempty,min=1"`
+	EmailDigestNotableSources    []string `mapstructure:"EMAIL_DIGEST_NOTABLE_SOURCES"`
+	EmailDigestNotableEventTypes []string `mapstructure:"EMAIL_DIGEST_NOTABLE_EVENT_TYPES"`
+	EmailDigestNotableLimit      int      `mapstructure:"EMAIL_DIGEST_NOTABLE_LIMIT" validate:"min=0"`
 }
 
 // Constants related to configuration
@@ -30,11 +223,67 @@ const (
 	EnvFile = ".env" // Environment file name
 )
 
-// NewConfig loads the configuration from env file and environment variables
-func NewConfig() (*Config, error) {
+// Supported run modes
+const (
+	RunModeAPI    = "api"
+	RunModeWorker = "worker"
+	RunModeAll    = "all"
+)
+
+// Fields of the configuration sections validated by the one-off commands
+var (
+	dbConfigFields      = []string{"DBDSN", "DBReplicaDSN", "DBQueryTimeout"}
+	archiveConfigFields = []string{"ArchiveAfterDays", "ArchiveCheckInterval", "ArchiveStore", "ArchiveDir", "ArchiveS3Bucket",
+		"ArchiveS3Prefix", "ArchiveS3Region", "ArchiveS3Endpoint", "ArchiveS3AccessKeyID", "ArchiveS3SecretAccessKey",
+		"ArchiveS3SessionToken", "ArchiveS3PathStyle"}
+)
+
+// NewConfig loads the configuration from env file and environment variables, in the given run mode if not empty
+func NewConfig(runMode string) (*Config, error) {
+	return loadConfig(runMode, validateConfig)
+}
+
+// NewDBConfig loads the configuration, validating only the database configuration, for the commands
+// only using the database
+func NewDBConfig() (*Config, error) {
+	return loadConfig("", func(config *Config) error {
+		return validator.New().StructPartial(config, dbConfigFields...)
+	})
+}
+
+// NewArchiveConfig loads the configuration, validating only the database and archival configuration
+func NewArchiveConfig() (*Config, error) {
+	return loadConfig("", func(config *Config) error {
+		return validator.New().StructPartial(config, slices.Concat(dbConfigFields, archiveConfigFields)...)
+	})
+}
+
+// NewImportConfig loads the configuration, validating neither the server nor the consumer configuration
+func NewImportConfig() (*Config, error) {
+	return loadConfig("", func(config *Config) error {
+		return validateModes(config, func(string) bool { return false })
+	})
+}
+
+// NewReplayConfig loads the configuration in the worker run mode, validating only the database and worker
+// configuration
+func NewReplayConfig() (*Config, error) {
+	return loadConfig(RunModeWorker, func(config *Config) error {
+		return validator.New().StructPartial(config, slices.Concat(dbConfigFields, modeFields(RunModeWorker))...)
+	})
+}
+
+// loadConfig loads the configuration from env file and environment variables, overriding the run mode if not
+// empty, and validates it
+func loadConfig(runMode string, validate func(*Config) error) (*Config, error) {
 	v := viper.New()
 	v.SetConfigFile(EnvFile)
 	v.AutomaticEnv() // read env
+	v.SetDefault("RUN_MODE", RunModeAll)
+	v.SetDefault("QUEUE_DRIVER", QueueDriverSQS)
+	if runMode != "" {
+		v.Set("RUN_MODE", runMode)
+	}
 
 	if err := v.ReadInConfig(); err != nil {
 		return nil, err
@@ -45,9 +294,73 @@ func NewConfig() (*Config, error) {
 		return nil, err
 	}
 
-	if err := validator.New().Struct(config); err != nil {
+	if err := validate(&config); err != nil {
 		return nil, err
 	}
 
 	return &config, nil
 }
+
+// RunsAPI reports whether the application serves HTTP requests
+func (c *Config) RunsAPI() bool {
+	return c.RunMode == RunModeAPI || c.RunMode == RunModeAll
+}
+
+// RunsWorker reports whether the application consumes the queue
+func (c *Config) RunsWorker() bool {
+	return c.RunMode == RunModeWorker || c.RunMode == RunModeAll
+}
+
+// runs reports whether the application runs the given mode
+func (c *Config) runs(mode string) bool {
+	switch mode {
+	case RunModeAPI:
+		return c.RunsAPI()
+	case RunModeWorker:
+		return c.RunsWorker()
+	}
+	return true
+}
+
+// validateConfig validates the configuration, skipping the fields tagged
+// with a mode the application does not run
+func validateConfig(config *Config) error {
+	return validateModes(config, config.runs)
+}
+
+// modeFields returns the names of the fields only used in the given run mode
+func modeFields(mode string) []string {
+	var fields []string
+	t := reflect.TypeOf(Config{})
+	for i := 0; i < t.NumField(); i++ {
+		if field := t.Field(i); field.Tag.Get("mode") == mode {
+			fields = append(fields, field.Name)
+		}
+	}
+	return fields
+}
+
+// validateModes validates the configuration, skipping the fields tagged with a mode not run
+func validateModes(config *Config, runs func(mode string) bool) error {
+	var skipped []string
+	t := reflect.TypeOf(*config)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if mode := field.Tag.Get("mode"); mode != "" && !runs(mode) {
+			skipped = append(skipped, field.Name)
+		}
+	}
+	if err := validator.New().StructExcept(config, skipped...); err != nil {
+		return err
+	}
+
+	if config.ArchiveAfterDays > 0 && config.EventRetentionDays > 0 && config.EventRetentionDays <= config.ArchiveAfterDays {
+		// Events would be dropped with their partition before being archived
+		return fmt.Errorf("EVENT_RETENTION_DAYS (%d) must be greater than ARCHIVE_AFTER_DAYS (%d)", config.EventRetentionDays, config.ArchiveAfterDays)
+	}
+	if config.NotificationGroupWindow > 0 && len(config.NotificationGroupBy) == 0 {
+		// Every event would be grouped with the first one, whatever its type
+		return fmt.Errorf("NOTIFICATION_GROUP_BY is required when NOTIFICATION_GROUP_WINDOW (%d) is set", config.NotificationGroupWindow)
+	}
+	return nil
+}
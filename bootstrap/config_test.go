@@ -0,0 +1,255 @@
+package bootstrap
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// inEnvDir runs the test in a temporary directory holding the env file
+func inEnvDir(t *testing.T, env string) {
+	t.Helper()
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, EnvFile), []byte(env), 0o600))
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	t.Cleanup(func() { require.NoError(t, os.Chdir(wd)) })
+}
+
+func TestNewConfig(t *testing.T) {
+	t.Run("Consumes SQS by default", func(t *testing.T) {
+		inEnvDir(t, "RUN_MODE=worker\nDB_DSN=dsn\nSQS_QUEUE_URL=http://localhost:9324/queue/events\nSQS_REGION=us-west-1\n"+
+			"SQS_MAX_NUMBER_OF_MESSAGES=10\nSQS_WAIT_TIME_SECONDS=20\nSQS_VISIBILITY_TIMEOUT=30\n")
+		config, err := NewConfig("")
+		require.NoError(t, err)
+		assert.Equal(t, QueueDriverSQS, config.QueueDriver)
+	})
+
+	t.Run("Runs all modes by default", func(t *testing.T) {
+		inEnvDir(t, "DB_DSN=dsn\nSQS_QUEUE_URL=http://localhost:9324/queue/events\nSQS_REGION=us-west-1\n"+
+			"SQS_MAX_NUMBER_OF_MESSAGES=10\nSQS_WAIT_TIME_SECONDS=20\nSQS_VISIBILITY_TIMEOUT=30\n")
+		_, err := NewConfig("")
+		assert.ErrorContains(t, err, "ServerPort")
+
+		inEnvDir(t, "DB_DSN=dsn\nSERVER_PORT=8080\nSQS_QUEUE_URL=http://localhost:9324/queue/events\nSQS_REGION=us-west-1\n"+
+			"SQS_MAX_NUMBER_OF_MESSAGES=10\nSQS_WAIT_TIME_SECONDS=20\nSQS_VISIBILITY_TIMEOUT=30\n")
+		config, err := NewConfig("")
+		require.NoError(t, err)
+		assert.Equal(t, RunModeAll, config.RunMode)
+	})
+
+	t.Run("Unknown queue driver", func(t *testing.T) {
+		inEnvDir(t, "RUN_MODE=worker\nDB_DSN=dsn\nQUEUE_DRIVER=kinesis\n")
+		_, err := NewConfig("")
+		assert.ErrorContains(t, err, "QueueDriver")
+	})
+
+	t.Run("Overrides the run mode", func(t *testing.T) {
+		inEnvDir(t, "RUN_MODE=worker\nDB_DSN=dsn\nSERVER_PORT=8080\n")
+		config, err := NewConfig(RunModeAPI)
+		require.NoError(t, err)
+		assert.Equal(t, RunModeAPI, config.RunMode)
+	})
+}
+
+func TestNewCommandConfigs(t *testing.T) {
+	// The server and queue configuration of the run mode are missing, and the rate limit is invalid
+	env := "RUN_MODE=all\nDB_DSN=dsn\nNOTIFICATION_RATE_LIMIT=-1\n"
+
+	t.Run("Database configuration", func(t *testing.T) {
+		inEnvDir(t, env)
+		config, err := NewDBConfig()
+		require.NoError(t, err)
+		assert.Equal(t, "dsn", config.DBDSN)
+
+		inEnvDir(t, "DB_QUERY_TIMEOUT=5\n")
+		_, err = NewDBConfig()
+		assert.ErrorContains(t, err, "DBDSN")
+	})
+
+	t.Run("Archive configuration", func(t *testing.T) {
+		inEnvDir(t, env+"ARCHIVE_STORE=local\nARCHIVE_DIR=/var/archive\n")
+		_, err := NewArchiveConfig()
+		require.NoError(t, err)
+
+		inEnvDir(t, env+"ARCHIVE_AFTER_DAYS=30\n")
+		_, err = NewArchiveConfig()
+		assert.ErrorContains(t, err, "ArchiveStore")
+	})
+
+	t.Run("Import configuration", func(t *testing.T) {
+		inEnvDir(t, "RUN_MODE=all\nDB_DSN=dsn\n")
+		_, err := NewImportConfig()
+		require.NoError(t, err)
+
+		inEnvDir(t, env)
+		_, err = NewImportConfig()
+		assert.ErrorContains(t, err, "NotificationRateLimit")
+	})
+
+	t.Run("Replay configuration", func(t *testing.T) {
+		inEnvDir(t, env+"QUEUE_DRIVER=postgres\nPG_QUEUE_BATCH_SIZE=10\nPG_QUEUE_VISIBILITY_TIMEOUT=30\n")
+		config, err := NewReplayConfig()
+		require.NoError(t, err)
+		assert.Equal(t, RunModeWorker, config.RunMode)
+
+		inEnvDir(t, env)
+		_, err = NewReplayConfig()
+		assert.ErrorContains(t, err, "SQSQueueURL")
+	})
+}
+
+func TestValidateConfig(t *testing.T) {
+	t.Run("API mode skips the worker configuration", func(t *testing.T) {
+		config := &Config{RunMode: RunModeAPI, ServerPort: 8080, DBDSN: "dsn", QueueDriver: QueueDriverSQS}
+		assert.NoError(t, validateConfig(config))
+	})
+
+	t.Run("Worker mode skips the server configuration", func(t *testing.T) {
+		config := &Config{RunMode: RunModeWorker, DBDSN: "dsn", QueueDriver: QueueDriverKafka,
+			KafkaBrokers: []string{"localhost:9092"}, KafkaTopics: []string{"events"}, KafkaGroupID: "group"}
+		assert.NoError(t, validateConfig(config))
+	})
+
+	t.Run("Worker mode requires the queue configuration", func(t *testing.T) {
+		config := &Config{RunMode: RunModeWorker, DBDSN: "dsn", QueueDriver: QueueDriverSQS}
+		assert.ErrorContains(t, validateConfig(config), "SQSQueueURL")
+	})
+
+	t.Run("FIFO queue requires a dead-letter queue", func(t *testing.T) {
+		config := &Config{RunMode: RunModeWorker, DBDSN: "dsn", QueueDriver: QueueDriverSQS,
+			SQSQueueURL: "http://localhost:9324/queue/events.fifo", SQSRegion: "us-west-1", SQSMaxNumberOfMessages: 10,
+			SQSWaitTimeSeconds: 20, SQSVisibilityTimeout: 30, SQSFIFO: true}
+		assert.ErrorContains(t, validateConfig(config), "SQSDeadLetterQueueURL")
+
+		config.SQSDeadLetterQueueURL = "http://localhost:9324/queue/events-dead.fifo"
+		assert.NoError(t, validateConfig(config))
+	})
+
+	t.Run("All mode requires both", func(t *testing.T) {
+		config := &Config{RunMode: RunModeAll, DBDSN: "dsn"}
+		err := validateConfig(config)
+		assert.ErrorContains(t, err, "ServerPort")
+		assert.ErrorContains(t, err, "QueueDriver")
+	})
+
+	t.Run("Retention requires a retention mode", func(t *testing.T) {
+		config := &Config{RunMode: RunModeAPI, ServerPort: 8080, DBDSN: "dsn", EventRetentionDays: 90}
+		assert.ErrorContains(t, validateConfig(config), "EventRetentionMode")
+
+		config.EventRetentionMode = RetentionModeDetach
+		assert.NoError(t, validateConfig(config))
+	})
+
+	t.Run("Archival requires a store", func(t *testing.T) {
+		config := &Config{RunMode: RunModeAPI, ServerPort: 8080, DBDSN: "dsn", ArchiveAfterDays: 30}
+		assert.ErrorContains(t, validateConfig(config), "ArchiveStore")
+
+		config.ArchiveStore = ArchiveStoreS3
+		err := validateConfig(config)
+		assert.ErrorContains(t, err, "ArchiveS3Bucket")
+		assert.ErrorContains(t, err, "ArchiveS3Region")
+
+		config.ArchiveStore, config.ArchiveDir = ArchiveStoreLocal, "/var/archive"
+		assert.NoError(t, validateConfig(config))
+	})
+
+	t.Run("Retention must outlast archival", func(t *testing.T) {
+		config := &Config{RunMode: RunModeAPI, ServerPort: 8080, DBDSN: "dsn", ArchiveAfterDays: 30,
+			ArchiveStore: ArchiveStoreLocal, ArchiveDir: "/var/archive", EventRetentionDays: 30, EventRetentionMode: RetentionModeDrop}
+		assert.ErrorContains(t, validateConfig(config), "EVENT_RETENTION_DAYS")
+	})
+
+	t.Run("Outbox publisher requires its destination", func(t *testing.T) {
+		config := &Config{RunMode: RunModeWorker, DBDSN: "dsn", QueueDriver: QueueDriverKafka,
+			KafkaBrokers: []string{"localhost:9092"}, KafkaTopics: []string{"events"}, KafkaGroupID: "group",
+			OutboxPublisher: OutboxPublisherSNS}
+		err := validateConfig(config)
+		assert.ErrorContains(t, err, "OutboxBatchSize")
+		assert.ErrorContains(t, err, "OutboxSNSTopicARN")
+
+		config.OutboxBatchSize, config.OutboxSNSTopicARN, config.OutboxSNSRegion = 100, "arn:aws:sns:us-west-1:000000000000:events", "us-west-1"
+		assert.NoError(t, validateConfig(config))
+	})
+
+	t.Run("Webhook delivery requires its batch size and max attempts", func(t *testing.T) {
+		config := &Config{RunMode: RunModeWorker, DBDSN: "dsn", QueueDriver: QueueDriverKafka,
+			KafkaBrokers: []string{"localhost:9092"}, KafkaTopics: []string{"events"}, KafkaGroupID: "group",
+			WebhookPollInterval: 1}
+		err := validateConfig(config)
+		assert.ErrorContains(t, err, "WebhookBatchSize")
+		assert.ErrorContains(t, err, "WebhookMaxAttempts")
+
+		config.WebhookBatchSize, config.WebhookMaxAttempts = 50, 10
+		assert.NoError(t, validateConfig(config))
+	})
+
+	t.Run("Slack notifications require workers", func(t *testing.T) {
+		config := &Config{RunMode: RunModeAPI, ServerPort: 8080, DBDSN: "dsn", SlackWebhook: "https://hooks.slack.com/services/T/B/1"}
+		assert.ErrorContains(t, validateConfig(config), "NotificationWorkers")
+
+		config.NotificationWorkers = 2
+		assert.NoError(t, validateConfig(config))
+	})
+
+	t.Run("Incident channels require workers, a base URL and a severity", func(t *testing.T) {
+		config := &Config{RunMode: RunModeAPI, ServerPort: 8080, DBDSN: "dsn", NotificationWorkers: 2, PagerDutyRoutingKey: "routing-key"}
+		assert.ErrorContains(t, validateConfig(config), "PagerDutySeverity")
+
+		config.PagerDutyBaseURL, config.PagerDutySeverity = "http://localhost:8081", "critical"
+		assert.NoError(t, validateConfig(config))
+
+		config.OpsgenieAPIKey, config.OpsgenieBaseURL, config.OpsgeniePriority = "api-key", "https://api.opsgenie.com", "P6"
+		assert.ErrorContains(t, validateConfig(config), "OpsgeniePriority")
+
+		config.OpsgeniePriority, config.NotificationWorkers = "P2", 0
+		assert.ErrorContains(t, validateConfig(config), "NotificationWorkers")
+	})
+
+	t.Run("Email digests require an SMTP server and a check interval", func(t *testing.T) {
+		config := &Config{RunMode: RunModeWorker, DBDSN: "dsn", QueueDriver: QueueDriverKafka,
+			KafkaBrokers: []string{"localhost:9092"}, KafkaTopics: []string{"events"}, KafkaGroupID: "group", EmailDigestPeriod: "daily"}
+		assert.ErrorContains(t, validateConfig(config), "SMTPHost")
+
+		config.SMTPHost, config.SMTPPort, config.EmailFrom, config.EmailTo, config.NotificationWorkers = "localhost", 1025, "events@example.com", []string{"ops@example.com"}, 1
+		assert.ErrorContains(t, validateConfig(config), "EmailDigestCheckInterval")
+
+		config.EmailDigestCheckInterval = 60
+		assert.NoError(t, validateConfig(config))
+
+		config.EmailDigestPeriod = "weekly"
+		assert.ErrorContains(t, validateConfig(config), "EmailDigestPeriod")
+	})
+
+	t.Run("Notification throttling requires valid group keys and a rate interval", func(t *testing.T) {
+		config := &Config{RunMode: RunModeAPI, ServerPort: 8080, DBDSN: "dsn", NotificationGroupBy: []string{"source", "region"}}
+		assert.ErrorContains(t, validateConfig(config), "NotificationGroupBy[1]")
+
+		config.NotificationGroupBy, config.NotificationRateLimit = []string{"source", "event_type"}, 10
+		assert.ErrorContains(t, validateConfig(config), "NotificationRateInterval")
+
+		config.NotificationRateInterval = 60
+		assert.NoError(t, validateConfig(config))
+
+		config.NotificationGroupBy, config.NotificationGroupWindow = nil, 60
+		assert.ErrorContains(t, validateConfig(config), "NOTIFICATION_GROUP_BY")
+	})
+
+	t.Run("Unknown mode", func(t *testing.T) {
+		config := &Config{RunMode: "batch", ServerPort: 8080, DBDSN: "dsn"}
+		assert.ErrorContains(t, validateConfig(config), "RunMode")
+	})
+}
+
+func TestConfig_Runs(t *testing.T) {
+	assert.True(t, (&Config{RunMode: RunModeAPI}).RunsAPI())
+	assert.False(t, (&Config{RunMode: RunModeAPI}).RunsWorker())
+	assert.False(t, (&Config{RunMode: RunModeWorker}).RunsAPI())
+	assert.True(t, (&Config{RunMode: RunModeWorker}).RunsWorker())
+	assert.True(t, (&Config{RunMode: RunModeAll}).RunsAPI())
+	assert.True(t, (&Config{RunMode: RunModeAll}).RunsWorker())
+}
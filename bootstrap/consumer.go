@@ -0,0 +1,274 @@
+package bootstrap
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/cvzm/go-web-project/domain"
+)
+
+// Constants related to message consumption
+const (
+	defaultDeduplicationWindow = 5 * time.Minute // matches the deduplication interval of SQS FIFO queues
+	receiveErrorBackoff        = time.Second     // pause after a failed receive
+)
+
+// errMalformedMessage is returned for messages that can never be processed, whatever the number of retries
+var errMalformedMessage = errors.New("malformed message")
+
+// Consumer consumes messages from a domain.MessageSource and saves them as events
+type Consumer struct {
+	source       domain.MessageSource
+	config       *Config
+	eventUsecase domain.EventUsecase
+	dedup        *deduplicator
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewConsumer creates a new Consumer instance
+func NewConsumer(source domain.MessageSource, config *Config, eventUsecase domain.EventUsecase) *Consumer {
+	ctx, cancel := context.WithCancel(context.Background())
+	consumer := &Consumer{
+		source:       source,
+		config:       config,
+		eventUsecase: eventUsecase,
+		ctx:          ctx,
+		cancel:       cancel,
+		done:         make(chan struct{}),
+	}
+	if config.ConsumerDeduplication {
+		window := time.Duration(config.ConsumerDeduplicationWindow) * time.Second
+		if window == 0 {
+			window = defaultDeduplicationWindow
+		}
+		consumer.dedup = newDeduplicator(window)
+	}
+	return consumer
+}
+
+// Start begins the message consumption loop, until Stop is called
+func (c *Consumer) Start() {
+	defer close(c.done)
+	c.consumeMessages()
+}
+
+// Stop stops the consumption loop, waits for the current batch and closes the source
+func (c *Consumer) Stop() error {
+	c.cancel()
+	<-c.done
+	return c.source.Close()
+}
+
+// consumeMessages continuously receives and processes messages
+func (c *Consumer) consumeMessages() {
+	for c.ctx.Err() == nil {
+		messages, err := c.source.Receive(c.ctx)
+		if err != nil {
+			if c.ctx.Err() != nil {
+				return
+			}
+			log.Printf("Error receiving messages: %v", err)
+			time.Sleep(receiveErrorBackoff)
+			continue
+		}
+
+		c.processMessages(messages)
+	}
+}
+
+// processMessages processes a batch of received messages.
+// Messages sharing a GroupID are processed strictly in order, while different groups
+// are processed in parallel. Ungrouped messages are processed independently.
+func (c *Consumer) processMessages(messages []domain.Message) {
+	ungrouped, groups := groupMessages(messages)
+
+	var wg sync.WaitGroup
+	for _, group := range groups {
+		wg.Add(1)
+		go func(group []domain.Message) {
+			defer wg.Done()
+			c.processGroup(group)
+		}(group)
+	}
+
+	for _, message := range ungrouped {
+		if err := c.processMessage(message); err != nil {
+			log.Printf("Error processing message %q: %v", message.ID, err)
+		}
+	}
+	wg.Wait()
+}
+
+// processGroup processes the messages of a single group in order.
+// Once a message fails, its successors are left untouched so they are redelivered
+// after it and can never overtake it.
+func (c *Consumer) processGroup(group []domain.Message) {
+	for i, message := range group {
+		if err := c.processMessage(message); err != nil {
+			log.Printf("Error processing message %q: %v, skipping %d subsequent message(s) in group %q",
+				message.ID, err, len(group)-i-1, message.GroupID)
+			return
+		}
+	}
+}
+
+// processMessage handles a single message, then acks it, nacks it on failure, or rejects it if malformed
+func (c *Consumer) processMessage(message domain.Message) error {
+	if c.dedup != nil && c.dedup.seen(message.DeduplicationID) {
+		log.Printf("Skipping duplicate message %q", message.DeduplicationID)
+		return c.source.Ack(c.ctx, message)
+	}
+
+	err := c.handleMessage(message)
+	if errors.Is(err, errMalformedMessage) {
+		// A poison message must not block its group, so it is rejected rather than retried
+		log.Printf("Rejecting message %q: %v", message.ID, err)
+		return c.source.Reject(c.ctx, message)
+	}
+	if err != nil {
+		delay := time.Duration(c.config.ConsumerRetryDelay) * time.Second
+		if nackErr := c.source.Nack(c.ctx, message, delay); nackErr != nil {
+			err = errors.Join(err, nackErr)
+		}
+		return err
+	}
+
+	if c.dedup != nil {
+		c.dedup.add(message.DeduplicationID)
+	}
+
+	if err := c.source.Ack(c.ctx, message); err != nil {
+		// The event is already saved, so the message is not considered failed
+		log.Printf("Error acking message %q: %v", message.ID, err)
+	}
+	return nil
+}
+
+// handleMessage saves the event carried by the message within the message timeout,
+// extending its lease while it is being saved
+func (c *Consumer) handleMessage(message domain.Message) error {
+	if lease := time.Duration(c.config.ConsumerLease) * time.Second; lease > 0 {
+		stop := c.keepAlive(message, lease)
+		defer stop()
+	}
+
+	cloudEvent, err := decodeMessage(message.Body)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errMalformedMessage, err)
+	}
+
+	// Messages being saved are not cancelled by Stop, which waits for them, but by their own timeout
+	ctx := context.WithoutCancel(c.ctx)
+	if timeout := time.Duration(c.config.ConsumerMessageTimeout) * time.Second; timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return c.eventUsecase.Save(ctx, cloudEvent)
+}
+
+// decodeMessage decodes the cloud event carried by a message, a GCP event if it has a GCP event type, such as
+// the GCP events buffered by the API in the Postgres queue, and an AWS event otherwise
+func decodeMessage(body []byte) (domain.CloudEvent, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, err
+	}
+	source := domain.SourceAWS
+	if _, ok := fields["gcp_event_type"]; ok {
+		source = domain.SourceGCP
+	}
+	return decodeCloudEvent(source, body)
+}
+
+// keepAlive extends the lease of the message at half its duration until the returned function is called
+func (c *Consumer) keepAlive(message domain.Message, lease time.Duration) func() {
+	ticker := time.NewTicker(lease / 2)
+	stop := make(chan struct{})
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := c.source.Extend(c.ctx, message, lease); err != nil {
+					log.Printf("Error extending message %q: %v", message.ID, err)
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+	return func() { close(stop) }
+}
+
+// groupMessages splits off ungrouped messages and groups the others by GroupID,
+// keeping the receive order within each group
+func groupMessages(messages []domain.Message) ([]domain.Message, [][]domain.Message) {
+	var ungrouped []domain.Message
+	var groups [][]domain.Message
+	index := map[string]int{}
+	for _, message := range messages {
+		if message.GroupID == "" {
+			ungrouped = append(ungrouped, message)
+			continue
+		}
+		i, ok := index[message.GroupID]
+		if !ok {
+			i = len(groups)
+			index[message.GroupID] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], message)
+	}
+	return ungrouped, groups
+}
+
+// deduplicator remembers processed deduplication IDs for a limited window
+type deduplicator struct {
+	mu     sync.Mutex
+	window time.Duration
+	ids    map[string]time.Time
+}
+
+// newDeduplicator creates a deduplicator that remembers IDs for the given window
+func newDeduplicator(window time.Duration) *deduplicator {
+	return &deduplicator{
+		window: window,
+		ids:    map[string]time.Time{},
+	}
+}
+
+// seen reports whether the ID was processed within the window
+func (d *deduplicator) seen(id string) bool {
+	if id == "" {
+		return false
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	expiresAt, ok := d.ids[id]
+	return ok && time.Now().Before(expiresAt)
+}
+
+// add records the ID as processed and evicts expired IDs
+func (d *deduplicator) add(id string) {
+	if id == "" {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := time.Now()
+	for key, expiresAt := range d.ids {
+		if !now.Before(expiresAt) {
+			delete(d.ids, key)
+		}
+	}
+	d.ids[id] = now.Add(d.window)
+}
@@ -0,0 +1,157 @@
+package bootstrap
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cvzm/go-web-project/domain"
+	domain_mock "github.com/cvzm/go-web-project/domain/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestMessage(id, groupID, dedupID, eventType string) domain.Message {
+	return domain.Message{
+		ID:              id,
+		Body:            []byte(`{"aws_event_type":"` + eventType + `"}`),
+		GroupID:         groupID,
+		DeduplicationID: dedupID,
+	}
+}
+
+func awsEventOfType(eventType string) any {
+	return mock.MatchedBy(func(e domain.AWSEvent) bool { return e.AWSEventType == eventType })
+}
+
+func messageWithID(id string) any {
+	return mock.MatchedBy(func(m domain.Message) bool { return m.ID == id })
+}
+
+func TestGroupMessages(t *testing.T) {
+	messages := []domain.Message{
+		newTestMessage("1", "a", "", "A1"),
+		newTestMessage("2", "", "", "U1"),
+		newTestMessage("3", "b", "", "B1"),
+		newTestMessage("4", "a", "", "A2"),
+	}
+
+	ungrouped, groups := groupMessages(messages)
+	assert.Equal(t, []domain.Message{messages[1]}, ungrouped)
+	assert.Equal(t, [][]domain.Message{
+		{messages[0], messages[3]},
+		{messages[2]},
+	}, groups)
+}
+
+func TestConsumer_ProcessMessages(t *testing.T) {
+	mockSource := new(domain_mock.MockMessageSource)
+	mockUsecase := new(domain_mock.MockEventUsecase)
+	consumer := NewConsumer(mockSource, &Config{ConsumerRetryDelay: 5}, mockUsecase)
+
+	mockUsecase.On("Save", mock.Anything, awsEventOfType("A1")).Return(errors.New("save failed")).Once()
+	mockUsecase.On("Save", mock.Anything, awsEventOfType("B1")).Return(nil).Once()
+	mockUsecase.On("Save", mock.Anything, awsEventOfType("B2")).Return(nil).Once()
+	mockUsecase.On("Save", mock.Anything, awsEventOfType("U1")).Return(errors.New("save failed")).Once()
+	mockUsecase.On("Save", mock.Anything, awsEventOfType("U2")).Return(nil).Once()
+
+	mockSource.On("Nack", mock.Anything, messageWithID("a1"), 5*time.Second).Return(nil).Once()
+	mockSource.On("Nack", mock.Anything, messageWithID("u1"), 5*time.Second).Return(nil).Once()
+	mockSource.On("Ack", mock.Anything, messageWithID("b1")).Return(nil).Once()
+	mockSource.On("Ack", mock.Anything, messageWithID("b2")).Return(nil).Once()
+	mockSource.On("Ack", mock.Anything, messageWithID("u2")).Return(nil).Once()
+
+	consumer.processMessages([]domain.Message{
+		newTestMessage("a1", "a", "", "A1"),
+		newTestMessage("b1", "b", "", "B1"),
+		newTestMessage("u1", "", "", "U1"),
+		newTestMessage("a2", "a", "", "A2"),
+		newTestMessage("b2", "b", "", "B2"),
+		newTestMessage("u2", "", "", "U2"),
+	})
+
+	// The failed message blocks its successor in the group, other messages are unaffected
+	mockUsecase.AssertNotCalled(t, "Save", awsEventOfType("A2"))
+	mockSource.AssertNotCalled(t, "Ack", mock.Anything, messageWithID("a2"))
+	mockSource.AssertNotCalled(t, "Nack", mock.Anything, messageWithID("a2"), mock.Anything)
+	mockUsecase.AssertExpectations(t)
+	mockSource.AssertExpectations(t)
+}
+
+func TestConsumer_ProcessMessagesDeduplication(t *testing.T) {
+	mockSource := new(domain_mock.MockMessageSource)
+	mockUsecase := new(domain_mock.MockEventUsecase)
+	consumer := NewConsumer(mockSource, &Config{ConsumerDeduplication: true}, mockUsecase)
+
+	mockUsecase.On("Save", mock.Anything, awsEventOfType("A1")).Return(nil).Once()
+	mockSource.On("Ack", mock.Anything, messageWithID("first")).Return(nil).Once()
+	mockSource.On("Ack", mock.Anything, messageWithID("second")).Return(nil).Once()
+
+	consumer.processMessages([]domain.Message{
+		newTestMessage("first", "a", "dedup-1", "A1"),
+		newTestMessage("second", "a", "dedup-1", "A1"),
+	})
+
+	mockUsecase.AssertExpectations(t)
+	mockSource.AssertExpectations(t)
+}
+
+func TestConsumer_ProcessMessagesMalformed(t *testing.T) {
+	mockSource := new(domain_mock.MockMessageSource)
+	mockUsecase := new(domain_mock.MockEventUsecase)
+	consumer := NewConsumer(mockSource, &Config{}, mockUsecase)
+
+	mockUsecase.On("Save", mock.Anything, awsEventOfType("A2")).Return(nil).Once()
+	mockSource.On("Reject", mock.Anything, messageWithID("a1")).Return(nil).Once()
+	mockSource.On("Ack", mock.Anything, messageWithID("a2")).Return(nil).Once()
+
+	// A poison message is rejected without blocking its group
+	consumer.processMessages([]domain.Message{
+		{ID: "a1", GroupID: "a", Body: []byte("not json")},
+		newTestMessage("a2", "a", "", "A2"),
+	})
+
+	mockUsecase.AssertExpectations(t)
+	mockSource.AssertExpectations(t)
+}
+
+func TestDecodeMessage(t *testing.T) {
+	cloudEvent, err := decodeMessage([]byte(`{"aws_event_id":"aws-123","aws_event_type":"EC2_STOPPED"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, domain.AWSEvent{AWSEventID: "aws-123", AWSEventType: "EC2_STOPPED"}, cloudEvent)
+
+	cloudEvent, err = decodeMessage([]byte(`{"gcp_event_id":"gcp-123","gcp_event_type":"VM_STOPPED"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, domain.GCPEvent{GCPEventID: "gcp-123", GCPEventType: "VM_STOPPED"}, cloudEvent)
+
+	_, err = decodeMessage([]byte(`not json`))
+	assert.Error(t, err)
+}
+
+func TestConsumer_StartAndStop(t *testing.T) {
+	mockSource := new(domain_mock.MockMessageSource)
+	mockUsecase := new(domain_mock.MockEventUsecase)
+	consumer := NewConsumer(mockSource, &Config{}, mockUsecase)
+
+	mockSource.On("Receive", mock.Anything).Return([]domain.Message{}, nil).Maybe()
+	mockSource.On("Close").Return(nil).Once()
+
+	go consumer.Start()
+	assert.NoError(t, consumer.Stop())
+	mockSource.AssertExpectations(t)
+}
+
+func TestDeduplicator(t *testing.T) {
+	d := newDeduplicator(time.Minute)
+	assert.False(t, d.seen("id"))
+
+	d.add("id")
+	assert.True(t, d.seen("id"))
+
+	// Empty IDs are never deduplicated
+	d.add("")
+	assert.False(t, d.seen(""))
+
+	d.ids["id"] = time.Now().Add(-time.Second)
+	assert.False(t, d.seen("id"))
+}
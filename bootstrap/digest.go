@@ -0,0 +1,51 @@
+package bootstrap
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/cvzm/go-web-project/domain"
+	"github.com/cvzm/go-web-project/usecase"
+)
+
+// initDigestUsecase initializes the digest use case, sending the digests with the email channel, if any
+func initDigestUsecase(cfg *Config, eventRepo domain.EventRepository, txManager domain.TransactionManager, channels *NotificationChannels) domain.DigestUsecase {
+	notable := domain.EventFilter{EventTypes: cfg.EmailDigestNotableEventTypes}
+	for _, source := range cfg.EmailDigestNotableSources {
+		notable.Sources = append(notable.Sources, domain.EventSource(source))
+	}
+	return usecase.NewDigestUsecase(eventRepo, txManager, channels.DigestSender, usecase.DigestConfig{
+		Period:       cfg.EmailDigestPeriod,
+		Notable:      notable,
+		NotableLimit: cfg.EmailDigestNotableLimit,
+	})
+}
+
+// DigestMailer periodically sends the digest of the last complete period, once per period across instances
+type DigestMailer struct {
+	*job
+	digestUsecase domain.DigestUsecase
+}
+
+// NewDigestMailer creates a new DigestMailer instance, running at every check interval once started if digests
+// are enabled and the application runs the worker
+func NewDigestMailer(config *Config, digestUsecase domain.DigestUsecase, channels *NotificationChannels) *DigestMailer {
+	m := &DigestMailer{digestUsecase: digestUsecase}
+	var interval time.Duration
+	if config.RunsWorker() && config.EmailDigestPeriod != "" && channels.DigestSender != nil {
+		interval = time.Duration(config.EmailDigestCheckInterval) * time.Second
+	}
+	m.job = newJob(interval, m.run)
+	return m
+}
+
+// run sends the digest of the last complete period unless already sent, and logs the outcome
+func (m *DigestMailer) run(ctx context.Context) {
+	sent, err := m.digestUsecase.Send(ctx, time.Now())
+	if err != nil {
+		log.Printf("Error sending the event digest: %v", err)
+	} else if sent {
+		log.Printf("Sent the event digest")
+	}
+}
@@ -0,0 +1,38 @@
+package bootstrap
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	domain_mock "github.com/cvzm/go-web-project/domain/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNewDigestMailer(t *testing.T) {
+	config := &Config{RunMode: RunModeWorker, EmailDigestPeriod: "daily", EmailDigestCheckInterval: 60}
+	channels := &NotificationChannels{DigestSender: new(domain_mock.MockDigestSender)}
+
+	mailer := NewDigestMailer(config, nil, channels)
+	assert.Equal(t, time.Minute, mailer.interval)
+
+	// Digests are only sent by workers, with an email channel
+	mailer = NewDigestMailer(&Config{RunMode: RunModeAPI, EmailDigestPeriod: "daily", EmailDigestCheckInterval: 60}, nil, channels)
+	assert.Zero(t, mailer.interval)
+	mailer = NewDigestMailer(config, nil, &NotificationChannels{})
+	assert.Zero(t, mailer.interval)
+}
+
+func TestDigestMailer_Run(t *testing.T) {
+	digestUsecase := new(domain_mock.MockDigestUsecase)
+	mailer := NewDigestMailer(&Config{}, digestUsecase, &NotificationChannels{})
+
+	digestUsecase.On("Send", mock.Anything, mock.AnythingOfType("time.Time")).Return(true, nil).Once()
+	digestUsecase.On("Send", mock.Anything, mock.AnythingOfType("time.Time")).Return(false, nil).Once()
+
+	mailer.run(context.Background())
+	mailer.run(context.Background())
+
+	digestUsecase.AssertExpectations(t)
+}
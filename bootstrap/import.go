@@ -0,0 +1,58 @@
+package bootstrap
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/cvzm/go-web-project/domain"
+)
+
+// maxImportLineSize is the maximum size of a single event in an import file
+const maxImportLineSize = 1024 * 1024
+
+// ImportEvents saves the events of an NDJSON reader, one cloud event of the given source per line.
+// It stops at the first invalid or unsaved event and returns the number of saved events.
+func (a *App) ImportEvents(ctx context.Context, r io.Reader, source domain.EventSource) (int, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxImportLineSize)
+
+	imported := 0
+	for line := 1; scanner.Scan(); line++ {
+		if err := ctx.Err(); err != nil {
+			return imported, err
+		}
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+
+		cloudEvent, err := decodeCloudEvent(source, scanner.Bytes())
+		if err != nil {
+			return imported, fmt.Errorf("line %d: %w", line, err)
+		}
+		if err := a.eventUsecase.Save(ctx, cloudEvent); err != nil {
+			return imported, fmt.Errorf("line %d: %w", line, err)
+		}
+		imported++
+	}
+	return imported, scanner.Err()
+}
+
+// decodeCloudEvent decodes a JSON cloud event of the given source
+func decodeCloudEvent(source domain.EventSource, data []byte) (domain.CloudEvent, error) {
+	switch source {
+	case domain.SourceAWS:
+		var event domain.AWSEvent
+		err := json.Unmarshal(data, &event)
+		return event, err
+	case domain.SourceGCP:
+		var event domain.GCPEvent
+		err := json.Unmarshal(data, &event)
+		return event, err
+	default:
+		return nil, fmt.Errorf("unsupported event source %q", source)
+	}
+}
@@ -0,0 +1,81 @@
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/cvzm/go-web-project/domain"
+	domain_mock "github.com/cvzm/go-web-project/domain/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestApp_ImportEvents(t *testing.T) {
+	t.Run("Import AWS events", func(t *testing.T) {
+		mockUsecase := new(domain_mock.MockEventUsecase)
+		app := &App{eventUsecase: mockUsecase}
+
+		mockUsecase.On("Save", mock.Anything, awsEventOfType("EC2_STARTED")).Return(nil).Once()
+		mockUsecase.On("Save", mock.Anything, awsEventOfType("EC2_STOPPED")).Return(nil).Once()
+
+		input := `{"aws_event_type":"EC2_STARTED"}` + "\n\n" + `{"aws_event_type":"EC2_STOPPED"}` + "\n"
+		imported, err := app.ImportEvents(context.Background(), strings.NewReader(input), domain.SourceAWS)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, imported)
+		mockUsecase.AssertExpectations(t)
+	})
+
+	t.Run("Import GCP events", func(t *testing.T) {
+		mockUsecase := new(domain_mock.MockEventUsecase)
+		app := &App{eventUsecase: mockUsecase}
+
+		mockUsecase.On("Save", mock.Anything, mock.MatchedBy(func(e domain.GCPEvent) bool { return e.GCPEventType == "VM_STOPPED" })).Return(nil).Once()
+
+		imported, err := app.ImportEvents(context.Background(), strings.NewReader(`{"gcp_event_type":"VM_STOPPED"}`), domain.SourceGCP)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, imported)
+		mockUsecase.AssertExpectations(t)
+	})
+
+	t.Run("Stop at the first failure", func(t *testing.T) {
+		mockUsecase := new(domain_mock.MockEventUsecase)
+		app := &App{eventUsecase: mockUsecase}
+
+		mockUsecase.On("Save", mock.Anything, awsEventOfType("EC2_STARTED")).Return(nil).Once()
+		mockUsecase.On("Save", mock.Anything, awsEventOfType("EC2_STOPPED")).Return(errors.New("save failed")).Once()
+
+		input := `{"aws_event_type":"EC2_STARTED"}` + "\n" + `{"aws_event_type":"EC2_STOPPED"}` + "\n" + `not json`
+		imported, err := app.ImportEvents(context.Background(), strings.NewReader(input), domain.SourceAWS)
+		assert.EqualError(t, err, "line 2: save failed")
+		assert.Equal(t, 1, imported)
+	})
+
+	t.Run("Invalid line", func(t *testing.T) {
+		app := &App{eventUsecase: new(domain_mock.MockEventUsecase)}
+
+		_, err := app.ImportEvents(context.Background(), strings.NewReader(`not json`), domain.SourceAWS)
+		assert.ErrorContains(t, err, "line 1")
+	})
+}
+
+func TestApp_ReplayMessages(t *testing.T) {
+	t.Run("Unsupported queue driver", func(t *testing.T) {
+		mockSource := new(domain_mock.MockMessageSource)
+		app := &App{
+			config: &Config{QueueDriver: QueueDriverSQS},
+			source: mockSource,
+		}
+
+		_, err := app.ReplayMessages(context.Background(), 0)
+		assert.EqualError(t, err, `queue driver "sqs" does not support replaying messages`)
+	})
+
+	t.Run("Without worker", func(t *testing.T) {
+		app := &App{config: &Config{}}
+
+		_, err := app.ReplayMessages(context.Background(), 0)
+		assert.Error(t, err)
+	})
+}
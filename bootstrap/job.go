@@ -0,0 +1,53 @@
+package bootstrap
+
+import (
+	"context"
+	"time"
+)
+
+// job runs a task right away, then at every interval until stopped
+type job struct {
+	interval time.Duration
+	run      func(ctx context.Context)
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// newJob creates a job running the task at every interval, never if the interval is zero
+func newJob(interval time.Duration, run func(ctx context.Context)) *job {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &job{
+		interval: interval,
+		run:      run,
+		ctx:      ctx,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+}
+
+// Start runs the task until Stop is called. It returns immediately if the interval is zero.
+func (j *job) Start() {
+	defer close(j.done)
+	if j.interval == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+	for {
+		j.run(j.ctx)
+		select {
+		case <-ticker.C:
+		case <-j.ctx.Done():
+			return
+		}
+	}
+}
+
+// Stop stops the job and waits for the current run
+func (j *job) Stop() {
+	j.cancel()
+	<-j.done
+}
@@ -0,0 +1,127 @@
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Metrics holds the Prometheus metrics of the application
+type Metrics struct {
+	registry *prometheus.Registry
+
+	OutboxRelayLag        prometheus.Gauge
+	OutboxPending         prometheus.Gauge
+	OutboxPublished       prometheus.Counter
+	OutboxPublishFailures prometheus.Counter
+	OutboxPublishDelay    prometheus.Histogram
+
+	WebhookDeliveries            *prometheus.CounterVec
+	WebhookDisabledSubscriptions prometheus.Counter
+
+	NotificationsSuppressed *prometheus.CounterVec
+}
+
+// NewMetrics creates the metrics of the application, along with the Go runtime and process metrics
+func NewMetrics() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		OutboxRelayLag: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "outbox_relay_lag_seconds",
+			Help: "Age of the oldest message waiting in the outbox, 0 if the outbox is empty.",
+		}),
+		OutboxPending: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "outbox_pending_messages",
+			Help: "Number of messages waiting in the outbox.",
+		}),
+		OutboxPublished: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "outbox_published_messages_total",
+			Help: "Number of outbox messages published.",
+		}),
+		OutboxPublishFailures: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "outbox_publish_failures_total",
+			Help: "Number of failed attempts to publish an outbox message.",
+		}),
+		OutboxPublishDelay: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "outbox_publish_delay_seconds",
+			Help:    "Delay between the recording of outbox messages and their publishing.",
+			Buckets: prometheus.ExponentialBuckets(0.1, 2, 15),
+		}),
+		WebhookDeliveries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "webhook_delivery_attempts_total",
+			Help: "Number of webhook delivery attempts, by result: succeeded, retried or given up.",
+		}, []string{"result"}),
+		WebhookDisabledSubscriptions: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "webhook_disabled_subscriptions_total",
+			Help: "Number of webhook subscriptions disabled after too many consecutive failures.",
+		}),
+		NotificationsSuppressed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "notifications_suppressed_total",
+			Help: "Number of notifications suppressed, by channel and reason: grouped or rate limited.",
+		}, []string{"channel", "reason"}),
+	}
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.OutboxRelayLag,
+		m.OutboxPending,
+		m.OutboxPublished,
+		m.OutboxPublishFailures,
+		m.OutboxPublishDelay,
+		m.WebhookDeliveries,
+		m.WebhookDisabledSubscriptions,
+		m.NotificationsSuppressed,
+	)
+	return m
+}
+
+// Handler returns the HTTP handler exposing the metrics
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// MetricsServer serves the metrics on /metrics, in every run mode
+type MetricsServer struct {
+	server *http.Server
+}
+
+// NewMetricsServer creates a new MetricsServer instance listening on the metrics port, none if it is zero
+func NewMetricsServer(config *Config, metrics *Metrics) *MetricsServer {
+	if config.MetricsPort == 0 {
+		return &MetricsServer{}
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+	return &MetricsServer{
+		server: &http.Server{
+			Addr:              fmt.Sprintf(":%d", config.MetricsPort),
+			Handler:           mux,
+			ReadHeaderTimeout: 10 * time.Second,
+		},
+	}
+}
+
+// Start serves the metrics until Shutdown is called. It returns immediately if the metrics are disabled.
+func (s *MetricsServer) Start() {
+	if s.server == nil {
+		return
+	}
+	if err := s.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Printf("Error serving metrics: %v", err)
+	}
+}
+
+// Shutdown stops serving the metrics
+func (s *MetricsServer) Shutdown(ctx context.Context) error {
+	if s.server == nil {
+		return nil
+	}
+	return s.server.Shutdown(ctx)
+}
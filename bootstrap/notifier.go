@@ -0,0 +1,493 @@
+package bootstrap
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cvzm/go-web-project/adapter/notification"
+	"github.com/cvzm/go-web-project/domain"
+	"github.com/cvzm/go-web-project/usecase"
+)
+
+// defaultSlackChannel is the name of the Slack channel of SLACK_WEBHOOK
+const defaultSlackChannel = "default"
+
+// Names of the incident management and email channels
+const (
+	pagerDutyChannel = "pagerduty"
+	opsgenieChannel  = "opsgenie"
+	emailChannel     = "email"
+)
+
+// NotificationRoute sends the events passing the filter to the channel, besides the routing rules
+type NotificationRoute struct {
+	Filter  domain.EventFilter
+	Channel domain.NotificationChannel
+}
+
+// NotificationChannels are the channels of the configuration, and their routes
+type NotificationChannels struct {
+	Channels     []domain.NotificationChannel
+	Routes       []NotificationRoute
+	DigestSender domain.DigestSender // email channel sending the digests, nil if not configured
+	Incidents    []string            // names of the channels triggering incidents
+}
+
+// Names returns the names of the channels
+func (c *NotificationChannels) Names() []string {
+	names := make([]string, 0, len(c.Channels))
+	for _, channel := range c.Channels {
+		names = append(names, channel.Name())
+	}
+	return names
+}
+
+// slackChannelConfig is a Slack channel of the channels file
+type slackChannelConfig struct {
+	Name    string `json:"name"`
+	Webhook string `json:"webhook"`
+	domain.EventFilter
+	Template  string `json:"template"`
+	RulesOnly bool   `json:"rules_only"` // the channel only receives the events routed to it by routing rules
+}
+
+// initNotificationChannels initializes the Slack channels of the configuration: the channel of SLACK_WEBHOOK,
+// if set, and those of the channels file, each routed the events passing its filter unless rules only,
+// followed by the incident management and email channels
+func initNotificationChannels(cfg *Config) (*NotificationChannels, error) {
+	channels := []slackChannelConfig{}
+	if cfg.SlackWebhook != "" {
+		channel := slackChannelConfig{Name: defaultSlackChannel, Webhook: cfg.SlackWebhook, Template: cfg.SlackTemplate}
+		channel.EventTypes = cfg.SlackEventTypes
+		for _, source := range cfg.SlackSources {
+			channel.Sources = append(channel.Sources, domain.EventSource(source))
+		}
+		for _, severity := range cfg.SlackSeverities {
+			channel.Severities = append(channel.Severities, domain.Severity(severity))
+		}
+		channels = append(channels, channel)
+	}
+	if cfg.SlackChannelsFile != "" {
+		data, err := os.ReadFile(cfg.SlackChannelsFile)
+		if err != nil {
+			return nil, err
+		}
+		var fileChannels []slackChannelConfig
+		if err := json.Unmarshal(data, &fileChannels); err != nil {
+			return nil, fmt.Errorf("invalid Slack channels file %s: %w", cfg.SlackChannelsFile, err)
+		}
+		for i, channel := range fileChannels {
+			if channel.Name == "" || channel.Webhook == "" {
+				return nil, fmt.Errorf("invalid Slack channels file %s: channel %d requires a name and a webhook", cfg.SlackChannelsFile, i+1)
+			}
+			if err := channel.EventFilter.Validate(); err != nil {
+				return nil, fmt.Errorf("invalid Slack channels file %s: channel %d: %w", cfg.SlackChannelsFile, i+1, err)
+			}
+		}
+		channels = append(channels, fileChannels...)
+	}
+
+	client := &http.Client{Timeout: time.Duration(cfg.SlackTimeout) * time.Second}
+	result := &NotificationChannels{}
+	for _, channel := range channels {
+		if slices.Contains(result.Names(), channel.Name) {
+			return nil, fmt.Errorf("duplicate notification channel %q", channel.Name)
+		}
+		slackChannel, err := notification.NewSlackChannel(notification.SlackConfig{
+			Name:       channel.Name,
+			WebhookURL: channel.Webhook,
+			Template:   channel.Template,
+			MaxRetries: cfg.SlackMaxRetries,
+			RetryDelay: time.Duration(cfg.SlackRetryDelay) * time.Second,
+		}, client)
+		if err != nil {
+			return nil, err
+		}
+		result.Channels = append(result.Channels, slackChannel)
+		if !channel.RulesOnly {
+			result.Routes = append(result.Routes, NotificationRoute{Filter: channel.EventFilter, Channel: slackChannel})
+		}
+	}
+
+	if err := initIncidentChannels(cfg, result); err != nil {
+		return nil, err
+	}
+	if err := initEmailChannel(cfg, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// incidentChannelConfig is an incident management channel, and the filter of its route
+type incidentChannelConfig struct {
+	channel    domain.NotificationChannel
+	sources    []string
+	eventTypes []string
+}
+
+// initIncidentChannels adds the PagerDuty and Opsgenie channels of the configuration to the channels. A channel is
+// routed the events passing its filter, and the recovery events resolving its incidents, when it has a filter,
+// and otherwise only receives the events routed to it by routing rules.
+func initIncidentChannels(cfg *Config, channels *NotificationChannels) error {
+	recoveries, err := parseRecoveries(cfg.IncidentRecoveryEventTypes)
+	if err != nil {
+		return err
+	}
+	client := &http.Client{Timeout: time.Duration(cfg.IncidentTimeout) * time.Second}
+	incidentConfig := func(name, baseURL, key, severity string) notification.IncidentConfig {
+		return notification.IncidentConfig{
+			Name:       name,
+			BaseURL:    baseURL,
+			Key:        key,
+			Severity:   severity,
+			Recoveries: recoveries,
+			MaxRetries: cfg.IncidentMaxRetries,
+			RetryDelay: time.Duration(cfg.IncidentRetryDelay) * time.Second,
+		}
+	}
+
+	incidentChannels := []incidentChannelConfig{}
+	if cfg.PagerDutyRoutingKey != "" {
+		channel := notification.NewPagerDutyChannel(incidentConfig(pagerDutyChannel, cfg.PagerDutyBaseURL, cfg.PagerDutyRoutingKey, cfg.PagerDutySeverity), client)
+		incidentChannels = append(incidentChannels, incidentChannelConfig{channel, cfg.PagerDutySources, cfg.PagerDutyEventTypes})
+	}
+	if cfg.OpsgenieAPIKey != "" {
+		channel := notification.NewOpsgenieChannel(incidentConfig(opsgenieChannel, cfg.OpsgenieBaseURL, cfg.OpsgenieAPIKey, cfg.OpsgeniePriority), client)
+		incidentChannels = append(incidentChannels, incidentChannelConfig{channel, cfg.OpsgenieSources, cfg.OpsgenieEventTypes})
+	}
+
+	for _, incidentChannel := range incidentChannels {
+		if slices.Contains(channels.Names(), incidentChannel.channel.Name()) {
+			return fmt.Errorf("duplicate notification channel %q", incidentChannel.channel.Name())
+		}
+		channels.Channels = append(channels.Channels, incidentChannel.channel)
+		channels.Incidents = append(channels.Incidents, incidentChannel.channel.Name())
+		if len(incidentChannel.sources) == 0 && len(incidentChannel.eventTypes) == 0 {
+			continue
+		}
+
+		filter := domain.EventFilter{}
+		for _, source := range incidentChannel.sources {
+			filter.Sources = append(filter.Sources, domain.EventSource(source))
+		}
+		if len(incidentChannel.eventTypes) > 0 {
+			filter.EventTypes = append(filter.EventTypes, incidentChannel.eventTypes...)
+			for recovery := range recoveries {
+				filter.EventTypes = append(filter.EventTypes, recovery)
+			}
+			slices.Sort(filter.EventTypes[len(incidentChannel.eventTypes):])
+		}
+		channels.Routes = append(channels.Routes, NotificationRoute{Filter: filter, Channel: incidentChannel.channel})
+	}
+	return nil
+}
+
+// initEmailChannel adds the email channel of the configuration, if any, to the channels. It is routed the events
+// passing its filter, e.g. those of high severity types, when it has a filter, and otherwise only receives the
+// events routed to it by routing rules. It sends the digests as well.
+func initEmailChannel(cfg *Config, channels *NotificationChannels) error {
+	if cfg.SMTPHost == "" {
+		return nil
+	}
+	channel, err := notification.NewEmailChannel(notification.EmailConfig{
+		Name: emailChannel,
+		SMTP: notification.SMTPConfig{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			StartTLS: cfg.SMTPStartTLS,
+			Timeout:  time.Duration(cfg.SMTPTimeout) * time.Second,
+		},
+		From:         cfg.EmailFrom,
+		To:           cfg.EmailTo,
+		TemplatesDir: cfg.EmailTemplatesDir,
+		MaxRetries:   cfg.EmailMaxRetries,
+		RetryDelay:   time.Duration(cfg.EmailRetryDelay) * time.Second,
+	})
+	if err != nil {
+		return err
+	}
+	if slices.Contains(channels.Names(), channel.Name()) {
+		return fmt.Errorf("duplicate notification channel %q", channel.Name())
+	}
+
+	channels.Channels = append(channels.Channels, channel)
+	channels.DigestSender = channel
+	if len(cfg.EmailSources) > 0 || len(cfg.EmailEventTypes) > 0 || len(cfg.EmailSeverities) > 0 {
+		filter := domain.EventFilter{EventTypes: cfg.EmailEventTypes}
+		for _, source := range cfg.EmailSources {
+			filter.Sources = append(filter.Sources, domain.EventSource(source))
+		}
+		for _, severity := range cfg.EmailSeverities {
+			filter.Severities = append(filter.Severities, domain.Severity(severity))
+		}
+		channels.Routes = append(channels.Routes, NotificationRoute{Filter: filter, Channel: channel})
+	}
+	return nil
+}
+
+// parseRecoveries parses the RECOVERY_TYPE=INCIDENT_TYPE pairs of the recovery event types
+func parseRecoveries(pairs []string) (map[string]string, error) {
+	recoveries := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		recovery, eventType, ok := strings.Cut(pair, "=")
+		recovery, eventType = strings.TrimSpace(recovery), strings.TrimSpace(eventType)
+		if !ok || recovery == "" || eventType == "" {
+			return nil, fmt.Errorf("invalid recovery event type %q, expecting RECOVERY_TYPE=INCIDENT_TYPE", pair)
+		}
+		recoveries[recovery] = eventType
+	}
+	return recoveries, nil
+}
+
+// initRoutingUsecase initializes the routing use case of the routing rules to the channels
+func initRoutingUsecase(cfg *Config, repo domain.RoutingRuleRepository, channels *NotificationChannels) domain.RoutingUsecase {
+	return usecase.NewRoutingUsecase(repo, channels.Names(), time.Duration(cfg.RoutingRulesRefreshInterval)*time.Second)
+}
+
+// initSilenceUsecase initializes the silence use case of the events not to notify
+func initSilenceUsecase(cfg *Config, repo domain.SilenceRepository) domain.SilenceUsecase {
+	return usecase.NewSilenceUsecase(repo, time.Duration(cfg.SilencesRefreshInterval)*time.Second)
+}
+
+// notificationFlushInterval is the interval between two flushes of the due notifications of the throttle
+const notificationFlushInterval = time.Second
+
+// Notifier is an implementation of the domain.Notifier interface queueing the notifications of the events,
+// and sending them from background workers to the channels of the matching routes and routing rules, through
+// the throttle grouping and rate limiting them. Notifications are dropped rather than blocking the caller
+// when the queue is full, or before the notifier is started, e.g. while importing events.
+type Notifier struct {
+	channels         map[string]domain.NotificationChannel
+	routes           []NotificationRoute
+	routingUsecase   domain.RoutingUsecase
+	throttle         *notificationThrottle // nil if notifications are neither grouped nor rate limited
+	workers          int
+	incidentChannels map[string]bool
+	recoveries       map[string]string // recovery event types resolving incidents, notified even when silenced
+
+	mu      sync.RWMutex
+	running bool
+	queue   chan domain.Event
+
+	ctx       context.Context
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+	stopFlush chan struct{}
+	flushDone chan struct{}
+}
+
+// NewNotifier creates a new Notifier instance for the channels, routing with the routing use case if not nil
+func NewNotifier(config *Config, channels *NotificationChannels, routingUsecase domain.RoutingUsecase, metrics *Metrics) (*Notifier, error) {
+	byName := make(map[string]domain.NotificationChannel, len(channels.Channels))
+	for _, channel := range channels.Channels {
+		byName[channel.Name()] = channel
+	}
+	channelRateLimits, err := parseChannelRateLimits(config.NotificationChannelRateLimits)
+	if err != nil {
+		return nil, err
+	}
+	for channel := range channelRateLimits {
+		if _, ok := byName[channel]; !ok {
+			return nil, fmt.Errorf("rate limit of unknown notification channel %q", channel)
+		}
+	}
+	recoveries, err := parseRecoveries(config.IncidentRecoveryEventTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	incidentChannels := make(map[string]bool, len(channels.Incidents))
+	for _, name := range channels.Incidents {
+		incidentChannels[name] = true
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	n := &Notifier{
+		channels:         byName,
+		routes:           channels.Routes,
+		routingUsecase:   routingUsecase,
+		workers:          config.NotificationWorkers,
+		incidentChannels: incidentChannels,
+		recoveries:       recoveries,
+		queue:            make(chan domain.Event, config.NotificationQueueSize),
+		ctx:              ctx,
+		cancel:           cancel,
+		stopFlush:        make(chan struct{}),
+		flushDone:        make(chan struct{}),
+	}
+	throttleConf := throttleConfig{
+		groupBy:           config.NotificationGroupBy,
+		groupWindow:       time.Duration(config.NotificationGroupWindow) * time.Second,
+		rateLimit:         config.NotificationRateLimit,
+		rateInterval:      time.Duration(config.NotificationRateInterval) * time.Second,
+		channelRateLimits: channelRateLimits,
+		incidentChannels:  channels.Incidents,
+		recoveries:        recoveries,
+	}
+	if throttleConf.enabled() {
+		n.throttle = newNotificationThrottle(throttleConf, func(channel, reason string) {
+			metrics.NotificationsSuppressed.WithLabelValues(channel, reason).Inc()
+		})
+	}
+	return n, nil
+}
+
+// Notify queues the notifications of the event. Silenced events are skipped, except the recovery events
+// sent to the incident channels, so that the incidents opened before the silence still resolve.
+func (n *Notifier) Notify(ctx context.Context, event domain.Event) {
+	if _, ok := n.recoveries[event.EventType]; event.Silenced && !ok {
+		return
+	}
+
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	if !n.running {
+		return
+	}
+
+	select {
+	case n.queue <- event:
+	default:
+		log.Printf("Dropping notifications of event %d, the notification queue is full", event.ID)
+	}
+}
+
+// Start starts the workers sending the notifications, and the flusher of the throttle if any.
+// It does nothing without channels.
+func (n *Notifier) Start() {
+	if len(n.channels) == 0 {
+		return
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.running = true
+	for i := 0; i < n.workers; i++ {
+		n.wg.Add(1)
+		go n.work()
+	}
+	if n.throttle != nil {
+		go n.flushThrottle()
+	} else {
+		close(n.flushDone)
+	}
+}
+
+// Stop stops queueing notifications and waits for the queued ones to be sent, followed by the pending
+// notifications of the throttle, abandoning them once the context is done
+func (n *Notifier) Stop(ctx context.Context) {
+	n.mu.Lock()
+	if !n.running {
+		n.mu.Unlock()
+		return
+	}
+	n.running = false
+	close(n.queue)
+	n.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		n.wg.Wait()
+		close(n.stopFlush)
+		<-n.flushDone
+		if n.throttle != nil {
+			n.sendPending(n.throttle.flush(time.Now(), true))
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		n.cancel()
+		<-done
+	}
+}
+
+// work sends the notifications of the queued events until the queue is closed
+func (n *Notifier) work() {
+	defer n.wg.Done()
+	for event := range n.queue {
+		for _, channel := range n.resolve(event) {
+			if event.Silenced && !n.incidentChannels[channel.Name()] {
+				continue
+			}
+			if n.throttle != nil && !n.throttle.admit(channel.Name(), event, time.Now()) {
+				continue
+			}
+			n.send(channel, event)
+		}
+	}
+}
+
+// flushThrottle sends the due notifications of the throttle at every flush interval until stopped
+func (n *Notifier) flushThrottle() {
+	defer close(n.flushDone)
+	ticker := time.NewTicker(notificationFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			n.sendPending(n.throttle.flush(time.Now(), false))
+		case <-n.stopFlush:
+			return
+		}
+	}
+}
+
+// sendPending sends the notifications of the throttle
+func (n *Notifier) sendPending(notifications []pendingNotification) {
+	for _, notification := range notifications {
+		n.send(n.channels[notification.channel], notification.event)
+	}
+}
+
+// send sends the notification of the event to the channel, logging failures
+func (n *Notifier) send(channel domain.NotificationChannel, event domain.Event) {
+	if err := channel.Send(n.ctx, event); err != nil {
+		log.Printf("Error sending notification of event %d to %s: %v", event.ID, channel.Name(), err)
+	}
+}
+
+// resolve returns the channels of the routes and routing rules matching the event, without duplicates.
+// The routes still apply when the routing rules cannot be loaded.
+func (n *Notifier) resolve(event domain.Event) []domain.NotificationChannel {
+	names := []string{}
+	for _, route := range n.routes {
+		if route.Filter.Matches(event) {
+			names = append(names, route.Channel.Name())
+		}
+	}
+	if n.routingUsecase != nil {
+		result, err := n.routingUsecase.Route(n.ctx, event)
+		if err != nil {
+			log.Printf("Error routing notifications of event %d: %v", event.ID, err)
+		}
+		names = append(names, result.Channels...)
+	}
+
+	channels := []domain.NotificationChannel{}
+	seen := map[string]bool{}
+	for _, name := range names {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		channel, ok := n.channels[name]
+		if !ok {
+			log.Printf("Dropping notification of event %d to unknown channel %s", event.ID, name)
+			continue
+		}
+		channels = append(channels, channel)
+	}
+	return channels
+}
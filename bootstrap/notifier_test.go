@@ -0,0 +1,335 @@
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cvzm/go-web-project/domain"
+	domain_mock "github.com/cvzm/go-web-project/domain/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingChannel is a domain.NotificationChannel recording the sent events
+type recordingChannel struct {
+	name  string
+	delay time.Duration
+
+	mu   sync.Mutex
+	sent []domain.Event
+}
+
+func (c *recordingChannel) Name() string {
+	return c.name
+}
+
+func (c *recordingChannel) Send(ctx context.Context, event domain.Event) error {
+	select {
+	case <-time.After(c.delay):
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sent = append(c.sent, event)
+	return nil
+}
+
+func (c *recordingChannel) eventTypes() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	eventTypes := []string{}
+	for _, event := range c.sent {
+		eventTypes = append(eventTypes, event.EventType)
+	}
+	return eventTypes
+}
+
+// allChannel returns the channel routed every event
+func allChannel(channel domain.NotificationChannel) *NotificationChannels {
+	return &NotificationChannels{Channels: []domain.NotificationChannel{channel}, Routes: []NotificationRoute{{Channel: channel}}}
+}
+
+// newTestNotifier creates a notifier of the channels
+func newTestNotifier(t *testing.T, config *Config, channels *NotificationChannels, routingUsecase domain.RoutingUsecase) *Notifier {
+	t.Helper()
+	notifier, err := NewNotifier(config, channels, routingUsecase, NewMetrics())
+	require.NoError(t, err)
+	return notifier
+}
+
+func TestNotifier(t *testing.T) {
+	config := &Config{NotificationWorkers: 2, NotificationQueueSize: 10}
+
+	t.Run("Sends the events to the channels of the matching routes", func(t *testing.T) {
+		aws := &recordingChannel{name: "aws"}
+		ec2 := &recordingChannel{name: "ec2"}
+		notifier := newTestNotifier(t, config, &NotificationChannels{
+			Channels: []domain.NotificationChannel{aws, ec2},
+			Routes: []NotificationRoute{
+				{Filter: domain.EventFilter{Sources: []domain.EventSource{domain.SourceAWS}}, Channel: aws},
+				{Filter: domain.EventFilter{EventTypes: []string{"EC2_*"}}, Channel: ec2},
+			},
+		}, nil)
+		notifier.Start()
+
+		notifier.Notify(context.Background(), domain.Event{Source: domain.SourceAWS, EventType: "EC2_STARTED"})
+		notifier.Notify(context.Background(), domain.Event{Source: domain.SourceAWS, EventType: "RDS_FAILOVER"})
+		notifier.Notify(context.Background(), domain.Event{Source: domain.SourceGCP, EventType: "VM_STOPPED"})
+		notifier.Stop(context.Background())
+
+		assert.ElementsMatch(t, []string{"EC2_STARTED", "RDS_FAILOVER"}, aws.eventTypes())
+		assert.Equal(t, []string{"EC2_STARTED"}, ec2.eventTypes())
+	})
+
+	t.Run("Sends the events to the channels of the matching routing rules", func(t *testing.T) {
+		infra := &recordingChannel{name: "infra"}
+		security := &recordingChannel{name: "security"}
+		routingUsecase := new(domain_mock.MockRoutingUsecase)
+		notifier := newTestNotifier(t, config, &NotificationChannels{
+			Channels: []domain.NotificationChannel{infra, security},
+			Routes:   []NotificationRoute{{Filter: domain.EventFilter{EventTypes: []string{"EC2_*"}}, Channel: infra}},
+		}, routingUsecase)
+
+		routingUsecase.On("Route", mock.Anything, mock.MatchedBy(func(e domain.Event) bool { return e.EventType == "EC2_STARTED" })).
+			Return(domain.RoutingResult{Channels: []string{"infra", "security", "removed"}}, nil).Once()
+		routingUsecase.On("Route", mock.Anything, mock.MatchedBy(func(e domain.Event) bool { return e.EventType == "IAM_KEY_CREATED" })).
+			Return(domain.RoutingResult{Channels: []string{"security"}}, nil).Once()
+		routingUsecase.On("Route", mock.Anything, mock.MatchedBy(func(e domain.Event) bool { return e.EventType == "EC2_STOPPED" })).
+			Return(domain.RoutingResult{}, errors.New("database unavailable")).Once()
+		notifier.Start()
+
+		notifier.Notify(context.Background(), domain.Event{Source: domain.SourceAWS, EventType: "EC2_STARTED"})
+		notifier.Notify(context.Background(), domain.Event{Source: domain.SourceAWS, EventType: "IAM_KEY_CREATED"})
+		notifier.Notify(context.Background(), domain.Event{Source: domain.SourceAWS, EventType: "EC2_STOPPED"})
+		notifier.Stop(context.Background())
+
+		// Each channel is notified once, and the routes still apply when the rules cannot be loaded
+		assert.ElementsMatch(t, []string{"EC2_STARTED", "EC2_STOPPED"}, infra.eventTypes())
+		assert.ElementsMatch(t, []string{"EC2_STARTED", "IAM_KEY_CREATED"}, security.eventTypes())
+		routingUsecase.AssertExpectations(t)
+	})
+
+	t.Run("Drops notifications when not started", func(t *testing.T) {
+		channel := &recordingChannel{name: "all"}
+		notifier := newTestNotifier(t, config, allChannel(channel), nil)
+
+		notifier.Notify(context.Background(), domain.Event{EventType: "EC2_STARTED"})
+		notifier.Stop(context.Background())
+		assert.Empty(t, channel.eventTypes())
+	})
+
+	t.Run("Drops notifications when the queue is full", func(t *testing.T) {
+		channel := &recordingChannel{name: "slow", delay: 50 * time.Millisecond}
+		notifier := newTestNotifier(t, &Config{NotificationWorkers: 1, NotificationQueueSize: 1}, allChannel(channel), nil)
+		notifier.Start()
+
+		start := time.Now()
+		for i := 0; i < 5; i++ {
+			notifier.Notify(context.Background(), domain.Event{EventType: "EC2_STARTED"})
+		}
+		// Notify never waits for the slow channel
+		assert.Less(t, time.Since(start), 50*time.Millisecond)
+		notifier.Stop(context.Background())
+		assert.LessOrEqual(t, len(channel.eventTypes()), 2)
+	})
+
+	t.Run("Groups and rate limits the notifications", func(t *testing.T) {
+		channel := &recordingChannel{name: "all"}
+		notifier := newTestNotifier(t, &Config{
+			NotificationWorkers:      1,
+			NotificationQueueSize:    10,
+			NotificationGroupBy:      []string{groupBySource, groupByEventType},
+			NotificationGroupWindow:  60,
+			NotificationRateLimit:    2,
+			NotificationRateInterval: 60,
+		}, allChannel(channel), nil)
+		notifier.Start()
+
+		for _, eventType := range []string{"EC2_STOPPED", "EC2_STOPPED", "EC2_STOPPED", "VM_STOPPED", "RDS_FAILOVER"} {
+			notifier.Notify(context.Background(), domain.Event{Source: domain.SourceAWS, EventType: eventType})
+		}
+		notifier.Stop(context.Background())
+
+		// The notification of the pending group exceeds the rate limit as well, and the burst summary is sent when stopping
+		assert.Equal(t, []string{"EC2_STOPPED", "VM_STOPPED", domain.EventTypeNotificationsSuppressed}, channel.eventTypes())
+		assert.Contains(t, channel.sent[2].Description, "2 notification(s) to all suppressed by its rate limit")
+		assert.Contains(t, channel.sent[2].Description, ": 1 AWS EC2_STOPPED, 1 AWS RDS_FAILOVER")
+	})
+
+	t.Run("Sends the recovery events of the throttled incident channels", func(t *testing.T) {
+		channel := &recordingChannel{name: pagerDutyChannel}
+		channels := allChannel(channel)
+		channels.Incidents = []string{pagerDutyChannel}
+		notifier := newTestNotifier(t, &Config{
+			NotificationWorkers:        1,
+			NotificationQueueSize:      10,
+			NotificationGroupBy:        []string{groupBySource},
+			NotificationGroupWindow:    60,
+			NotificationRateLimit:      1,
+			NotificationRateInterval:   60,
+			IncidentRecoveryEventTypes: []string{"EC2_STARTED=EC2_STOPPED"},
+		}, channels, nil)
+		notifier.Start()
+
+		for _, eventType := range []string{"EC2_STOPPED", "EC2_STARTED", "RDS_FAILOVER", "EC2_STARTED"} {
+			notifier.Notify(context.Background(), domain.Event{Source: domain.SourceAWS, EventType: eventType})
+		}
+		notifier.Stop(context.Background())
+
+		// The grouped notification exceeds the rate limit, and no burst summary is sent to the incident channel
+		assert.Equal(t, []string{"EC2_STOPPED", "EC2_STARTED", "EC2_STARTED"}, channel.eventTypes())
+	})
+
+	t.Run("Sends the silenced recovery events to the incident channels only", func(t *testing.T) {
+		slack := &recordingChannel{name: defaultSlackChannel}
+		pagerDuty := &recordingChannel{name: pagerDutyChannel}
+		notifier := newTestNotifier(t, &Config{
+			NotificationWorkers:        1,
+			NotificationQueueSize:      10,
+			IncidentRecoveryEventTypes: []string{"EC2_STARTED=EC2_STOPPED"},
+		}, &NotificationChannels{
+			Channels:  []domain.NotificationChannel{slack, pagerDuty},
+			Routes:    []NotificationRoute{{Channel: slack}, {Channel: pagerDuty}},
+			Incidents: []string{pagerDutyChannel},
+		}, nil)
+		notifier.Start()
+
+		notifier.Notify(context.Background(), domain.Event{Source: domain.SourceAWS, EventType: "EC2_STOPPED"})
+		notifier.Notify(context.Background(), domain.Event{Source: domain.SourceAWS, EventType: "EC2_STOPPED", Silenced: true})
+		notifier.Notify(context.Background(), domain.Event{Source: domain.SourceAWS, EventType: "EC2_STARTED", Silenced: true})
+		notifier.Stop(context.Background())
+
+		assert.Equal(t, []string{"EC2_STOPPED"}, slack.eventTypes())
+		assert.Equal(t, []string{"EC2_STOPPED", "EC2_STARTED"}, pagerDuty.eventTypes())
+	})
+
+	t.Run("Rate limit of an unknown channel", func(t *testing.T) {
+		_, err := NewNotifier(&Config{NotificationChannelRateLimits: []string{"email=5"}}, &NotificationChannels{}, nil, NewMetrics())
+		assert.EqualError(t, err, `rate limit of unknown notification channel "email"`)
+	})
+
+	t.Run("Abandons queued notifications once the stop context is done", func(t *testing.T) {
+		channel := &recordingChannel{name: "slow", delay: time.Hour}
+		notifier := newTestNotifier(t, config, allChannel(channel), nil)
+		notifier.Start()
+		notifier.Notify(context.Background(), domain.Event{EventType: "EC2_STARTED"})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		notifier.Stop(ctx)
+		assert.Empty(t, channel.eventTypes())
+	})
+}
+
+func TestInitNotificationChannels(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "channels.json")
+	require.NoError(t, os.WriteFile(file, []byte(`[
+		{"name": "gcp", "webhook": "https://hooks.slack.com/services/T/B/2", "sources": ["GCP"], "template": "{{.EventType}}"},
+		{"name": "security", "webhook": "https://hooks.slack.com/services/T/B/3", "rules_only": true}
+	]`), 0o600))
+
+	t.Run("Default and file channels", func(t *testing.T) {
+		channels, err := initNotificationChannels(&Config{
+			SlackWebhook:      "https://hooks.slack.com/services/T/B/1",
+			SlackSources:      []string{"AWS"},
+			SlackEventTypes:   []string{"EC2_*"},
+			SlackSeverities:   []string{"error", "critical"},
+			SlackChannelsFile: file,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{defaultSlackChannel, "gcp", "security"}, channels.Names())
+		require.Len(t, channels.Routes, 2)
+		assert.Equal(t, defaultSlackChannel, channels.Routes[0].Channel.Name())
+		assert.Equal(t, domain.EventFilter{
+			Sources:    []domain.EventSource{domain.SourceAWS},
+			EventTypes: []string{"EC2_*"},
+			Severities: []domain.Severity{domain.SeverityError, domain.SeverityCritical},
+		}, channels.Routes[0].Filter)
+		assert.Equal(t, "gcp", channels.Routes[1].Channel.Name())
+		assert.Equal(t, domain.EventFilter{Sources: []domain.EventSource{domain.SourceGCP}}, channels.Routes[1].Filter)
+	})
+
+	t.Run("No channel", func(t *testing.T) {
+		channels, err := initNotificationChannels(&Config{})
+		require.NoError(t, err)
+		assert.Empty(t, channels.Channels)
+		assert.Empty(t, channels.Routes)
+	})
+
+	t.Run("Channel without webhook", func(t *testing.T) {
+		invalid := filepath.Join(t.TempDir(), "channels.json")
+		require.NoError(t, os.WriteFile(invalid, []byte(`[{"name": "gcp"}]`), 0o600))
+		_, err := initNotificationChannels(&Config{SlackChannelsFile: invalid})
+		assert.ErrorContains(t, err, "channel 1 requires a name and a webhook")
+	})
+
+	t.Run("Channel with an invalid severity", func(t *testing.T) {
+		invalid := filepath.Join(t.TempDir(), "channels.json")
+		require.NoError(t, os.WriteFile(invalid, []byte(`[{"name": "gcp", "webhook": "https://hooks.slack.com/services/T/B/2", "severities": ["high"]}]`), 0o600))
+		_, err := initNotificationChannels(&Config{SlackChannelsFile: invalid})
+		assert.ErrorContains(t, err, `channel 1: invalid severity "high"`)
+	})
+
+	t.Run("Duplicate channel", func(t *testing.T) {
+		duplicate := filepath.Join(t.TempDir(), "channels.json")
+		require.NoError(t, os.WriteFile(duplicate, []byte(`[{"name": "default", "webhook": "https://hooks.slack.com/services/T/B/2"}]`), 0o600))
+		_, err := initNotificationChannels(&Config{SlackWebhook: "https://hooks.slack.com/services/T/B/1", SlackChannelsFile: duplicate})
+		assert.EqualError(t, err, `duplicate notification channel "default"`)
+	})
+
+	t.Run("Incident channels", func(t *testing.T) {
+		channels, err := initNotificationChannels(&Config{
+			IncidentRecoveryEventTypes: []string{"EC2_STARTED=EC2_STOPPED", " VM_STARTED = VM_STOPPED "},
+			PagerDutyRoutingKey:        "routing-key",
+			PagerDutySeverity:          "critical",
+			PagerDutyEventTypes:        []string{"EC2_STOPPED", "VM_STOPPED"},
+			OpsgenieAPIKey:             "api-key",
+			OpsgeniePriority:           "P1",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{pagerDutyChannel, opsgenieChannel}, channels.Names())
+		assert.Equal(t, []string{pagerDutyChannel, opsgenieChannel}, channels.Incidents)
+		require.Len(t, channels.Routes, 1)
+		assert.Equal(t, pagerDutyChannel, channels.Routes[0].Channel.Name())
+		assert.Equal(t, domain.EventFilter{EventTypes: []string{"EC2_STOPPED", "VM_STOPPED", "EC2_STARTED", "VM_STARTED"}}, channels.Routes[0].Filter)
+	})
+
+	t.Run("Invalid recovery event type", func(t *testing.T) {
+		_, err := initNotificationChannels(&Config{PagerDutyRoutingKey: "routing-key", IncidentRecoveryEventTypes: []string{"EC2_STARTED"}})
+		assert.ErrorContains(t, err, `invalid recovery event type "EC2_STARTED"`)
+	})
+
+	t.Run("Duplicate incident channel", func(t *testing.T) {
+		duplicate := filepath.Join(t.TempDir(), "channels.json")
+		require.NoError(t, os.WriteFile(duplicate, []byte(`[{"name": "opsgenie", "webhook": "https://hooks.slack.com/services/T/B/2"}]`), 0o600))
+		_, err := initNotificationChannels(&Config{SlackChannelsFile: duplicate, OpsgenieAPIKey: "api-key"})
+		assert.EqualError(t, err, `duplicate notification channel "opsgenie"`)
+	})
+
+	t.Run("Email channel", func(t *testing.T) {
+		channels, err := initNotificationChannels(&Config{
+			SMTPHost:        "localhost",
+			SMTPPort:        1025,
+			EmailFrom:       "events@example.com",
+			EmailTo:         []string{"ops@example.com"},
+			EmailEventTypes: []string{"*_DELETED"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{emailChannel}, channels.Names())
+		assert.NotNil(t, channels.DigestSender)
+		require.Len(t, channels.Routes, 1)
+		assert.Equal(t, domain.EventFilter{EventTypes: []string{"*_DELETED"}}, channels.Routes[0].Filter)
+	})
+
+	t.Run("Invalid email address", func(t *testing.T) {
+		_, err := initNotificationChannels(&Config{SMTPHost: "localhost", SMTPPort: 1025, EmailFrom: "events"})
+		assert.ErrorContains(t, err, `invalid sender address "events"`)
+	})
+}
@@ -0,0 +1,137 @@
+package bootstrap
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/cvzm/go-web-project/adapter/publisher"
+	"github.com/cvzm/go-web-project/domain"
+	"github.com/cvzm/go-web-project/usecase"
+)
+
+// Supported outbox publishers
+const (
+	OutboxPublisherKafka = "kafka"
+	OutboxPublisherSNS   = "sns"
+
+	outboxPurgeInterval = time.Hour // interval between two purges of the expired published messages
+)
+
+// initOutboxPublisher initializes the outbox publisher selected by the configuration, none if not configured
+func initOutboxPublisher(cfg *Config) (domain.OutboxPublisher, error) {
+	switch cfg.OutboxPublisher {
+	case OutboxPublisherKafka:
+		return publisher.NewKafkaPublisher(publisher.KafkaConfig{
+			Brokers: cfg.OutboxKafkaBrokers,
+			Topic:   cfg.OutboxKafkaTopic,
+		})
+	case OutboxPublisherSNS:
+		return publisher.NewSNSPublisher(context.Background(), publisher.SNSConfig{
+			TopicARN:        cfg.OutboxSNSTopicARN,
+			Region:          cfg.OutboxSNSRegion,
+			Endpoint:        cfg.OutboxSNSEndpoint,
+			AccessKeyID:     cfg.OutboxSNSAccessKeyID,
+			SecretAccessKey: cfg.OutboxSNSSecretAccessKey,
+			SessionToken:    cfg.OutboxSNSSessionToken,
+		})
+	}
+	return nil, nil
+}
+
+// initOutboxUsecase initializes the outbox use case with the relay configuration
+func initOutboxUsecase(cfg *Config, repo domain.OutboxRepository, txManager domain.TransactionManager, outboxPublisher domain.OutboxPublisher) domain.OutboxUsecase {
+	return usecase.NewOutboxUsecase(repo, txManager, outboxPublisher, usecase.OutboxConfig{
+		BatchSize:  cfg.OutboxBatchSize,
+		RetryDelay: time.Duration(cfg.OutboxRetryDelay) * time.Second,
+		MaxBackoff: time.Duration(cfg.OutboxMaxBackoff) * time.Second,
+	})
+}
+
+// OutboxRelay polls the outbox and publishes the pending messages, recording the relay metrics
+type OutboxRelay struct {
+	*job
+	config        *Config
+	outboxUsecase domain.OutboxUsecase
+	publisher     domain.OutboxPublisher
+	metrics       *Metrics
+
+	lastPurge time.Time
+}
+
+// NewOutboxRelay creates a new OutboxRelay instance, running at every poll interval once started
+// if the application runs the worker and a publisher is configured
+func NewOutboxRelay(config *Config, outboxUsecase domain.OutboxUsecase, outboxPublisher domain.OutboxPublisher, metrics *Metrics) *OutboxRelay {
+	r := &OutboxRelay{
+		config:        config,
+		outboxUsecase: outboxUsecase,
+		publisher:     outboxPublisher,
+		metrics:       metrics,
+	}
+	var interval time.Duration
+	if config.RunsWorker() && outboxPublisher != nil {
+		interval = time.Duration(config.OutboxPollInterval) * time.Second
+	}
+	r.job = newJob(interval, r.run)
+	return r
+}
+
+// Close releases the publisher, once the relay is stopped or was never started
+func (r *OutboxRelay) Close() error {
+	if r.publisher == nil {
+		return nil
+	}
+	return r.publisher.Close()
+}
+
+// run publishes the pending messages until none is left, then refreshes the lag and purges
+// the expired published messages
+func (r *OutboxRelay) run(ctx context.Context) {
+	// Each relay publishes at most one message per aggregate, the next ones become available once it is marked
+	for ctx.Err() == nil {
+		result, err := r.outboxUsecase.Relay(ctx)
+		if err != nil {
+			log.Printf("Error relaying outbox messages: %v", err)
+			break
+		}
+		r.record(result)
+		if len(result.Published) == 0 {
+			break
+		}
+	}
+
+	stats, err := r.outboxUsecase.Stats(ctx)
+	if err != nil {
+		log.Printf("Error reading outbox stats: %v", err)
+	} else {
+		r.metrics.OutboxPending.Set(float64(stats.Pending))
+		var lag time.Duration
+		if stats.OldestPendingAt != nil {
+			lag = time.Since(*stats.OldestPendingAt)
+		}
+		r.metrics.OutboxRelayLag.Set(lag.Seconds())
+	}
+
+	if r.config.OutboxRetentionDays > 0 && time.Since(r.lastPurge) >= outboxPurgeInterval {
+		r.lastPurge = time.Now()
+		purged, err := r.outboxUsecase.Purge(ctx, time.Now().AddDate(0, 0, -r.config.OutboxRetentionDays))
+		if err != nil {
+			log.Printf("Error purging published outbox messages: %v", err)
+		} else if purged > 0 {
+			log.Printf("Purged %d published outbox message(s)", purged)
+		}
+	}
+}
+
+// record records the metrics of the relay result and logs its failures
+func (r *OutboxRelay) record(result domain.OutboxRelayResult) {
+	for _, message := range result.Published {
+		r.metrics.OutboxPublished.Inc()
+		r.metrics.OutboxPublishDelay.Observe(message.PublishedAt.Sub(message.CreatedAt).Seconds())
+	}
+	for _, message := range result.Failed {
+		r.metrics.OutboxPublishFailures.Inc()
+		log.Printf("Error publishing outbox message %d (attempt %d), retrying at %s: %s",
+			message.ID, message.Attempts, message.AvailableAt.Format(time.RFC3339), message.LastError)
+	}
+}
@@ -0,0 +1,55 @@
+package bootstrap
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cvzm/go-web-project/domain"
+	domain_mock "github.com/cvzm/go-web-project/domain/mock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestOutboxRelay_Run(t *testing.T) {
+	config := &Config{RunMode: RunModeWorker, OutboxPollInterval: 1, OutboxRetentionDays: 7}
+	metrics := NewMetrics()
+	outboxUsecase := new(domain_mock.MockOutboxUsecase)
+	relay := NewOutboxRelay(config, outboxUsecase, new(domain_mock.MockOutboxPublisher), metrics)
+
+	now := time.Now()
+	publishedAt := now.Add(-time.Second)
+	oldestPendingAt := now.Add(-time.Minute)
+	outboxUsecase.On("Relay", mock.Anything).Return(domain.OutboxRelayResult{
+		Published: []domain.OutboxMessage{{ID: 1, CreatedAt: publishedAt.Add(-2 * time.Second), PublishedAt: &publishedAt}},
+		Failed:    []domain.OutboxMessage{{ID: 2, Attempts: 1, AvailableAt: now.Add(time.Second), LastError: "broker unavailable"}},
+	}, nil).Once()
+	outboxUsecase.On("Relay", mock.Anything).Return(domain.OutboxRelayResult{}, nil).Twice()
+	outboxUsecase.On("Stats", mock.Anything).Return(domain.OutboxStats{Pending: 1, OldestPendingAt: &oldestPendingAt}, nil)
+	outboxUsecase.On("Purge", mock.Anything, mock.AnythingOfType("time.Time")).Return(int64(0), nil).Once()
+
+	relay.run(context.Background())
+	// Purged once per purge interval
+	relay.run(context.Background())
+
+	outboxUsecase.AssertNumberOfCalls(t, "Relay", 3)
+	outboxUsecase.AssertNumberOfCalls(t, "Purge", 1)
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.OutboxPublished))
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.OutboxPublishFailures))
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.OutboxPending))
+	assert.InDelta(t, time.Minute.Seconds(), testutil.ToFloat64(metrics.OutboxRelayLag), 5)
+}
+
+func TestNewOutboxRelay(t *testing.T) {
+	t.Run("Disabled without publisher", func(t *testing.T) {
+		relay := NewOutboxRelay(&Config{RunMode: RunModeAll, OutboxPollInterval: 1}, nil, nil, NewMetrics())
+		assert.Zero(t, relay.interval)
+		assert.NoError(t, relay.Close())
+	})
+
+	t.Run("Disabled outside the worker", func(t *testing.T) {
+		relay := NewOutboxRelay(&Config{RunMode: RunModeAPI, OutboxPollInterval: 1}, nil, new(domain_mock.MockOutboxPublisher), NewMetrics())
+		assert.Zero(t, relay.interval)
+	})
+}
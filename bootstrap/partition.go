@@ -0,0 +1,64 @@
+package bootstrap
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/cvzm/go-web-project/adapter/storage"
+
+	"gorm.io/gorm"
+)
+
+// Constants related to the events table partitions
+const (
+	RetentionModeDrop   = "drop"   // expired partitions are detached and dropped
+	RetentionModeDetach = "detach" // expired partitions are detached and kept as standalone tables
+
+	eventPartitionLockID int64 = 4171062140 // advisory lock held while maintaining the partitions
+)
+
+// PartitionMaintainer periodically creates the upcoming monthly partitions of the events table
+// and applies the retention policy to the expired ones
+type PartitionMaintainer struct {
+	*job
+	partitioner *storage.MonthlyPartitioner
+	config      *Config
+}
+
+// NewPartitionMaintainer creates a new PartitionMaintainer instance, running at every check interval once started
+func NewPartitionMaintainer(db *gorm.DB, config *Config) *PartitionMaintainer {
+	m := &PartitionMaintainer{
+		partitioner: storage.NewMonthlyPartitioner(db, "events", "created_at", eventPartitionLockID),
+		config:      config,
+	}
+	m.job = newJob(time.Duration(config.EventPartitionCheckInterval)*time.Second, m.Maintain)
+	return m
+}
+
+// Maintain runs the partition maintenance once and logs its outcome.
+// Runs are skipped while another instance is maintaining the partitions.
+func (m *PartitionMaintainer) Maintain(ctx context.Context) {
+	retention := time.Duration(m.config.EventRetentionDays) * 24 * time.Hour
+	detachOnly := m.config.EventRetentionMode == RetentionModeDetach
+	result, locked, err := m.partitioner.Maintain(ctx, time.Now(), m.config.EventPartitionsAhead, retention, detachOnly)
+	if err != nil {
+		log.Printf("Error maintaining event partitions: %v", err)
+		return
+	}
+	if !locked {
+		return
+	}
+	for _, partition := range result.Created {
+		log.Printf("Created event partition %s", partition.Name)
+	}
+	for _, partition := range result.Detached {
+		log.Printf("Detached expired event partition %s", partition.Name)
+	}
+	for _, partition := range result.Dropped {
+		log.Printf("Dropped expired event partition %s", partition.Name)
+	}
+	if result.Purged > 0 {
+		log.Printf("Deleted %d expired event(s) from the default partition", result.Purged)
+	}
+}
@@ -0,0 +1,128 @@
+package bootstrap
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/cvzm/go-web-project/adapter/queue"
+	"github.com/cvzm/go-web-project/domain"
+	"github.com/cvzm/go-web-project/usecase"
+
+	"gorm.io/gorm"
+)
+
+// Supported queue drivers
+const (
+	QueueDriverSQS      = "sqs"
+	QueueDriverKafka    = "kafka"
+	QueueDriverNATS     = "nats"
+	QueueDriverRedis    = "redis"
+	QueueDriverAMQP     = "amqp"
+	QueueDriverPostgres = "postgres"
+)
+
+// initMessageSource initializes the message source selected by the queue driver.
+// It returns no source when the application does not run the worker.
+func initMessageSource(cfg *Config, db *gorm.DB) (domain.MessageSource, error) {
+	if !cfg.RunsWorker() {
+		return nil, nil
+	}
+
+	switch cfg.QueueDriver {
+	case QueueDriverSQS:
+		return queue.NewSQSSource(context.Background(), queue.SQSConfig{
+			QueueURL:            cfg.SQSQueueURL,
+			Region:              cfg.SQSRegion,
+			MaxNumberOfMessages: cfg.SQSMaxNumberOfMessages,
+			WaitTimeSeconds:     cfg.SQSWaitTimeSeconds,
+			VisibilityTimeout:   cfg.SQSVisibilityTimeout,
+			FIFO:                cfg.SQSFIFO,
+			DeadLetterQueueURL:  cfg.SQSDeadLetterQueueURL,
+			Endpoint:            cfg.SQSEndpoint,
+			AccessKeyID:         cfg.SQSAccessKeyID,
+			SecretAccessKey:     cfg.SQSSecretAccessKey,
+			SessionToken:        cfg.SQSSessionToken,
+			PathStyleQueueURL:   cfg.SQSPathStyleQueueURL,
+		})
+	case QueueDriverKafka:
+		return queue.NewKafkaSource(queue.KafkaConfig{
+			Brokers:        cfg.KafkaBrokers,
+			Topics:         cfg.KafkaTopics,
+			GroupID:        cfg.KafkaGroupID,
+			MaxPollRecords: cfg.KafkaMaxPollRecords,
+		})
+	case QueueDriverNATS:
+		return queue.NewNATSSource(context.Background(), queue.NATSConfig{
+			URL:           cfg.NATSURL,
+			Stream:        cfg.NATSStream,
+			Subject:       cfg.NATSSubject,
+			Durable:       cfg.NATSDurable,
+			MaxMessages:   cfg.NATSMaxMessages,
+			MaxWait:       time.Duration(cfg.NATSMaxWait) * time.Second,
+			AckWait:       time.Duration(cfg.NATSAckWait) * time.Second,
+			MaxDeliveries: cfg.NATSMaxDeliveries,
+		})
+	case QueueDriverRedis:
+		consumerName := cfg.RedisConsumer
+		if consumerName == "" {
+			// Each replica needs its own consumer name within the group
+			hostname, err := os.Hostname()
+			if err != nil {
+				return nil, err
+			}
+			consumerName = hostname
+		}
+		return queue.NewRedisSource(context.Background(), queue.RedisConfig{
+			Addr:             cfg.RedisAddr,
+			Password:         cfg.RedisPassword,
+			DB:               cfg.RedisDB,
+			Stream:           cfg.RedisStream,
+			Group:            cfg.RedisGroup,
+			Consumer:         consumerName,
+			DeadLetterStream: cfg.RedisDeadLetterStream,
+			Count:            cfg.RedisCount,
+			Block:            time.Duration(cfg.RedisBlock) * time.Second,
+			ClaimMinIdle:     time.Duration(cfg.RedisClaimMinIdle) * time.Second,
+			MaxDeliveries:    cfg.RedisMaxDeliveries,
+		})
+	case QueueDriverAMQP:
+		return queue.NewAMQPSource(queue.AMQPConfig{
+			URL:                 cfg.AMQPURL,
+			Queue:               cfg.AMQPQueue,
+			Exchange:            cfg.AMQPExchange,
+			ExchangeType:        cfg.AMQPExchangeType,
+			BindingKeys:         cfg.AMQPBindingKeys,
+			DeadLetterExchange:  cfg.AMQPDeadLetterExchange,
+			Prefetch:            cfg.AMQPPrefetch,
+			ReconnectMinBackoff: time.Duration(cfg.AMQPReconnectMinBackoff) * time.Second,
+			ReconnectMaxBackoff: time.Duration(cfg.AMQPReconnectMaxBackoff) * time.Second,
+		}, queue.DialAMQP)
+	case QueueDriverPostgres:
+		return queue.NewPostgresSource(db, postgresQueueConfig(cfg)), nil
+	default:
+		return nil, fmt.Errorf("unsupported queue driver %q", cfg.QueueDriver)
+	}
+}
+
+// initEventIngester initializes the ingester of the events received by the API. With the Postgres queue driver,
+// the events are durably buffered in the queue and saved by the workers, otherwise they are saved right away.
+func initEventIngester(cfg *Config, db *gorm.DB, eventUsecase domain.EventUsecase) domain.EventIngester {
+	if cfg.QueueDriver == QueueDriverPostgres {
+		return usecase.NewQueueEventIngester(queue.NewPostgresSource(db, postgresQueueConfig(cfg)))
+	}
+	return usecase.NewDirectEventIngester(eventUsecase)
+}
+
+// postgresQueueConfig returns the configuration of the Postgres work queue
+func postgresQueueConfig(cfg *Config) queue.PostgresQueueConfig {
+	return queue.PostgresQueueConfig{
+		Queue:             cfg.PGQueueName,
+		BatchSize:         cfg.PGQueueBatchSize,
+		VisibilityTimeout: time.Duration(cfg.PGQueueVisibilityTimeout) * time.Second,
+		PollInterval:      time.Duration(cfg.PGQueuePollInterval) * time.Second,
+		MaxAttempts:       cfg.PGQueueMaxAttempts,
+		MaxBackoff:        time.Duration(cfg.PGQueueMaxBackoff) * time.Second,
+	}
+}
@@ -0,0 +1,53 @@
+package bootstrap
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/cvzm/go-web-project/adapter/queue"
+	"github.com/cvzm/go-web-project/adapter/storage"
+	"github.com/cvzm/go-web-project/domain"
+	domain_mock "github.com/cvzm/go-web-project/domain/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInitEventIngester(t *testing.T) {
+	event := domain.AWSEvent{AWSEventID: "aws-123", AWSEventType: "EC2_STOPPED", AWSTimestamp: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+
+	t.Run("Saves the events right away", func(t *testing.T) {
+		mockUsecase := new(domain_mock.MockEventUsecase)
+		ingester := initEventIngester(&Config{QueueDriver: QueueDriverSQS}, nil, mockUsecase)
+
+		mockUsecase.On("Save", mock.Anything, event).Return(nil).Once()
+
+		require.NoError(t, ingester.Ingest(context.Background(), event))
+		mockUsecase.AssertExpectations(t)
+	})
+
+	t.Run("Buffers the events in the Postgres queue", func(t *testing.T) {
+		gormDB, sqlMock := storage.GetMockDB(t)
+		mockUsecase := new(domain_mock.MockEventUsecase)
+		ingester := initEventIngester(&Config{QueueDriver: QueueDriverPostgres, PGQueueName: "events"}, gormDB, mockUsecase)
+
+		body := `{"aws_event_id":"aws-123","aws_event_type":"EC2_STOPPED","aws_message":"","aws_timestamp":"2024-05-01T12:00:00Z"}`
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "queue_messages"`)+".*"+regexp.QuoteMeta(`ON CONFLICT DO NOTHING`)).
+			WithArgs("events", body, "", "AWS:aws-123", queue.QueueMessagePending, 0, sqlmock.AnyArg(), "", sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		sqlMock.ExpectCommit()
+
+		require.NoError(t, ingester.Ingest(context.Background(), event))
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+		mockUsecase.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+
+		// The workers decode the buffered event back
+		cloudEvent, err := decodeMessage([]byte(body))
+		require.NoError(t, err)
+		assert.Equal(t, event, cloudEvent)
+	})
+}
@@ -0,0 +1,35 @@
+package bootstrap
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/cvzm/go-web-project/domain"
+	"github.com/cvzm/go-web-project/usecase"
+)
+
+// initEventClassifier initializes the classifier of the events without a provider-native severity, with the
+// rules of the severity rules file if any
+func initEventClassifier(cfg *Config) (domain.EventClassifier, error) {
+	rules := []domain.SeverityRule{}
+	if cfg.SeverityRulesFile != "" {
+		data, err := os.ReadFile(cfg.SeverityRulesFile)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &rules); err != nil {
+			return nil, fmt.Errorf("invalid severity rules file %s: %w", cfg.SeverityRulesFile, err)
+		}
+	}
+
+	defaultSeverity := domain.SeverityInfo
+	if cfg.SeverityDefault != "" {
+		defaultSeverity = domain.Severity(cfg.SeverityDefault)
+	}
+	classifier, err := usecase.NewSeverityClassifier(rules, defaultSeverity)
+	if err != nil {
+		return nil, fmt.Errorf("invalid severity rules file %s: %w", cfg.SeverityRulesFile, err)
+	}
+	return classifier, nil
+}
@@ -0,0 +1,41 @@
+package bootstrap
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cvzm/go-web-project/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInitEventClassifier(t *testing.T) {
+	t.Run("Rules file", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "severity-rules.json")
+		require.NoError(t, os.WriteFile(file, []byte(`[
+			{"severity": "critical", "event_types": ["RDS_*"], "description_regex": "(?i)failed"},
+			{"severity": "warning", "event_types": ["*_STOPPED"]}
+		]`), 0o600))
+
+		classifier, err := initEventClassifier(&Config{SeverityRulesFile: file, SeverityDefault: "info"})
+		require.NoError(t, err)
+		assert.Equal(t, domain.SeverityCritical, classifier.Classify(domain.Event{EventType: "RDS_FAILOVER", Description: "Failover failed"}))
+		assert.Equal(t, domain.SeverityWarning, classifier.Classify(domain.Event{EventType: "EC2_STOPPED"}))
+		assert.Equal(t, domain.SeverityInfo, classifier.Classify(domain.Event{EventType: "EC2_STARTED"}))
+	})
+
+	t.Run("Default severity without rules", func(t *testing.T) {
+		classifier, err := initEventClassifier(&Config{})
+		require.NoError(t, err)
+		assert.Equal(t, domain.SeverityInfo, classifier.Classify(domain.Event{EventType: "EC2_STOPPED"}))
+	})
+
+	t.Run("Invalid rule", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "severity-rules.json")
+		require.NoError(t, os.WriteFile(file, []byte(`[{"severity": "high"}]`), 0o600))
+
+		_, err := initEventClassifier(&Config{SeverityRulesFile: file})
+		assert.EqualError(t, err, `invalid severity rules file `+file+`: severity rule 1: invalid severity "high", expecting info, warning, error or critical`)
+	})
+}
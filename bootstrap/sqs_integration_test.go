@@ -0,0 +1,285 @@
+package bootstrap
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cvzm/go-web-project/domain"
+	domain_mock "github.com/cvzm/go-web-project/domain/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// Paths of the queue and dead-letter queue of fakeSQSServer
+const (
+	fakeQueuePath           = "/000000000000/events"
+	fakeDeadLetterQueuePath = "/000000000000/events-dead"
+)
+
+// fakeSQSMessage is a message stored by fakeSQSServer
+type fakeSQSMessage struct {
+	id            string
+	groupID       string
+	body          string
+	receiptHandle string
+	visibleAt     time.Time
+}
+
+// fakeSQSServer is an in-process SQS server speaking the AWS JSON 1.0 protocol
+type fakeSQSServer struct {
+	*httptest.Server
+
+	mu                sync.Mutex
+	messages          []*fakeSQSMessage
+	receipts          int
+	deleted           []string
+	deadLettered      []string
+	visibilityChanges map[string]int32
+	missingQueue      bool
+	accessKeys        []string
+}
+
+func newFakeSQSServer(t *testing.T) *fakeSQSServer {
+	t.Helper()
+	f := &fakeSQSServer{visibilityChanges: map[string]int32{}}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.handle))
+	t.Cleanup(f.Close)
+	return f
+}
+
+// send adds a message to the queue
+func (f *fakeSQSServer) send(id, body string) {
+	f.sendToGroup(id, "", body)
+}
+
+// sendToGroup adds a message of the FIFO group to the queue
+func (f *fakeSQSServer) sendToGroup(id, groupID, body string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.messages = append(f.messages, &fakeSQSMessage{id: id, groupID: groupID, body: body})
+}
+
+func (f *fakeSQSServer) handle(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// Authorization: AWS4-HMAC-SHA256 Credential=<access key>/<date>/...
+	if _, credential, ok := strings.Cut(r.Header.Get("Authorization"), "Credential="); ok {
+		accessKey, _, _ := strings.Cut(credential, "/")
+		f.accessKeys = append(f.accessKeys, accessKey)
+	}
+
+	var input struct {
+		QueueUrl            string
+		ReceiptHandle       string
+		MaxNumberOfMessages int
+		VisibilityTimeout   int32
+		MessageBody         string
+		MessageGroupId      string
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		f.writeError(w, "InvalidParameterValue", err.Error())
+		return
+	}
+	action := strings.TrimPrefix(r.Header.Get("X-Amz-Target"), "AmazonSQS.")
+	if action == "SendMessage" && input.QueueUrl == f.URL+fakeDeadLetterQueuePath {
+		f.deadLettered = append(f.deadLettered, input.MessageGroupId+":"+input.MessageBody)
+		f.writeJSON(w, map[string]any{"MessageId": fmt.Sprintf("dead-%d", len(f.deadLettered))})
+		return
+	}
+	if f.missingQueue || input.QueueUrl != f.URL+fakeQueuePath {
+		f.writeError(w, "QueueDoesNotExist", "The specified queue does not exist.")
+		return
+	}
+
+	switch action {
+	case "ReceiveMessage":
+		type message struct {
+			MessageId, ReceiptHandle, Body string
+			Attributes                     map[string]string
+		}
+		var received []message
+		now := time.Now()
+		for _, m := range f.messages {
+			if len(received) == input.MaxNumberOfMessages || now.Before(m.visibleAt) {
+				continue
+			}
+			f.receipts++
+			m.receiptHandle = fmt.Sprintf("receipt-%d", f.receipts)
+			m.visibleAt = now.Add(time.Duration(input.VisibilityTimeout) * time.Second)
+			received = append(received, message{MessageId: m.id, ReceiptHandle: m.receiptHandle, Body: m.body,
+				Attributes: map[string]string{"MessageGroupId": m.groupID}})
+		}
+		f.writeJSON(w, map[string]any{"Messages": received})
+	case "DeleteMessage":
+		for i, m := range f.messages {
+			if m.receiptHandle == input.ReceiptHandle {
+				f.messages = append(f.messages[:i], f.messages[i+1:]...)
+				f.deleted = append(f.deleted, m.id)
+				f.writeJSON(w, map[string]any{})
+				return
+			}
+		}
+		f.writeError(w, "ReceiptHandleIsInvalid", "The input receipt handle is invalid.")
+	case "ChangeMessageVisibility":
+		for _, m := range f.messages {
+			if m.receiptHandle == input.ReceiptHandle {
+				m.visibleAt = time.Now().Add(time.Duration(input.VisibilityTimeout) * time.Second)
+				f.visibilityChanges[m.id] = input.VisibilityTimeout
+				f.writeJSON(w, map[string]any{})
+				return
+			}
+		}
+		f.writeError(w, "ReceiptHandleIsInvalid", "The input receipt handle is invalid.")
+	default:
+		f.writeError(w, "UnsupportedOperation", "Unsupported action "+action)
+	}
+}
+
+func (f *fakeSQSServer) writeJSON(w http.ResponseWriter, body any) {
+	w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+	json.NewEncoder(w).Encode(body)
+}
+
+func (f *fakeSQSServer) writeError(w http.ResponseWriter, code, message string) {
+	w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(map[string]string{"__type": "com.amazonaws.sqs#" + code, "message": message})
+}
+
+// newFakeSQSConsumer wires a consumer to the fake server through the SQS driver configuration
+func newFakeSQSConsumer(t *testing.T, server *fakeSQSServer, usecase domain.EventUsecase, overrides ...func(*Config)) (*Consumer, domain.MessageSource) {
+	t.Helper()
+	cfg := &Config{
+		RunMode:                RunModeWorker,
+		QueueDriver:            QueueDriverSQS,
+		SQSQueueURL:            "https://sqs.us-west-1.amazonaws.com" + fakeQueuePath,
+		SQSRegion:              "us-west-1",
+		SQSMaxNumberOfMessages: 10,
+		SQSVisibilityTimeout:   30,
+		SQSEndpoint:            server.URL,
+		SQSAccessKeyID:         "local-key",
+		SQSSecretAccessKey:     "local-secret",
+		SQSPathStyleQueueURL:   true,
+		ConsumerRetryDelay:     0,
+	}
+	for _, override := range overrides {
+		override(cfg)
+	}
+	source, err := initMessageSource(cfg, nil)
+	require.NoError(t, err)
+	return NewConsumer(source, cfg, usecase), source
+}
+
+func TestConsumer_FakeSQSServer(t *testing.T) {
+	server := newFakeSQSServer(t)
+	mockUsecase := new(domain_mock.MockEventUsecase)
+	consumer, source := newFakeSQSConsumer(t, server, mockUsecase)
+
+	server.send("saved", `{"aws_event_type":"EC2_STARTED"}`)
+	server.send("retried", `{"aws_event_type":"EC2_STOPPED"}`)
+	server.send("malformed", `not json`)
+
+	mockUsecase.On("Save", mock.Anything, awsEventOfType("EC2_STARTED")).Return(nil).Once()
+	mockUsecase.On("Save", mock.Anything, awsEventOfType("EC2_STOPPED")).Return(errors.New("database unavailable")).Once()
+
+	messages, err := source.Receive(consumer.ctx)
+	require.NoError(t, err)
+	require.Len(t, messages, 3)
+	consumer.processMessages(messages)
+
+	// The saved message is deleted, the failed one is made visible again,
+	// the malformed one is dropped without dead-letter queue
+	assert.ElementsMatch(t, []string{"saved", "malformed"}, server.deleted)
+	assert.Equal(t, map[string]int32{"retried": 0}, server.visibilityChanges)
+	assert.Contains(t, server.accessKeys, "local-key")
+
+	mockUsecase.On("Save", mock.Anything, awsEventOfType("EC2_STOPPED")).Return(nil).Once()
+	messages, err = source.Receive(consumer.ctx)
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	assert.Equal(t, "retried", messages[0].ID)
+	consumer.processMessages(messages)
+
+	assert.Equal(t, "retried", server.deleted[len(server.deleted)-1])
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestConsumer_FakeSQSServerMalformedInGroup(t *testing.T) {
+	server := newFakeSQSServer(t)
+	mockUsecase := new(domain_mock.MockEventUsecase)
+	consumer, source := newFakeSQSConsumer(t, server, mockUsecase, func(cfg *Config) {
+		cfg.SQSFIFO = true
+		cfg.SQSDeadLetterQueueURL = "https://sqs.us-west-1.amazonaws.com" + fakeDeadLetterQueuePath
+	})
+
+	server.sendToGroup("first", "i-123", `{"aws_event_type":"EC2_STARTED"}`)
+	server.sendToGroup("malformed", "i-123", `not json`)
+	server.sendToGroup("last", "i-123", `{"aws_event_type":"EC2_STOPPED"}`)
+
+	var saved []string
+	mockUsecase.On("Save", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		saved = append(saved, args.Get(1).(domain.AWSEvent).AWSEventType)
+	}).Return(nil).Twice()
+
+	messages, err := source.Receive(consumer.ctx)
+	require.NoError(t, err)
+	require.Len(t, messages, 3)
+	consumer.processMessages(messages)
+
+	// The malformed message is moved to the dead-letter queue and deleted in order, so that it is never
+	// redelivered after its successor
+	assert.Equal(t, []string{"EC2_STARTED", "EC2_STOPPED"}, saved)
+	assert.Equal(t, []string{"first", "malformed", "last"}, server.deleted)
+	assert.Equal(t, []string{"i-123:not json"}, server.deadLettered)
+	mockUsecase.AssertExpectations(t)
+
+	t.Run("Stops the group when the message can't be dead-lettered", func(t *testing.T) {
+		server := newFakeSQSServer(t)
+		mockUsecase := new(domain_mock.MockEventUsecase)
+		consumer, source := newFakeSQSConsumer(t, server, mockUsecase, func(cfg *Config) {
+			cfg.SQSFIFO = true
+			cfg.SQSDeadLetterQueueURL = "https://sqs.us-west-1.amazonaws.com/000000000000/missing"
+		})
+
+		server.sendToGroup("malformed", "i-123", `not json`)
+		server.sendToGroup("last", "i-123", `{"aws_event_type":"EC2_STOPPED"}`)
+
+		messages, err := source.Receive(consumer.ctx)
+		require.NoError(t, err)
+		consumer.processMessages(messages)
+
+		assert.Empty(t, server.deleted)
+		mockUsecase.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+	})
+}
+
+func TestConsumer_FakeSQSServerErrors(t *testing.T) {
+	server := newFakeSQSServer(t)
+	mockUsecase := new(domain_mock.MockEventUsecase)
+	consumer, source := newFakeSQSConsumer(t, server, mockUsecase)
+
+	t.Run("Receive from a missing queue", func(t *testing.T) {
+		server.missingQueue = true
+		defer func() { server.missingQueue = false }()
+
+		_, err := source.Receive(consumer.ctx)
+		assert.ErrorContains(t, err, "QueueDoesNotExist")
+	})
+
+	t.Run("Ack with an expired receipt handle", func(t *testing.T) {
+		err := source.Ack(consumer.ctx, domain.Message{ID: "expired", Handle: "receipt-unknown"})
+		assert.ErrorContains(t, err, "ReceiptHandleIsInvalid")
+
+		err = source.Extend(consumer.ctx, domain.Message{ID: "expired", Handle: "receipt-unknown"}, time.Minute)
+		assert.ErrorContains(t, err, "ReceiptHandleIsInvalid")
+	})
+}
@@ -0,0 +1,310 @@
+package bootstrap
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cvzm/go-web-project/domain"
+)
+
+// Keys grouping the notifications
+const (
+	groupBySource    = "source"
+	groupByEventType = "event_type"
+	groupByResource  = "resource"
+)
+
+// Reasons of suppressed notifications, as recorded by the metrics
+const (
+	notificationGrouped     = "grouped"
+	notificationRateLimited = "rate_limited"
+)
+
+// maxGroupResources is the maximum number of affected resources listed by the notification of a group
+const maxGroupResources = 20
+
+// throttleConfig is notification grouping and rate limiting related configuration
+type throttleConfig struct {
+	groupBy           []string          // keys grouping the notifications
+	groupWindow       time.Duration     // window grouping the notifications following a notified event, none if zero
+	rateLimit         int               // notifications per interval of any channel, unlimited if zero
+	rateInterval      time.Duration     // interval of the rate limits
+	channelRateLimits map[string]int    // rate limits of specific channels, unlimited if zero
+	incidentChannels  []string          // channels triggering incidents, never sent burst summaries
+	recoveries        map[string]string // recovery event types resolving incidents, never grouped nor rate limited
+}
+
+// enabled reports whether notifications may be grouped or rate limited
+func (c throttleConfig) enabled() bool {
+	if c.groupWindow > 0 || c.rateLimit > 0 {
+		return true
+	}
+	for _, limit := range c.channelRateLimits {
+		if limit > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// notificationGroup is the window grouping the notifications of a channel similar to a notified event
+type notificationGroup struct {
+	channel   string
+	notified  time.Time // time the first event was notified
+	closesAt  time.Time
+	first     domain.Event
+	last      domain.Event
+	severity  domain.Severity // highest severity of the grouped events, and of the first one
+	count     int             // events grouped after the first one
+	counts    map[string]int  // grouped events by source and type
+	resources []string
+}
+
+// add groups the event
+func (g *notificationGroup) add(event domain.Event) {
+	g.count++
+	g.last = event
+	if event.Severity.AtLeast(g.severity) {
+		g.severity = event.Severity
+	}
+	g.counts[string(event.Source)+" "+event.EventType]++
+	for _, resource := range event.AffectedResources {
+		if len(g.resources) < maxGroupResources && !slices.Contains(g.resources, resource) {
+			g.resources = append(g.resources, resource)
+		}
+	}
+}
+
+// notification returns the aggregated notification of the grouped events
+func (g *notificationGroup) notification() domain.Event {
+	description := fmt.Sprintf("%d more similar event(s) since %s: %s",
+		g.count, g.notified.UTC().Format(time.RFC3339), formatCounts(g.counts))
+	if g.last.Description != "" {
+		description += ". Latest: " + g.last.Description
+	}
+	return domain.Event{
+		ID:                g.last.ID,
+		Source:            g.first.Source,
+		EventType:         g.first.EventType,
+		Severity:          g.severity,
+		Description:       description,
+		AffectedResources: g.resources,
+		CreatedAt:         g.last.CreatedAt,
+	}
+}
+
+// channelLimit is the rate limit state of a channel, and the burst of notifications it suppresses
+type channelLimit struct {
+	windowStart time.Time
+	sent        int // notifications sent since the start of the window
+
+	suppressed      int
+	firstSuppressed time.Time
+	lastSuppressed  time.Time
+	counts          map[string]int // suppressed notifications by source and type
+}
+
+// pendingNotification is a notification of the throttle, due to a channel
+type pendingNotification struct {
+	channel string
+	event   domain.Event
+}
+
+// notificationThrottle groups the notifications of each channel similar to a notified event within a window, and
+// sends one aggregated notification with counts once the window closes. Channels are sent a limited number of
+// notifications per interval, and a summary of the suppressed ones once a whole interval passes without any,
+// except the incident channels. Recovery events are neither grouped nor rate limited.
+type notificationThrottle struct {
+	conf       throttleConfig
+	suppressed func(channel, reason string) // called for every suppressed notification
+
+	mu     sync.Mutex
+	groups map[string]*notificationGroup
+	limits map[string]*channelLimit
+	due    []pendingNotification // notifications of the groups replaced before being flushed
+}
+
+// newNotificationThrottle creates a new notification throttle, reporting the suppressed notifications
+func newNotificationThrottle(conf throttleConfig, suppressed func(channel, reason string)) *notificationThrottle {
+	return &notificationThrottle{
+		conf:       conf,
+		suppressed: suppressed,
+		groups:     map[string]*notificationGroup{},
+		limits:     map[string]*channelLimit{},
+	}
+}
+
+// admit reports whether the notification of the event is sent to the channel right away, or grouped or suppressed
+func (t *notificationThrottle) admit(channel string, event domain.Event, now time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// Recovery events are sent right away, so that they resolve the incidents of the notified events
+	if _, ok := t.conf.recoveries[event.EventType]; ok {
+		return true
+	}
+	if t.conf.groupWindow > 0 {
+		key := channel + "\x00" + t.groupKey(event)
+		if group, ok := t.groups[key]; ok {
+			if now.Before(group.closesAt) {
+				group.add(event)
+				t.suppressed(channel, notificationGrouped)
+				return false
+			}
+			t.close(group, now)
+		}
+		t.groups[key] = &notificationGroup{
+			channel:  channel,
+			notified: now,
+			closesAt: now.Add(t.conf.groupWindow),
+			first:    event,
+			severity: event.Severity,
+			counts:   map[string]int{},
+		}
+	}
+	return t.allow(channel, event, now)
+}
+
+// flush returns the due notifications: those of the closed groups, and the summaries of the ended bursts.
+// All the pending notifications are due when flushing all, e.g. when stopping.
+func (t *notificationThrottle) flush(now time.Time, all bool) []pendingNotification {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	due := t.due
+	t.due = nil
+	for key, group := range t.groups {
+		if all || !now.Before(group.closesAt) {
+			delete(t.groups, key)
+			t.close(group, now)
+		}
+	}
+	due = append(due, t.due...)
+	t.due = nil
+
+	for channel, limit := range t.limits {
+		if limit.suppressed == 0 || (!all && now.Sub(limit.lastSuppressed) < t.conf.rateInterval) {
+			continue
+		}
+		// Summaries would trigger incidents of their own
+		if !slices.Contains(t.conf.incidentChannels, channel) {
+			due = append(due, pendingNotification{channel: channel, event: t.burstSummary(channel, limit, now)})
+		}
+		limit.suppressed = 0
+		limit.counts = nil
+	}
+	slices.SortStableFunc(due, func(a, b pendingNotification) int { return cmp.Compare(a.channel, b.channel) })
+	return due
+}
+
+// close makes the aggregated notification of the group due, if it grouped any event and the rate limit allows
+func (t *notificationThrottle) close(group *notificationGroup, now time.Time) {
+	if group.count == 0 {
+		return
+	}
+	notification := group.notification()
+	if t.allow(group.channel, notification, now) {
+		t.due = append(t.due, pendingNotification{channel: group.channel, event: notification})
+	}
+}
+
+// allow reports whether the rate limit of the channel allows its notification, counting it in the burst otherwise
+func (t *notificationThrottle) allow(channel string, event domain.Event, now time.Time) bool {
+	rateLimit, ok := t.conf.channelRateLimits[channel]
+	if !ok {
+		rateLimit = t.conf.rateLimit
+	}
+	if rateLimit == 0 {
+		return true
+	}
+
+	limit, ok := t.limits[channel]
+	if !ok {
+		limit = &channelLimit{}
+		t.limits[channel] = limit
+	}
+	if !now.Before(limit.windowStart.Add(t.conf.rateInterval)) {
+		limit.windowStart = now
+		limit.sent = 0
+	}
+	if limit.sent < rateLimit {
+		limit.sent++
+		return true
+	}
+
+	if limit.suppressed == 0 {
+		limit.firstSuppressed = now
+		limit.counts = map[string]int{}
+	}
+	limit.suppressed++
+	limit.lastSuppressed = now
+	limit.counts[string(event.Source)+" "+event.EventType]++
+	t.suppressed(channel, notificationRateLimited)
+	return false
+}
+
+// burstSummary returns the notification summarising the notifications of the channel suppressed by its rate limit
+func (t *notificationThrottle) burstSummary(channel string, limit *channelLimit, now time.Time) domain.Event {
+	return domain.Event{
+		Source:    domain.SourceNotifier,
+		EventType: domain.EventTypeNotificationsSuppressed,
+		Description: fmt.Sprintf("%d notification(s) to %s suppressed by its rate limit from %s to %s: %s",
+			limit.suppressed, channel, limit.firstSuppressed.UTC().Format(time.RFC3339),
+			limit.lastSuppressed.UTC().Format(time.RFC3339), formatCounts(limit.counts)),
+		CreatedAt: now,
+	}
+}
+
+// groupKey returns the key of the group of the event, made of the values of the grouping keys
+func (t *notificationThrottle) groupKey(event domain.Event) string {
+	values := make([]string, 0, len(t.conf.groupBy))
+	for _, key := range t.conf.groupBy {
+		switch key {
+		case groupBySource:
+			values = append(values, string(event.Source))
+		case groupByEventType:
+			values = append(values, event.EventType)
+		case groupByResource:
+			resources := slices.Clone(event.AffectedResources)
+			slices.Sort(resources)
+			values = append(values, strings.Join(resources, ","))
+		}
+	}
+	return strings.Join(values, "\x00")
+}
+
+// formatCounts formats the counts by source and type, by descending count
+func formatCounts(counts map[string]int) string {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, func(a, b string) int {
+		return cmp.Or(cmp.Compare(counts[b], counts[a]), cmp.Compare(a, b))
+	})
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		parts = append(parts, strconv.Itoa(counts[key])+" "+key)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// parseChannelRateLimits parses the CHANNEL=LIMIT pairs of the channel rate limits
+func parseChannelRateLimits(pairs []string) (map[string]int, error) {
+	limits := make(map[string]int, len(pairs))
+	for _, pair := range pairs {
+		channel, value, ok := strings.Cut(pair, "=")
+		channel = strings.TrimSpace(channel)
+		limit, err := strconv.Atoi(strings.TrimSpace(value))
+		if !ok || channel == "" || err != nil || limit < 0 {
+			return nil, fmt.Errorf("invalid channel rate limit %q, expecting CHANNEL=LIMIT", pair)
+		}
+		limits[channel] = limit
+	}
+	return limits, nil
+}
@@ -0,0 +1,175 @@
+package bootstrap
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cvzm/go-web-project/domain"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// suppressedCounts records the notifications suppressed by a throttle, by channel and reason
+type suppressedCounts map[string]int
+
+func (c suppressedCounts) record(channel, reason string) {
+	c[channel+" "+reason]++
+}
+
+func TestNotificationThrottle_Group(t *testing.T) {
+	suppressed := suppressedCounts{}
+	throttle := newNotificationThrottle(throttleConfig{
+		groupBy:     []string{groupBySource, groupByEventType},
+		groupWindow: time.Minute,
+	}, suppressed.record)
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	event := func(id uint, eventType string, resources ...string) domain.Event {
+		return domain.Event{ID: id, Source: domain.SourceAWS, EventType: eventType, Description: "outage", AffectedResources: pq.StringArray(resources)}
+	}
+
+	// The first event of a group is notified right away, the similar ones are grouped
+	assert.True(t, throttle.admit("ops", event(1, "EC2_STOPPED", "i-1"), now))
+	assert.True(t, throttle.admit("ops", event(2, "RDS_FAILOVER"), now))
+	assert.True(t, throttle.admit("security", event(3, "EC2_STOPPED"), now))
+	assert.False(t, throttle.admit("ops", event(4, "EC2_STOPPED", "i-2"), now.Add(10*time.Second)))
+	assert.False(t, throttle.admit("ops", event(5, "EC2_STOPPED", "i-2", "i-3"), now.Add(20*time.Second)))
+	assert.Equal(t, suppressedCounts{"ops grouped": 2}, suppressed)
+
+	assert.Empty(t, throttle.flush(now.Add(59*time.Second), false))
+
+	// Closed windows send one aggregated notification, if they grouped any event
+	due := throttle.flush(now.Add(time.Minute), false)
+	require.Len(t, due, 1)
+	assert.Equal(t, "ops", due[0].channel)
+	assert.Equal(t, domain.Event{
+		ID:                5,
+		Source:            domain.SourceAWS,
+		EventType:         "EC2_STOPPED",
+		Description:       "2 more similar event(s) since 2024-05-01T12:00:00Z: 2 AWS EC2_STOPPED. Latest: outage",
+		AffectedResources: []string{"i-2", "i-3"},
+	}, due[0].event)
+
+	// A new window opens afterwards
+	assert.True(t, throttle.admit("ops", event(6, "EC2_STOPPED"), now.Add(2*time.Minute)))
+}
+
+func TestNotificationThrottle_GroupReplacedBeforeFlush(t *testing.T) {
+	throttle := newNotificationThrottle(throttleConfig{groupBy: []string{groupByResource}, groupWindow: time.Minute}, suppressedCounts{}.record)
+	now := time.Now()
+	event := domain.Event{Source: domain.SourceGCP, EventType: "VM_STOPPED", AffectedResources: pq.StringArray{"vm-2", "vm-1"}}
+
+	assert.True(t, throttle.admit("ops", event, now))
+	event.AffectedResources = pq.StringArray{"vm-1", "vm-2"}
+	assert.False(t, throttle.admit("ops", event, now))
+	assert.True(t, throttle.admit("ops", event, now.Add(time.Minute)))
+
+	// The notification of the replaced group is still due
+	due := throttle.flush(now.Add(time.Minute), false)
+	require.Len(t, due, 1)
+	assert.Contains(t, due[0].event.Description, "1 more similar event(s)")
+}
+
+func TestNotificationThrottle_GroupSeverity(t *testing.T) {
+	throttle := newNotificationThrottle(throttleConfig{groupBy: []string{groupBySource}, groupWindow: time.Minute}, suppressedCounts{}.record)
+	now := time.Now()
+	event := func(severity domain.Severity) domain.Event {
+		return domain.Event{Source: domain.SourceAWS, EventType: "EC2_STOPPED", Severity: severity}
+	}
+
+	assert.True(t, throttle.admit("ops", event(domain.SeverityWarning), now))
+	assert.False(t, throttle.admit("ops", event(domain.SeverityCritical), now))
+	assert.False(t, throttle.admit("ops", event(domain.SeverityInfo), now))
+
+	// The aggregated notification has the highest severity of the group
+	due := throttle.flush(now.Add(time.Minute), false)
+	require.Len(t, due, 1)
+	assert.Equal(t, domain.SeverityCritical, due[0].event.Severity)
+}
+
+func TestNotificationThrottle_RateLimit(t *testing.T) {
+	suppressed := suppressedCounts{}
+	throttle := newNotificationThrottle(throttleConfig{
+		rateLimit:         2,
+		rateInterval:      time.Minute,
+		channelRateLimits: map[string]int{"email": 0},
+	}, suppressed.record)
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	event := domain.Event{Source: domain.SourceAWS, EventType: "EC2_STOPPED"}
+
+	assert.True(t, throttle.admit("ops", event, now))
+	assert.True(t, throttle.admit("ops", event, now))
+	assert.False(t, throttle.admit("ops", event, now.Add(time.Second)))
+	assert.False(t, throttle.admit("ops", event, now.Add(2*time.Second)))
+	// Channels with a limit of zero are unlimited
+	for i := 0; i < 5; i++ {
+		assert.True(t, throttle.admit("email", event, now))
+	}
+	assert.Equal(t, suppressedCounts{"ops rate_limited": 2}, suppressed)
+
+	// The next interval allows notifications again, the burst lasting until an interval without suppression
+	assert.True(t, throttle.admit("ops", event, now.Add(time.Minute)))
+	assert.Empty(t, throttle.flush(now.Add(time.Minute), false))
+
+	due := throttle.flush(now.Add(time.Minute+2*time.Second), false)
+	require.Len(t, due, 1)
+	assert.Equal(t, "ops", due[0].channel)
+	assert.Equal(t, domain.SourceNotifier, due[0].event.Source)
+	assert.Equal(t, domain.EventTypeNotificationsSuppressed, due[0].event.EventType)
+	assert.Equal(t, "2 notification(s) to ops suppressed by its rate limit from 2024-05-01T12:00:01Z to 2024-05-01T12:00:02Z: 2 AWS EC2_STOPPED",
+		due[0].event.Description)
+
+	assert.Empty(t, throttle.flush(now.Add(time.Hour), false))
+}
+
+func TestNotificationThrottle_Incidents(t *testing.T) {
+	suppressed := suppressedCounts{}
+	throttle := newNotificationThrottle(throttleConfig{
+		groupBy:          []string{groupBySource},
+		groupWindow:      time.Minute,
+		rateLimit:        1,
+		rateInterval:     time.Minute,
+		incidentChannels: []string{pagerDutyChannel},
+		recoveries:       map[string]string{"EC2_STARTED": "EC2_STOPPED"},
+	}, suppressed.record)
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	stopped := domain.Event{Source: domain.SourceAWS, EventType: "EC2_STOPPED"}
+	started := domain.Event{Source: domain.SourceAWS, EventType: "EC2_STARTED"}
+
+	// Recovery events are neither grouped nor rate limited, nor do they count in the rate limit
+	for _, channel := range []string{pagerDutyChannel, "ops"} {
+		assert.True(t, throttle.admit(channel, stopped, now))
+		assert.True(t, throttle.admit(channel, started, now))
+		assert.True(t, throttle.admit(channel, started, now))
+		assert.False(t, throttle.admit(channel, stopped, now))
+		assert.False(t, throttle.admit(channel, domain.Event{Source: domain.SourceGCP, EventType: "VM_STOPPED"}, now))
+	}
+	assert.Equal(t, suppressedCounts{"pagerduty grouped": 1, "pagerduty rate_limited": 1, "ops grouped": 1, "ops rate_limited": 1}, suppressed)
+
+	// Only the channels without incidents are sent the summaries of their bursts, those of the incident channels
+	// being discarded
+	due := throttle.flush(now.Add(time.Minute), true)
+	require.Len(t, due, 3)
+	assert.Equal(t, "ops", due[0].channel)
+	assert.Equal(t, "ops", due[1].channel)
+	assert.Equal(t, pagerDutyChannel, due[2].channel)
+	assert.Equal(t, domain.EventTypeNotificationsSuppressed, due[1].event.EventType)
+	assert.Equal(t, "EC2_STOPPED", due[2].event.EventType)
+	assert.Empty(t, throttle.flush(now.Add(time.Hour), true))
+}
+
+func TestThrottleConfig_Enabled(t *testing.T) {
+	assert.False(t, throttleConfig{}.enabled())
+	assert.False(t, throttleConfig{channelRateLimits: map[string]int{"ops": 0}}.enabled())
+	assert.True(t, throttleConfig{groupWindow: time.Minute}.enabled())
+	assert.True(t, throttleConfig{channelRateLimits: map[string]int{"ops": 10}}.enabled())
+}
+
+func TestParseChannelRateLimits(t *testing.T) {
+	limits, err := parseChannelRateLimits([]string{"pagerduty=5", " email = 0 "})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"pagerduty": 5, "email": 0}, limits)
+
+	_, err = parseChannelRateLimits([]string{"pagerduty=-1"})
+	assert.EqualError(t, err, `invalid channel rate limit "pagerduty=-1", expecting CHANNEL=LIMIT`)
+}
@@ -0,0 +1,104 @@
+package bootstrap
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/cvzm/go-web-project/adapter/webhook"
+	"github.com/cvzm/go-web-project/domain"
+	"github.com/cvzm/go-web-project/usecase"
+)
+
+// Results of webhook delivery attempts, as recorded by the metrics
+const (
+	webhookResultSucceeded = "succeeded"
+	webhookResultRetried   = "retried"
+	webhookResultGivenUp   = "given_up"
+
+	webhookPurgeInterval = time.Hour // interval between two purges of the expired finished deliveries
+)
+
+// initWebhookUsecase initializes the webhook use case, sending the deliveries over HTTP with the delivery configuration
+func initWebhookUsecase(cfg *Config, subscriptionRepo domain.WebhookSubscriptionRepository, deliveryRepo domain.WebhookDeliveryRepository, txManager domain.TransactionManager) domain.WebhookUsecase {
+	sender := webhook.NewHTTPSender(&http.Client{Timeout: time.Duration(cfg.WebhookTimeout) * time.Second})
+	return usecase.NewWebhookUsecase(subscriptionRepo, deliveryRepo, txManager, sender, usecase.WebhookConfig{
+		BatchSize:    cfg.WebhookBatchSize,
+		MaxAttempts:  cfg.WebhookMaxAttempts,
+		RetryDelay:   time.Duration(cfg.WebhookRetryDelay) * time.Second,
+		MaxBackoff:   time.Duration(cfg.WebhookMaxBackoff) * time.Second,
+		DisableAfter: cfg.WebhookDisableAfterFailures,
+	})
+}
+
+// WebhookDispatcher polls the due webhook deliveries and sends them, recording the delivery metrics
+type WebhookDispatcher struct {
+	*job
+	config         *Config
+	webhookUsecase domain.WebhookUsecase
+	metrics        *Metrics
+
+	lastPurge time.Time
+}
+
+// NewWebhookDispatcher creates a new WebhookDispatcher instance, running at every poll interval once started
+// if the application runs the worker
+func NewWebhookDispatcher(config *Config, webhookUsecase domain.WebhookUsecase, metrics *Metrics) *WebhookDispatcher {
+	d := &WebhookDispatcher{
+		config:         config,
+		webhookUsecase: webhookUsecase,
+		metrics:        metrics,
+	}
+	var interval time.Duration
+	if config.RunsWorker() {
+		interval = time.Duration(config.WebhookPollInterval) * time.Second
+	}
+	d.job = newJob(interval, d.run)
+	return d
+}
+
+// run sends the due deliveries until none is left, then purges the expired finished deliveries
+func (d *WebhookDispatcher) run(ctx context.Context) {
+	for ctx.Err() == nil {
+		result, err := d.webhookUsecase.Dispatch(ctx)
+		if err != nil {
+			log.Printf("Error dispatching webhook deliveries: %v", err)
+			break
+		}
+		d.record(result)
+		if len(result.Succeeded)+len(result.Failed) == 0 {
+			break
+		}
+	}
+
+	if d.config.WebhookDeliveryRetentionDays > 0 && time.Since(d.lastPurge) >= webhookPurgeInterval {
+		d.lastPurge = time.Now()
+		purged, err := d.webhookUsecase.Purge(ctx, time.Now().AddDate(0, 0, -d.config.WebhookDeliveryRetentionDays))
+		if err != nil {
+			log.Printf("Error purging webhook deliveries: %v", err)
+		} else if purged > 0 {
+			log.Printf("Purged %d webhook delivery(ies)", purged)
+		}
+	}
+}
+
+// record records the metrics of the dispatch result and logs its failures
+func (d *WebhookDispatcher) record(result domain.WebhookDispatchResult) {
+	d.metrics.WebhookDeliveries.WithLabelValues(webhookResultSucceeded).Add(float64(len(result.Succeeded)))
+	for _, delivery := range result.Failed {
+		if delivery.Status == domain.WebhookDeliveryFailed {
+			d.metrics.WebhookDeliveries.WithLabelValues(webhookResultGivenUp).Inc()
+			log.Printf("Giving up webhook delivery %d to subscription %d after %d attempt(s): %s",
+				delivery.ID, delivery.SubscriptionID, delivery.Attempts, delivery.LastError)
+			continue
+		}
+		d.metrics.WebhookDeliveries.WithLabelValues(webhookResultRetried).Inc()
+		log.Printf("Error sending webhook delivery %d to subscription %d (attempt %d), retrying at %s: %s",
+			delivery.ID, delivery.SubscriptionID, delivery.Attempts, delivery.NextAttemptAt.Format(time.RFC3339), delivery.LastError)
+	}
+	for _, subscription := range result.Disabled {
+		d.metrics.WebhookDisabledSubscriptions.Inc()
+		log.Printf("Disabled webhook subscription %d (%s): %s", subscription.ID, subscription.Name, subscription.DisabledReason)
+	}
+}
@@ -0,0 +1,48 @@
+package bootstrap
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cvzm/go-web-project/domain"
+	domain_mock "github.com/cvzm/go-web-project/domain/mock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestWebhookDispatcher_Run(t *testing.T) {
+	config := &Config{RunMode: RunModeWorker, WebhookPollInterval: 1, WebhookDeliveryRetentionDays: 30}
+	metrics := NewMetrics()
+	webhookUsecase := new(domain_mock.MockWebhookUsecase)
+	dispatcher := NewWebhookDispatcher(config, webhookUsecase, metrics)
+
+	webhookUsecase.On("Dispatch", mock.Anything).Return(domain.WebhookDispatchResult{
+		Succeeded: []domain.WebhookDelivery{{ID: 1, Status: domain.WebhookDeliverySucceeded}},
+		Failed: []domain.WebhookDelivery{
+			{ID: 2, Status: domain.WebhookDeliveryPending, Attempts: 1, NextAttemptAt: time.Now().Add(time.Second)},
+			{ID: 3, Status: domain.WebhookDeliveryFailed, Attempts: 10},
+		},
+		Disabled: []domain.WebhookSubscription{{ID: 4, Name: "billing", Disabled: true}},
+	}, nil).Once()
+	webhookUsecase.On("Dispatch", mock.Anything).Return(domain.WebhookDispatchResult{}, nil).Twice()
+	webhookUsecase.On("Purge", mock.Anything, mock.AnythingOfType("time.Time")).Return(int64(3), nil).Once()
+
+	dispatcher.run(context.Background())
+	// Purged once per purge interval
+	dispatcher.run(context.Background())
+
+	webhookUsecase.AssertNumberOfCalls(t, "Dispatch", 3)
+	webhookUsecase.AssertNumberOfCalls(t, "Purge", 1)
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.WebhookDeliveries.WithLabelValues(webhookResultSucceeded)))
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.WebhookDeliveries.WithLabelValues(webhookResultRetried)))
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.WebhookDeliveries.WithLabelValues(webhookResultGivenUp)))
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.WebhookDisabledSubscriptions))
+}
+
+func TestNewWebhookDispatcher(t *testing.T) {
+	// Only workers dispatch the deliveries
+	dispatcher := NewWebhookDispatcher(&Config{RunMode: RunModeAPI, WebhookPollInterval: 1}, nil, NewMetrics())
+	assert.Zero(t, dispatcher.interval)
+}
@@ -5,35 +5,81 @@ package bootstrap
 
 import (
 	"github.com/cvzm/go-web-project/api"
+	"github.com/cvzm/go-web-project/domain"
 	"github.com/cvzm/go-web-project/repository"
 	"github.com/cvzm/go-web-project/usecase"
 
 	"github.com/google/wire"
 )
 
-// InitializeApp initializes the application using the Wire framework
-func InitializeApp() (*App, error) {
+// InitializeApp initializes the application using the Wire framework, in the given run mode if not empty
+func InitializeApp(runMode string) (*App, error) {
 	wire.Build(
 		// Initialize configuration
 		NewConfig,
 
-		// Initialize database connection
+		// Initialize database connection and schema migrator
 		initDatabase,
+		initMigrator,
 
-		// Initialize SQS consumer
-		initSQSConsumer,
+		// Create events partition maintenance and archival jobs
+		NewPartitionMaintainer,
+		initArchiveStore,
+		repository.NewEventArchiveRepository,
+		usecase.NewArchiveUsecase,
+		NewEventArchiver,
+
+		// Initialize message source and consumer
+		initMessageSource,
+		NewConsumer,
 
 		// Create API server instance
 		api.NewServer,
 
-		// Create event repository instance
-		repository.NewEventRepository,
+		// Create transaction manager and outbox relay instances
+		repository.NewTransactionManager,
+		repository.NewOutboxRepository,
+		initOutboxPublisher,
+		initOutboxUsecase,
+		NewMetrics,
+		NewMetricsServer,
+		NewOutboxRelay,
+
+		// Create webhook delivery instances
+		repository.NewWebhookSubscriptionRepository,
+		repository.NewWebhookDeliveryRepository,
+		initWebhookUsecase,
+		NewWebhookDispatcher,
 
-		// Create event usecase instance
+		// Create notification routing, silences, notifier, classifier and event usecase instances
+		initNotificationChannels,
+		repository.NewRoutingRuleRepository,
+		initRoutingUsecase,
+		repository.NewSilenceRepository,
+		initSilenceUsecase,
+		NewNotifier,
+		wire.Bind(new(domain.Notifier), new(*Notifier)),
+		repository.NewEventRepository,
+		repository.NewEventChangeRepository,
+		initEventClassifier,
 		usecase.NewEventUsecase,
+		initEventIngester,
+
+		// Create event comments and annotations instances
+		repository.NewEventCommentRepository,
+		repository.NewEventAnnotationRepository,
+		usecase.NewCommentUsecase,
 
-		// Create event controller instance
+		// Create email digest instances
+		initDigestUsecase,
+		NewDigestMailer,
+
+		// Create controller instances
 		api.NewEventController,
+		api.NewCommentController,
+		api.NewRoutingRuleController,
+		api.NewSilenceController,
+		api.NewWebhookController,
 
 		// Create and return App instance
 		NewApp,
@@ -42,3 +88,78 @@ func InitializeApp() (*App, error) {
 	// wire.NewSet(NewConfig, NewEventUsecase, SetupSQSConsumer)
 	return &App{}, nil
 }
+
+// InitializeMigrationApp initializes the part of the application migrating the database schema, validating only
+// the database configuration
+func InitializeMigrationApp() (*App, error) {
+	wire.Build(
+		NewDBConfig,
+		initDatabase,
+		initMigrator,
+		newMigrationApp,
+	)
+	return &App{}, nil
+}
+
+// InitializeArchiveApp initializes the part of the application archiving and restoring events, validating only
+// the database and archival configuration
+func InitializeArchiveApp() (*App, error) {
+	wire.Build(
+		NewArchiveConfig,
+		initDatabase,
+		initArchiveStore,
+		repository.NewEventArchiveRepository,
+		usecase.NewArchiveUsecase,
+		NewEventArchiver,
+		newArchiveApp,
+	)
+	return &App{}, nil
+}
+
+// InitializeReplayApp initializes the part of the application replaying dead-lettered messages, validating only
+// the database and worker configuration
+func InitializeReplayApp() (*App, error) {
+	wire.Build(
+		NewReplayConfig,
+		initDatabase,
+		initMessageSource,
+		newReplayApp,
+	)
+	return &App{}, nil
+}
+
+// InitializeImportApp initializes the part of the application saving events, validating neither the server nor
+// the consumer configuration
+func InitializeImportApp() (*App, error) {
+	wire.Build(
+		NewImportConfig,
+		initDatabase,
+
+		// Create the outbox and webhook instances recording the saved events
+		repository.NewTransactionManager,
+		repository.NewOutboxRepository,
+		initOutboxPublisher,
+		initOutboxUsecase,
+		NewMetrics,
+		NewOutboxRelay,
+		repository.NewWebhookSubscriptionRepository,
+		repository.NewWebhookDeliveryRepository,
+		initWebhookUsecase,
+
+		// Create the notifier, left stopped, silences, classifier and event usecase instances
+		initNotificationChannels,
+		repository.NewRoutingRuleRepository,
+		initRoutingUsecase,
+		repository.NewSilenceRepository,
+		initSilenceUsecase,
+		NewNotifier,
+		wire.Bind(new(domain.Notifier), new(*Notifier)),
+		repository.NewEventRepository,
+		repository.NewEventChangeRepository,
+		initEventClassifier,
+		usecase.NewEventUsecase,
+
+		newImportApp,
+	)
+	return &App{}, nil
+}
@@ -14,9 +14,9 @@ import (
 
 // Injectors from wire.go:
 
-// InitializeApp initializes the application using the Wire framework
-func InitializeApp() (*App, error) {
-	config, err := NewConfig()
+// InitializeApp initializes the application using the Wire framework, in the given run mode if not empty
+func InitializeApp(runMode string) (*App, error) {
+	config, err := NewConfig(runMode)
 	if err != nil {
 		return nil, err
 	}
@@ -24,14 +24,174 @@ func InitializeApp() (*App, error) {
 	if err != nil {
 		return nil, err
 	}
+	migrator, err := initMigrator(db)
+	if err != nil {
+		return nil, err
+	}
+	partitionMaintainer := NewPartitionMaintainer(db, config)
+	archiveStore, err := initArchiveStore(config)
+	if err != nil {
+		return nil, err
+	}
+	eventArchiveRepository := repository.NewEventArchiveRepository(db)
+	archiveUsecase := usecase.NewArchiveUsecase(eventArchiveRepository, archiveStore)
+	eventArchiver := NewEventArchiver(db, config, archiveUsecase)
 	echo := api.NewServer()
+	messageSource, err := initMessageSource(config, db)
+	if err != nil {
+		return nil, err
+	}
+	transactionManager := repository.NewTransactionManager(db)
+	outboxRepository := repository.NewOutboxRepository(db)
+	outboxPublisher, err := initOutboxPublisher(config)
+	if err != nil {
+		return nil, err
+	}
+	outboxUsecase := initOutboxUsecase(config, outboxRepository, transactionManager, outboxPublisher)
+	webhookSubscriptionRepository := repository.NewWebhookSubscriptionRepository(db)
+	webhookDeliveryRepository := repository.NewWebhookDeliveryRepository(db)
+	webhookUsecase := initWebhookUsecase(config, webhookSubscriptionRepository, webhookDeliveryRepository, transactionManager)
+	notificationChannels, err := initNotificationChannels(config)
+	if err != nil {
+		return nil, err
+	}
+	routingRuleRepository := repository.NewRoutingRuleRepository(db)
+	routingUsecase := initRoutingUsecase(config, routingRuleRepository, notificationChannels)
+	metrics := NewMetrics()
+	notifier, err := NewNotifier(config, notificationChannels, routingUsecase, metrics)
+	if err != nil {
+		return nil, err
+	}
+	silenceRepository := repository.NewSilenceRepository(db)
+	silenceUsecase := initSilenceUsecase(config, silenceRepository)
+	eventRepository := repository.NewEventRepository(db)
+	eventChangeRepository := repository.NewEventChangeRepository(db)
+	eventClassifier, err := initEventClassifier(config)
+	if err != nil {
+		return nil, err
+	}
+	eventUsecase := usecase.NewEventUsecase(eventRepository, eventChangeRepository, transactionManager, outboxUsecase, webhookUsecase, silenceUsecase, eventClassifier, notifier)
+	consumer := NewConsumer(messageSource, config, eventUsecase)
+	outboxRelay := NewOutboxRelay(config, outboxUsecase, outboxPublisher, metrics)
+	webhookDispatcher := NewWebhookDispatcher(config, webhookUsecase, metrics)
+	digestUsecase := initDigestUsecase(config, eventRepository, transactionManager, notificationChannels)
+	digestMailer := NewDigestMailer(config, digestUsecase, notificationChannels)
+	metricsServer := NewMetricsServer(config, metrics)
+	eventIngester := initEventIngester(config, db, eventUsecase)
+	eventController := api.NewEventController(eventUsecase, eventIngester)
+	eventCommentRepository := repository.NewEventCommentRepository(db)
+	eventAnnotationRepository := repository.NewEventAnnotationRepository(db)
+	commentUsecase := usecase.NewCommentUsecase(eventRepository, eventCommentRepository, eventAnnotationRepository)
+	commentController := api.NewCommentController(commentUsecase)
+	routingRuleController := api.NewRoutingRuleController(routingUsecase)
+	silenceController := api.NewSilenceController(silenceUsecase)
+	webhookController := api.NewWebhookController(webhookUsecase)
+	app := NewApp(config, db, echo, consumer, migrator, partitionMaintainer, eventArchiver, outboxRelay, webhookDispatcher, digestMailer, metricsServer, notifier, eventUsecase, archiveUsecase, eventController, commentController, routingRuleController, silenceController, webhookController)
+	return app, nil
+}
+
+// InitializeMigrationApp initializes the part of the application migrating the database schema, validating only
+// the database configuration
+func InitializeMigrationApp() (*App, error) {
+	config, err := NewDBConfig()
+	if err != nil {
+		return nil, err
+	}
+	db, err := initDatabase(config)
+	if err != nil {
+		return nil, err
+	}
+	migrator, err := initMigrator(db)
+	if err != nil {
+		return nil, err
+	}
+	app := newMigrationApp(config, db, migrator)
+	return app, nil
+}
+
+// InitializeArchiveApp initializes the part of the application archiving and restoring events, validating only
+// the database and archival configuration
+func InitializeArchiveApp() (*App, error) {
+	config, err := NewArchiveConfig()
+	if err != nil {
+		return nil, err
+	}
+	db, err := initDatabase(config)
+	if err != nil {
+		return nil, err
+	}
+	archiveStore, err := initArchiveStore(config)
+	if err != nil {
+		return nil, err
+	}
+	eventArchiveRepository := repository.NewEventArchiveRepository(db)
+	archiveUsecase := usecase.NewArchiveUsecase(eventArchiveRepository, archiveStore)
+	eventArchiver := NewEventArchiver(db, config, archiveUsecase)
+	app := newArchiveApp(config, db, eventArchiver, archiveUsecase)
+	return app, nil
+}
+
+// InitializeReplayApp initializes the part of the application replaying dead-lettered messages, validating only
+// the database and worker configuration
+func InitializeReplayApp() (*App, error) {
+	config, err := NewReplayConfig()
+	if err != nil {
+		return nil, err
+	}
+	db, err := initDatabase(config)
+	if err != nil {
+		return nil, err
+	}
+	messageSource, err := initMessageSource(config, db)
+	if err != nil {
+		return nil, err
+	}
+	app := newReplayApp(config, db, messageSource)
+	return app, nil
+}
+
+// InitializeImportApp initializes the part of the application saving events, validating neither the server nor
+// the consumer configuration
+func InitializeImportApp() (*App, error) {
+	config, err := NewImportConfig()
+	if err != nil {
+		return nil, err
+	}
+	db, err := initDatabase(config)
+	if err != nil {
+		return nil, err
+	}
+	transactionManager := repository.NewTransactionManager(db)
+	outboxRepository := repository.NewOutboxRepository(db)
+	outboxPublisher, err := initOutboxPublisher(config)
+	if err != nil {
+		return nil, err
+	}
+	outboxUsecase := initOutboxUsecase(config, outboxRepository, transactionManager, outboxPublisher)
+	metrics := NewMetrics()
+	outboxRelay := NewOutboxRelay(config, outboxUsecase, outboxPublisher, metrics)
+	webhookSubscriptionRepository := repository.NewWebhookSubscriptionRepository(db)
+	webhookDeliveryRepository := repository.NewWebhookDeliveryRepository(db)
+	webhookUsecase := initWebhookUsecase(config, webhookSubscriptionRepository, webhookDeliveryRepository, transactionManager)
+	notificationChannels, err := initNotificationChannels(config)
+	if err != nil {
+		return nil, err
+	}
+	routingRuleRepository := repository.NewRoutingRuleRepository(db)
+	routingUsecase := initRoutingUsecase(config, routingRuleRepository, notificationChannels)
+	notifier, err := NewNotifier(config, notificationChannels, routingUsecase, metrics)
+	if err != nil {
+		return nil, err
+	}
+	silenceRepository := repository.NewSilenceRepository(db)
+	silenceUsecase := initSilenceUsecase(config, silenceRepository)
 	eventRepository := repository.NewEventRepository(db)
-	eventUsecase := usecase.NewEventUsecase(eventRepository)
-	sqsConsumer, err := initSQSConsumer(config, eventUsecase)
+	eventChangeRepository := repository.NewEventChangeRepository(db)
+	eventClassifier, err := initEventClassifier(config)
 	if err != nil {
 		return nil, err
 	}
-	eventController := api.NewEventController(eventUsecase)
-	app := NewApp(config, db, echo, sqsConsumer, eventController)
+	eventUsecase := usecase.NewEventUsecase(eventRepository, eventChangeRepository, transactionManager, outboxUsecase, webhookUsecase, silenceUsecase, eventClassifier, notifier)
+	app := newImportApp(config, db, outboxRelay, eventUsecase)
 	return app, nil
 }
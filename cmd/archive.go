@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"github.com/cvzm/go-web-project/bootstrap"
+
+	"github.com/spf13/cobra"
+)
+
+var archiveCmd = &cobra.Command{
+	Use:   "archive",
+	Short: "Move aged events to the archive store",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		days, err := cmd.Flags().GetInt("older-than")
+		if err != nil {
+			return err
+		}
+
+		app, err := bootstrap.InitializeArchiveApp()
+		if err != nil {
+			return err
+		}
+		defer app.Close()
+
+		manifests, err := app.ArchiveEvents(cmd.Context(), days)
+		for _, manifest := range manifests {
+			cmd.Printf("Archived %d %s event(s) of %s to %s\n", manifest.Count, manifest.Source, manifest.Date, manifest.Key)
+		}
+		return err
+	},
+}
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore manifest-key...",
+	Short: "Load archived events back from their manifests",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		app, err := bootstrap.InitializeArchiveApp()
+		if err != nil {
+			return err
+		}
+		defer app.Close()
+
+		for _, key := range args {
+			restored, err := app.RestoreArchive(cmd.Context(), key)
+			if err != nil {
+				return err
+			}
+			cmd.Printf("Restored %d event(s) from %s\n", restored, key)
+		}
+		return nil
+	},
+}
+
+func init() {
+	archiveCmd.Flags().Int("older-than", 0, "age in days of the events to archive, ARCHIVE_AFTER_DAYS if zero")
+	rootCmd.AddCommand(archiveCmd, restoreCmd)
+}
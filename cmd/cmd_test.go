@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cvzm/go-web-project/adapter/storage"
+	"github.com/cvzm/go-web-project/bootstrap"
+	"github.com/cvzm/go-web-project/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// inTempDir runs the test in a temporary directory holding the given env file
+func inTempDir(t *testing.T, env string) {
+	t.Helper()
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, bootstrap.EnvFile), []byte(env), 0o600))
+
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+// execute runs the root command with the arguments and returns its output
+func execute(args ...string) (string, error) {
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	rootCmd.SetErr(&out)
+	rootCmd.SetArgs(args)
+	err := rootCmd.Execute()
+	return out.String(), err
+}
+
+func TestConfigCheck(t *testing.T) {
+	t.Run("Valid configuration", func(t *testing.T) {
+		inTempDir(t, "RUN_MODE=api\nSERVER_PORT=8080\nDB_DSN=postgres://localhost/events\n")
+
+		out, err := execute("config", "check")
+		assert.NoError(t, err)
+		assert.Contains(t, out, "Configuration is valid (run mode: api)")
+	})
+
+	t.Run("Invalid configuration", func(t *testing.T) {
+		inTempDir(t, "RUN_MODE=worker\nDB_DSN=postgres://localhost/events\nQUEUE_DRIVER=sqs\n")
+
+		_, err := execute("config", "check")
+		assert.ErrorContains(t, err, "SQSQueueURL")
+	})
+}
+
+func TestParseEventSource(t *testing.T) {
+	source, err := parseEventSource("gcp")
+	assert.NoError(t, err)
+	assert.Equal(t, domain.SourceGCP, source)
+
+	_, err = parseEventSource("Azure")
+	assert.Error(t, err)
+}
+
+func TestCommands(t *testing.T) {
+	names := []string{}
+	for _, c := range rootCmd.Commands() {
+		names = append(names, c.Name())
+	}
+	for _, name := range []string{"serve", "worker", "migrate", "replay", "import", "archive", "restore", "config"} {
+		assert.Contains(t, names, name)
+	}
+}
+
+func TestMigrateCommands(t *testing.T) {
+	names := []string{}
+	for _, c := range migrateCmd.Commands() {
+		names = append(names, c.Name())
+	}
+	assert.ElementsMatch(t, []string{"up", "down", "status"}, names)
+}
+
+func TestFormatMigrationStatus(t *testing.T) {
+	migration := storage.Migration{Version: 3, Name: "index_events"}
+	assert.Equal(t, "0003_index_events\tpending", formatMigrationStatus(storage.MigrationStatus{Migration: migration}))
+
+	appliedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	assert.Equal(t, "0003_index_events\tapplied 2024-05-01T12:00:00Z",
+		formatMigrationStatus(storage.MigrationStatus{Migration: migration, AppliedAt: &appliedAt}))
+}
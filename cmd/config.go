@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"github.com/cvzm/go-web-project/bootstrap"
+
+	"github.com/spf13/cobra"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect the configuration",
+}
+
+var configCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Load and validate the configuration without connecting to any service",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config, err := bootstrap.NewConfig("")
+		if err != nil {
+			return err
+		}
+		cmd.Printf("Configuration is valid (run mode: %s", config.RunMode)
+		if config.RunsWorker() {
+			cmd.Printf(", queue driver: %s", config.QueueDriver)
+		}
+		cmd.Println(")")
+		return nil
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configCheckCmd)
+	rootCmd.AddCommand(configCmd)
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/cvzm/go-web-project/bootstrap"
+	"github.com/cvzm/go-web-project/domain"
+
+	"github.com/spf13/cobra"
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import [file...]",
+	Short: "Import events from NDJSON files, or from the standard input",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		source, err := cmd.Flags().GetString("source")
+		if err != nil {
+			return err
+		}
+		eventSource, err := parseEventSource(source)
+		if err != nil {
+			return err
+		}
+
+		app, err := bootstrap.InitializeImportApp()
+		if err != nil {
+			return err
+		}
+		defer app.Close()
+
+		if len(args) == 0 {
+			args = []string{"-"}
+		}
+		for _, path := range args {
+			imported, err := importFile(cmd, app, path, eventSource)
+			cmd.Printf("Imported %d event(s) from %s\n", imported, path)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
+func init() {
+	importCmd.Flags().String("source", string(domain.SourceAWS), "source of the events (AWS or GCP)")
+	rootCmd.AddCommand(importCmd)
+}
+
+// importFile imports the events of a file, "-" being the standard input
+func importFile(cmd *cobra.Command, app *bootstrap.App, path string, source domain.EventSource) (int, error) {
+	var r io.Reader = cmd.InOrStdin()
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return 0, err
+		}
+		defer f.Close()
+		r = f
+	}
+	return app.ImportEvents(cmd.Context(), r, source)
+}
+
+// parseEventSource returns the event source matching the name, case-insensitively
+func parseEventSource(name string) (domain.EventSource, error) {
+	for _, source := range []domain.EventSource{domain.SourceAWS, domain.SourceGCP} {
+		if strings.EqualFold(name, string(source)) {
+			return source, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported event source %q, expected AWS or GCP", name)
+}
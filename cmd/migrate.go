@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cvzm/go-web-project/adapter/storage"
+	"github.com/cvzm/go-web-project/bootstrap"
+
+	"github.com/spf13/cobra"
+)
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Manage the versioned migrations of the database schema",
+}
+
+var migrateUpCmd = &cobra.Command{
+	Use:   "up",
+	Short: "Apply pending migrations",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runMigration(cmd, "Applied", (*storage.Migrator).Up)
+	},
+}
+
+var migrateDownCmd = &cobra.Command{
+	Use:   "down",
+	Short: "Revert applied migrations, the last one by default",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runMigration(cmd, "Reverted", (*storage.Migrator).Down)
+	},
+}
+
+var migrateStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show applied and pending migrations",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		app, err := bootstrap.InitializeMigrationApp()
+		if err != nil {
+			return err
+		}
+		defer app.Close()
+
+		statuses, err := app.Migrator().Status(cmd.Context())
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			cmd.Println(formatMigrationStatus(status))
+		}
+		return nil
+	},
+}
+
+// runMigration runs the migrate function with the steps flag and prints the migrations it applied or reverted
+func runMigration(cmd *cobra.Command, verb string, migrate func(*storage.Migrator, context.Context, int) ([]storage.Migration, error)) error {
+	steps, err := cmd.Flags().GetInt("steps")
+	if err != nil {
+		return err
+	}
+
+	app, err := bootstrap.InitializeMigrationApp()
+	if err != nil {
+		return err
+	}
+	defer app.Close()
+
+	migrations, err := migrate(app.Migrator(), cmd.Context(), steps)
+	for _, migration := range migrations {
+		cmd.Printf("%s %04d_%s\n", verb, migration.Version, migration.Name)
+	}
+	if err != nil {
+		return err
+	}
+	if len(migrations) == 0 {
+		cmd.Println("No migration to run")
+	}
+	return nil
+}
+
+// formatMigrationStatus formats a migration status as a line of the status command output
+func formatMigrationStatus(status storage.MigrationStatus) string {
+	state := "pending"
+	if status.AppliedAt != nil {
+		state = "applied " + status.AppliedAt.Format(time.RFC3339)
+	}
+	return fmt.Sprintf("%04d_%s\t%s", status.Version, status.Name, state)
+}
+
+func init() {
+	migrateUpCmd.Flags().Int("steps", 0, "maximum number of migrations to apply, all of them if zero")
+	migrateDownCmd.Flags().Int("steps", 1, "number of migrations to revert, all of them if zero")
+	migrateCmd.AddCommand(migrateUpCmd, migrateDownCmd, migrateStatusCmd)
+	rootCmd.AddCommand(migrateCmd)
+}
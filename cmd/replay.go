@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"github.com/cvzm/go-web-project/bootstrap"
+
+	"github.com/spf13/cobra"
+)
+
+var replayCmd = &cobra.Command{
+	Use:   "replay",
+	Short: "Move dead-lettered messages back to the queue",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		limit, err := cmd.Flags().GetInt("limit")
+		if err != nil {
+			return err
+		}
+
+		app, err := bootstrap.InitializeReplayApp()
+		if err != nil {
+			return err
+		}
+		defer app.Close()
+
+		replayed, err := app.ReplayMessages(cmd.Context(), limit)
+		if err != nil {
+			return err
+		}
+		cmd.Printf("Replayed %d message(s)\n", replayed)
+		return nil
+	},
+}
+
+func init() {
+	replayCmd.Flags().Int("limit", 0, "maximum number of messages to replay, all of them if zero")
+	rootCmd.AddCommand(replayCmd)
+}
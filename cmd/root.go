@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/cvzm/go-web-project/bootstrap"
+
+	"github.com/spf13/cobra"
+)
+
+// rootCmd runs the application in the run mode of the configuration when called without subcommand
+var rootCmd = &cobra.Command{
+	Use:          "app",
+	Short:        "Cloud event ingestion and query service",
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runApp("")
+	},
+}
+
+// Execute runs the command selected by the command line arguments, cancelling its context on shutdown signals
+func Execute() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
+		stop()
+		os.Exit(1)
+	}
+}
+
+// runApp initializes the application in the given run mode if not empty, and runs it until it is shut down
+func runApp(runMode string) error {
+	app, err := bootstrap.InitializeApp(runMode)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Starting server")
+	return app.SetupAndRun()
+}
@@ -0,0 +1,20 @@
+package cmd
+
+import (
+	"github.com/cvzm/go-web-project/bootstrap"
+
+	"github.com/spf13/cobra"
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve the HTTP API without consuming the queue",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runApp(bootstrap.RunModeAPI)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+}
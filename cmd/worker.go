@@ -0,0 +1,20 @@
+package cmd
+
+import (
+	"github.com/cvzm/go-web-project/bootstrap"
+
+	"github.com/spf13/cobra"
+)
+
+var workerCmd = &cobra.Command{
+	Use:   "worker",
+	Short: "Consume the queue without serving the HTTP API",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runApp(bootstrap.RunModeWorker)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(workerCmd)
+}
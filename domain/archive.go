@@ -0,0 +1,59 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ArchiveFormatNDJSONGzip is the format of archives holding one JSON event per line, gzip compressed
+const ArchiveFormatNDJSONGzip = "ndjson.gz"
+
+// ErrNoArchiveStore is returned when archiving or restoring events without any archive store configured
+var ErrNoArchiveStore = errors.New("no archive store configured")
+
+// ArchiveManifest describes an archive holding the events of a source on a given day
+type ArchiveManifest struct {
+	Key       string      `json:"key"`    // key of the archive in the store
+	Format    string      `json:"format"` // format of the archive, e.g. ndjson.gz
+	Source    EventSource `json:"source"`
+	Date      string      `json:"date"` // day of the archived events, as YYYY-MM-DD in UTC
+	Count     int         `json:"count"`
+	FirstID   uint        `json:"first_id"`
+	LastID    uint        `json:"last_id"`
+	Size      int         `json:"size"`   // size of the archive in bytes
+	SHA256    string      `json:"sha256"` // hex encoded SHA-256 checksum of the archive
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// ArchiveBatch identifies the events of a source created on a given day, the unit of archival
+type ArchiveBatch struct {
+	Source EventSource
+	Day    time.Time // start of the day in UTC
+}
+
+// ArchiveStore defines the interface for the storage of archive objects, e.g. a local directory or an S3 bucket
+type ArchiveStore interface {
+	Put(ctx context.Context, key string, data []byte) error
+	Get(ctx context.Context, key string) ([]byte, error)
+}
+
+// EventArchiveRepository defines the interface for event storage operations used by archival
+type EventArchiveRepository interface {
+	// FindArchiveBatches returns the batches of the events created before the given time
+	FindArchiveBatches(ctx context.Context, before time.Time) ([]ArchiveBatch, error)
+	// FindBatchEvents returns the events of a batch ordered by ID
+	FindBatchEvents(ctx context.Context, batch ArchiveBatch) ([]Event, error)
+	// DeleteBatchEvents deletes the events of a batch up to lastID, and nothing unless there are exactly count of them
+	DeleteBatchEvents(ctx context.Context, batch ArchiveBatch, lastID uint, count int) error
+	// Restore inserts archived events, skipping those still stored, and returns the number of inserted events
+	Restore(ctx context.Context, events []Event) (int, error)
+}
+
+// ArchiveUsecase defines the interface for event archival use cases
+type ArchiveUsecase interface {
+	// Archive moves the events created before the given time to the archive store and returns the written manifests
+	Archive(ctx context.Context, before time.Time) ([]ArchiveManifest, error)
+	// Restore loads back the archive of the manifest stored at the given key and returns the number of restored events
+	Restore(ctx context.Context, manifestKey string) (int, error)
+}
@@ -0,0 +1,132 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Limits of the comments and annotations of an event
+const (
+	MaxCommentBodyLength     = 10000
+	MaxAnnotationValueLength = 1024
+)
+
+// annotationKeyPattern matches the keys of the annotations, e.g. jira_ticket or runbook.url
+var annotationKeyPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]{0,62}$`)
+
+// EventComment struct defines a comment of an event, a reply to another comment of the event if it has a parent
+type EventComment struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	EventID   uint           `gorm:"not null" json:"event_id"`
+	ParentID  *uint          `json:"parent_id"`
+	Author    string         `gorm:"type:varchar(255);not null" json:"author"`
+	Body      string         `gorm:"type:text;not null" json:"body"` // markdown
+	Replies   []EventComment `gorm:"-" json:"replies,omitempty"`     // set when listing the threads of an event
+	CreatedAt time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName returns the name of the event comments table
+func (EventComment) TableName() string {
+	return "event_comments"
+}
+
+// Validate checks the comment
+func (c *EventComment) Validate() error {
+	if c.Author == "" {
+		return &ValidationError{Message: "author is required"}
+	}
+	if strings.TrimSpace(c.Body) == "" {
+		return &ValidationError{Message: "body is required"}
+	}
+	if len(c.Body) > MaxCommentBodyLength {
+		return &ValidationError{Message: fmt.Sprintf("body must not exceed %d characters", MaxCommentBodyLength)}
+	}
+	return nil
+}
+
+// EventAnnotation struct defines a key-value annotation of an event, such as a ticket ID or a runbook link.
+// An event has at most one annotation per key.
+type EventAnnotation struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	EventID   uint      `gorm:"not null" json:"event_id"`
+	Key       string    `gorm:"type:varchar(63);not null" json:"key"`
+	Value     string    `gorm:"type:varchar(1024);not null" json:"value"`
+	Author    string    `gorm:"type:varchar(255);not null" json:"author"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName returns the name of the event annotations table
+func (EventAnnotation) TableName() string {
+	return "event_annotations"
+}
+
+// Validate checks the annotation
+func (a *EventAnnotation) Validate() error {
+	if !annotationKeyPattern.MatchString(a.Key) {
+		return &ValidationError{Message: fmt.Sprintf("invalid key %q, expecting up to 63 lowercase letters, digits, '_', '.' or '-'", a.Key)}
+	}
+	if a.Value == "" {
+		return &ValidationError{Message: "value is required"}
+	}
+	if len(a.Value) > MaxAnnotationValueLength {
+		return &ValidationError{Message: fmt.Sprintf("value must not exceed %d characters", MaxAnnotationValueLength)}
+	}
+	if a.Author == "" {
+		return &ValidationError{Message: "author is required"}
+	}
+	return nil
+}
+
+// AnnotationFilter selects the events having an annotation of the key, with the value if not empty
+type AnnotationFilter struct {
+	Key   string
+	Value string
+}
+
+// parseAnnotationFilter parses an annotation filter of an event query, formatted as key or key:value
+func parseAnnotationFilter(filter string) (AnnotationFilter, error) {
+	key, value, _ := strings.Cut(filter, ":")
+	if !annotationKeyPattern.MatchString(key) {
+		return AnnotationFilter{}, &ValidationError{Message: fmt.Sprintf("invalid annotation %q, expecting key or key:value", filter)}
+	}
+	return AnnotationFilter{Key: key, Value: value}, nil
+}
+
+// EventCommentRepository defines the interface for event comment storage
+type EventCommentRepository interface {
+	// FindByEvent returns the comments of the event, oldest first
+	FindByEvent(ctx context.Context, eventID uint) ([]EventComment, error)
+	Find(ctx context.Context, eventID, id uint) (EventComment, error)
+	Create(ctx context.Context, comment *EventComment) error
+	// UpdateBody updates the body of the comment
+	UpdateBody(ctx context.Context, comment *EventComment) error
+}
+
+// EventAnnotationRepository defines the interface for event annotation storage
+type EventAnnotationRepository interface {
+	// FindByEvent returns the annotations of the event, by key
+	FindByEvent(ctx context.Context, eventID uint) ([]EventAnnotation, error)
+	// Save creates the annotation, or updates the annotation of the event with the same key
+	Save(ctx context.Context, annotation *EventAnnotation) error
+	Delete(ctx context.Context, eventID uint, key string) error
+}
+
+// CommentUsecase defines the interface for the use cases of the comments and annotations of the events
+type CommentUsecase interface {
+	// Comments returns the threads of comments of the event, oldest first, with their replies
+	Comments(ctx context.Context, eventID uint) ([]EventComment, error)
+	// AddComment adds the comment to the event, replying to its parent if set
+	AddComment(ctx context.Context, eventID uint, comment *EventComment) error
+	// EditComment replaces the body of the comment, returning the edited comment
+	EditComment(ctx context.Context, eventID, id uint, body string) (EventComment, error)
+
+	Annotations(ctx context.Context, eventID uint) ([]EventAnnotation, error)
+	// Annotate sets the annotation of the event, replacing the value of its key if already set
+	Annotate(ctx context.Context, eventID uint, annotation *EventAnnotation) error
+	RemoveAnnotation(ctx context.Context, eventID uint, key string) error
+}
@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Periods of the notification digests
+const (
+	DigestHourly = "hourly"
+	DigestDaily  = "daily"
+)
+
+// EventCount is the number of events of a source and type
+type EventCount struct {
+	Source    EventSource `json:"source"`
+	EventType string      `json:"event_type"`
+	Count     int64       `json:"count"`
+}
+
+// DigestSource summarises the events of a source over the period of a digest
+type DigestSource struct {
+	Source  EventSource
+	Total   int64
+	Counts  []EventCount // by descending count
+	Notable []Event      // latest notable events, up to the configured limit
+}
+
+// Digest summarises the events created over a period, by source
+type Digest struct {
+	Period  string    // hourly or daily
+	From    time.Time // start of the period, inclusive
+	To      time.Time // end of the period, exclusive
+	Total   int64
+	Sources []DigestSource
+}
+
+// DigestPeriod returns the last period of the given kind complete at the given time, in UTC
+func DigestPeriod(period string, now time.Time) (from, to time.Time) {
+	now = now.UTC()
+	if period == DigestDaily {
+		to = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		return to.AddDate(0, 0, -1), to
+	}
+	to = now.Truncate(time.Hour)
+	return to.Add(-time.Hour), to
+}
+
+// DigestRepository defines the interface for recording the sent digests
+type DigestRepository interface {
+	// Claim records the digest of the period starting at the given time, reporting false if already recorded
+	Claim(ctx context.Context, period string, from time.Time) (bool, error)
+}
+
+// DigestSender defines the interface for sending digests, e.g. by email
+type DigestSender interface {
+	SendDigest(ctx context.Context, digest Digest) error
+}
+
+// DigestUsecase defines the interface for notification digest use cases
+type DigestUsecase interface {
+	// Build returns the digest of the events created over the period
+	Build(ctx context.Context, period string, from, to time.Time) (Digest, error)
+	// Send sends the digest of the last period complete at the given time, unless already sent by any instance.
+	// It reports whether the digest was sent.
+	Send(ctx context.Context, now time.Time) (bool, error)
+}
@@ -0,0 +1,24 @@
+package domain
+
+import "errors"
+
+// ErrNotFound is returned when the requested record does not exist
+var ErrNotFound = errors.New("not found")
+
+// ValidationError is returned when the input of a use case is invalid
+type ValidationError struct {
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+// ConflictError is returned when a change conflicts with the current state of a record
+type ConflictError struct {
+	Message string
+}
+
+func (e *ConflictError) Error() string {
+	return e.Message
+}
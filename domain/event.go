@@ -1,6 +1,9 @@
 package domain
 
 import (
+	"context"
+	"fmt"
+	"slices"
 	"time"
 
 	"github.com/lib/pq"
@@ -8,13 +11,19 @@ import (
 
 // Event struct defines the properties of an event
 type Event struct {
-	ID                uint           `gorm:"primaryKey"`
-	Source            EventSource    `gorm:"type:varchar(255);not null"`
-	EventType         string         `gorm:"type:varchar(100);not null"`
-	Description       string         `gorm:"type:text"`
-	AffectedResources pq.StringArray `gorm:"type:varchar(200)[];"`
-	CreatedAt         time.Time      `gorm:"autoCreateTime"`
-	UpdatedAt         time.Time      `gorm:"autoUpdateTime"`
+	ID                uint           `gorm:"primaryKey;autoIncrement" json:"id"`
+	Source            EventSource    `gorm:"type:varchar(255);not null" json:"source"`
+	EventType         string         `gorm:"type:varchar(100);not null" json:"event_type"`
+	Description       string         `gorm:"type:text" json:"description"`
+	AffectedResources pq.StringArray `gorm:"type:varchar(200)[];" json:"affected_resources"`
+	Severity          Severity       `gorm:"type:varchar(20);not null" json:"severity"` // provider-native if any, classified otherwise
+	Silenced          bool           `gorm:"not null;default:false" json:"silenced"`    // matched by an active silence when received, hence not notified
+	Status            EventStatus    `gorm:"type:varchar(20);not null" json:"status"`
+	Assignee          string         `gorm:"type:varchar(255)" json:"assignee"`
+	AcknowledgedAt    *time.Time     `json:"acknowledged_at"`
+	ResolvedAt        *time.Time     `json:"resolved_at"`
+	CreatedAt         time.Time      `gorm:"primaryKey;autoCreateTime" json:"created_at"` // partition key of the events table, part of its primary key
+	UpdatedAt         time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
 }
 
 // TableName returns the name of the events table
@@ -36,21 +45,125 @@ const (
 
 // EventRepository defines the interface for event storage
 type EventRepository interface {
-	Save(event *Event) error
-	FindAll() ([]Event, error)
+	Save(ctx context.Context, event *Event) error
+	FindAll(ctx context.Context) ([]Event, error)
+	// Query returns the events matching the query, latest first
+	Query(ctx context.Context, query EventQuery) ([]Event, error)
+	// CountByType counts the events created in [from, to) by source and type, by descending count
+	CountByType(ctx context.Context, from, to time.Time) ([]EventCount, error)
+	// FindLatest returns the latest events of the source and types created in [from, to)
+	FindLatest(ctx context.Context, source EventSource, eventTypes []string, from, to time.Time, limit int) ([]Event, error)
+	Find(ctx context.Context, id uint) (Event, error)
+	// Lock finds the event, locking it until the end of the transaction
+	Lock(ctx context.Context, id uint) (Event, error)
+	// UpdateStatus updates the status, assignee and status timestamps of the event
+	UpdateStatus(ctx context.Context, event *Event) error
 }
 
 // EventUsecase defines the interface for event use cases
 type EventUsecase interface {
-	Save(cloudEvent CloudEvent) error
+	Save(ctx context.Context, cloudEvent CloudEvent) error
+	Query(ctx context.Context, query EventQuery) ([]Event, error)
+	Get(ctx context.Context, id uint) (Event, error)
 
-	// TODO: GetAllEvents
-	// GetAllEvents() ([]Event, error)
+	// Acknowledge, Resolve and Assign change the event following its lifecycle, recording the change in its
+	// history, and return the changed event
+	Acknowledge(ctx context.Context, id uint, request EventChangeRequest) (Event, error)
+	Resolve(ctx context.Context, id uint, request EventChangeRequest) (Event, error)
+	Assign(ctx context.Context, id uint, request EventChangeRequest) (Event, error)
+	// History returns the changes of the event, oldest first
+	History(ctx context.Context, id uint) ([]EventChange, error)
+}
+
+// EventIngester defines the interface for ingesting the cloud events received by the API, either saving them
+// right away or buffering them for the workers
+type EventIngester interface {
+	Ingest(ctx context.Context, cloudEvent CloudEvent) error
+}
+
+// Limits of the number of events returned by a query
+const (
+	DefaultEventQueryLimit = 100
+	MaxEventQueryLimit     = 1000
+)
+
+// EventQuery selects events, bound from the query string. Empty criteria match any event.
+type EventQuery struct {
+	Sources     []EventSource `query:"source"`
+	EventTypes  []string      `query:"event_type"`
+	Severities  []Severity    `query:"severity"`
+	MinSeverity Severity      `query:"min_severity"`
+	Statuses    []EventStatus `query:"status"`
+	Assignee    string        `query:"assignee"`
+	Resource    string        `query:"resource"`   // affected resource
+	Annotations []string      `query:"annotation"` // key or key:value, all matching
+	From        *time.Time    `query:"from"`       // inclusive, RFC 3339
+	To          *time.Time    `query:"to"`         // exclusive, RFC 3339
+	Limit       int           `query:"limit"`
+	Offset      int           `query:"offset"`
+}
+
+// Validate checks the query, defaulting its limit
+func (q *EventQuery) Validate() error {
+	if err := validateSeverities(q.Severities); err != nil {
+		return err
+	}
+	if q.MinSeverity != "" && !q.MinSeverity.Valid() {
+		return &ValidationError{Message: fmt.Sprintf("invalid min_severity %q, expecting info, warning, error or critical", q.MinSeverity)}
+	}
+	for _, status := range q.Statuses {
+		if !status.Valid() {
+			return &ValidationError{Message: fmt.Sprintf("invalid status %q, expecting open, acknowledged or resolved", status)}
+		}
+	}
+	for _, annotation := range q.Annotations {
+		if _, err := parseAnnotationFilter(annotation); err != nil {
+			return err
+		}
+	}
+	if q.From != nil && q.To != nil && !q.To.After(*q.From) {
+		return &ValidationError{Message: "to must be after from"}
+	}
+	if q.Limit < 0 || q.Limit > MaxEventQueryLimit {
+		return &ValidationError{Message: fmt.Sprintf("limit must be between 1 and %d", MaxEventQueryLimit)}
+	}
+	if q.Limit == 0 {
+		q.Limit = DefaultEventQueryLimit
+	}
+	if q.Offset < 0 {
+		return &ValidationError{Message: "offset must not be negative"}
+	}
+	return nil
+}
+
+// SeverityRange returns the severities selected by the severities and the minimum severity of the query,
+// nil if any severity is selected
+func (q EventQuery) SeverityRange() []Severity {
+	if len(q.Severities) == 0 && q.MinSeverity == "" {
+		return nil
+	}
+	severities := []Severity{}
+	for _, severity := range Severities {
+		if (len(q.Severities) == 0 || slices.Contains(q.Severities, severity)) && severity.AtLeast(q.MinSeverity) {
+			severities = append(severities, severity)
+		}
+	}
+	return severities
+}
+
+// AnnotationFilters returns the annotations selected by the validated query
+func (q EventQuery) AnnotationFilters() []AnnotationFilter {
+	filters := make([]AnnotationFilter, 0, len(q.Annotations))
+	for _, annotation := range q.Annotations {
+		filter, _ := parseAnnotationFilter(annotation)
+		filters = append(filters, filter)
+	}
+	return filters
 }
 
 // CloudEvent defines the interface for cloud events
 type CloudEvent interface {
-	Parse() (Event, error)
+	Parse(ctx context.Context) (Event, error)
 }
 
 // AWSEvent represents an AWS cloud event
@@ -58,15 +171,17 @@ type AWSEvent struct {
 	AWSEventID   string    `json:"aws_event_id"`
 	AWSEventType string    `json:"aws_event_type"`
 	AWSMessage   string    `json:"aws_message"`
+	AWSSeverity  string    `json:"aws_severity,omitempty"` // finding severity label, e.g. HIGH
 	AWSTimestamp time.Time `json:"aws_timestamp"`
 }
 
 // Parse implements the CloudEvent interface for AWSEvent
-func (a AWSEvent) Parse() (Event, error) {
+func (a AWSEvent) Parse(ctx context.Context) (Event, error) {
 	return Event{
 		Source:      SourceAWS,
 		EventType:   a.AWSEventType,
 		Description: a.AWSMessage,
+		Severity:    nativeSeverity(awsSeverities, a.AWSSeverity),
 		CreatedAt:   a.AWSTimestamp,
 	}, nil
 }
@@ -76,15 +191,17 @@ type GCPEvent struct {
 	GCPEventID   string    `json:"gcp_event_id"`
 	GCPEventType string    `json:"gcp_event_type"`
 	GCPMessage   string    `json:"gcp_message"`
+	GCPSeverity  string    `json:"gcp_severity,omitempty"` // log entry severity, e.g. ERROR
 	GCPTimestamp time.Time `json:"gcp_timestamp"`
 }
 
 // Parse implements the CloudEvent interface for GCPEvent
-func (g GCPEvent) Parse() (Event, error) {
+func (g GCPEvent) Parse(ctx context.Context) (Event, error) {
 	return Event{
 		Source:      SourceGCP,
 		EventType:   g.GCPEventType,
 		Description: g.GCPMessage,
+		Severity:    nativeSeverity(gcpSeverities, g.GCPSeverity),
 		CreatedAt:   g.GCPTimestamp,
 	}, nil
 }
@@ -0,0 +1,116 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// EventStatus represents the triage status of an event
+type EventStatus string
+
+// Constants defining the statuses of an event
+const (
+	EventStatusOpen         EventStatus = "open"
+	EventStatusAcknowledged EventStatus = "acknowledged"
+	EventStatusResolved     EventStatus = "resolved"
+)
+
+// EventStatuses are the statuses of an event, in lifecycle order
+var EventStatuses = []EventStatus{EventStatusOpen, EventStatusAcknowledged, EventStatusResolved}
+
+// eventTransitions are the statuses each status may change to. Resolved events are final.
+var eventTransitions = map[EventStatus][]EventStatus{
+	EventStatusOpen:         {EventStatusAcknowledged, EventStatusResolved},
+	EventStatusAcknowledged: {EventStatusResolved},
+}
+
+// Valid reports whether the status is one of the known statuses
+func (s EventStatus) Valid() bool {
+	return slices.Contains(EventStatuses, s)
+}
+
+// CanChangeTo reports whether the status may change to the other one
+func (s EventStatus) CanChangeTo(other EventStatus) bool {
+	return slices.Contains(eventTransitions[s], other)
+}
+
+// EventAction represents a change made to an event
+type EventAction string
+
+// Constants defining the changes made to an event
+const (
+	EventActionAcknowledge EventAction = "acknowledge"
+	EventActionResolve     EventAction = "resolve"
+	EventActionAssign      EventAction = "assign"
+)
+
+// Acknowledge acknowledges the open event
+func (e *Event) Acknowledge(now time.Time) error {
+	if err := e.changeStatus(EventStatusAcknowledged); err != nil {
+		return err
+	}
+	e.AcknowledgedAt = &now
+	return nil
+}
+
+// Resolve resolves the open or acknowledged event
+func (e *Event) Resolve(now time.Time) error {
+	if err := e.changeStatus(EventStatusResolved); err != nil {
+		return err
+	}
+	e.ResolvedAt = &now
+	return nil
+}
+
+// Assign assigns the event to the assignee, or unassigns it if empty, unless resolved
+func (e *Event) Assign(assignee string) error {
+	if e.Status == EventStatusResolved {
+		return &ConflictError{Message: fmt.Sprintf("event %d is resolved and cannot be assigned", e.ID)}
+	}
+	e.Assignee = assignee
+	return nil
+}
+
+// changeStatus changes the status of the event, following the lifecycle
+func (e *Event) changeStatus(status EventStatus) error {
+	if !e.Status.CanChangeTo(status) {
+		return &ConflictError{Message: fmt.Sprintf("event %d is %s and cannot be %s", e.ID, e.Status, status)}
+	}
+	e.Status = status
+	return nil
+}
+
+// EventChangeRequest is a change requested to an event
+type EventChangeRequest struct {
+	Actor    string `json:"actor"`    // who makes the change
+	Assignee string `json:"assignee"` // assignments only, empty to unassign
+	Note     string `json:"note"`
+}
+
+// EventChange struct defines an entry of the audit history of an event, recording who changed what and when
+type EventChange struct {
+	ID           uint        `gorm:"primaryKey" json:"id"`
+	EventID      uint        `gorm:"not null" json:"event_id"`
+	Action       EventAction `gorm:"type:varchar(20);not null" json:"action"`
+	Actor        string      `gorm:"type:varchar(255);not null" json:"actor"`
+	FromStatus   EventStatus `gorm:"type:varchar(20);not null" json:"from_status"`
+	ToStatus     EventStatus `gorm:"type:varchar(20);not null" json:"to_status"`
+	FromAssignee string      `gorm:"type:varchar(255)" json:"from_assignee"`
+	ToAssignee   string      `gorm:"type:varchar(255)" json:"to_assignee"`
+	Note         string      `gorm:"type:text" json:"note"`
+	CreatedAt    time.Time   `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName returns the name of the event changes table
+func (EventChange) TableName() string {
+	return "event_changes"
+}
+
+// EventChangeRepository defines the interface for the storage of the audit history of the events
+type EventChangeRepository interface {
+	Create(ctx context.Context, change *EventChange) error
+	// FindByEvent returns the changes of the event, oldest first
+	FindByEvent(ctx context.Context, eventID uint) ([]EventChange, error)
+}
@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Message is a transport-agnostic message delivered by a MessageSource
+type Message struct {
+	ID   string
+	Body []byte
+
+	// GroupID orders messages: messages sharing a non-empty GroupID are processed strictly in order
+	GroupID string
+
+	// DeduplicationID identifies duplicate deliveries of the same message, if the transport provides one
+	DeduplicationID string
+
+	// Handle is the transport-specific handle used to ack, nack or extend the message
+	Handle any
+}
+
+// MessageSource defines the interface for a queue or stream delivering messages
+type MessageSource interface {
+	// Receive waits for and returns the next batch of messages
+	Receive(ctx context.Context) ([]Message, error)
+
+	// Ack marks the message as successfully processed
+	Ack(ctx context.Context, message Message) error
+
+	// Nack returns the message to the source so it is redelivered after the given delay
+	Nack(ctx context.Context, message Message, delay time.Duration) error
+
+	// Reject discards a message that can never be processed, dead-lettering it where the transport supports it
+	Reject(ctx context.Context, message Message) error
+
+	// Extend keeps the message reserved for the consumer for the given duration
+	Extend(ctx context.Context, message Message, lease time.Duration) error
+
+	// Close releases the resources held by the source
+	Close() error
+}
+
+// MessageProducer is implemented by message sources the application writes to, buffering messages durably
+type MessageProducer interface {
+	// Enqueue adds a message to the source, ignoring it if a message with the deduplication ID is still queued
+	Enqueue(ctx context.Context, body []byte, groupID, deduplicationID string) error
+}
+
+// MessageReplayer is implemented by message sources able to move dead-lettered messages back to the queue
+type MessageReplayer interface {
+	// Replay requeues up to limit dead-lettered messages, all of them if limit is zero,
+	// and returns the number of requeued messages
+	Replay(ctx context.Context, limit int) (int, error)
+}
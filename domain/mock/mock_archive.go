@@ -0,0 +1,71 @@
+package domain_mock
+
+import (
+	"context"
+	"time"
+
+	"github.com/cvzm/go-web-project/domain"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockArchiveStore is a mock implementation of domain.ArchiveStore
+type MockArchiveStore struct {
+	mock.Mock
+}
+
+// Put mocks the method for writing an archive object
+func (m *MockArchiveStore) Put(ctx context.Context, key string, data []byte) error {
+	args := m.Called(ctx, key, data)
+	return args.Error(0)
+}
+
+// Get mocks the method for reading an archive object
+func (m *MockArchiveStore) Get(ctx context.Context, key string) ([]byte, error) {
+	args := m.Called(ctx, key)
+	data, _ := args.Get(0).([]byte)
+	return data, args.Error(1)
+}
+
+// MockEventArchiveRepository is a mock implementation of domain.EventArchiveRepository
+type MockEventArchiveRepository struct {
+	mock.Mock
+}
+
+// FindArchiveBatches mocks the method for finding the batches to archive
+func (m *MockEventArchiveRepository) FindArchiveBatches(ctx context.Context, before time.Time) ([]domain.ArchiveBatch, error) {
+	args := m.Called(ctx, before)
+	return args.Get(0).([]domain.ArchiveBatch), args.Error(1)
+}
+
+// FindBatchEvents mocks the method for finding the events of a batch
+func (m *MockEventArchiveRepository) FindBatchEvents(ctx context.Context, batch domain.ArchiveBatch) ([]domain.Event, error) {
+	args := m.Called(ctx, batch)
+	return args.Get(0).([]domain.Event), args.Error(1)
+}
+
+// DeleteBatchEvents mocks the method for deleting the archived events of a batch
+func (m *MockEventArchiveRepository) DeleteBatchEvents(ctx context.Context, batch domain.ArchiveBatch, lastID uint, count int) error {
+	args := m.Called(ctx, batch, lastID, count)
+	return args.Error(0)
+}
+
+// Restore mocks the method for restoring archived events
+func (m *MockEventArchiveRepository) Restore(ctx context.Context, events []domain.Event) (int, error) {
+	args := m.Called(ctx, events)
+	return args.Int(0), args.Error(1)
+}
+
+// MockArchiveUsecase is a mock implementation of domain.ArchiveUsecase
+type MockArchiveUsecase struct {
+	mock.Mock
+}
+
+func (m *MockArchiveUsecase) Archive(ctx context.Context, before time.Time) ([]domain.ArchiveManifest, error) {
+	args := m.Called(ctx, before)
+	return args.Get(0).([]domain.ArchiveManifest), args.Error(1)
+}
+
+func (m *MockArchiveUsecase) Restore(ctx context.Context, manifestKey string) (int, error) {
+	args := m.Called(ctx, manifestKey)
+	return args.Int(0), args.Error(1)
+}
@@ -0,0 +1,101 @@
+package domain_mock
+
+import (
+	"context"
+
+	"github.com/cvzm/go-web-project/domain"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockEventCommentRepository is a mock implementation of domain.EventCommentRepository
+type MockEventCommentRepository struct {
+	mock.Mock
+}
+
+// FindByEvent mocks the method for finding the comments of an event
+func (m *MockEventCommentRepository) FindByEvent(ctx context.Context, eventID uint) ([]domain.EventComment, error) {
+	args := m.Called(ctx, eventID)
+	return args.Get(0).([]domain.EventComment), args.Error(1)
+}
+
+// Find mocks the method for finding a comment of an event
+func (m *MockEventCommentRepository) Find(ctx context.Context, eventID, id uint) (domain.EventComment, error) {
+	args := m.Called(ctx, eventID, id)
+	return args.Get(0).(domain.EventComment), args.Error(1)
+}
+
+// Create mocks the method for creating a comment
+func (m *MockEventCommentRepository) Create(ctx context.Context, comment *domain.EventComment) error {
+	args := m.Called(ctx, comment)
+	return args.Error(0)
+}
+
+// UpdateBody mocks the method for updating the body of a comment
+func (m *MockEventCommentRepository) UpdateBody(ctx context.Context, comment *domain.EventComment) error {
+	args := m.Called(ctx, comment)
+	return args.Error(0)
+}
+
+// MockEventAnnotationRepository is a mock implementation of domain.EventAnnotationRepository
+type MockEventAnnotationRepository struct {
+	mock.Mock
+}
+
+// FindByEvent mocks the method for finding the annotations of an event
+func (m *MockEventAnnotationRepository) FindByEvent(ctx context.Context, eventID uint) ([]domain.EventAnnotation, error) {
+	args := m.Called(ctx, eventID)
+	return args.Get(0).([]domain.EventAnnotation), args.Error(1)
+}
+
+// Save mocks the method for creating or updating an annotation
+func (m *MockEventAnnotationRepository) Save(ctx context.Context, annotation *domain.EventAnnotation) error {
+	args := m.Called(ctx, annotation)
+	return args.Error(0)
+}
+
+// Delete mocks the method for deleting an annotation
+func (m *MockEventAnnotationRepository) Delete(ctx context.Context, eventID uint, key string) error {
+	args := m.Called(ctx, eventID, key)
+	return args.Error(0)
+}
+
+// MockCommentUsecase is a mock implementation of domain.CommentUsecase
+type MockCommentUsecase struct {
+	mock.Mock
+}
+
+// Comments mocks the method for listing the threads of comments of an event
+func (m *MockCommentUsecase) Comments(ctx context.Context, eventID uint) ([]domain.EventComment, error) {
+	args := m.Called(ctx, eventID)
+	return args.Get(0).([]domain.EventComment), args.Error(1)
+}
+
+// AddComment mocks the method for adding a comment to an event
+func (m *MockCommentUsecase) AddComment(ctx context.Context, eventID uint, comment *domain.EventComment) error {
+	args := m.Called(ctx, eventID, comment)
+	return args.Error(0)
+}
+
+// EditComment mocks the method for editing a comment of an event
+func (m *MockCommentUsecase) EditComment(ctx context.Context, eventID, id uint, body string) (domain.EventComment, error) {
+	args := m.Called(ctx, eventID, id, body)
+	return args.Get(0).(domain.EventComment), args.Error(1)
+}
+
+// Annotations mocks the method for listing the annotations of an event
+func (m *MockCommentUsecase) Annotations(ctx context.Context, eventID uint) ([]domain.EventAnnotation, error) {
+	args := m.Called(ctx, eventID)
+	return args.Get(0).([]domain.EventAnnotation), args.Error(1)
+}
+
+// Annotate mocks the method for setting an annotation of an event
+func (m *MockCommentUsecase) Annotate(ctx context.Context, eventID uint, annotation *domain.EventAnnotation) error {
+	args := m.Called(ctx, eventID, annotation)
+	return args.Error(0)
+}
+
+// RemoveAnnotation mocks the method for removing an annotation of an event
+func (m *MockCommentUsecase) RemoveAnnotation(ctx context.Context, eventID uint, key string) error {
+	args := m.Called(ctx, eventID, key)
+	return args.Error(0)
+}
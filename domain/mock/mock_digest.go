@@ -0,0 +1,48 @@
+package domain_mock
+
+import (
+	"context"
+	"time"
+
+	"github.com/cvzm/go-web-project/domain"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockDigestRepository is a mock implementation of domain.DigestRepository
+type MockDigestRepository struct {
+	mock.Mock
+}
+
+// Claim mocks the method for recording a digest
+func (m *MockDigestRepository) Claim(ctx context.Context, period string, from time.Time) (bool, error) {
+	args := m.Called(ctx, period, from)
+	return args.Bool(0), args.Error(1)
+}
+
+// MockDigestSender is a mock implementation of domain.DigestSender
+type MockDigestSender struct {
+	mock.Mock
+}
+
+// SendDigest mocks the method for sending a digest
+func (m *MockDigestSender) SendDigest(ctx context.Context, digest domain.Digest) error {
+	args := m.Called(ctx, digest)
+	return args.Error(0)
+}
+
+// MockDigestUsecase is a mock implementation of domain.DigestUsecase
+type MockDigestUsecase struct {
+	mock.Mock
+}
+
+// Build mocks the method for building a digest
+func (m *MockDigestUsecase) Build(ctx context.Context, period string, from, to time.Time) (domain.Digest, error) {
+	args := m.Called(ctx, period, from, to)
+	return args.Get(0).(domain.Digest), args.Error(1)
+}
+
+// Send mocks the method for sending the digest of the last period
+func (m *MockDigestUsecase) Send(ctx context.Context, now time.Time) (bool, error) {
+	args := m.Called(ctx, now)
+	return args.Bool(0), args.Error(1)
+}
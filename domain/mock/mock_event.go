@@ -1,6 +1,9 @@
 package domain_mock
 
 import (
+	"context"
+	"time"
+
 	"github.com/cvzm/go-web-project/domain"
 	"github.com/stretchr/testify/mock"
 )
@@ -11,23 +14,144 @@ type MockEventRepository struct {
 }
 
 // Save mocks the method for saving an event
-func (m *MockEventRepository) Save(event *domain.Event) error {
-	args := m.Called(event)
+func (m *MockEventRepository) Save(ctx context.Context, event *domain.Event) error {
+	args := m.Called(ctx, event)
 	return args.Error(0)
 }
 
 // FindAll mocks the method for finding all events
-func (m *MockEventRepository) FindAll() ([]domain.Event, error) {
-	args := m.Called()
+func (m *MockEventRepository) FindAll(ctx context.Context) ([]domain.Event, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]domain.Event), args.Error(1)
+}
+
+// CountByType mocks the method for counting events by source and type
+func (m *MockEventRepository) CountByType(ctx context.Context, from, to time.Time) ([]domain.EventCount, error) {
+	args := m.Called(ctx, from, to)
+	return args.Get(0).([]domain.EventCount), args.Error(1)
+}
+
+// FindLatest mocks the method for finding the latest events of a source and types
+func (m *MockEventRepository) FindLatest(ctx context.Context, source domain.EventSource, eventTypes []string, from, to time.Time, limit int) ([]domain.Event, error) {
+	args := m.Called(ctx, source, eventTypes, from, to, limit)
 	return args.Get(0).([]domain.Event), args.Error(1)
 }
 
+// Query mocks the method for querying events
+func (m *MockEventRepository) Query(ctx context.Context, query domain.EventQuery) ([]domain.Event, error) {
+	args := m.Called(ctx, query)
+	return args.Get(0).([]domain.Event), args.Error(1)
+}
+
+// Find mocks the method for finding an event
+func (m *MockEventRepository) Find(ctx context.Context, id uint) (domain.Event, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(domain.Event), args.Error(1)
+}
+
+// Lock mocks the method for finding and locking an event
+func (m *MockEventRepository) Lock(ctx context.Context, id uint) (domain.Event, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(domain.Event), args.Error(1)
+}
+
+// UpdateStatus mocks the method for updating the status of an event
+func (m *MockEventRepository) UpdateStatus(ctx context.Context, event *domain.Event) error {
+	args := m.Called(ctx, event)
+	return args.Error(0)
+}
+
 // MockEventUsecase is a mock implementation of domain.EventUsecase
 type MockEventUsecase struct {
 	mock.Mock
 }
 
-func (m *MockEventUsecase) Save(cloudEvent domain.CloudEvent) error {
-	args := m.Called(cloudEvent)
+func (m *MockEventUsecase) Save(ctx context.Context, cloudEvent domain.CloudEvent) error {
+	args := m.Called(ctx, cloudEvent)
+	return args.Error(0)
+}
+
+// Query mocks the method for querying events
+func (m *MockEventUsecase) Query(ctx context.Context, query domain.EventQuery) ([]domain.Event, error) {
+	args := m.Called(ctx, query)
+	return args.Get(0).([]domain.Event), args.Error(1)
+}
+
+// Get mocks the method for getting an event
+func (m *MockEventUsecase) Get(ctx context.Context, id uint) (domain.Event, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(domain.Event), args.Error(1)
+}
+
+// Acknowledge mocks the method for acknowledging an event
+func (m *MockEventUsecase) Acknowledge(ctx context.Context, id uint, request domain.EventChangeRequest) (domain.Event, error) {
+	args := m.Called(ctx, id, request)
+	return args.Get(0).(domain.Event), args.Error(1)
+}
+
+// Resolve mocks the method for resolving an event
+func (m *MockEventUsecase) Resolve(ctx context.Context, id uint, request domain.EventChangeRequest) (domain.Event, error) {
+	args := m.Called(ctx, id, request)
+	return args.Get(0).(domain.Event), args.Error(1)
+}
+
+// Assign mocks the method for assigning an event
+func (m *MockEventUsecase) Assign(ctx context.Context, id uint, request domain.EventChangeRequest) (domain.Event, error) {
+	args := m.Called(ctx, id, request)
+	return args.Get(0).(domain.Event), args.Error(1)
+}
+
+// History mocks the method for getting the changes of an event
+func (m *MockEventUsecase) History(ctx context.Context, id uint) ([]domain.EventChange, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).([]domain.EventChange), args.Error(1)
+}
+
+// MockEventClassifier is a mock implementation of domain.EventClassifier
+type MockEventClassifier struct {
+	mock.Mock
+}
+
+// Classify mocks the method for classifying an event
+func (m *MockEventClassifier) Classify(event domain.Event) domain.Severity {
+	args := m.Called(event)
+	return args.Get(0).(domain.Severity)
+}
+
+// MockNotifier is a mock implementation of domain.Notifier
+type MockNotifier struct {
+	mock.Mock
+}
+
+// Notify mocks the method for notifying about an event
+func (m *MockNotifier) Notify(ctx context.Context, event domain.Event) {
+	m.Called(ctx, event)
+}
+
+// MockEventChangeRepository is a mock implementation of domain.EventChangeRepository
+type MockEventChangeRepository struct {
+	mock.Mock
+}
+
+// Create mocks the method for recording a change of an event
+func (m *MockEventChangeRepository) Create(ctx context.Context, change *domain.EventChange) error {
+	args := m.Called(ctx, change)
+	return args.Error(0)
+}
+
+// FindByEvent mocks the method for finding the changes of an event
+func (m *MockEventChangeRepository) FindByEvent(ctx context.Context, eventID uint) ([]domain.EventChange, error) {
+	args := m.Called(ctx, eventID)
+	return args.Get(0).([]domain.EventChange), args.Error(1)
+}
+
+// MockEventIngester is a mock implementation of domain.EventIngester
+type MockEventIngester struct {
+	mock.Mock
+}
+
+// Ingest mocks the method for ingesting a cloud event
+func (m *MockEventIngester) Ingest(ctx context.Context, cloudEvent domain.CloudEvent) error {
+	args := m.Called(ctx, cloudEvent)
 	return args.Error(0)
 }
@@ -0,0 +1,61 @@
+package domain_mock
+
+import (
+	"context"
+	"time"
+
+	"github.com/cvzm/go-web-project/domain"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockMessageSource is a mock implementation of domain.MessageSource
+type MockMessageSource struct {
+	mock.Mock
+}
+
+// Receive mocks the method for receiving messages
+func (m *MockMessageSource) Receive(ctx context.Context) ([]domain.Message, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]domain.Message), args.Error(1)
+}
+
+// Ack mocks the method for acking a message
+func (m *MockMessageSource) Ack(ctx context.Context, message domain.Message) error {
+	args := m.Called(ctx, message)
+	return args.Error(0)
+}
+
+// Nack mocks the method for nacking a message
+func (m *MockMessageSource) Nack(ctx context.Context, message domain.Message, delay time.Duration) error {
+	args := m.Called(ctx, message, delay)
+	return args.Error(0)
+}
+
+// Reject mocks the method for rejecting a message
+func (m *MockMessageSource) Reject(ctx context.Context, message domain.Message) error {
+	args := m.Called(ctx, message)
+	return args.Error(0)
+}
+
+// Extend mocks the method for extending the lease of a message
+func (m *MockMessageSource) Extend(ctx context.Context, message domain.Message, lease time.Duration) error {
+	args := m.Called(ctx, message, lease)
+	return args.Error(0)
+}
+
+// Close mocks the method for closing the source
+func (m *MockMessageSource) Close() error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+// MockMessageProducer is a mock implementation of domain.MessageProducer
+type MockMessageProducer struct {
+	mock.Mock
+}
+
+// Enqueue mocks the method for enqueuing a message
+func (m *MockMessageProducer) Enqueue(ctx context.Context, body []byte, groupID, deduplicationID string) error {
+	args := m.Called(ctx, body, groupID, deduplicationID)
+	return args.Error(0)
+}
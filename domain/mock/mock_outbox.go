@@ -0,0 +1,96 @@
+package domain_mock
+
+import (
+	"context"
+	"time"
+
+	"github.com/cvzm/go-web-project/domain"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockOutboxRepository is a mock implementation of domain.OutboxRepository
+type MockOutboxRepository struct {
+	mock.Mock
+}
+
+// Add mocks the method for adding messages to the outbox
+func (m *MockOutboxRepository) Add(ctx context.Context, messages ...*domain.OutboxMessage) error {
+	args := m.Called(ctx, messages)
+	return args.Error(0)
+}
+
+// FindPublishable mocks the method for locking the publishable messages
+func (m *MockOutboxRepository) FindPublishable(ctx context.Context, now time.Time, limit int) ([]domain.OutboxMessage, error) {
+	args := m.Called(ctx, now, limit)
+	return args.Get(0).([]domain.OutboxMessage), args.Error(1)
+}
+
+// MarkPublished mocks the method for marking a message as published
+func (m *MockOutboxRepository) MarkPublished(ctx context.Context, id uint, publishedAt time.Time) error {
+	args := m.Called(ctx, id, publishedAt)
+	return args.Error(0)
+}
+
+// MarkFailed mocks the method for recording a failed publishing attempt
+func (m *MockOutboxRepository) MarkFailed(ctx context.Context, id uint, availableAt time.Time, reason string) error {
+	args := m.Called(ctx, id, availableAt, reason)
+	return args.Error(0)
+}
+
+// Stats mocks the method for describing the pending messages
+func (m *MockOutboxRepository) Stats(ctx context.Context) (domain.OutboxStats, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(domain.OutboxStats), args.Error(1)
+}
+
+// DeletePublished mocks the method for deleting published messages
+func (m *MockOutboxRepository) DeletePublished(ctx context.Context, before time.Time) (int64, error) {
+	args := m.Called(ctx, before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// MockOutboxPublisher is a mock implementation of domain.OutboxPublisher
+type MockOutboxPublisher struct {
+	mock.Mock
+}
+
+// Publish mocks the method for publishing a message downstream
+func (m *MockOutboxPublisher) Publish(ctx context.Context, message domain.OutboxMessage) error {
+	args := m.Called(ctx, message)
+	return args.Error(0)
+}
+
+// Close mocks the method for closing the publisher
+func (m *MockOutboxPublisher) Close() error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+// MockOutboxUsecase is a mock implementation of domain.OutboxUsecase
+type MockOutboxUsecase struct {
+	mock.Mock
+}
+
+// Enqueue mocks the method for recording a message in the outbox
+func (m *MockOutboxUsecase) Enqueue(ctx context.Context, repos domain.Repositories, message *domain.OutboxMessage) error {
+	args := m.Called(ctx, repos, message)
+	return args.Error(0)
+}
+
+// Relay mocks the method for publishing the pending messages
+func (m *MockOutboxUsecase) Relay(ctx context.Context) (domain.OutboxRelayResult, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(domain.OutboxRelayResult), args.Error(1)
+}
+
+// Stats mocks the method for describing the pending messages
+func (m *MockOutboxUsecase) Stats(ctx context.Context) (domain.OutboxStats, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(domain.OutboxStats), args.Error(1)
+}
+
+// Purge mocks the method for deleting published messages
+func (m *MockOutboxUsecase) Purge(ctx context.Context, before time.Time) (int64, error) {
+	args := m.Called(ctx, before)
+	return args.Get(0).(int64), args.Error(1)
+}
@@ -0,0 +1,90 @@
+package domain_mock
+
+import (
+	"context"
+
+	"github.com/cvzm/go-web-project/domain"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockRoutingRuleRepository is a mock implementation of domain.RoutingRuleRepository
+type MockRoutingRuleRepository struct {
+	mock.Mock
+}
+
+// FindAll mocks the method for finding the rules in evaluation order
+func (m *MockRoutingRuleRepository) FindAll(ctx context.Context) ([]domain.RoutingRule, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]domain.RoutingRule), args.Error(1)
+}
+
+// Find mocks the method for finding a rule
+func (m *MockRoutingRuleRepository) Find(ctx context.Context, id uint) (domain.RoutingRule, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(domain.RoutingRule), args.Error(1)
+}
+
+// Create mocks the method for creating a rule
+func (m *MockRoutingRuleRepository) Create(ctx context.Context, rule *domain.RoutingRule) error {
+	args := m.Called(ctx, rule)
+	return args.Error(0)
+}
+
+// Update mocks the method for updating a rule
+func (m *MockRoutingRuleRepository) Update(ctx context.Context, rule *domain.RoutingRule) error {
+	args := m.Called(ctx, rule)
+	return args.Error(0)
+}
+
+// Delete mocks the method for deleting a rule
+func (m *MockRoutingRuleRepository) Delete(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// MockRoutingUsecase is a mock implementation of domain.RoutingUsecase
+type MockRoutingUsecase struct {
+	mock.Mock
+}
+
+// List mocks the method for listing the rules
+func (m *MockRoutingUsecase) List(ctx context.Context) ([]domain.RoutingRule, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]domain.RoutingRule), args.Error(1)
+}
+
+// Get mocks the method for getting a rule
+func (m *MockRoutingUsecase) Get(ctx context.Context, id uint) (domain.RoutingRule, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(domain.RoutingRule), args.Error(1)
+}
+
+// Create mocks the method for creating a rule
+func (m *MockRoutingUsecase) Create(ctx context.Context, rule *domain.RoutingRule) error {
+	args := m.Called(ctx, rule)
+	return args.Error(0)
+}
+
+// Update mocks the method for updating a rule
+func (m *MockRoutingUsecase) Update(ctx context.Context, rule *domain.RoutingRule) error {
+	args := m.Called(ctx, rule)
+	return args.Error(0)
+}
+
+// Delete mocks the method for deleting a rule
+func (m *MockRoutingUsecase) Delete(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// Route mocks the method for routing an event with the cached rules
+func (m *MockRoutingUsecase) Route(ctx context.Context, event domain.Event) (domain.RoutingResult, error) {
+	args := m.Called(ctx, event)
+	return args.Get(0).(domain.RoutingResult), args.Error(1)
+}
+
+// Test mocks the method for routing an event with the stored rules
+func (m *MockRoutingUsecase) Test(ctx context.Context, event domain.Event) (domain.RoutingResult, error) {
+	args := m.Called(ctx, event)
+	return args.Get(0).(domain.RoutingResult), args.Error(1)
+}
@@ -0,0 +1,91 @@
+package domain_mock
+
+import (
+	"context"
+	"time"
+
+	"github.com/cvzm/go-web-project/domain"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockSilenceRepository is a mock implementation of domain.SilenceRepository
+type MockSilenceRepository struct {
+	mock.Mock
+}
+
+// FindAll mocks the method for finding the silences
+func (m *MockSilenceRepository) FindAll(ctx context.Context) ([]domain.Silence, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]domain.Silence), args.Error(1)
+}
+
+// FindCurrent mocks the method for finding the silences not ended
+func (m *MockSilenceRepository) FindCurrent(ctx context.Context, now time.Time) ([]domain.Silence, error) {
+	args := m.Called(ctx, now)
+	return args.Get(0).([]domain.Silence), args.Error(1)
+}
+
+// Find mocks the method for finding a silence
+func (m *MockSilenceRepository) Find(ctx context.Context, id uint) (domain.Silence, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(domain.Silence), args.Error(1)
+}
+
+// Create mocks the method for creating a silence
+func (m *MockSilenceRepository) Create(ctx context.Context, silence *domain.Silence) error {
+	args := m.Called(ctx, silence)
+	return args.Error(0)
+}
+
+// Update mocks the method for updating a silence
+func (m *MockSilenceRepository) Update(ctx context.Context, silence *domain.Silence) error {
+	args := m.Called(ctx, silence)
+	return args.Error(0)
+}
+
+// Delete mocks the method for deleting a silence
+func (m *MockSilenceRepository) Delete(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// MockSilenceUsecase is a mock implementation of domain.SilenceUsecase
+type MockSilenceUsecase struct {
+	mock.Mock
+}
+
+// List mocks the method for listing the silences
+func (m *MockSilenceUsecase) List(ctx context.Context) ([]domain.Silence, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]domain.Silence), args.Error(1)
+}
+
+// Get mocks the method for getting a silence
+func (m *MockSilenceUsecase) Get(ctx context.Context, id uint) (domain.Silence, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(domain.Silence), args.Error(1)
+}
+
+// Create mocks the method for creating a silence
+func (m *MockSilenceUsecase) Create(ctx context.Context, silence *domain.Silence) error {
+	args := m.Called(ctx, silence)
+	return args.Error(0)
+}
+
+// Update mocks the method for updating a silence
+func (m *MockSilenceUsecase) Update(ctx context.Context, silence *domain.Silence) error {
+	args := m.Called(ctx, silence)
+	return args.Error(0)
+}
+
+// Delete mocks the method for deleting a silence
+func (m *MockSilenceUsecase) Delete(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// Silenced mocks the method for checking whether an active silence matches an event
+func (m *MockSilenceUsecase) Silenced(ctx context.Context, event domain.Event) (bool, error) {
+	args := m.Called(ctx, event)
+	return args.Bool(0), args.Error(1)
+}
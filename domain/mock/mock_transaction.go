@@ -0,0 +1,70 @@
+package domain_mock
+
+import (
+	"context"
+
+	"github.com/cvzm/go-web-project/domain"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockRepositories is an implementation of domain.Repositories handing out repository mocks
+type MockRepositories struct {
+	EventRepo               *MockEventRepository
+	EventChangeRepo         *MockEventChangeRepository
+	EventArchiveRepo        *MockEventArchiveRepository
+	OutboxRepo              *MockOutboxRepository
+	WebhookSubscriptionRepo *MockWebhookSubscriptionRepository
+	WebhookDeliveryRepo     *MockWebhookDeliveryRepository
+	DigestRepo              *MockDigestRepository
+}
+
+// Events returns the event repository mock
+func (r *MockRepositories) Events() domain.EventRepository {
+	return r.EventRepo
+}
+
+// EventChanges returns the event change repository mock
+func (r *MockRepositories) EventChanges() domain.EventChangeRepository {
+	return r.EventChangeRepo
+}
+
+// EventArchive returns the event archive repository mock
+func (r *MockRepositories) EventArchive() domain.EventArchiveRepository {
+	return r.EventArchiveRepo
+}
+
+// Outbox returns the outbox repository mock
+func (r *MockRepositories) Outbox() domain.OutboxRepository {
+	return r.OutboxRepo
+}
+
+// WebhookSubscriptions returns the webhook subscription repository mock
+func (r *MockRepositories) WebhookSubscriptions() domain.WebhookSubscriptionRepository {
+	return r.WebhookSubscriptionRepo
+}
+
+// WebhookDeliveries returns the webhook delivery repository mock
+func (r *MockRepositories) WebhookDeliveries() domain.WebhookDeliveryRepository {
+	return r.WebhookDeliveryRepo
+}
+
+// Digests returns the digest repository mock
+func (r *MockRepositories) Digests() domain.DigestRepository {
+	return r.DigestRepo
+}
+
+// MockTransactionManager is a mock implementation of domain.TransactionManager.
+// It runs the unit of work with its repositories, then returns the mocked commit error.
+type MockTransactionManager struct {
+	mock.Mock
+	Repositories domain.Repositories
+}
+
+// Transaction mocks the method for running a unit of work
+func (m *MockTransactionManager) Transaction(ctx context.Context, fn domain.TransactionFunc) error {
+	args := m.Called(ctx)
+	if err := fn(ctx, m.Repositories); err != nil {
+		return err
+	}
+	return args.Error(0)
+}
@@ -0,0 +1,179 @@
+package domain_mock
+
+import (
+	"context"
+	"time"
+
+	"github.com/cvzm/go-web-project/domain"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockWebhookSubscriptionRepository is a mock implementation of domain.WebhookSubscriptionRepository
+type MockWebhookSubscriptionRepository struct {
+	mock.Mock
+}
+
+// FindAll mocks the method for finding all subscriptions
+func (m *MockWebhookSubscriptionRepository) FindAll(ctx context.Context) ([]domain.WebhookSubscription, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]domain.WebhookSubscription), args.Error(1)
+}
+
+// FindEnabled mocks the method for finding the enabled subscriptions
+func (m *MockWebhookSubscriptionRepository) FindEnabled(ctx context.Context) ([]domain.WebhookSubscription, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]domain.WebhookSubscription), args.Error(1)
+}
+
+// Find mocks the method for finding a subscription
+func (m *MockWebhookSubscriptionRepository) Find(ctx context.Context, id uint) (domain.WebhookSubscription, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(domain.WebhookSubscription), args.Error(1)
+}
+
+// Create mocks the method for creating a subscription
+func (m *MockWebhookSubscriptionRepository) Create(ctx context.Context, subscription *domain.WebhookSubscription) error {
+	args := m.Called(ctx, subscription)
+	return args.Error(0)
+}
+
+// Update mocks the method for updating a subscription
+func (m *MockWebhookSubscriptionRepository) Update(ctx context.Context, subscription *domain.WebhookSubscription) error {
+	args := m.Called(ctx, subscription)
+	return args.Error(0)
+}
+
+// Delete mocks the method for deleting a subscription
+func (m *MockWebhookSubscriptionRepository) Delete(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// RecordSuccess mocks the method for resetting the failures of a subscription
+func (m *MockWebhookSubscriptionRepository) RecordSuccess(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// RecordFailure mocks the method for counting a failure of a subscription
+func (m *MockWebhookSubscriptionRepository) RecordFailure(ctx context.Context, id uint, disableAfter int, reason string) (bool, error) {
+	args := m.Called(ctx, id, disableAfter, reason)
+	return args.Bool(0), args.Error(1)
+}
+
+// MockWebhookDeliveryRepository is a mock implementation of domain.WebhookDeliveryRepository
+type MockWebhookDeliveryRepository struct {
+	mock.Mock
+}
+
+// Add mocks the method for adding deliveries
+func (m *MockWebhookDeliveryRepository) Add(ctx context.Context, deliveries ...*domain.WebhookDelivery) error {
+	args := m.Called(ctx, deliveries)
+	return args.Error(0)
+}
+
+// FindDue mocks the method for locking the due deliveries
+func (m *MockWebhookDeliveryRepository) FindDue(ctx context.Context, now time.Time, limit int) ([]domain.WebhookDelivery, error) {
+	args := m.Called(ctx, now, limit)
+	return args.Get(0).([]domain.WebhookDelivery), args.Error(1)
+}
+
+// Find mocks the method for finding a delivery
+func (m *MockWebhookDeliveryRepository) Find(ctx context.Context, id uint) (domain.WebhookDelivery, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(domain.WebhookDelivery), args.Error(1)
+}
+
+// FindBySubscription mocks the method for querying the deliveries of a subscription
+func (m *MockWebhookDeliveryRepository) FindBySubscription(ctx context.Context, subscriptionID uint, query domain.WebhookDeliveryQuery) ([]domain.WebhookDelivery, error) {
+	args := m.Called(ctx, subscriptionID, query)
+	return args.Get(0).([]domain.WebhookDelivery), args.Error(1)
+}
+
+// Update mocks the method for recording the outcome of an attempt
+func (m *MockWebhookDeliveryRepository) Update(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	args := m.Called(ctx, delivery)
+	return args.Error(0)
+}
+
+// DeleteFinished mocks the method for deleting finished deliveries
+func (m *MockWebhookDeliveryRepository) DeleteFinished(ctx context.Context, before time.Time) (int64, error) {
+	args := m.Called(ctx, before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// MockWebhookSender is a mock implementation of domain.WebhookSender
+type MockWebhookSender struct {
+	mock.Mock
+}
+
+// Send mocks the method for posting a delivery
+func (m *MockWebhookSender) Send(ctx context.Context, subscription domain.WebhookSubscription, delivery domain.WebhookDelivery) (int, error) {
+	args := m.Called(ctx, subscription, delivery)
+	return args.Int(0), args.Error(1)
+}
+
+// MockWebhookUsecase is a mock implementation of domain.WebhookUsecase
+type MockWebhookUsecase struct {
+	mock.Mock
+}
+
+// List mocks the method for listing the subscriptions
+func (m *MockWebhookUsecase) List(ctx context.Context) ([]domain.WebhookSubscription, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]domain.WebhookSubscription), args.Error(1)
+}
+
+// Get mocks the method for getting a subscription
+func (m *MockWebhookUsecase) Get(ctx context.Context, id uint) (domain.WebhookSubscription, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(domain.WebhookSubscription), args.Error(1)
+}
+
+// Create mocks the method for creating a subscription
+func (m *MockWebhookUsecase) Create(ctx context.Context, subscription *domain.WebhookSubscription) error {
+	args := m.Called(ctx, subscription)
+	return args.Error(0)
+}
+
+// Update mocks the method for updating a subscription
+func (m *MockWebhookUsecase) Update(ctx context.Context, subscription *domain.WebhookSubscription) error {
+	args := m.Called(ctx, subscription)
+	return args.Error(0)
+}
+
+// Delete mocks the method for deleting a subscription
+func (m *MockWebhookUsecase) Delete(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// Enqueue mocks the method for recording the deliveries of an event
+func (m *MockWebhookUsecase) Enqueue(ctx context.Context, repos domain.Repositories, event domain.Event) error {
+	args := m.Called(ctx, repos, event)
+	return args.Error(0)
+}
+
+// Deliveries mocks the method for querying the deliveries of a subscription
+func (m *MockWebhookUsecase) Deliveries(ctx context.Context, subscriptionID uint, query domain.WebhookDeliveryQuery) ([]domain.WebhookDelivery, error) {
+	args := m.Called(ctx, subscriptionID, query)
+	return args.Get(0).([]domain.WebhookDelivery), args.Error(1)
+}
+
+// Redeliver mocks the method for redelivering a delivery
+func (m *MockWebhookUsecase) Redeliver(ctx context.Context, subscriptionID, deliveryID uint) (domain.WebhookDelivery, error) {
+	args := m.Called(ctx, subscriptionID, deliveryID)
+	return args.Get(0).(domain.WebhookDelivery), args.Error(1)
+}
+
+// Dispatch mocks the method for sending the due deliveries
+func (m *MockWebhookUsecase) Dispatch(ctx context.Context) (domain.WebhookDispatchResult, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(domain.WebhookDispatchResult), args.Error(1)
+}
+
+// Purge mocks the method for deleting finished deliveries
+func (m *MockWebhookUsecase) Purge(ctx context.Context, before time.Time) (int64, error) {
+	args := m.Called(ctx, before)
+	return args.Get(0).(int64), args.Error(1)
+}
@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"context"
+	"path"
+	"slices"
+)
+
+// Source and type of the notifications summarising the notifications of a channel suppressed by its rate limit
+const (
+	SourceNotifier                   EventSource = "Notifier"
+	EventTypeNotificationsSuppressed             = "NOTIFICATIONS_SUPPRESSED"
+)
+
+// EventFilter selects events by source, type and severity, an empty list matching any value
+type EventFilter struct {
+	Sources    []EventSource `json:"sources,omitempty"`
+	EventTypes []string      `json:"event_types,omitempty"` // glob patterns, e.g. EC2_*
+	Severities []Severity    `json:"severities,omitempty"`
+}
+
+// Matches reports whether the event passes the filter
+func (f EventFilter) Matches(event Event) bool {
+	if len(f.Sources) > 0 && !slices.Contains(f.Sources, event.Source) {
+		return false
+	}
+	if len(f.Severities) > 0 && !slices.Contains(f.Severities, event.Severity) {
+		return false
+	}
+	if len(f.EventTypes) == 0 {
+		return true
+	}
+	for _, pattern := range f.EventTypes {
+		if matched, _ := path.Match(pattern, event.EventType); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks the severities of the filter
+func (f EventFilter) Validate() error {
+	return validateSeverities(f.Severities)
+}
+
+// NotificationChannel defines the interface for a destination of event notifications, e.g. a Slack channel
+type NotificationChannel interface {
+	Name() string
+	// Send delivers the notification of the event, retrying as the destination allows
+	Send(ctx context.Context, event Event) error
+}
+
+// Notifier defines the interface for notifying about saved events.
+// Notify never blocks on delivery, which happens in the background. Silenced events are only
+// notified to the incident channels when they are recovery events, so that incidents still resolve.
+type Notifier interface {
+	Notify(ctx context.Context, event Event)
+}
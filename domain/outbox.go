@@ -0,0 +1,95 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// Constants defining the aggregates and event types of outbox messages
+const (
+	AggregateEvent = "event"
+
+	OutboxEventCreated = "event.created"
+)
+
+// OutboxMessage struct defines a message to publish downstream, recorded in the transaction of the change it describes
+type OutboxMessage struct {
+	ID            uint            `gorm:"primaryKey" json:"id"`
+	AggregateType string          `gorm:"type:varchar(100);not null" json:"aggregate_type"`
+	AggregateID   string          `gorm:"type:varchar(255);not null" json:"aggregate_id"` // messages of an aggregate are published in order
+	EventType     string          `gorm:"type:varchar(100);not null" json:"event_type"`
+	Payload       json.RawMessage `gorm:"type:jsonb;not null" json:"payload"`
+	Attempts      int             `gorm:"not null;default:0" json:"attempts"`
+	AvailableAt   time.Time       `gorm:"not null" json:"available_at"` // time of the next publishing attempt
+	LastError     string          `gorm:"type:text" json:"last_error,omitempty"`
+	CreatedAt     time.Time       `gorm:"autoCreateTime" json:"created_at"`
+	PublishedAt   *time.Time      `json:"published_at,omitempty"`
+}
+
+// TableName returns the name of the outbox messages table
+func (OutboxMessage) TableName() string {
+	return "outbox_messages"
+}
+
+// NewOutboxMessage creates an outbox message of the aggregate with the JSON encoded payload, available right away
+func NewOutboxMessage(aggregateType, aggregateID, eventType string, payload any) (*OutboxMessage, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return &OutboxMessage{
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		EventType:     eventType,
+		Payload:       data,
+		AvailableAt:   time.Now(),
+	}, nil
+}
+
+// OutboxStats describes the messages waiting in the outbox
+type OutboxStats struct {
+	Pending         int64
+	OldestPendingAt *time.Time // creation time of the oldest pending message, nil if there is none
+}
+
+// OutboxRelayResult describes the outcome of a relay run
+type OutboxRelayResult struct {
+	Published []OutboxMessage
+	Failed    []OutboxMessage // messages whose publishing failed, to be retried after a backoff
+}
+
+// OutboxRepository defines the interface for outbox storage
+type OutboxRepository interface {
+	Add(ctx context.Context, messages ...*OutboxMessage) error
+	// FindPublishable locks and returns the oldest pending message of each aggregate available at the given time,
+	// skipping the messages locked by other relays along with the following messages of their aggregate
+	FindPublishable(ctx context.Context, now time.Time, limit int) ([]OutboxMessage, error)
+	// MarkPublished marks a pending message as published, failing if it has already been marked
+	MarkPublished(ctx context.Context, id uint, publishedAt time.Time) error
+	// MarkFailed records a failed publishing attempt and postpones the next one
+	MarkFailed(ctx context.Context, id uint, availableAt time.Time, reason string) error
+	Stats(ctx context.Context) (OutboxStats, error)
+	// DeletePublished deletes the messages published before the given time and returns their number
+	DeletePublished(ctx context.Context, before time.Time) (int64, error)
+}
+
+// OutboxPublisher defines the interface for publishing outbox messages downstream, e.g. to Kafka or SNS
+type OutboxPublisher interface {
+	Publish(ctx context.Context, message OutboxMessage) error
+
+	// Close releases the resources held by the publisher
+	Close() error
+}
+
+// OutboxUsecase defines the interface for outbox use cases
+type OutboxUsecase interface {
+	// Enqueue records the message in the outbox within the unit of work of the repositories,
+	// unless no publisher is configured
+	Enqueue(ctx context.Context, repos Repositories, message *OutboxMessage) error
+	// Relay publishes the available pending messages, at most one per aggregate, and marks them as published
+	Relay(ctx context.Context) (OutboxRelayResult, error)
+	Stats(ctx context.Context) (OutboxStats, error)
+	// Purge deletes the messages published before the given time and returns their number
+	Purge(ctx context.Context, before time.Time) (int64, error)
+}
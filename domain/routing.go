@@ -0,0 +1,139 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// RoutingRule struct defines a rule routing the notifications of the matching events to notification channels.
+// Empty criteria match any event.
+type RoutingRule struct {
+	ID               uint           `gorm:"primaryKey" json:"id"`
+	Name             string         `gorm:"type:varchar(255);not null" json:"name"`
+	Priority         int            `gorm:"not null;default:0" json:"priority"` // rules are evaluated by ascending priority
+	Disabled         bool           `gorm:"not null;default:false" json:"disabled"`
+	Sources          pq.StringArray `gorm:"type:varchar(255)[]" json:"sources"`
+	EventTypes       pq.StringArray `gorm:"type:varchar(255)[]" json:"event_types"` // glob patterns, e.g. EC2_*
+	Resources        pq.StringArray `gorm:"type:varchar(255)[]" json:"resources"`   // glob patterns, any affected resource matching
+	Severities       pq.StringArray `gorm:"type:varchar(20)[]" json:"severities"`
+	DescriptionRegex string         `gorm:"type:text" json:"description_regex"`
+	Channels         pq.StringArray `gorm:"type:varchar(255)[];not null" json:"channels"`
+	Stop             bool           `gorm:"not null;default:false" json:"stop"` // no further rule is evaluated once this one matches
+	CreatedAt        time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt        time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+
+	descriptionRegexp *regexp.Regexp
+}
+
+// TableName returns the name of the routing rules table
+func (RoutingRule) TableName() string {
+	return "routing_rules"
+}
+
+// Validate checks the rule, compiling its description regex
+func (r *RoutingRule) Validate() error {
+	if r.Name == "" {
+		return &ValidationError{Message: "name is required"}
+	}
+	if len(r.Channels) == 0 {
+		return &ValidationError{Message: "at least one channel is required"}
+	}
+	for _, pattern := range append(append([]string{}, r.EventTypes...), r.Resources...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return &ValidationError{Message: fmt.Sprintf("invalid pattern %q", pattern)}
+		}
+	}
+	if err := validateSeverities(r.severities()); err != nil {
+		return err
+	}
+	if r.DescriptionRegex != "" {
+		re, err := regexp.Compile(r.DescriptionRegex)
+		if err != nil {
+			return &ValidationError{Message: fmt.Sprintf("invalid description regex: %v", err)}
+		}
+		r.descriptionRegexp = re
+	}
+	return nil
+}
+
+// severities returns the severities of the rule
+func (r *RoutingRule) severities() []Severity {
+	severities := make([]Severity, 0, len(r.Severities))
+	for _, severity := range r.Severities {
+		severities = append(severities, Severity(severity))
+	}
+	return severities
+}
+
+// Matches reports whether the event meets every criteria of the rule
+func (r *RoutingRule) Matches(event Event) bool {
+	filter := EventFilter{EventTypes: r.EventTypes, Severities: r.severities()}
+	for _, source := range r.Sources {
+		filter.Sources = append(filter.Sources, EventSource(source))
+	}
+	if !filter.Matches(event) {
+		return false
+	}
+	if len(r.Resources) > 0 && !matchesAny(r.Resources, event.AffectedResources) {
+		return false
+	}
+	if r.DescriptionRegex == "" {
+		return true
+	}
+	if r.descriptionRegexp == nil {
+		re, err := regexp.Compile(r.DescriptionRegex)
+		if err != nil {
+			return false
+		}
+		r.descriptionRegexp = re
+	}
+	return r.descriptionRegexp.MatchString(event.Description)
+}
+
+// matchesAny reports whether any value matches any of the glob patterns
+func matchesAny(patterns, values []string) bool {
+	for _, value := range values {
+		for _, pattern := range patterns {
+			if matched, _ := path.Match(pattern, value); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// RoutingResult describes the routing of an event
+type RoutingResult struct {
+	Rules    []RoutingRule `json:"rules"`    // matching rules, in evaluation order
+	Channels []string      `json:"channels"` // channels of the matching rules, without duplicates
+}
+
+// RoutingRuleRepository defines the interface for routing rule storage
+type RoutingRuleRepository interface {
+	// FindAll returns the rules in evaluation order
+	FindAll(ctx context.Context) ([]RoutingRule, error)
+	Find(ctx context.Context, id uint) (RoutingRule, error)
+	Create(ctx context.Context, rule *RoutingRule) error
+	Update(ctx context.Context, rule *RoutingRule) error
+	Delete(ctx context.Context, id uint) error
+}
+
+// RoutingUsecase defines the interface for notification routing use cases
+type RoutingUsecase interface {
+	List(ctx context.Context) ([]RoutingRule, error)
+	Get(ctx context.Context, id uint) (RoutingRule, error)
+	Create(ctx context.Context, rule *RoutingRule) error
+	Update(ctx context.Context, rule *RoutingRule) error
+	Delete(ctx context.Context, id uint) error
+
+	// Route returns the routing of the event, evaluating the rules until a matching stop rule.
+	// Rules are cached, hence changes made by other instances apply after the refresh interval.
+	Route(ctx context.Context, event Event) (RoutingResult, error)
+	// Test returns the routing of the event with the stored rules, bypassing the cache
+	Test(ctx context.Context, event Event) (RoutingResult, error)
+}
@@ -0,0 +1,113 @@
+package domain
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Severity represents the importance of an event
+type Severity string
+
+// Constants defining the severities, by increasing importance
+const (
+	SeverityInfo     Severity = "info"
+	SeverityWarning  Severity = "warning"
+	SeverityError    Severity = "error"
+	SeverityCritical Severity = "critical"
+)
+
+// Severities are the severities by increasing importance
+var Severities = []Severity{SeverityInfo, SeverityWarning, SeverityError, SeverityCritical}
+
+// Valid reports whether the severity is one of the known severities
+func (s Severity) Valid() bool {
+	return slices.Contains(Severities, s)
+}
+
+// AtLeast reports whether the severity is as important as the other one, or more
+func (s Severity) AtLeast(other Severity) bool {
+	return slices.Index(Severities, s) >= slices.Index(Severities, other)
+}
+
+// validateSeverities checks that the severities of a filter or a rule are known
+func validateSeverities(severities []Severity) error {
+	for _, severity := range severities {
+		if !severity.Valid() {
+			return &ValidationError{Message: fmt.Sprintf("invalid severity %q, expecting info, warning, error or critical", severity)}
+		}
+	}
+	return nil
+}
+
+// awsSeverities maps the AWS finding severity labels, e.g. of Security Hub or GuardDuty, to severities
+var awsSeverities = map[string]Severity{
+	"INFORMATIONAL": SeverityInfo,
+	"LOW":           SeverityInfo,
+	"MEDIUM":        SeverityWarning,
+	"HIGH":          SeverityError,
+	"CRITICAL":      SeverityCritical,
+}
+
+// gcpSeverities maps the GCP log entry severities to severities
+var gcpSeverities = map[string]Severity{
+	"DEFAULT":   SeverityInfo,
+	"DEBUG":     SeverityInfo,
+	"INFO":      SeverityInfo,
+	"NOTICE":    SeverityInfo,
+	"WARNING":   SeverityWarning,
+	"ERROR":     SeverityError,
+	"CRITICAL":  SeverityCritical,
+	"ALERT":     SeverityCritical,
+	"EMERGENCY": SeverityCritical,
+}
+
+// nativeSeverity maps a provider-native severity, empty if missing or unknown so that the event gets classified
+func nativeSeverity(severities map[string]Severity, native string) Severity {
+	return severities[strings.ToUpper(strings.TrimSpace(native))]
+}
+
+// SeverityRule classifies the events of the matching types and description as of its severity.
+// Empty criteria match any event.
+type SeverityRule struct {
+	Severity         Severity `json:"severity"`
+	EventTypes       []string `json:"event_types"` // glob patterns, e.g. EC2_*
+	DescriptionRegex string   `json:"description_regex"`
+
+	descriptionRegexp *regexp.Regexp
+}
+
+// Validate checks the rule, compiling its description regex
+func (r *SeverityRule) Validate() error {
+	if err := validateSeverities([]Severity{r.Severity}); err != nil {
+		return err
+	}
+	for _, pattern := range r.EventTypes {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return &ValidationError{Message: fmt.Sprintf("invalid pattern %q", pattern)}
+		}
+	}
+	if r.DescriptionRegex != "" {
+		re, err := regexp.Compile(r.DescriptionRegex)
+		if err != nil {
+			return &ValidationError{Message: fmt.Sprintf("invalid description regex: %v", err)}
+		}
+		r.descriptionRegexp = re
+	}
+	return nil
+}
+
+// Matches reports whether the event meets every criteria of the rule, which must have been validated
+func (r *SeverityRule) Matches(event Event) bool {
+	if !(EventFilter{EventTypes: r.EventTypes}).Matches(event) {
+		return false
+	}
+	return r.descriptionRegexp == nil || r.descriptionRegexp.MatchString(event.Description)
+}
+
+// EventClassifier defines the interface for assigning a severity to the events without a provider-native one
+type EventClassifier interface {
+	Classify(event Event) Severity
+}
@@ -0,0 +1,173 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// weekdays are the abbreviations of the days of a recurrence, indexed by time.Weekday
+var weekdays = []string{"SUN", "MON", "TUE", "WED", "THU", "FRI", "SAT"}
+
+// Silence struct defines a silence of the matching events, which are stored flagged as silenced without being
+// notified. A silence with a recurrence is a maintenance window, only active during the occurrences of its
+// recurrence between its start and end.
+type Silence struct {
+	ID         uint              `gorm:"primaryKey" json:"id"`
+	Sources    pq.StringArray    `gorm:"type:varchar(255)[]" json:"sources"`
+	EventTypes pq.StringArray    `gorm:"type:varchar(255)[]" json:"event_types"` // glob patterns, e.g. EC2_*
+	Resources  pq.StringArray    `gorm:"type:varchar(255)[]" json:"resources"`   // glob patterns, any affected resource matching
+	StartsAt   time.Time         `gorm:"not null" json:"starts_at"`
+	EndsAt     *time.Time        `json:"ends_at"` // only optional for maintenance windows, recurring until deleted
+	Recurrence SilenceRecurrence `gorm:"embedded;embeddedPrefix:recurrence_" json:"recurrence"`
+	CreatedBy  string            `gorm:"type:varchar(255);not null" json:"created_by"`
+	Comment    string            `gorm:"type:text" json:"comment"`
+	Active     bool              `gorm:"-" json:"active"` // whether the silence is active at the time of the request
+	CreatedAt  time.Time         `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time         `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// SilenceRecurrence is the weekly schedule of a maintenance window, none if it has no day
+type SilenceRecurrence struct {
+	Days     pq.StringArray `gorm:"type:varchar(3)[]" json:"days,omitempty"`      // SUN to SAT
+	Start    string         `gorm:"type:varchar(5)" json:"start,omitempty"`       // local start time of the occurrences, e.g. 02:00
+	Duration int            `gorm:"not null;default:0" json:"duration,omitempty"` // duration of the occurrences in minutes
+	Timezone string         `gorm:"type:varchar(64)" json:"timezone,omitempty"`   // IANA time zone of the start time, UTC if empty
+}
+
+// TableName returns the name of the silences table
+func (Silence) TableName() string {
+	return "silences"
+}
+
+// Validate checks the silence
+func (s *Silence) Validate() error {
+	if s.CreatedBy == "" {
+		return &ValidationError{Message: "created_by is required"}
+	}
+	if len(s.Sources) == 0 && len(s.EventTypes) == 0 && len(s.Resources) == 0 {
+		return &ValidationError{Message: "at least one matcher of sources, event_types or resources is required"}
+	}
+	for _, pattern := range append(append([]string{}, s.EventTypes...), s.Resources...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return &ValidationError{Message: fmt.Sprintf("invalid pattern %q", pattern)}
+		}
+	}
+	if s.StartsAt.IsZero() {
+		return &ValidationError{Message: "starts_at is required"}
+	}
+	if s.EndsAt == nil && !s.Recurring() {
+		return &ValidationError{Message: "ends_at is required unless the silence has a recurrence"}
+	}
+	if s.EndsAt != nil && !s.EndsAt.After(s.StartsAt) {
+		return &ValidationError{Message: "ends_at must be after starts_at"}
+	}
+	if !s.Recurring() {
+		if s.Recurrence.Start != "" || s.Recurrence.Duration != 0 || s.Recurrence.Timezone != "" {
+			return &ValidationError{Message: "recurrence requires at least one day"}
+		}
+		return nil
+	}
+	return s.Recurrence.validate()
+}
+
+// Recurring reports whether the silence is a recurring maintenance window
+func (s *Silence) Recurring() bool {
+	return len(s.Recurrence.Days) > 0
+}
+
+// ActiveAt reports whether the silence is active at the given time
+func (s *Silence) ActiveAt(t time.Time) bool {
+	if t.Before(s.StartsAt) || (s.EndsAt != nil && !t.Before(*s.EndsAt)) {
+		return false
+	}
+	if !s.Recurring() {
+		return true
+	}
+	return s.Recurrence.activeAt(t)
+}
+
+// Matches reports whether the event meets every matcher of the silence
+func (s *Silence) Matches(event Event) bool {
+	filter := EventFilter{EventTypes: s.EventTypes}
+	for _, source := range s.Sources {
+		filter.Sources = append(filter.Sources, EventSource(source))
+	}
+	if !filter.Matches(event) {
+		return false
+	}
+	return len(s.Resources) == 0 || matchesAny(s.Resources, event.AffectedResources)
+}
+
+// validate checks the recurrence
+func (r *SilenceRecurrence) validate() error {
+	for i, day := range r.Days {
+		r.Days[i] = strings.ToUpper(day)
+		if !slices.Contains(weekdays, r.Days[i]) {
+			return &ValidationError{Message: fmt.Sprintf("invalid recurrence day %q, expecting SUN to SAT", day)}
+		}
+	}
+	if _, err := time.Parse("15:04", r.Start); err != nil {
+		return &ValidationError{Message: "recurrence start must be a time of day, e.g. 02:00"}
+	}
+	if r.Duration <= 0 || r.Duration > 7*24*60 {
+		return &ValidationError{Message: "recurrence duration must be between 1 and 10080 minutes"}
+	}
+	if _, err := time.LoadLocation(r.Timezone); err != nil {
+		return &ValidationError{Message: fmt.Sprintf("invalid recurrence timezone %q", r.Timezone)}
+	}
+	return nil
+}
+
+// activeAt reports whether the given time falls within an occurrence, started on a day of the recurrence
+func (r *SilenceRecurrence) activeAt(t time.Time) bool {
+	location, err := time.LoadLocation(r.Timezone)
+	if err != nil {
+		return false
+	}
+	start, err := time.Parse("15:04", r.Start)
+	if err != nil {
+		return false
+	}
+	duration := time.Duration(r.Duration) * time.Minute
+	local := t.In(location)
+	// Occurrences started on the previous days may still be running
+	for days := 0; days <= r.Duration/(24*60)+1; days++ {
+		day := local.AddDate(0, 0, -days)
+		occurrence := time.Date(day.Year(), day.Month(), day.Day(), start.Hour(), start.Minute(), 0, 0, location)
+		if slices.Contains(r.Days, weekdays[occurrence.Weekday()]) && !t.Before(occurrence) && t.Before(occurrence.Add(duration)) {
+			return true
+		}
+	}
+	return false
+}
+
+// SilenceRepository defines the interface for silence storage
+type SilenceRepository interface {
+	// FindAll returns the silences, latest start first
+	FindAll(ctx context.Context) ([]Silence, error)
+	// FindCurrent returns the silences not ended at the given time
+	FindCurrent(ctx context.Context, now time.Time) ([]Silence, error)
+	Find(ctx context.Context, id uint) (Silence, error)
+	Create(ctx context.Context, silence *Silence) error
+	Update(ctx context.Context, silence *Silence) error
+	Delete(ctx context.Context, id uint) error
+}
+
+// SilenceUsecase defines the interface for silence use cases
+type SilenceUsecase interface {
+	List(ctx context.Context) ([]Silence, error)
+	Get(ctx context.Context, id uint) (Silence, error)
+	Create(ctx context.Context, silence *Silence) error
+	Update(ctx context.Context, silence *Silence) error
+	Delete(ctx context.Context, id uint) error
+
+	// Silenced reports whether an active silence matches the event. Silences are cached, hence changes made
+	// by other instances apply after the refresh interval.
+	Silenced(ctx context.Context, event Event) (bool, error)
+}
@@ -0,0 +1,25 @@
+package domain
+
+import "context"
+
+// Repositories defines the set of repositories bound to a transaction
+type Repositories interface {
+	Events() EventRepository
+	EventChanges() EventChangeRepository
+	EventArchive() EventArchiveRepository
+	Outbox() OutboxRepository
+	WebhookSubscriptions() WebhookSubscriptionRepository
+	WebhookDeliveries() WebhookDeliveryRepository
+	Digests() DigestRepository
+}
+
+// TransactionFunc is the work of a unit of work, run with the repositories of its transaction
+type TransactionFunc func(ctx context.Context, repos Repositories) error
+
+// TransactionManager defines the interface for running units of work across repositories
+type TransactionManager interface {
+	// Transaction runs fn in a transaction, committed if fn returns nil and rolled back otherwise.
+	// Called again with the context passed to fn, it runs the nested fn in a savepoint of the
+	// transaction, so that only the nested work is rolled back if it fails.
+	Transaction(ctx context.Context, fn TransactionFunc) error
+}
@@ -0,0 +1,172 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Statuses of webhook deliveries
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed" // given up after the maximum number of attempts
+)
+
+// WebhookSubscription struct defines a subscription of an HTTP endpoint to the events passing its filter.
+// Deliveries are signed with the secret of the subscription.
+type WebhookSubscription struct {
+	ID                  uint           `gorm:"primaryKey" json:"id"`
+	Name                string         `gorm:"type:varchar(255);not null" json:"name"`
+	URL                 string         `gorm:"type:text;not null" json:"url"`
+	Secret              string         `gorm:"type:varchar(255);not null" json:"secret,omitempty"` // only returned on creation
+	Sources             pq.StringArray `gorm:"type:varchar(255)[]" json:"sources"`
+	EventTypes          pq.StringArray `gorm:"type:varchar(255)[]" json:"event_types"` // glob patterns, e.g. EC2_*
+	Disabled            bool           `gorm:"not null;default:false" json:"disabled"`
+	DisabledReason      string         `gorm:"type:text" json:"disabled_reason,omitempty"`
+	ConsecutiveFailures int            `gorm:"not null;default:0" json:"consecutive_failures"`
+	CreatedAt           time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt           time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName returns the name of the webhook subscriptions table
+func (WebhookSubscription) TableName() string {
+	return "webhook_subscriptions"
+}
+
+// Validate checks the subscription
+func (s *WebhookSubscription) Validate() error {
+	if s.Name == "" {
+		return &ValidationError{Message: "name is required"}
+	}
+	u, err := url.Parse(s.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return &ValidationError{Message: "url must be an absolute http or https URL"}
+	}
+	for _, pattern := range s.EventTypes {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return &ValidationError{Message: fmt.Sprintf("invalid pattern %q", pattern)}
+		}
+	}
+	return nil
+}
+
+// Matches reports whether the event passes the filter of the subscription
+func (s *WebhookSubscription) Matches(event Event) bool {
+	filter := EventFilter{EventTypes: s.EventTypes}
+	for _, source := range s.Sources {
+		filter.Sources = append(filter.Sources, EventSource(source))
+	}
+	return filter.Matches(event)
+}
+
+// WebhookDelivery struct defines the delivery of an event to a subscription, and the outcome of its last attempt
+type WebhookDelivery struct {
+	ID             uint            `gorm:"primaryKey" json:"id"`
+	SubscriptionID uint            `gorm:"not null" json:"subscription_id"`
+	EventID        uint            `gorm:"not null" json:"event_id"`
+	EventType      string          `gorm:"type:varchar(100);not null" json:"event_type"` // type of the webhook, e.g. event.created
+	Payload        json.RawMessage `gorm:"type:jsonb;not null" json:"payload"`
+	Status         string          `gorm:"type:varchar(20);not null" json:"status"`
+	Attempts       int             `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt  time.Time       `gorm:"not null" json:"next_attempt_at"`
+	ResponseStatus int             `gorm:"not null;default:0" json:"response_status,omitempty"` // HTTP status of the last attempt, 0 if none
+	LastError      string          `gorm:"type:text" json:"last_error,omitempty"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time       `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time       `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName returns the name of the webhook deliveries table
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+// NewWebhookDelivery creates a pending delivery of the event to the subscription, due right away
+func NewWebhookDelivery(subscriptionID uint, event Event) (*WebhookDelivery, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	return &WebhookDelivery{
+		SubscriptionID: subscriptionID,
+		EventID:        event.ID,
+		EventType:      OutboxEventCreated,
+		Payload:        payload,
+		Status:         WebhookDeliveryPending,
+		NextAttemptAt:  time.Now(),
+	}, nil
+}
+
+// WebhookDeliveryQuery selects the deliveries of a subscription, newest first
+type WebhookDeliveryQuery struct {
+	Status string `query:"status"` // any status if empty
+	Limit  int    `query:"limit"`
+}
+
+// WebhookDispatchResult describes the outcome of a dispatch run
+type WebhookDispatchResult struct {
+	Succeeded []WebhookDelivery
+	Failed    []WebhookDelivery     // failed attempts, retried after a backoff unless given up
+	Disabled  []WebhookSubscription // subscriptions disabled after too many consecutive failures
+}
+
+// WebhookSubscriptionRepository defines the interface for webhook subscription storage
+type WebhookSubscriptionRepository interface {
+	FindAll(ctx context.Context) ([]WebhookSubscription, error)
+	FindEnabled(ctx context.Context) ([]WebhookSubscription, error)
+	Find(ctx context.Context, id uint) (WebhookSubscription, error)
+	Create(ctx context.Context, subscription *WebhookSubscription) error
+	Update(ctx context.Context, subscription *WebhookSubscription) error
+	Delete(ctx context.Context, id uint) error
+	// RecordSuccess resets the consecutive failures of the subscription
+	RecordSuccess(ctx context.Context, id uint) error
+	// RecordFailure counts a failure of the subscription, and disables it once it reaches the given number of
+	// consecutive failures, if not zero. It reports whether the subscription got disabled.
+	RecordFailure(ctx context.Context, id uint, disableAfter int, reason string) (bool, error)
+}
+
+// WebhookDeliveryRepository defines the interface for webhook delivery storage
+type WebhookDeliveryRepository interface {
+	Add(ctx context.Context, deliveries ...*WebhookDelivery) error
+	// FindDue locks the pending deliveries due at the given time of the enabled subscriptions
+	FindDue(ctx context.Context, now time.Time, limit int) ([]WebhookDelivery, error)
+	Find(ctx context.Context, id uint) (WebhookDelivery, error)
+	FindBySubscription(ctx context.Context, subscriptionID uint, query WebhookDeliveryQuery) ([]WebhookDelivery, error)
+	// Update records the outcome of an attempt
+	Update(ctx context.Context, delivery *WebhookDelivery) error
+	// DeleteFinished deletes the succeeded and failed deliveries last updated before the given time
+	DeleteFinished(ctx context.Context, before time.Time) (int64, error)
+}
+
+// WebhookSender defines the interface for sending signed deliveries to the endpoint of their subscription
+type WebhookSender interface {
+	// Send posts the delivery once, returning the HTTP status of the response, 0 if none was received
+	Send(ctx context.Context, subscription WebhookSubscription, delivery WebhookDelivery) (int, error)
+}
+
+// WebhookUsecase defines the interface for webhook use cases
+type WebhookUsecase interface {
+	List(ctx context.Context) ([]WebhookSubscription, error)
+	Get(ctx context.Context, id uint) (WebhookSubscription, error)
+	// Create creates the subscription, generating its secret if not set
+	Create(ctx context.Context, subscription *WebhookSubscription) error
+	// Update updates the subscription, keeping its secret if not set. Enabling it again resets its failures.
+	Update(ctx context.Context, subscription *WebhookSubscription) error
+	Delete(ctx context.Context, id uint) error
+
+	// Enqueue records the deliveries of the event to the matching subscriptions within the transaction of the repositories
+	Enqueue(ctx context.Context, repos Repositories, event Event) error
+	Deliveries(ctx context.Context, subscriptionID uint, query WebhookDeliveryQuery) ([]WebhookDelivery, error)
+	// Redeliver records a new delivery of the payload of the given delivery, due right away
+	Redeliver(ctx context.Context, subscriptionID, deliveryID uint) (WebhookDelivery, error)
+	// Dispatch sends a batch of due deliveries
+	Dispatch(ctx context.Context) (WebhookDispatchResult, error)
+	// Purge deletes the finished deliveries last updated before the given time, returning their number
+	Purge(ctx context.Context, before time.Time) (int64, error)
+}
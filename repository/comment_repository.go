@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/cvzm/go-web-project/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type eventCommentRepository struct {
+	db *gorm.DB
+}
+
+func NewEventCommentRepository(db *gorm.DB) domain.EventCommentRepository {
+	return &eventCommentRepository{db: db}
+}
+
+func (r *eventCommentRepository) FindByEvent(ctx context.Context, eventID uint) ([]domain.EventComment, error) {
+	comments := []domain.EventComment{}
+	err := r.db.WithContext(ctx).Where("event_id = ?", eventID).Order("id").Find(&comments).Error
+	return comments, err
+}
+
+func (r *eventCommentRepository) Find(ctx context.Context, eventID, id uint) (domain.EventComment, error) {
+	var comment domain.EventComment
+	err := r.db.WithContext(ctx).Where("id = ? AND event_id = ?", id, eventID).Take(&comment).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return comment, domain.ErrNotFound
+	}
+	return comment, err
+}
+
+func (r *eventCommentRepository) Create(ctx context.Context, comment *domain.EventComment) error {
+	return r.db.WithContext(ctx).Create(comment).Error
+}
+
+func (r *eventCommentRepository) UpdateBody(ctx context.Context, comment *domain.EventComment) error {
+	result := r.db.WithContext(ctx).Model(comment).Where("event_id = ?", comment.EventID).
+		Select("body", "updated_at").Updates(comment)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+type eventAnnotationRepository struct {
+	db *gorm.DB
+}
+
+func NewEventAnnotationRepository(db *gorm.DB) domain.EventAnnotationRepository {
+	return &eventAnnotationRepository{db: db}
+}
+
+func (r *eventAnnotationRepository) FindByEvent(ctx context.Context, eventID uint) ([]domain.EventAnnotation, error) {
+	annotations := []domain.EventAnnotation{}
+	err := r.db.WithContext(ctx).Where("event_id = ?", eventID).Order("key").Find(&annotations).Error
+	return annotations, err
+}
+
+func (r *eventAnnotationRepository) Save(ctx context.Context, annotation *domain.EventAnnotation) error {
+	// The annotation returned keeps its creation time when it replaces the value of its key
+	return r.db.WithContext(ctx).Clauses(
+		clause.OnConflict{
+			Columns:   []clause.Column{{Name: "event_id"}, {Name: "key"}},
+			DoUpdates: clause.AssignmentColumns([]string{"value", "author", "updated_at"}),
+		},
+		clause.Returning{Columns: []clause.Column{{Name: "id"}, {Name: "created_at"}}},
+	).Create(annotation).Error
+}
+
+func (r *eventAnnotationRepository) Delete(ctx context.Context, eventID uint, key string) error {
+	result := r.db.WithContext(ctx).Where("event_id = ? AND key = ?", eventID, key).Delete(&domain.EventAnnotation{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
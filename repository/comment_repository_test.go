@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/cvzm/go-web-project/adapter/storage"
+	"github.com/cvzm/go-web-project/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventCommentRepository_FindByEvent(t *testing.T) {
+	gormDB, mock := storage.GetMockDB(t)
+	repo := NewEventCommentRepository(gormDB)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "event_comments" WHERE event_id = $1 ORDER BY id`)).
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "event_id", "parent_id", "author", "body"}).
+			AddRow(1, 7, nil, "alice", "Disk full").
+			AddRow(2, 7, 1, "bob", "Cleaned up the logs"))
+
+	comments, err := repo.FindByEvent(context.Background(), 7)
+	require.NoError(t, err)
+	require.Len(t, comments, 2)
+	assert.Nil(t, comments[0].ParentID)
+	require.NotNil(t, comments[1].ParentID)
+	assert.Equal(t, uint(1), *comments[1].ParentID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestEventCommentRepository_Find(t *testing.T) {
+	t.Run("Finds the comment of the event", func(t *testing.T) {
+		gormDB, mock := storage.GetMockDB(t)
+		repo := NewEventCommentRepository(gormDB)
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "event_comments" WHERE id = $1 AND event_id = $2 LIMIT $3`)).
+			WithArgs(2, 7, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "event_id", "author"}).AddRow(2, 7, "bob"))
+
+		comment, err := repo.Find(context.Background(), 7, 2)
+		require.NoError(t, err)
+		assert.Equal(t, "bob", comment.Author)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Comment of another event", func(t *testing.T) {
+		gormDB, mock := storage.GetMockDB(t)
+		repo := NewEventCommentRepository(gormDB)
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "event_comments" WHERE id = $1 AND event_id = $2 LIMIT $3`)).
+			WithArgs(2, 8, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		_, err := repo.Find(context.Background(), 8, 2)
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestEventCommentRepository_Create(t *testing.T) {
+	gormDB, mock := storage.GetMockDB(t)
+	repo := NewEventCommentRepository(gormDB)
+	parentID := uint(1)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "event_comments" ("event_id","parent_id","author","body","created_at","updated_at") VALUES ($1,$2,$3,$4,$5,$6) RETURNING "id"`)).
+		WithArgs(7, 1, "bob", "Cleaned up the logs", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	mock.ExpectCommit()
+
+	comment := &domain.EventComment{EventID: 7, ParentID: &parentID, Author: "bob", Body: "Cleaned up the logs"}
+	require.NoError(t, repo.Create(context.Background(), comment))
+	assert.Equal(t, uint(2), comment.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestEventCommentRepository_UpdateBody(t *testing.T) {
+	t.Run("Updates the body", func(t *testing.T) {
+		gormDB, mock := storage.GetMockDB(t)
+		repo := NewEventCommentRepository(gormDB)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "event_comments" SET "body"=$1,"updated_at"=$2 WHERE event_id = $3 AND "id" = $4`)).
+			WithArgs("Rotated the logs", sqlmock.AnyArg(), 7, 2).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		comment := &domain.EventComment{ID: 2, EventID: 7, Body: "Rotated the logs"}
+		require.NoError(t, repo.UpdateBody(context.Background(), comment))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Comment not found", func(t *testing.T) {
+		gormDB, mock := storage.GetMockDB(t)
+		repo := NewEventCommentRepository(gormDB)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "event_comments"`)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		err := repo.UpdateBody(context.Background(), &domain.EventComment{ID: 9, EventID: 7, Body: "Rotated the logs"})
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+	})
+}
+
+func TestEventAnnotationRepository_FindByEvent(t *testing.T) {
+	gormDB, mock := storage.GetMockDB(t)
+	repo := NewEventAnnotationRepository(gormDB)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "event_annotations" WHERE event_id = $1 ORDER BY key`)).
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "event_id", "key", "value"}).
+			AddRow(1, 7, "jira", "OPS-42").
+			AddRow(2, 7, "runbook", "https://runbooks.example.com/disk-full"))
+
+	annotations, err := repo.FindByEvent(context.Background(), 7)
+	require.NoError(t, err)
+	require.Len(t, annotations, 2)
+	assert.Equal(t, "OPS-42", annotations[0].Value)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestEventAnnotationRepository_Save(t *testing.T) {
+	gormDB, mock := storage.GetMockDB(t)
+	repo := NewEventAnnotationRepository(gormDB)
+	createdAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "event_annotations" ("event_id","key","value","author","created_at","updated_at") VALUES ($1,$2,$3,$4,$5,$6) ON CONFLICT ("event_id","key") DO UPDATE SET "value"="excluded"."value","author"="excluded"."author","updated_at"="excluded"."updated_at" RETURNING "id","created_at"`)).
+		WithArgs(7, "jira", "OPS-43", "bob", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(1, createdAt))
+	mock.ExpectCommit()
+
+	annotation := &domain.EventAnnotation{EventID: 7, Key: "jira", Value: "OPS-43", Author: "bob"}
+	require.NoError(t, repo.Save(context.Background(), annotation))
+	assert.Equal(t, uint(1), annotation.ID)
+	assert.Equal(t, createdAt, annotation.CreatedAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestEventAnnotationRepository_Delete(t *testing.T) {
+	t.Run("Deletes the annotation", func(t *testing.T) {
+		gormDB, mock := storage.GetMockDB(t)
+		repo := NewEventAnnotationRepository(gormDB)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "event_annotations" WHERE event_id = $1 AND key = $2`)).
+			WithArgs(7, "jira").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		require.NoError(t, repo.Delete(context.Background(), 7, "jira"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Annotation not found", func(t *testing.T) {
+		gormDB, mock := storage.GetMockDB(t)
+		repo := NewEventAnnotationRepository(gormDB)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "event_annotations"`)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		assert.ErrorIs(t, repo.Delete(context.Background(), 7, "jira"), domain.ErrNotFound)
+	})
+}
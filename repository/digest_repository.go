@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/cvzm/go-web-project/domain"
+
+	"gorm.io/gorm"
+)
+
+type digestRepository struct {
+	db *gorm.DB
+}
+
+func NewDigestRepository(db *gorm.DB) domain.DigestRepository {
+	return &digestRepository{db: db}
+}
+
+// Claim inserts the digest row, which another transaction claiming the same digest waits for,
+// then finds already recorded once the first one commits
+func (r *digestRepository) Claim(ctx context.Context, period string, from time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Exec(
+		"INSERT INTO notification_digests (period, period_start, sent_at) VALUES (?, ?, ?) ON CONFLICT DO NOTHING",
+		period, from, time.Now())
+	return result.RowsAffected == 1, result.Error
+}
@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/cvzm/go-web-project/adapter/storage"
+	"github.com/cvzm/go-web-project/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDigestRepositoryClaim(t *testing.T) {
+	from := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	query := regexp.QuoteMeta(`INSERT INTO notification_digests (period, period_start, sent_at) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING`)
+
+	t.Run("Claimed", func(t *testing.T) {
+		gormDB, mock := storage.GetMockDB(t)
+		repo := NewDigestRepository(gormDB)
+
+		mock.ExpectExec(query).WithArgs("hourly", from, sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		claimed, err := repo.Claim(context.Background(), domain.DigestHourly, from)
+		assert.NoError(t, err)
+		assert.True(t, claimed)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Already sent", func(t *testing.T) {
+		gormDB, mock := storage.GetMockDB(t)
+		repo := NewDigestRepository(gormDB)
+
+		mock.ExpectExec(query).WithArgs("hourly", from, sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		claimed, err := repo.Claim(context.Background(), domain.DigestHourly, from)
+		assert.NoError(t, err)
+		assert.False(t, claimed)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cvzm/go-web-project/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type eventArchiveRepository struct {
+	db *gorm.DB
+}
+
+func NewEventArchiveRepository(db *gorm.DB) domain.EventArchiveRepository {
+	return &eventArchiveRepository{db: db}
+}
+
+func (r *eventArchiveRepository) FindArchiveBatches(ctx context.Context, before time.Time) ([]domain.ArchiveBatch, error) {
+	var rows []struct {
+		Source domain.EventSource
+		Day    time.Time
+	}
+	err := r.db.WithContext(ctx).Model(&domain.Event{}).
+		Select("source, date_trunc('day', created_at AT TIME ZONE 'UTC') AS day").
+		Where("created_at < ?", before).
+		Group("source, day").
+		Order("day, source").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	batches := make([]domain.ArchiveBatch, 0, len(rows))
+	for _, row := range rows {
+		day := row.Day
+		batches = append(batches, domain.ArchiveBatch{
+			Source: row.Source,
+			Day:    time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC),
+		})
+	}
+	return batches, nil
+}
+
+func (r *eventArchiveRepository) FindBatchEvents(ctx context.Context, batch domain.ArchiveBatch) ([]domain.Event, error) {
+	events := []domain.Event{}
+	err := r.db.WithContext(ctx).Scopes(batchScope(batch)).Order("id").Find(&events).Error
+	return events, err
+}
+
+func (r *eventArchiveRepository) DeleteBatchEvents(ctx context.Context, batch domain.ArchiveBatch, lastID uint, count int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Scopes(batchScope(batch)).Where("id <= ?", lastID).Delete(&domain.Event{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != int64(count) {
+			// Events were added to or removed from the batch since it was archived
+			return fmt.Errorf("expected to delete %d archived events, found %d", count, result.RowsAffected)
+		}
+		return nil
+	})
+}
+
+func (r *eventArchiveRepository) Restore(ctx context.Context, events []domain.Event) (int, error) {
+	if len(events) == 0 {
+		return 0, nil
+	}
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&events)
+	return int(result.RowsAffected), result.Error
+}
+
+// batchScope restricts a query to the events of the batch
+func batchScope(batch domain.ArchiveBatch) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("source = ? AND created_at >= ? AND created_at < ?", batch.Source, batch.Day, batch.Day.AddDate(0, 0, 1))
+	}
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/cvzm/go-web-project/adapter/storage"
+	"github.com/cvzm/go-web-project/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testBatch = domain.ArchiveBatch{Source: domain.SourceAWS, Day: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)}
+
+func TestEventArchiveRepository_FindArchiveBatches(t *testing.T) {
+	gormDB, mock := storage.GetMockDB(t)
+	repo := NewEventArchiveRepository(gormDB)
+	before := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT source, date_trunc('day', created_at AT TIME ZONE 'UTC') AS day FROM "events" WHERE created_at < $1 GROUP BY source, day ORDER BY day, source`)).
+		WithArgs(before).
+		WillReturnRows(sqlmock.NewRows([]string{"source", "day"}).
+			AddRow("AWS", testBatch.Day).
+			AddRow("GCP", testBatch.Day))
+
+	batches, err := repo.FindArchiveBatches(context.Background(), before)
+	require.NoError(t, err)
+	assert.Equal(t, []domain.ArchiveBatch{testBatch, {Source: domain.SourceGCP, Day: testBatch.Day}}, batches)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestEventArchiveRepository_FindBatchEvents(t *testing.T) {
+	gormDB, mock := storage.GetMockDB(t)
+	repo := NewEventArchiveRepository(gormDB)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "events" WHERE source = $1 AND created_at >= $2 AND created_at < $3 ORDER BY id`)).
+		WithArgs(domain.SourceAWS, testBatch.Day, testBatch.Day.AddDate(0, 0, 1)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "source", "event_type"}).AddRow(1, "AWS", "EC2_STARTED"))
+
+	events, err := repo.FindBatchEvents(context.Background(), testBatch)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, uint(1), events[0].ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestEventArchiveRepository_DeleteBatchEvents(t *testing.T) {
+	deleteSQL := regexp.QuoteMeta(`DELETE FROM "events" WHERE id <= $1 AND (source = $2 AND created_at >= $3 AND created_at < $4)`)
+
+	t.Run("Deletes the archived events", func(t *testing.T) {
+		gormDB, mock := storage.GetMockDB(t)
+		repo := NewEventArchiveRepository(gormDB)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(deleteSQL).
+			WithArgs(7, domain.SourceAWS, testBatch.Day, testBatch.Day.AddDate(0, 0, 1)).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+
+		assert.NoError(t, repo.DeleteBatchEvents(context.Background(), testBatch, 7, 2))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Rolls back when the batch changed", func(t *testing.T) {
+		gormDB, mock := storage.GetMockDB(t)
+		repo := NewEventArchiveRepository(gormDB)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(deleteSQL).WillReturnResult(sqlmock.NewResult(0, 3))
+		mock.ExpectRollback()
+
+		assert.ErrorContains(t, repo.DeleteBatchEvents(context.Background(), testBatch, 7, 2), "expected to delete 2 archived events, found 3")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestEventArchiveRepository_Restore(t *testing.T) {
+	gormDB, mock := storage.GetMockDB(t)
+	repo := NewEventArchiveRepository(gormDB)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "events"`) + ".*" + regexp.QuoteMeta(`ON CONFLICT DO NOTHING`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	restored, err := repo.Restore(context.Background(), []domain.Event{{ID: 1, Source: domain.SourceAWS, EventType: "EC2_STARTED", CreatedAt: testBatch.Day}})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, restored)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/cvzm/go-web-project/domain"
+
+	"gorm.io/gorm"
+)
+
+type eventChangeRepository struct {
+	db *gorm.DB
+}
+
+func NewEventChangeRepository(db *gorm.DB) domain.EventChangeRepository {
+	return &eventChangeRepository{db: db}
+}
+
+func (r *eventChangeRepository) Create(ctx context.Context, change *domain.EventChange) error {
+	return r.db.WithContext(ctx).Create(change).Error
+}
+
+func (r *eventChangeRepository) FindByEvent(ctx context.Context, eventID uint) ([]domain.EventChange, error) {
+	changes := []domain.EventChange{}
+	err := r.db.WithContext(ctx).Where("event_id = ?", eventID).Order("id").Find(&changes).Error
+	return changes, err
+}
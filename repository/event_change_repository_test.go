@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/cvzm/go-web-project/adapter/storage"
+	"github.com/cvzm/go-web-project/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventChangeRepository_Create(t *testing.T) {
+	gormDB, mock := storage.GetMockDB(t)
+	repo := NewEventChangeRepository(gormDB)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "event_changes" ("event_id","action","actor","from_status","to_status","from_assignee","to_assignee","note","created_at") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9) RETURNING "id"`)).
+		WithArgs(7, "assign", "bob", "open", "open", "", "alice", "Owner of the database", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	change := &domain.EventChange{EventID: 7, Action: domain.EventActionAssign, Actor: "bob", FromStatus: domain.EventStatusOpen,
+		ToStatus: domain.EventStatusOpen, ToAssignee: "alice", Note: "Owner of the database"}
+	require.NoError(t, repo.Create(context.Background(), change))
+	assert.Equal(t, uint(1), change.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestEventChangeRepository_FindByEvent(t *testing.T) {
+	gormDB, mock := storage.GetMockDB(t)
+	repo := NewEventChangeRepository(gormDB)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "event_changes" WHERE event_id = $1 ORDER BY id`)).
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "event_id", "action", "actor", "from_status", "to_status"}).
+			AddRow(1, 7, "acknowledge", "alice", "open", "acknowledged").
+			AddRow(2, 7, "resolve", "alice", "acknowledged", "resolved"))
+
+	changes, err := repo.FindByEvent(context.Background(), 7)
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+	assert.Equal(t, domain.EventActionResolve, changes[1].Action)
+	assert.Equal(t, domain.EventStatusResolved, changes[1].ToStatus)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
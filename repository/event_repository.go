@@ -1,9 +1,15 @@
 package repository
 
 import (
+	"context"
+	"errors"
+	"time"
+
 	"github.com/cvzm/go-web-project/domain"
 
+	"github.com/lib/pq"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type eventRepository struct {
@@ -14,10 +20,102 @@ func NewEventRepository(db *gorm.DB) domain.EventRepository {
 	return &eventRepository{db: db}
 }
 
-func (r *eventRepository) Save(event *domain.Event) error {
-	return Save(r.db, event)
+func (r *eventRepository) Save(ctx context.Context, event *domain.Event) error {
+	return Save(ctx, r.db, event)
+}
+
+func (r *eventRepository) FindAll(ctx context.Context) ([]domain.Event, error) {
+	return FindAll(ctx, r.db, &domain.Event{})
+}
+
+func (r *eventRepository) CountByType(ctx context.Context, from, to time.Time) ([]domain.EventCount, error) {
+	counts := []domain.EventCount{}
+	err := r.db.WithContext(ctx).Model(&domain.Event{}).
+		Select("source, event_type, count(*) AS count").
+		Where("created_at >= ? AND created_at < ?", from, to).
+		Group("source, event_type").
+		Order("count DESC, source, event_type").
+		Scan(&counts).Error
+	return counts, err
+}
+
+func (r *eventRepository) FindLatest(ctx context.Context, source domain.EventSource, eventTypes []string, from, to time.Time, limit int) ([]domain.Event, error) {
+	events := []domain.Event{}
+	err := r.db.WithContext(ctx).
+		Where("source = ? AND event_type IN ? AND created_at >= ? AND created_at < ?", source, eventTypes, from, to).
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Find(&events).Error
+	return events, err
+}
+
+func (r *eventRepository) Query(ctx context.Context, query domain.EventQuery) ([]domain.Event, error) {
+	db := r.db.WithContext(ctx)
+	if len(query.Sources) > 0 {
+		db = db.Where("source IN ?", query.Sources)
+	}
+	if len(query.EventTypes) > 0 {
+		db = db.Where("event_type IN ?", query.EventTypes)
+	}
+	if severities := query.SeverityRange(); severities != nil {
+		db = db.Where("severity IN ?", severities)
+	}
+	if len(query.Statuses) > 0 {
+		db = db.Where("status IN ?", query.Statuses)
+	}
+	if query.Assignee != "" {
+		db = db.Where("assignee = ?", query.Assignee)
+	}
+	if query.Resource != "" {
+		// Containment is served by the GIN index of the affected resources
+		db = db.Where("affected_resources @> ?", pq.StringArray{query.Resource})
+	}
+	for _, filter := range query.AnnotationFilters() {
+		annotations := r.db.Table("event_annotations").Select("1").
+			Where("event_annotations.event_id = events.id").Where("event_annotations.key = ?", filter.Key)
+		if filter.Value != "" {
+			annotations = annotations.Where("event_annotations.value = ?", filter.Value)
+		}
+		db = db.Where("EXISTS (?)", annotations)
+	}
+	if query.From != nil {
+		db = db.Where("created_at >= ?", *query.From)
+	}
+	if query.To != nil {
+		db = db.Where("created_at < ?", *query.To)
+	}
+
+	events := []domain.Event{}
+	err := db.Order("created_at DESC, id DESC").Limit(query.Limit).Offset(query.Offset).Find(&events).Error
+	return events, err
+}
+
+func (r *eventRepository) Find(ctx context.Context, id uint) (domain.Event, error) {
+	return r.find(r.db.WithContext(ctx), id)
+}
+
+func (r *eventRepository) Lock(ctx context.Context, id uint) (domain.Event, error) {
+	return r.find(r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}), id)
+}
+
+// find finds the event by ID, which is unique across the partitions of the events table
+func (r *eventRepository) find(db *gorm.DB, id uint) (domain.Event, error) {
+	var event domain.Event
+	err := db.Where("id = ?", id).Take(&event).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return event, domain.ErrNotFound
+	}
+	return event, err
 }
 
-func (r *eventRepository) FindAll() ([]domain.Event, error) {
-	return FindAll(r.db, &domain.Event{})
+func (r *eventRepository) UpdateStatus(ctx context.Context, event *domain.Event) error {
+	// The partition key is part of the primary key of the model, so the update only scans the partition of the event
+	result := r.db.WithContext(ctx).Model(event).Select("status", "assignee", "acknowledged_at", "resolved_at", "updated_at").Updates(event)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
 }
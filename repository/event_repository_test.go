@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"regexp"
 	"testing"
 	"time"
@@ -26,9 +27,9 @@ func TestEventRepositorySave(t *testing.T) {
 	createdAt := time.Now()
 
 	mock.ExpectBegin()
-	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "events"`)).
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "events"`)+".*"+regexp.QuoteMeta(`ON CONFLICT ("id","created_at") DO UPDATE`)).
 		WithArgs("AWS", "EC2_STARTED", "EC2 instance started",
-			pq.StringArray([]string{"A", "B"}),
+			pq.StringArray([]string{"A", "B"}), "error", false, "open", "", nil, nil,
 			createdAt, sqlmock.AnyArg(), sqlmock.AnyArg()).
 		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 	mock.ExpectCommit()
@@ -38,9 +39,11 @@ func TestEventRepositorySave(t *testing.T) {
 		EventType:         "EC2_STARTED",
 		Description:       "EC2 instance started",
 		AffectedResources: []string{"A", "B"},
+		Severity:          domain.SeverityError,
+		Status:            domain.EventStatusOpen,
 		CreatedAt:         createdAt,
 	}
-	err := repo.Save(event)
+	err := repo.Save(context.Background(), event)
 	assert.NoError(t, err)
 	assert.Equal(t, uint(1), event.ID)
 
@@ -58,7 +61,7 @@ func TestEventRepositoryFindAll(t *testing.T) {
 			AddRow(1, "AWS", "EC2_STARTED", "EC2 instance started", timestamp, timestamp).
 			AddRow(2, "GCP", "VM_STOPPED", "VM instance stopped", timestamp, timestamp))
 
-	events, err := repo.FindAll()
+	events, err := repo.FindAll(context.Background())
 	assert.NoError(t, err)
 	assert.Equal(t, []domain.Event{
 		{
@@ -81,3 +84,143 @@ func TestEventRepositoryFindAll(t *testing.T) {
 
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestEventRepositoryCountByType(t *testing.T) {
+	gormDB, mock := storage.GetMockDB(t)
+	repo := NewEventRepository(gormDB)
+	from := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	to := from.Add(time.Hour)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT source, event_type, count(*) AS count FROM "events" WHERE created_at >= $1 AND created_at < $2 GROUP BY source, event_type ORDER BY count DESC, source, event_type`)).
+		WithArgs(from, to).
+		WillReturnRows(sqlmock.NewRows([]string{"source", "event_type", "count"}).
+			AddRow("AWS", "EC2_STOPPED", 3).
+			AddRow("GCP", "VM_STARTED", 1))
+
+	counts, err := repo.CountByType(context.Background(), from, to)
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.EventCount{
+		{Source: domain.SourceAWS, EventType: "EC2_STOPPED", Count: 3},
+		{Source: domain.SourceGCP, EventType: "VM_STARTED", Count: 1},
+	}, counts)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestEventRepositoryFindLatest(t *testing.T) {
+	gormDB, mock := storage.GetMockDB(t)
+	repo := NewEventRepository(gormDB)
+	from := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	to := from.Add(time.Hour)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "events" WHERE source = $1 AND event_type IN ($2,$3) AND created_at >= $4 AND created_at < $5 ORDER BY created_at DESC, id DESC LIMIT $6`)).
+		WithArgs("AWS", "EC2_STOPPED", "EC2_TERMINATED", from, to, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "source", "event_type"}).AddRow(7, "AWS", "EC2_STOPPED"))
+
+	events, err := repo.FindLatest(context.Background(), domain.SourceAWS, []string{"EC2_STOPPED", "EC2_TERMINATED"}, from, to, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.Event{{ID: 7, Source: domain.SourceAWS, EventType: "EC2_STOPPED"}}, events)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestEventRepositoryQuery(t *testing.T) {
+	t.Run("Filters by every criteria", func(t *testing.T) {
+		gormDB, mock := storage.GetMockDB(t)
+		repo := NewEventRepository(gormDB)
+		from := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+		to := from.Add(time.Hour)
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "events" WHERE source IN ($1) AND event_type IN ($2,$3) AND severity IN ($4,$5) AND status IN ($6,$7) AND assignee = $8 AND affected_resources @> $9 AND created_at >= $10 AND created_at < $11 ORDER BY created_at DESC, id DESC LIMIT $12 OFFSET $13`)).
+			WithArgs("AWS", "EC2_STOPPED", "EC2_TERMINATED", "error", "critical", "open", "acknowledged", "alice", pq.StringArray{"i-123"}, from, to, 50, 100).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "source", "event_type", "severity"}).AddRow(7, "AWS", "EC2_STOPPED", "critical"))
+
+		events, err := repo.Query(context.Background(), domain.EventQuery{
+			Sources:     []domain.EventSource{domain.SourceAWS},
+			EventTypes:  []string{"EC2_STOPPED", "EC2_TERMINATED"},
+			MinSeverity: domain.SeverityError,
+			Statuses:    []domain.EventStatus{domain.EventStatusOpen, domain.EventStatusAcknowledged},
+			Assignee:    "alice",
+			Resource:    "i-123",
+			From:        &from,
+			To:          &to,
+			Limit:       50,
+			Offset:      100,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []domain.Event{{ID: 7, Source: domain.SourceAWS, EventType: "EC2_STOPPED", Severity: domain.SeverityCritical}}, events)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Filters by annotations", func(t *testing.T) {
+		gormDB, mock := storage.GetMockDB(t)
+		repo := NewEventRepository(gormDB)
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "events" WHERE EXISTS (SELECT 1 FROM "event_annotations" WHERE event_annotations.event_id = events.id AND event_annotations.key = $1 AND event_annotations.value = $2) AND EXISTS (SELECT 1 FROM "event_annotations" WHERE event_annotations.event_id = events.id AND event_annotations.key = $3) ORDER BY created_at DESC, id DESC LIMIT $4`)).
+			WithArgs("jira", "OPS-42", "runbook", 100).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+
+		events, err := repo.Query(context.Background(), domain.EventQuery{Annotations: []string{"jira:OPS-42", "runbook"}, Limit: 100})
+		assert.NoError(t, err)
+		assert.Equal(t, []domain.Event{{ID: 7}}, events)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Returns the latest events without criteria", func(t *testing.T) {
+		gormDB, mock := storage.GetMockDB(t)
+		repo := NewEventRepository(gormDB)
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "events" ORDER BY created_at DESC, id DESC LIMIT $1`)).
+			WithArgs(100).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		events, err := repo.Query(context.Background(), domain.EventQuery{Limit: 100})
+		assert.NoError(t, err)
+		assert.Empty(t, events)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestEventRepositoryLock(t *testing.T) {
+	t.Run("Locks the event", func(t *testing.T) {
+		gormDB, mock := storage.GetMockDB(t)
+		repo := NewEventRepository(gormDB)
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "events" WHERE id = $1 LIMIT $2 FOR UPDATE`)).
+			WithArgs(7, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).AddRow(7, "open"))
+
+		event, err := repo.Lock(context.Background(), 7)
+		assert.NoError(t, err)
+		assert.Equal(t, domain.EventStatusOpen, event.Status)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Fails when not found", func(t *testing.T) {
+		gormDB, mock := storage.GetMockDB(t)
+		repo := NewEventRepository(gormDB)
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "events" WHERE id = $1 LIMIT $2 FOR UPDATE`)).
+			WithArgs(7, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		_, err := repo.Lock(context.Background(), 7)
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestEventRepositoryUpdateStatus(t *testing.T) {
+	gormDB, mock := storage.GetMockDB(t)
+	repo := NewEventRepository(gormDB)
+	createdAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	acknowledgedAt := createdAt.Add(time.Hour)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "events" SET "status"=$1,"assignee"=$2,"acknowledged_at"=$3,"resolved_at"=$4,"updated_at"=$5 WHERE "id" = $6 AND "created_at" = $7`)).
+		WithArgs("acknowledged", "alice", acknowledgedAt, nil, sqlmock.AnyArg(), 7, createdAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	event := &domain.Event{ID: 7, Status: domain.EventStatusAcknowledged, Assignee: "alice", AcknowledgedAt: &acknowledgedAt, CreatedAt: createdAt}
+	assert.NoError(t, repo.UpdateStatus(context.Background(), event))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
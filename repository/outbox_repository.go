@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cvzm/go-web-project/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type outboxRepository struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepository(db *gorm.DB) domain.OutboxRepository {
+	return &outboxRepository{db: db}
+}
+
+func (r *outboxRepository) Add(ctx context.Context, messages ...*domain.OutboxMessage) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Create(messages).Error
+}
+
+func (r *outboxRepository) FindPublishable(ctx context.Context, now time.Time, limit int) ([]domain.OutboxMessage, error) {
+	messages := []domain.OutboxMessage{}
+	// A message locked by another relay is still pending for this one, which keeps the following
+	// messages of its aggregate out of the batch
+	err := r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("published_at IS NULL AND available_at <= ?", now).
+		Where(`NOT EXISTS (SELECT 1 FROM outbox_messages AS previous WHERE previous.aggregate_type = outbox_messages.aggregate_type
+			AND previous.aggregate_id = outbox_messages.aggregate_id AND previous.published_at IS NULL AND previous.id < outbox_messages.id)`).
+		Order("id").
+		Limit(limit).
+		Find(&messages).Error
+	return messages, err
+}
+
+func (r *outboxRepository) MarkPublished(ctx context.Context, id uint, publishedAt time.Time) error {
+	result := r.db.WithContext(ctx).Model(&domain.OutboxMessage{}).
+		Where("id = ? AND published_at IS NULL", id).
+		Update("published_at", publishedAt)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected != 1 {
+		return fmt.Errorf("outbox message %d is not pending", id)
+	}
+	return nil
+}
+
+func (r *outboxRepository) MarkFailed(ctx context.Context, id uint, availableAt time.Time, reason string) error {
+	return r.db.WithContext(ctx).Model(&domain.OutboxMessage{}).Where("id = ?", id).Updates(map[string]any{
+		"attempts":     gorm.Expr("attempts + 1"),
+		"available_at": availableAt,
+		"last_error":   reason,
+	}).Error
+}
+
+func (r *outboxRepository) Stats(ctx context.Context) (domain.OutboxStats, error) {
+	var stats domain.OutboxStats
+	err := r.db.WithContext(ctx).Model(&domain.OutboxMessage{}).
+		Select("count(*) AS pending, min(created_at) AS oldest_pending_at").
+		Where("published_at IS NULL").
+		Scan(&stats).Error
+	return stats, err
+}
+
+func (r *outboxRepository) DeletePublished(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("published_at < ?", before).Delete(&domain.OutboxMessage{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/cvzm/go-web-project/adapter/storage"
+	"github.com/cvzm/go-web-project/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutboxRepository_Add(t *testing.T) {
+	gormDB, mock := storage.GetMockDB(t)
+	repo := NewOutboxRepository(gormDB)
+
+	message, err := domain.NewOutboxMessage(domain.AggregateEvent, "42", domain.OutboxEventCreated, map[string]string{"event_type": "EC2_STARTED"})
+	require.NoError(t, err)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "outbox_messages"`)).
+		WithArgs(domain.AggregateEvent, "42", domain.OutboxEventCreated, []byte(`{"event_type":"EC2_STARTED"}`), 0, message.AvailableAt, "", sqlmock.AnyArg(), nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectCommit()
+
+	assert.NoError(t, repo.Add(context.Background(), message))
+	assert.Equal(t, uint(7), message.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOutboxRepository_FindPublishable(t *testing.T) {
+	gormDB, mock := storage.GetMockDB(t)
+	repo := NewOutboxRepository(gormDB)
+	now := time.Now()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "outbox_messages" WHERE (published_at IS NULL AND available_at <= $1) AND (NOT EXISTS (SELECT 1 FROM outbox_messages AS previous WHERE previous.aggregate_type = outbox_messages.aggregate_type`)+
+		`.*`+regexp.QuoteMeta(`previous.id < outbox_messages.id)) ORDER BY id LIMIT $2 FOR UPDATE SKIP LOCKED`)).
+		WithArgs(now, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "aggregate_type", "aggregate_id"}).AddRow(3, "event", "42"))
+
+	messages, err := repo.FindPublishable(context.Background(), now, 10)
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	assert.Equal(t, "42", messages[0].AggregateID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOutboxRepository_MarkPublished(t *testing.T) {
+	updateSQL := regexp.QuoteMeta(`UPDATE "outbox_messages" SET "published_at"=$1 WHERE id = $2 AND published_at IS NULL`)
+	publishedAt := time.Now()
+
+	t.Run("Marks the pending message", func(t *testing.T) {
+		gormDB, mock := storage.GetMockDB(t)
+		repo := NewOutboxRepository(gormDB)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(updateSQL).WithArgs(publishedAt, 3).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		assert.NoError(t, repo.MarkPublished(context.Background(), 3, publishedAt))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Fails when already published", func(t *testing.T) {
+		gormDB, mock := storage.GetMockDB(t)
+		repo := NewOutboxRepository(gormDB)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(updateSQL).WithArgs(publishedAt, 3).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		assert.EqualError(t, repo.MarkPublished(context.Background(), 3, publishedAt), "outbox message 3 is not pending")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestOutboxRepository_Stats(t *testing.T) {
+	gormDB, mock := storage.GetMockDB(t)
+	repo := NewOutboxRepository(gormDB)
+	oldest := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) AS pending, min(created_at) AS oldest_pending_at FROM "outbox_messages" WHERE published_at IS NULL`)).
+		WillReturnRows(sqlmock.NewRows([]string{"pending", "oldest_pending_at"}).AddRow(5, oldest))
+
+	stats, err := repo.Stats(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), stats.Pending)
+	require.NotNil(t, stats.OldestPendingAt)
+	assert.Equal(t, oldest, *stats.OldestPendingAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOutboxRepository_DeletePublished(t *testing.T) {
+	gormDB, mock := storage.GetMockDB(t)
+	repo := NewOutboxRepository(gormDB)
+	before := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "outbox_messages" WHERE published_at < $1`)).
+		WithArgs(before).
+		WillReturnResult(sqlmock.NewResult(0, 4))
+	mock.ExpectCommit()
+
+	deleted, err := repo.DeletePublished(context.Background(), before)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), deleted)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
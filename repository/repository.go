@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"fmt"
 
 	"gorm.io/gorm"
@@ -8,16 +9,16 @@ import (
 
 // FindAll retrieves all records from the database that match the provided parameter.
 // It returns a slice of the generic type T and an error.
-func FindAll[T any](db *gorm.DB, param *T) ([]T, error) {
+func FindAll[T any](ctx context.Context, db *gorm.DB, param *T) ([]T, error) {
 	data := []T{}
-	err := db.Where(param).Find(&data).Error
+	err := db.WithContext(ctx).Where(param).Find(&data).Error
 	return data, err
 }
 
 // Find retrieves a record by its field.
-func Find[T any](db *gorm.DB, field string, value any) (T, error) {
+func Find[T any](ctx context.Context, db *gorm.DB, field string, value any) (T, error) {
 	var data T
-	err := db.First(&data, fmt.Sprintf("%s = ?", field), value).Error
+	err := db.WithContext(ctx).First(&data, fmt.Sprintf("%s = ?", field), value).Error
 	if err == gorm.ErrRecordNotFound {
 		return data, nil
 	}
@@ -25,29 +26,29 @@ func Find[T any](db *gorm.DB, field string, value any) (T, error) {
 }
 
 // Save persists one or more records.
-func Save[T any](db *gorm.DB, data ...*T) error {
+func Save[T any](ctx context.Context, db *gorm.DB, data ...*T) error {
 	if len(data) == 0 {
 		return nil
 	}
+	db = db.WithContext(ctx)
 	total := len(data)
 	batchSize := db.Config.CreateBatchSize
 	if batchSize == 0 || total <= batchSize {
 		return db.Save(data).Error
 	}
 
-	// The save creation is not batch processed, so the active batch call
-	tx := db.Begin()
-
-	for i := 0; i < total; i += batchSize {
-		end := i + batchSize
-		if end > total {
-			end = total
-		}
-		if err := tx.Save(data[i:end]).Error; err != nil {
-			tx.Rollback()
-			return err
+	// The save creation is not batch processed, so the batches are saved in a transaction of their own,
+	// or a savepoint when saving within a unit of work
+	return db.Transaction(func(tx *gorm.DB) error {
+		for i := 0; i < total; i += batchSize {
+			end := i + batchSize
+			if end > total {
+				end = total
+			}
+			if err := tx.Save(data[i:end]).Error; err != nil {
+				return err
+			}
 		}
-	}
-
-	return tx.Commit().Error
+		return nil
+	})
 }
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"regexp"
 	"testing"
 	"time"
@@ -24,7 +25,7 @@ func TestFindAll(t *testing.T) {
 			AddRow(1, "A").
 			AddRow(2, "B"))
 
-	results, err := FindAll(gormDB, &TestModel{})
+	results, err := FindAll(context.Background(), gormDB, &TestModel{})
 	assert.NoError(t, err)
 	assert.Equal(t, results, []TestModel{
 		{ID: 1, Name: "A"},
@@ -44,7 +45,7 @@ func TestFind(t *testing.T) {
 		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "created_at"}).
 			AddRow(1, "Test", createAt))
 
-	data, err := Find[TestModel](gormDB, "name", "Test")
+	data, err := Find[TestModel](context.Background(), gormDB, "name", "Test")
 	assert.NoError(t, err)
 	assert.Equal(t, data, TestModel{
 		ID:        1,
@@ -65,7 +66,7 @@ func TestSave(t *testing.T) {
 	mock.ExpectCommit()
 
 	event := &TestModel{Name: "A"}
-	err := Save(gormDB, event)
+	err := Save(context.Background(), gormDB, event)
 	assert.NoError(t, err)
 	assert.Equal(t, uint(3), event.ID)
 
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/cvzm/go-web-project/domain"
+
+	"gorm.io/gorm"
+)
+
+type routingRuleRepository struct {
+	db *gorm.DB
+}
+
+func NewRoutingRuleRepository(db *gorm.DB) domain.RoutingRuleRepository {
+	return &routingRuleRepository{db: db}
+}
+
+func (r *routingRuleRepository) FindAll(ctx context.Context) ([]domain.RoutingRule, error) {
+	rules := []domain.RoutingRule{}
+	err := r.db.WithContext(ctx).Order("priority, id").Find(&rules).Error
+	return rules, err
+}
+
+func (r *routingRuleRepository) Find(ctx context.Context, id uint) (domain.RoutingRule, error) {
+	var rule domain.RoutingRule
+	err := r.db.WithContext(ctx).First(&rule, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return rule, domain.ErrNotFound
+	}
+	return rule, err
+}
+
+func (r *routingRuleRepository) Create(ctx context.Context, rule *domain.RoutingRule) error {
+	return r.db.WithContext(ctx).Create(rule).Error
+}
+
+func (r *routingRuleRepository) Update(ctx context.Context, rule *domain.RoutingRule) error {
+	// Zero values are updated as well, e.g. to clear the criteria or enable the rule again
+	result := r.db.WithContext(ctx).Model(rule).Select("*").Omit("id", "created_at").Updates(rule)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+func (r *routingRuleRepository) Delete(ctx context.Context, id uint) error {
+	result := r.db.WithContext(ctx).Delete(&domain.RoutingRule{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/cvzm/go-web-project/adapter/storage"
+	"github.com/cvzm/go-web-project/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoutingRuleRepository_FindAll(t *testing.T) {
+	gormDB, mock := storage.GetMockDB(t)
+	repo := NewRoutingRuleRepository(gormDB)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "routing_rules" ORDER BY priority, id`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "channels"}).
+			AddRow(1, "IAM to security", "{security}").
+			AddRow(2, "EC2 to infra", "{infra,oncall}"))
+
+	rules, err := repo.FindAll(context.Background())
+	require.NoError(t, err)
+	require.Len(t, rules, 2)
+	assert.Equal(t, "EC2 to infra", rules[1].Name)
+	assert.Equal(t, []string{"infra", "oncall"}, []string(rules[1].Channels))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRoutingRuleRepository_Find(t *testing.T) {
+	findSQL := regexp.QuoteMeta(`SELECT * FROM "routing_rules" WHERE "routing_rules"."id" = $1 ORDER BY "routing_rules"."id" LIMIT $2`)
+
+	t.Run("Finds the rule", func(t *testing.T) {
+		gormDB, mock := storage.GetMockDB(t)
+		repo := NewRoutingRuleRepository(gormDB)
+
+		mock.ExpectQuery(findSQL).WithArgs(1, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "IAM to security"))
+
+		rule, err := repo.Find(context.Background(), 1)
+		require.NoError(t, err)
+		assert.Equal(t, "IAM to security", rule.Name)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Fails when not found", func(t *testing.T) {
+		gormDB, mock := storage.GetMockDB(t)
+		repo := NewRoutingRuleRepository(gormDB)
+
+		mock.ExpectQuery(findSQL).WithArgs(1, 1).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		_, err := repo.Find(context.Background(), 1)
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRoutingRuleRepository_Update(t *testing.T) {
+	updateSQL := regexp.QuoteMeta(`UPDATE "routing_rules" SET "name"=$1,"priority"=$2,"disabled"=$3,"sources"=$4,"event_types"=$5,"resources"=$6,"severities"=$7,"description_regex"=$8,"channels"=$9,"stop"=$10,"updated_at"=$11 WHERE "id" = $12`)
+
+	t.Run("Updates every field", func(t *testing.T) {
+		gormDB, mock := storage.GetMockDB(t)
+		repo := NewRoutingRuleRepository(gormDB)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(updateSQL).
+			WithArgs("IAM to security", 0, false, nil, nil, nil, nil, "", `{"security"}`, false, sqlmock.AnyArg(), 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		rule := &domain.RoutingRule{ID: 1, Name: "IAM to security", Channels: []string{"security"}}
+		assert.NoError(t, repo.Update(context.Background(), rule))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Fails when not found", func(t *testing.T) {
+		gormDB, mock := storage.GetMockDB(t)
+		repo := NewRoutingRuleRepository(gormDB)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(updateSQL).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		rule := &domain.RoutingRule{ID: 1, Name: "IAM to security", Channels: []string{"security"}}
+		assert.ErrorIs(t, repo.Update(context.Background(), rule), domain.ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRoutingRuleRepository_Delete(t *testing.T) {
+	gormDB, mock := storage.GetMockDB(t)
+	repo := NewRoutingRuleRepository(gormDB)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "routing_rules" WHERE "routing_rules"."id" = $1`)).
+		WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	assert.ErrorIs(t, repo.Delete(context.Background(), 1), domain.ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/cvzm/go-web-project/domain"
+
+	"gorm.io/gorm"
+)
+
+type silenceRepository struct {
+	db *gorm.DB
+}
+
+func NewSilenceRepository(db *gorm.DB) domain.SilenceRepository {
+	return &silenceRepository{db: db}
+}
+
+func (r *silenceRepository) FindAll(ctx context.Context) ([]domain.Silence, error) {
+	silences := []domain.Silence{}
+	err := r.db.WithContext(ctx).Order("starts_at DESC, id DESC").Find(&silences).Error
+	return silences, err
+}
+
+func (r *silenceRepository) FindCurrent(ctx context.Context, now time.Time) ([]domain.Silence, error) {
+	silences := []domain.Silence{}
+	err := r.db.WithContext(ctx).Where("ends_at IS NULL OR ends_at > ?", now).Order("id").Find(&silences).Error
+	return silences, err
+}
+
+func (r *silenceRepository) Find(ctx context.Context, id uint) (domain.Silence, error) {
+	var silence domain.Silence
+	err := r.db.WithContext(ctx).First(&silence, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return silence, domain.ErrNotFound
+	}
+	return silence, err
+}
+
+func (r *silenceRepository) Create(ctx context.Context, silence *domain.Silence) error {
+	return r.db.WithContext(ctx).Create(silence).Error
+}
+
+func (r *silenceRepository) Update(ctx context.Context, silence *domain.Silence) error {
+	// Zero values are updated as well, e.g. to clear the matchers or the recurrence
+	result := r.db.WithContext(ctx).Model(silence).Select("*").Omit("id", "created_at").Updates(silence)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+func (r *silenceRepository) Delete(ctx context.Context, id uint) error {
+	result := r.db.WithContext(ctx).Delete(&domain.Silence{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
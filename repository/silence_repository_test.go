@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/cvzm/go-web-project/adapter/storage"
+	"github.com/cvzm/go-web-project/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSilenceRepository_FindCurrent(t *testing.T) {
+	gormDB, mock := storage.GetMockDB(t)
+	repo := NewSilenceRepository(gormDB)
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "silences" WHERE ends_at IS NULL OR ends_at > $1 ORDER BY id`)).
+		WithArgs(now).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "sources", "recurrence_days", "recurrence_start", "recurrence_duration", "created_by"}).
+			AddRow(1, "{AWS}", nil, nil, 0, "alice").
+			AddRow(2, "{GCP}", "{SAT,SUN}", "02:00", 120, "bob"))
+
+	silences, err := repo.FindCurrent(context.Background(), now)
+	require.NoError(t, err)
+	require.Len(t, silences, 2)
+	assert.False(t, silences[0].Recurring())
+	assert.Equal(t, domain.SilenceRecurrence{Days: []string{"SAT", "SUN"}, Start: "02:00", Duration: 120}, silences[1].Recurrence)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSilenceRepository_Find(t *testing.T) {
+	gormDB, mock := storage.GetMockDB(t)
+	repo := NewSilenceRepository(gormDB)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "silences" WHERE "silences"."id" = $1 ORDER BY "silences"."id" LIMIT $2`)).
+		WithArgs(1, 1).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	_, err := repo.Find(context.Background(), 1)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSilenceRepository_Update(t *testing.T) {
+	updateSQL := regexp.QuoteMeta(`UPDATE "silences" SET "sources"=$1,"event_types"=$2,"resources"=$3,"starts_at"=$4,"ends_at"=$5,"recurrence_days"=$6,"recurrence_start"=$7,"recurrence_duration"=$8,"recurrence_timezone"=$9,"created_by"=$10,"comment"=$11,"updated_at"=$12 WHERE "id" = $13`)
+	startsAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	endsAt := startsAt.Add(time.Hour)
+
+	t.Run("Updates every field", func(t *testing.T) {
+		gormDB, mock := storage.GetMockDB(t)
+		repo := NewSilenceRepository(gormDB)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(updateSQL).
+			WithArgs(`{"AWS"}`, nil, nil, startsAt, endsAt, nil, "", 0, "", "alice", "", sqlmock.AnyArg(), 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		silence := &domain.Silence{ID: 1, Sources: []string{"AWS"}, StartsAt: startsAt, EndsAt: &endsAt, CreatedBy: "alice"}
+		assert.NoError(t, repo.Update(context.Background(), silence))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Fails when not found", func(t *testing.T) {
+		gormDB, mock := storage.GetMockDB(t)
+		repo := NewSilenceRepository(gormDB)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(updateSQL).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		silence := &domain.Silence{ID: 1, Sources: []string{"AWS"}, StartsAt: startsAt, EndsAt: &endsAt, CreatedBy: "alice"}
+		assert.ErrorIs(t, repo.Update(context.Background(), silence), domain.ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestSilenceRepository_Delete(t *testing.T) {
+	gormDB, mock := storage.GetMockDB(t)
+	repo := NewSilenceRepository(gormDB)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "silences" WHERE "silences"."id" = $1`)).
+		WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	assert.NoError(t, repo.Delete(context.Background(), 1))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
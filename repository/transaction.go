@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/cvzm/go-web-project/domain"
+
+	"gorm.io/gorm"
+)
+
+// txContextKey is the context key of the transaction of the running unit of work
+type txContextKey struct{}
+
+type transactionManager struct {
+	db *gorm.DB
+}
+
+// NewTransactionManager creates a new transaction manager of the database
+func NewTransactionManager(db *gorm.DB) domain.TransactionManager {
+	return &transactionManager{db: db}
+}
+
+func (m *transactionManager) Transaction(ctx context.Context, fn domain.TransactionFunc) error {
+	db := m.db
+	// Gorm runs transactions started within a transaction in a savepoint
+	if tx, ok := ctx.Value(txContextKey{}).(*gorm.DB); ok {
+		db = tx
+	}
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txContextKey{}, tx), &repositories{db: tx})
+	})
+}
+
+// repositories is the set of repositories bound to a transaction
+type repositories struct {
+	db *gorm.DB
+}
+
+func (r *repositories) Events() domain.EventRepository {
+	return NewEventRepository(r.db)
+}
+
+func (r *repositories) EventChanges() domain.EventChangeRepository {
+	return NewEventChangeRepository(r.db)
+}
+
+func (r *repositories) EventArchive() domain.EventArchiveRepository {
+	return NewEventArchiveRepository(r.db)
+}
+
+func (r *repositories) Outbox() domain.OutboxRepository {
+	return NewOutboxRepository(r.db)
+}
+
+func (r *repositories) WebhookSubscriptions() domain.WebhookSubscriptionRepository {
+	return NewWebhookSubscriptionRepository(r.db)
+}
+
+func (r *repositories) WebhookDeliveries() domain.WebhookDeliveryRepository {
+	return NewWebhookDeliveryRepository(r.db)
+}
+
+func (r *repositories) Digests() domain.DigestRepository {
+	return NewDigestRepository(r.db)
+}
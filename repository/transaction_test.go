@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/cvzm/go-web-project/adapter/storage"
+	"github.com/cvzm/go-web-project/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransactionManager(t *testing.T) {
+	insertSQL := regexp.QuoteMeta(`INSERT INTO "events"`)
+	saveEvent := func(ctx context.Context, repos domain.Repositories) error {
+		return repos.Events().Save(ctx, &domain.Event{Source: domain.SourceAWS, EventType: "EC2_STARTED"})
+	}
+
+	t.Run("Commits the unit of work", func(t *testing.T) {
+		gormDB, mock := storage.GetMockDB(t)
+		manager := NewTransactionManager(gormDB)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(insertSQL).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectQuery(insertSQL).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+		mock.ExpectCommit()
+
+		err := manager.Transaction(context.Background(), func(ctx context.Context, repos domain.Repositories) error {
+			if err := saveEvent(ctx, repos); err != nil {
+				return err
+			}
+			return saveEvent(ctx, repos)
+		})
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Rolls back the unit of work on error", func(t *testing.T) {
+		gormDB, mock := storage.GetMockDB(t)
+		manager := NewTransactionManager(gormDB)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(insertSQL).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectRollback()
+
+		failure := errors.New("outbox write failed")
+		err := manager.Transaction(context.Background(), func(ctx context.Context, repos domain.Repositories) error {
+			if err := saveEvent(ctx, repos); err != nil {
+				return err
+			}
+			return failure
+		})
+		assert.ErrorIs(t, err, failure)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Rolls back a nested unit of work to its savepoint", func(t *testing.T) {
+		gormDB, mock := storage.GetMockDB(t)
+		manager := NewTransactionManager(gormDB)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(insertSQL).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectExec(`SAVEPOINT sp\w+`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(insertSQL).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+		mock.ExpectExec(`ROLLBACK TO SAVEPOINT sp\w+`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		failure := errors.New("link failed")
+		err := manager.Transaction(context.Background(), func(ctx context.Context, repos domain.Repositories) error {
+			if err := saveEvent(ctx, repos); err != nil {
+				return err
+			}
+			nestedErr := manager.Transaction(ctx, func(ctx context.Context, repos domain.Repositories) error {
+				if err := saveEvent(ctx, repos); err != nil {
+					return err
+				}
+				return failure
+			})
+			assert.ErrorIs(t, nestedErr, failure)
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/cvzm/go-web-project/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// defaultDeliveryQueryLimit is the number of deliveries returned when the query sets no limit
+const defaultDeliveryQueryLimit = 50
+
+type webhookSubscriptionRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookSubscriptionRepository(db *gorm.DB) domain.WebhookSubscriptionRepository {
+	return &webhookSubscriptionRepository{db: db}
+}
+
+func (r *webhookSubscriptionRepository) FindAll(ctx context.Context) ([]domain.WebhookSubscription, error) {
+	subscriptions := []domain.WebhookSubscription{}
+	err := r.db.WithContext(ctx).Order("id").Find(&subscriptions).Error
+	return subscriptions, err
+}
+
+func (r *webhookSubscriptionRepository) FindEnabled(ctx context.Context) ([]domain.WebhookSubscription, error) {
+	subscriptions := []domain.WebhookSubscription{}
+	err := r.db.WithContext(ctx).Where("NOT disabled").Order("id").Find(&subscriptions).Error
+	return subscriptions, err
+}
+
+func (r *webhookSubscriptionRepository) Find(ctx context.Context, id uint) (domain.WebhookSubscription, error) {
+	var subscription domain.WebhookSubscription
+	err := r.db.WithContext(ctx).First(&subscription, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return subscription, domain.ErrNotFound
+	}
+	return subscription, err
+}
+
+func (r *webhookSubscriptionRepository) Create(ctx context.Context, subscription *domain.WebhookSubscription) error {
+	return r.db.WithContext(ctx).Create(subscription).Error
+}
+
+func (r *webhookSubscriptionRepository) Update(ctx context.Context, subscription *domain.WebhookSubscription) error {
+	result := r.db.WithContext(ctx).Model(subscription).Select("*").Omit("id", "created_at").Updates(subscription)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+func (r *webhookSubscriptionRepository) Delete(ctx context.Context, id uint) error {
+	result := r.db.WithContext(ctx).Delete(&domain.WebhookSubscription{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+func (r *webhookSubscriptionRepository) RecordSuccess(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Model(&domain.WebhookSubscription{}).
+		Where("id = ? AND consecutive_failures > 0", id).
+		Update("consecutive_failures", 0).Error
+}
+
+func (r *webhookSubscriptionRepository) RecordFailure(ctx context.Context, id uint, disableAfter int, reason string) (bool, error) {
+	err := r.db.WithContext(ctx).Model(&domain.WebhookSubscription{}).
+		Where("id = ?", id).
+		Update("consecutive_failures", gorm.Expr("consecutive_failures + 1")).Error
+	if err != nil || disableAfter <= 0 {
+		return false, err
+	}
+
+	result := r.db.WithContext(ctx).Model(&domain.WebhookSubscription{}).
+		Where("id = ? AND NOT disabled AND consecutive_failures >= ?", id, disableAfter).
+		Updates(map[string]any{"disabled": true, "disabled_reason": reason})
+	return result.RowsAffected == 1, result.Error
+}
+
+type webhookDeliveryRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookDeliveryRepository(db *gorm.DB) domain.WebhookDeliveryRepository {
+	return &webhookDeliveryRepository{db: db}
+}
+
+func (r *webhookDeliveryRepository) Add(ctx context.Context, deliveries ...*domain.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Create(deliveries).Error
+}
+
+func (r *webhookDeliveryRepository) FindDue(ctx context.Context, now time.Time, limit int) ([]domain.WebhookDelivery, error) {
+	deliveries := []domain.WebhookDelivery{}
+	// Deliveries of disabled subscriptions stay pending until they are enabled again
+	err := r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("status = ? AND next_attempt_at <= ?", domain.WebhookDeliveryPending, now).
+		Where("subscription_id IN (SELECT id FROM webhook_subscriptions WHERE NOT disabled)").
+		Order("next_attempt_at, id").
+		Limit(limit).
+		Find(&deliveries).Error
+	return deliveries, err
+}
+
+func (r *webhookDeliveryRepository) Find(ctx context.Context, id uint) (domain.WebhookDelivery, error) {
+	var delivery domain.WebhookDelivery
+	err := r.db.WithContext(ctx).First(&delivery, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return delivery, domain.ErrNotFound
+	}
+	return delivery, err
+}
+
+func (r *webhookDeliveryRepository) FindBySubscription(ctx context.Context, subscriptionID uint, query domain.WebhookDeliveryQuery) ([]domain.WebhookDelivery, error) {
+	deliveries := []domain.WebhookDelivery{}
+	db := r.db.WithContext(ctx).Where("subscription_id = ?", subscriptionID)
+	if query.Status != "" {
+		db = db.Where("status = ?", query.Status)
+	}
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultDeliveryQueryLimit
+	}
+	err := db.Order("id DESC").Limit(limit).Find(&deliveries).Error
+	return deliveries, err
+}
+
+func (r *webhookDeliveryRepository) Update(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	return r.db.WithContext(ctx).Model(delivery).
+		Select("status", "attempts", "next_attempt_at", "response_status", "last_error", "delivered_at", "updated_at").
+		Updates(delivery).Error
+}
+
+func (r *webhookDeliveryRepository) DeleteFinished(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Where("status <> ? AND updated_at < ?", domain.WebhookDeliveryPending, before).
+		Delete(&domain.WebhookDelivery{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/cvzm/go-web-project/adapter/storage"
+	"github.com/cvzm/go-web-project/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookSubscriptionRepository_RecordFailure(t *testing.T) {
+	countSQL := regexp.QuoteMeta(`UPDATE "webhook_subscriptions" SET "consecutive_failures"=consecutive_failures + 1,"updated_at"=$1 WHERE id = $2`)
+	disableSQL := regexp.QuoteMeta(`UPDATE "webhook_subscriptions" SET "disabled"=$1,"disabled_reason"=$2,"updated_at"=$3 WHERE id = $4 AND NOT disabled AND consecutive_failures >= $5`)
+
+	t.Run("Disables the subscription at the threshold", func(t *testing.T) {
+		gormDB, mock := storage.GetMockDB(t)
+		repo := NewWebhookSubscriptionRepository(gormDB)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(countSQL).WithArgs(sqlmock.AnyArg(), 3).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		mock.ExpectBegin()
+		mock.ExpectExec(disableSQL).WithArgs(true, "HTTP 500", sqlmock.AnyArg(), 3, 5).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		disabled, err := repo.RecordFailure(context.Background(), 3, 5, "HTTP 500")
+		require.NoError(t, err)
+		assert.True(t, disabled)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Never disables without threshold", func(t *testing.T) {
+		gormDB, mock := storage.GetMockDB(t)
+		repo := NewWebhookSubscriptionRepository(gormDB)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(countSQL).WithArgs(sqlmock.AnyArg(), 3).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		disabled, err := repo.RecordFailure(context.Background(), 3, 0, "HTTP 500")
+		require.NoError(t, err)
+		assert.False(t, disabled)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestWebhookDeliveryRepository_FindDue(t *testing.T) {
+	gormDB, mock := storage.GetMockDB(t)
+	repo := NewWebhookDeliveryRepository(gormDB)
+	now := time.Now()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "webhook_deliveries" WHERE (status = $1 AND next_attempt_at <= $2) AND subscription_id IN (SELECT id FROM webhook_subscriptions WHERE NOT disabled) ORDER BY next_attempt_at, id LIMIT $3 FOR UPDATE SKIP LOCKED`)).
+		WithArgs(domain.WebhookDeliveryPending, now, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "subscription_id", "event_id"}).AddRow(4, 3, 42))
+
+	deliveries, err := repo.FindDue(context.Background(), now, 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, uint(42), deliveries[0].EventID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWebhookDeliveryRepository_FindBySubscription(t *testing.T) {
+	t.Run("Filters by status", func(t *testing.T) {
+		gormDB, mock := storage.GetMockDB(t)
+		repo := NewWebhookDeliveryRepository(gormDB)
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "webhook_deliveries" WHERE subscription_id = $1 AND status = $2 ORDER BY id DESC LIMIT $3`)).
+			WithArgs(3, domain.WebhookDeliveryFailed, 10).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).AddRow(4, domain.WebhookDeliveryFailed))
+
+		deliveries, err := repo.FindBySubscription(context.Background(), 3, domain.WebhookDeliveryQuery{Status: domain.WebhookDeliveryFailed, Limit: 10})
+		require.NoError(t, err)
+		assert.Len(t, deliveries, 1)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Limits by default", func(t *testing.T) {
+		gormDB, mock := storage.GetMockDB(t)
+		repo := NewWebhookDeliveryRepository(gormDB)
+
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "webhook_deliveries" WHERE subscription_id = $1 ORDER BY id DESC LIMIT $2`)).
+			WithArgs(3, defaultDeliveryQueryLimit).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		_, err := repo.FindBySubscription(context.Background(), 3, domain.WebhookDeliveryQuery{})
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestWebhookDeliveryRepository_Update(t *testing.T) {
+	gormDB, mock := storage.GetMockDB(t)
+	repo := NewWebhookDeliveryRepository(gormDB)
+	next := time.Now().Add(time.Minute)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "webhook_deliveries" SET "status"=$1,"attempts"=$2,"next_attempt_at"=$3,"response_status"=$4,"last_error"=$5,"delivered_at"=$6,"updated_at"=$7 WHERE "id" = $8`)).
+		WithArgs(domain.WebhookDeliveryPending, 1, next, 503, "HTTP 503", nil, sqlmock.AnyArg(), 4).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	delivery := &domain.WebhookDelivery{ID: 4, Status: domain.WebhookDeliveryPending, Attempts: 1, NextAttemptAt: next, ResponseStatus: 503, LastError: "HTTP 503"}
+	assert.NoError(t, repo.Update(context.Background(), delivery))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
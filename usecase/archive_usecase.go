@@ -0,0 +1,204 @@
+package usecase
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cvzm/go-web-project/domain"
+)
+
+// Constants related to archive keys
+const (
+	archiveDataSuffix     = "." + domain.ArchiveFormatNDJSONGzip
+	archiveManifestSuffix = ".manifest.json"
+)
+
+type archiveUsecase struct {
+	archiveRepo domain.EventArchiveRepository
+	store       domain.ArchiveStore
+}
+
+// NewArchiveUsecase creates a new archive use case writing to the store, which may be nil if none is configured
+func NewArchiveUsecase(repo domain.EventArchiveRepository, store domain.ArchiveStore) domain.ArchiveUsecase {
+	return &archiveUsecase{archiveRepo: repo, store: store}
+}
+
+// Archive writes the events of each batch created before the given time to the store, along with a manifest,
+// and deletes them only once the written archive has been read back and verified
+func (u *archiveUsecase) Archive(ctx context.Context, before time.Time) ([]domain.ArchiveManifest, error) {
+	if u.store == nil {
+		return nil, domain.ErrNoArchiveStore
+	}
+
+	batches, err := u.archiveRepo.FindArchiveBatches(ctx, before)
+	if err != nil {
+		return nil, err
+	}
+
+	manifests := []domain.ArchiveManifest{}
+	for _, batch := range batches {
+		if err := ctx.Err(); err != nil {
+			return manifests, err
+		}
+		manifest, err := u.archiveBatch(ctx, batch)
+		if err != nil {
+			return manifests, fmt.Errorf("archiving %s events of %s: %w", batch.Source, batch.Day.Format(time.DateOnly), err)
+		}
+		if manifest != nil {
+			manifests = append(manifests, *manifest)
+		}
+	}
+	return manifests, nil
+}
+
+// Restore verifies the archive of the manifest against its checksum and inserts its events back
+func (u *archiveUsecase) Restore(ctx context.Context, manifestKey string) (int, error) {
+	if u.store == nil {
+		return 0, domain.ErrNoArchiveStore
+	}
+
+	data, err := u.store.Get(ctx, manifestKey)
+	if err != nil {
+		return 0, err
+	}
+	var manifest domain.ArchiveManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return 0, fmt.Errorf("invalid archive manifest %q: %w", manifestKey, err)
+	}
+	if manifest.Format != domain.ArchiveFormatNDJSONGzip {
+		return 0, fmt.Errorf("unsupported archive format %q", manifest.Format)
+	}
+
+	data, err = u.store.Get(ctx, manifest.Key)
+	if err != nil {
+		return 0, err
+	}
+	if checksum := sha256Hex(data); checksum != manifest.SHA256 {
+		return 0, fmt.Errorf("archive %q is corrupted: checksum %s, expected %s", manifest.Key, checksum, manifest.SHA256)
+	}
+
+	events, err := decodeArchive(data)
+	if err != nil {
+		return 0, fmt.Errorf("invalid archive %q: %w", manifest.Key, err)
+	}
+	if len(events) != manifest.Count {
+		return 0, fmt.Errorf("archive %q holds %d events, expected %d", manifest.Key, len(events), manifest.Count)
+	}
+	return u.archiveRepo.Restore(ctx, events)
+}
+
+// archiveBatch archives the events of the batch and returns the manifest of the archive, nil if the batch is empty
+func (u *archiveUsecase) archiveBatch(ctx context.Context, batch domain.ArchiveBatch) (*domain.ArchiveManifest, error) {
+	events, err := u.archiveRepo.FindBatchEvents(ctx, batch)
+	if err != nil || len(events) == 0 {
+		return nil, err
+	}
+
+	data, err := encodeArchive(events)
+	if err != nil {
+		return nil, err
+	}
+
+	firstID, lastID := events[0].ID, events[len(events)-1].ID
+	// Archives are named after the ID range, so that events added to an archived day later on
+	// get an archive of their own rather than overwriting the existing one
+	prefix := fmt.Sprintf("date=%s/source=%s/events-%d-%d", batch.Day.Format(time.DateOnly), batch.Source, firstID, lastID)
+	manifest := &domain.ArchiveManifest{
+		Key:       prefix + archiveDataSuffix,
+		Format:    domain.ArchiveFormatNDJSONGzip,
+		Source:    batch.Source,
+		Date:      batch.Day.Format(time.DateOnly),
+		Count:     len(events),
+		FirstID:   firstID,
+		LastID:    lastID,
+		Size:      len(data),
+		SHA256:    sha256Hex(data),
+		CreatedAt: time.Now().UTC(),
+	}
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := u.putVerified(ctx, manifest.Key, data); err != nil {
+		return nil, err
+	}
+	if err := u.putVerified(ctx, prefix+archiveManifestSuffix, manifestData); err != nil {
+		return nil, err
+	}
+
+	if err := u.archiveRepo.DeleteBatchEvents(ctx, batch, lastID, len(events)); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// putVerified writes the data to the store and reads it back to check it was written entirely
+func (u *archiveUsecase) putVerified(ctx context.Context, key string, data []byte) error {
+	if err := u.store.Put(ctx, key, data); err != nil {
+		return err
+	}
+	written, err := u.store.Get(ctx, key)
+	if err != nil {
+		return fmt.Errorf("reading back %q: %w", key, err)
+	}
+	if sha256Hex(written) != sha256Hex(data) {
+		return fmt.Errorf("verifying %q: checksum mismatch", key)
+	}
+	return nil
+}
+
+// encodeArchive encodes the events as gzip compressed NDJSON
+func encodeArchive(events []domain.Event) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	encoder := json.NewEncoder(writer)
+	for _, event := range events {
+		if err := encoder.Encode(event); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeArchive decodes the events of gzip compressed NDJSON
+func decodeArchive(data []byte) ([]domain.Event, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	events := []domain.Event{}
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var event domain.Event
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, scanner.Err()
+}
+
+// sha256Hex returns the hex encoded SHA-256 checksum of the data
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,116 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/cvzm/go-web-project/domain"
+	domain_mock "github.com/cvzm/go-web-project/domain/mock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryStore is an in-memory domain.ArchiveStore
+type memoryStore map[string][]byte
+
+func (s memoryStore) Put(ctx context.Context, key string, data []byte) error {
+	s[key] = append([]byte(nil), data...)
+	return nil
+}
+
+func (s memoryStore) Get(ctx context.Context, key string) ([]byte, error) {
+	data, ok := s[key]
+	if !ok {
+		return nil, assert.AnError
+	}
+	return data, nil
+}
+
+func TestArchiveUsecase_Archive(t *testing.T) {
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	batch := domain.ArchiveBatch{Source: domain.SourceAWS, Day: day}
+	events := []domain.Event{
+		{ID: 3, Source: domain.SourceAWS, EventType: "EC2_STARTED", AffectedResources: pq.StringArray{"i-1"}, CreatedAt: day.Add(time.Hour), UpdatedAt: day.Add(time.Hour)},
+		{ID: 7, Source: domain.SourceAWS, EventType: "EC2_STOPPED", AffectedResources: pq.StringArray{"i-1"}, CreatedAt: day.Add(2 * time.Hour), UpdatedAt: day.Add(2 * time.Hour)},
+	}
+	before := day.AddDate(0, 0, 30)
+
+	t.Run("Archives, deletes and restores events", func(t *testing.T) {
+		repo := new(domain_mock.MockEventArchiveRepository)
+		store := memoryStore{}
+		usecase := NewArchiveUsecase(repo, store)
+
+		repo.On("FindArchiveBatches", mock.Anything, before).Return([]domain.ArchiveBatch{batch}, nil).Once()
+		repo.On("FindBatchEvents", mock.Anything, batch).Return(events, nil).Once()
+		repo.On("DeleteBatchEvents", mock.Anything, batch, uint(7), 2).Return(nil).Once()
+
+		manifests, err := usecase.Archive(context.Background(), before)
+		require.NoError(t, err)
+		require.Len(t, manifests, 1)
+		manifest := manifests[0]
+		assert.Equal(t, "date=2024-05-01/source=AWS/events-3-7.ndjson.gz", manifest.Key)
+		assert.Equal(t, domain.ArchiveFormatNDJSONGzip, manifest.Format)
+		assert.Equal(t, "2024-05-01", manifest.Date)
+		assert.Equal(t, 2, manifest.Count)
+		assert.Equal(t, sha256Hex(store[manifest.Key]), manifest.SHA256)
+		repo.AssertExpectations(t)
+
+		var written domain.ArchiveManifest
+		require.NoError(t, json.Unmarshal(store["date=2024-05-01/source=AWS/events-3-7.manifest.json"], &written))
+		assert.Equal(t, manifest.SHA256, written.SHA256)
+
+		repo.On("Restore", mock.Anything, mock.MatchedBy(func(restored []domain.Event) bool {
+			return assert.ObjectsAreEqual(events, restored)
+		})).Return(2, nil).Once()
+
+		restored, err := usecase.Restore(context.Background(), "date=2024-05-01/source=AWS/events-3-7.manifest.json")
+		require.NoError(t, err)
+		assert.Equal(t, 2, restored)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Keeps events when the written archive can't be verified", func(t *testing.T) {
+		repo := new(domain_mock.MockEventArchiveRepository)
+		store := new(domain_mock.MockArchiveStore)
+		usecase := NewArchiveUsecase(repo, store)
+
+		repo.On("FindArchiveBatches", mock.Anything, before).Return([]domain.ArchiveBatch{batch}, nil).Once()
+		repo.On("FindBatchEvents", mock.Anything, batch).Return(events, nil).Once()
+		store.On("Put", mock.Anything, "date=2024-05-01/source=AWS/events-3-7.ndjson.gz", mock.Anything).Return(nil).Once()
+		store.On("Get", mock.Anything, "date=2024-05-01/source=AWS/events-3-7.ndjson.gz").Return([]byte("truncated"), nil).Once()
+
+		manifests, err := usecase.Archive(context.Background(), before)
+		assert.ErrorContains(t, err, "checksum mismatch")
+		assert.Empty(t, manifests)
+		repo.AssertNotCalled(t, "DeleteBatchEvents", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Requires a store", func(t *testing.T) {
+		usecase := NewArchiveUsecase(new(domain_mock.MockEventArchiveRepository), nil)
+		_, err := usecase.Archive(context.Background(), before)
+		assert.ErrorIs(t, err, domain.ErrNoArchiveStore)
+	})
+}
+
+func TestArchiveUsecase_RestoreCorruptedArchive(t *testing.T) {
+	repo := new(domain_mock.MockEventArchiveRepository)
+	data, err := encodeArchive([]domain.Event{{ID: 1, Source: domain.SourceGCP}})
+	require.NoError(t, err)
+	manifest, err := json.Marshal(domain.ArchiveManifest{
+		Key:    "events-1-1.ndjson.gz",
+		Format: domain.ArchiveFormatNDJSONGzip,
+		Count:  1,
+		SHA256: "0000",
+	})
+	require.NoError(t, err)
+	store := memoryStore{"events-1-1.manifest.json": manifest, "events-1-1.ndjson.gz": data}
+	usecase := NewArchiveUsecase(repo, store)
+
+	_, err = usecase.Restore(context.Background(), "events-1-1.manifest.json")
+	assert.ErrorContains(t, err, "is corrupted")
+	repo.AssertNotCalled(t, "Restore", mock.Anything, mock.Anything)
+}
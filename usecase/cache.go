@@ -0,0 +1,46 @@
+package usecase
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ttlCache caches the values returned by load, reloading them once the TTL has elapsed or after an invalidation
+type ttlCache[T any] struct {
+	ttl  time.Duration
+	load func(ctx context.Context) ([]T, error)
+
+	mu       sync.Mutex
+	values   []T
+	loaded   bool
+	loadedAt time.Time
+}
+
+// newTTLCache creates a new ttlCache instance loading the values with load
+func newTTLCache[T any](ttl time.Duration, load func(ctx context.Context) ([]T, error)) *ttlCache[T] {
+	return &ttlCache[T]{ttl: ttl, load: load}
+}
+
+// get returns the cached values, loading them if they are missing or expired
+func (c *ttlCache[T]) get(ctx context.Context) ([]T, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.loaded && time.Since(c.loadedAt) < c.ttl {
+		return c.values, nil
+	}
+
+	values, err := c.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.values, c.loaded, c.loadedAt = values, true, time.Now()
+	return values, nil
+}
+
+// invalidate makes the next get reload the values
+func (c *ttlCache[T]) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values, c.loaded = nil, false
+}
@@ -0,0 +1,44 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTTLCache(t *testing.T) {
+	loads := 0
+	cache := newTTLCache(time.Hour, func(ctx context.Context) ([]int, error) {
+		loads++
+		return []int{loads}, nil
+	})
+
+	values, err := cache.get(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []int{1}, values)
+
+	// Cached until invalidated
+	values, _ = cache.get(context.Background())
+	assert.Equal(t, []int{1}, values)
+	cache.invalidate()
+	values, _ = cache.get(context.Background())
+	assert.Equal(t, []int{2}, values)
+
+	t.Run("Reloads once expired", func(t *testing.T) {
+		cache.ttl = 0
+		values, _ := cache.get(context.Background())
+		assert.Equal(t, []int{3}, values)
+	})
+
+	t.Run("Load failure", func(t *testing.T) {
+		cache := newTTLCache(time.Hour, func(ctx context.Context) ([]int, error) {
+			return nil, errors.New("db down")
+		})
+		_, err := cache.get(context.Background())
+		assert.EqualError(t, err, "db down")
+	})
+}
@@ -0,0 +1,118 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/cvzm/go-web-project/domain"
+)
+
+type commentUsecase struct {
+	eventRepo      domain.EventRepository
+	commentRepo    domain.EventCommentRepository
+	annotationRepo domain.EventAnnotationRepository
+}
+
+// NewCommentUsecase creates a new use case of the comments and annotations of the events
+func NewCommentUsecase(eventRepo domain.EventRepository, commentRepo domain.EventCommentRepository, annotationRepo domain.EventAnnotationRepository) domain.CommentUsecase {
+	return &commentUsecase{
+		eventRepo:      eventRepo,
+		commentRepo:    commentRepo,
+		annotationRepo: annotationRepo,
+	}
+}
+
+func (u *commentUsecase) Comments(ctx context.Context, eventID uint) ([]domain.EventComment, error) {
+	// The comments of an unknown event are not found rather than empty
+	if _, err := u.eventRepo.Find(ctx, eventID); err != nil {
+		return nil, err
+	}
+	comments, err := u.commentRepo.FindByEvent(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	return threads(comments), nil
+}
+
+func (u *commentUsecase) AddComment(ctx context.Context, eventID uint, comment *domain.EventComment) error {
+	if err := comment.Validate(); err != nil {
+		return err
+	}
+	if _, err := u.eventRepo.Find(ctx, eventID); err != nil {
+		return err
+	}
+	if comment.ParentID != nil {
+		_, err := u.commentRepo.Find(ctx, eventID, *comment.ParentID)
+		if errors.Is(err, domain.ErrNotFound) {
+			return &domain.ValidationError{Message: fmt.Sprintf("parent comment %d not found on event %d", *comment.ParentID, eventID)}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	comment.EventID = eventID
+	return u.commentRepo.Create(ctx, comment)
+}
+
+func (u *commentUsecase) EditComment(ctx context.Context, eventID, id uint, body string) (domain.EventComment, error) {
+	comment, err := u.commentRepo.Find(ctx, eventID, id)
+	if err != nil {
+		return comment, err
+	}
+	comment.Body = body
+	if err := comment.Validate(); err != nil {
+		return comment, err
+	}
+	if err := u.commentRepo.UpdateBody(ctx, &comment); err != nil {
+		return comment, err
+	}
+	return comment, nil
+}
+
+func (u *commentUsecase) Annotations(ctx context.Context, eventID uint) ([]domain.EventAnnotation, error) {
+	if _, err := u.eventRepo.Find(ctx, eventID); err != nil {
+		return nil, err
+	}
+	return u.annotationRepo.FindByEvent(ctx, eventID)
+}
+
+func (u *commentUsecase) Annotate(ctx context.Context, eventID uint, annotation *domain.EventAnnotation) error {
+	if err := annotation.Validate(); err != nil {
+		return err
+	}
+	if _, err := u.eventRepo.Find(ctx, eventID); err != nil {
+		return err
+	}
+	annotation.EventID = eventID
+	return u.annotationRepo.Save(ctx, annotation)
+}
+
+func (u *commentUsecase) RemoveAnnotation(ctx context.Context, eventID uint, key string) error {
+	return u.annotationRepo.Delete(ctx, eventID, key)
+}
+
+// threads nests the replies of the comments, ordered by ID, under their parent
+func threads(comments []domain.EventComment) []domain.EventComment {
+	replies := map[uint][]domain.EventComment{}
+	for _, comment := range comments {
+		var parentID uint
+		if comment.ParentID != nil {
+			parentID = *comment.ParentID
+		}
+		replies[parentID] = append(replies[parentID], comment)
+	}
+	return thread(replies, 0)
+}
+
+// thread returns the replies to the parent comment with their own replies, the comments without parent if zero
+func thread(replies map[uint][]domain.EventComment, parentID uint) []domain.EventComment {
+	comments := replies[parentID]
+	for i := range comments {
+		comments[i].Replies = thread(replies, comments[i].ID)
+	}
+	if comments == nil && parentID == 0 {
+		return []domain.EventComment{}
+	}
+	return comments
+}
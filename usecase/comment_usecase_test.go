@@ -0,0 +1,193 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/cvzm/go-web-project/domain"
+	domain_mock "github.com/cvzm/go-web-project/domain/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func uintPtr(v uint) *uint {
+	return &v
+}
+
+func TestCommentUsecase_Comments(t *testing.T) {
+	t.Run("Nests the replies under their parent", func(t *testing.T) {
+		eventRepo := new(domain_mock.MockEventRepository)
+		commentRepo := new(domain_mock.MockEventCommentRepository)
+		usecase := NewCommentUsecase(eventRepo, commentRepo, nil)
+
+		eventRepo.On("Find", mock.Anything, uint(7)).Return(domain.Event{ID: 7}, nil).Once()
+		commentRepo.On("FindByEvent", mock.Anything, uint(7)).Return([]domain.EventComment{
+			{ID: 1, EventID: 7, Author: "alice", Body: "Disk full"},
+			{ID: 2, EventID: 7, ParentID: uintPtr(1), Author: "bob", Body: "Cleaned up the logs"},
+			{ID: 3, EventID: 7, Author: "carol", Body: "Ticket opened"},
+			{ID: 4, EventID: 7, ParentID: uintPtr(2), Author: "alice", Body: "Thanks"},
+		}, nil).Once()
+
+		comments, err := usecase.Comments(context.Background(), 7)
+		require.NoError(t, err)
+		require.Len(t, comments, 2)
+		assert.Equal(t, uint(1), comments[0].ID)
+		require.Len(t, comments[0].Replies, 1)
+		assert.Equal(t, uint(2), comments[0].Replies[0].ID)
+		require.Len(t, comments[0].Replies[0].Replies, 1)
+		assert.Equal(t, uint(4), comments[0].Replies[0].Replies[0].ID)
+		assert.Equal(t, uint(3), comments[1].ID)
+		assert.Empty(t, comments[1].Replies)
+	})
+
+	t.Run("Returns no thread without comments", func(t *testing.T) {
+		eventRepo := new(domain_mock.MockEventRepository)
+		commentRepo := new(domain_mock.MockEventCommentRepository)
+		usecase := NewCommentUsecase(eventRepo, commentRepo, nil)
+
+		eventRepo.On("Find", mock.Anything, uint(7)).Return(domain.Event{ID: 7}, nil).Once()
+		commentRepo.On("FindByEvent", mock.Anything, uint(7)).Return([]domain.EventComment{}, nil).Once()
+
+		comments, err := usecase.Comments(context.Background(), 7)
+		require.NoError(t, err)
+		assert.NotNil(t, comments)
+		assert.Empty(t, comments)
+	})
+
+	t.Run("Event not found", func(t *testing.T) {
+		eventRepo := new(domain_mock.MockEventRepository)
+		commentRepo := new(domain_mock.MockEventCommentRepository)
+		usecase := NewCommentUsecase(eventRepo, commentRepo, nil)
+
+		eventRepo.On("Find", mock.Anything, uint(9)).Return(domain.Event{}, domain.ErrNotFound).Once()
+
+		_, err := usecase.Comments(context.Background(), 9)
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+		commentRepo.AssertNotCalled(t, "FindByEvent", mock.Anything, mock.Anything)
+	})
+}
+
+func TestCommentUsecase_AddComment(t *testing.T) {
+	t.Run("Replies to a comment of the event", func(t *testing.T) {
+		eventRepo := new(domain_mock.MockEventRepository)
+		commentRepo := new(domain_mock.MockEventCommentRepository)
+		usecase := NewCommentUsecase(eventRepo, commentRepo, nil)
+		comment := &domain.EventComment{ParentID: uintPtr(1), Author: "bob", Body: "Cleaned up the logs"}
+
+		eventRepo.On("Find", mock.Anything, uint(7)).Return(domain.Event{ID: 7}, nil).Once()
+		commentRepo.On("Find", mock.Anything, uint(7), uint(1)).Return(domain.EventComment{ID: 1, EventID: 7}, nil).Once()
+		commentRepo.On("Create", mock.Anything, comment).Return(nil).Once()
+
+		require.NoError(t, usecase.AddComment(context.Background(), 7, comment))
+		assert.Equal(t, uint(7), comment.EventID)
+		commentRepo.AssertExpectations(t)
+	})
+
+	t.Run("Parent comment of another event", func(t *testing.T) {
+		eventRepo := new(domain_mock.MockEventRepository)
+		commentRepo := new(domain_mock.MockEventCommentRepository)
+		usecase := NewCommentUsecase(eventRepo, commentRepo, nil)
+
+		eventRepo.On("Find", mock.Anything, uint(7)).Return(domain.Event{ID: 7}, nil).Once()
+		commentRepo.On("Find", mock.Anything, uint(7), uint(5)).Return(domain.EventComment{}, domain.ErrNotFound).Once()
+
+		err := usecase.AddComment(context.Background(), 7, &domain.EventComment{ParentID: uintPtr(5), Author: "bob", Body: "Done"})
+		var validationErr *domain.ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, "parent comment 5 not found on event 7", validationErr.Message)
+		commentRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	invalid := []struct {
+		name    string
+		comment domain.EventComment
+		message string
+	}{
+		{"Missing author", domain.EventComment{Body: "Disk full"}, "author is required"},
+		{"Blank body", domain.EventComment{Author: "alice", Body: " \n"}, "body is required"},
+		{"Body too long", domain.EventComment{Author: "alice", Body: strings.Repeat("a", domain.MaxCommentBodyLength+1)}, "body must not exceed 10000 characters"},
+	}
+	for _, tc := range invalid {
+		t.Run(tc.name, func(t *testing.T) {
+			eventRepo := new(domain_mock.MockEventRepository)
+			usecase := NewCommentUsecase(eventRepo, nil, nil)
+
+			err := usecase.AddComment(context.Background(), 7, &tc.comment)
+			var validationErr *domain.ValidationError
+			require.ErrorAs(t, err, &validationErr)
+			assert.Equal(t, tc.message, validationErr.Message)
+			eventRepo.AssertNotCalled(t, "Find", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestCommentUsecase_EditComment(t *testing.T) {
+	commentRepo := new(domain_mock.MockEventCommentRepository)
+	usecase := NewCommentUsecase(nil, commentRepo, nil)
+
+	commentRepo.On("Find", mock.Anything, uint(7), uint(2)).Return(domain.EventComment{ID: 2, EventID: 7, Author: "bob", Body: "Cleaned up"}, nil).Twice()
+	commentRepo.On("UpdateBody", mock.Anything, mock.MatchedBy(func(comment *domain.EventComment) bool {
+		return comment.ID == 2 && comment.Body == "Rotated the logs"
+	})).Return(nil).Once()
+
+	comment, err := usecase.EditComment(context.Background(), 7, 2, "Rotated the logs")
+	require.NoError(t, err)
+	assert.Equal(t, "Rotated the logs", comment.Body)
+	assert.Equal(t, "bob", comment.Author)
+
+	_, err = usecase.EditComment(context.Background(), 7, 2, "")
+	var validationErr *domain.ValidationError
+	assert.ErrorAs(t, err, &validationErr)
+	commentRepo.AssertNumberOfCalls(t, "UpdateBody", 1)
+}
+
+func TestCommentUsecase_Annotate(t *testing.T) {
+	t.Run("Sets the annotation of the event", func(t *testing.T) {
+		eventRepo := new(domain_mock.MockEventRepository)
+		annotationRepo := new(domain_mock.MockEventAnnotationRepository)
+		usecase := NewCommentUsecase(eventRepo, nil, annotationRepo)
+		annotation := &domain.EventAnnotation{Key: "runbook", Value: "https://runbooks.example.com/disk-full", Author: "alice"}
+
+		eventRepo.On("Find", mock.Anything, uint(7)).Return(domain.Event{ID: 7}, nil).Once()
+		annotationRepo.On("Save", mock.Anything, annotation).Return(nil).Once()
+
+		require.NoError(t, usecase.Annotate(context.Background(), 7, annotation))
+		assert.Equal(t, uint(7), annotation.EventID)
+		annotationRepo.AssertExpectations(t)
+	})
+
+	invalid := []struct {
+		name       string
+		annotation domain.EventAnnotation
+		message    string
+	}{
+		{"Invalid key", domain.EventAnnotation{Key: "Jira Ticket", Value: "OPS-42", Author: "alice"}, `invalid key "Jira Ticket", expecting up to 63 lowercase letters, digits, '_', '.' or '-'`},
+		{"Missing value", domain.EventAnnotation{Key: "jira", Author: "alice"}, "value is required"},
+		{"Missing author", domain.EventAnnotation{Key: "jira", Value: "OPS-42"}, "author is required"},
+	}
+	for _, tc := range invalid {
+		t.Run(tc.name, func(t *testing.T) {
+			eventRepo := new(domain_mock.MockEventRepository)
+			usecase := NewCommentUsecase(eventRepo, nil, nil)
+
+			err := usecase.Annotate(context.Background(), 7, &tc.annotation)
+			var validationErr *domain.ValidationError
+			require.ErrorAs(t, err, &validationErr)
+			assert.Equal(t, tc.message, validationErr.Message)
+		})
+	}
+
+	t.Run("Event not found", func(t *testing.T) {
+		eventRepo := new(domain_mock.MockEventRepository)
+		annotationRepo := new(domain_mock.MockEventAnnotationRepository)
+		usecase := NewCommentUsecase(eventRepo, nil, annotationRepo)
+
+		eventRepo.On("Find", mock.Anything, uint(9)).Return(domain.Event{}, domain.ErrNotFound).Once()
+
+		err := usecase.Annotate(context.Background(), 9, &domain.EventAnnotation{Key: "jira", Value: "OPS-42", Author: "alice"})
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+		annotationRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,105 @@
+package usecase
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+
+	"github.com/cvzm/go-web-project/domain"
+)
+
+// DigestConfig is notification digest related configuration
+type DigestConfig struct {
+	Period       string             // hourly or daily
+	Notable      domain.EventFilter // notable events, listed in the digest besides the counts
+	NotableLimit int                // maximum number of notable events listed per source, none if zero
+}
+
+type digestUsecase struct {
+	eventRepo domain.EventRepository
+	txManager domain.TransactionManager
+	sender    domain.DigestSender
+	conf      DigestConfig
+}
+
+// NewDigestUsecase creates a new digest use case sending the digests with the sender
+func NewDigestUsecase(eventRepo domain.EventRepository, txManager domain.TransactionManager, sender domain.DigestSender, conf DigestConfig) domain.DigestUsecase {
+	return &digestUsecase{eventRepo: eventRepo, txManager: txManager, sender: sender, conf: conf}
+}
+
+func (u *digestUsecase) Build(ctx context.Context, period string, from, to time.Time) (domain.Digest, error) {
+	return u.build(ctx, u.eventRepo, period, from, to)
+}
+
+// Send claims the digest and sends it in the same transaction, so that the other instances wait for the outcome
+// and the digest is sent again at the next run if sending fails. Events created during the period but saved
+// once its digest is sent are left out.
+func (u *digestUsecase) Send(ctx context.Context, now time.Time) (bool, error) {
+	from, to := domain.DigestPeriod(u.conf.Period, now)
+	sent := false
+	err := u.txManager.Transaction(ctx, func(ctx context.Context, repos domain.Repositories) error {
+		sent = false
+		claimed, err := repos.Digests().Claim(ctx, u.conf.Period, from)
+		if err != nil || !claimed {
+			return err
+		}
+		digest, err := u.build(ctx, repos.Events(), u.conf.Period, from, to)
+		if err != nil {
+			return err
+		}
+		if err := u.sender.SendDigest(ctx, digest); err != nil {
+			return err
+		}
+		sent = true
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return sent, nil
+}
+
+// build counts the events of the period by source and type, and finds the latest notable ones of each source
+func (u *digestUsecase) build(ctx context.Context, eventRepo domain.EventRepository, period string, from, to time.Time) (domain.Digest, error) {
+	counts, err := eventRepo.CountByType(ctx, from, to)
+	if err != nil {
+		return domain.Digest{}, err
+	}
+
+	digest := domain.Digest{Period: period, From: from, To: to, Sources: []domain.DigestSource{}}
+	for _, count := range counts {
+		i := slices.IndexFunc(digest.Sources, func(source domain.DigestSource) bool { return source.Source == count.Source })
+		if i < 0 {
+			digest.Sources = append(digest.Sources, domain.DigestSource{Source: count.Source, Counts: []domain.EventCount{}, Notable: []domain.Event{}})
+			i = len(digest.Sources) - 1
+		}
+		digest.Sources[i].Total += count.Count
+		digest.Sources[i].Counts = append(digest.Sources[i].Counts, count)
+		digest.Total += count.Count
+	}
+	slices.SortStableFunc(digest.Sources, func(a, b domain.DigestSource) int {
+		return cmp.Compare(b.Total, a.Total)
+	})
+
+	if u.conf.NotableLimit == 0 {
+		return digest, nil
+	}
+	for i := range digest.Sources {
+		source := &digest.Sources[i]
+		notableTypes := []string{}
+		for _, count := range source.Counts {
+			if u.conf.Notable.Matches(domain.Event{Source: count.Source, EventType: count.EventType}) {
+				notableTypes = append(notableTypes, count.EventType)
+			}
+		}
+		if len(notableTypes) == 0 {
+			continue
+		}
+		source.Notable, err = eventRepo.FindLatest(ctx, source.Source, notableTypes, from, to, u.conf.NotableLimit)
+		if err != nil {
+			return domain.Digest{}, err
+		}
+	}
+	return digest, nil
+}
@@ -0,0 +1,133 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cvzm/go-web-project/domain"
+	domain_mock "github.com/cvzm/go-web-project/domain/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var digestConfig = DigestConfig{
+	Period:       domain.DigestHourly,
+	Notable:      domain.EventFilter{EventTypes: []string{"*_STOPPED"}},
+	NotableLimit: 5,
+}
+
+func TestDigestUsecase_Build(t *testing.T) {
+	from := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	to := from.Add(time.Hour)
+
+	t.Run("Groups the counts by source and lists the notable events", func(t *testing.T) {
+		eventRepo := new(domain_mock.MockEventRepository)
+		usecase := NewDigestUsecase(eventRepo, nil, nil, digestConfig)
+		stopped := []domain.Event{{ID: 7, Source: domain.SourceAWS, EventType: "EC2_STOPPED"}}
+
+		eventRepo.On("CountByType", mock.Anything, from, to).Return([]domain.EventCount{
+			{Source: domain.SourceGCP, EventType: "VM_STARTED", Count: 4},
+			{Source: domain.SourceAWS, EventType: "EC2_STARTED", Count: 3},
+			{Source: domain.SourceAWS, EventType: "EC2_STOPPED", Count: 2},
+		}, nil).Once()
+		eventRepo.On("FindLatest", mock.Anything, domain.SourceAWS, []string{"EC2_STOPPED"}, from, to, 5).Return(stopped, nil).Once()
+
+		digest, err := usecase.Build(context.Background(), domain.DigestHourly, from, to)
+		require.NoError(t, err)
+		assert.Equal(t, int64(9), digest.Total)
+		require.Len(t, digest.Sources, 2)
+		assert.Equal(t, domain.SourceAWS, digest.Sources[0].Source)
+		assert.Equal(t, int64(5), digest.Sources[0].Total)
+		assert.Len(t, digest.Sources[0].Counts, 2)
+		assert.Equal(t, stopped, digest.Sources[0].Notable)
+		assert.Equal(t, domain.SourceGCP, digest.Sources[1].Source)
+		assert.Empty(t, digest.Sources[1].Notable)
+		eventRepo.AssertExpectations(t)
+	})
+
+	t.Run("Without notable events", func(t *testing.T) {
+		eventRepo := new(domain_mock.MockEventRepository)
+		usecase := NewDigestUsecase(eventRepo, nil, nil, DigestConfig{Period: domain.DigestDaily})
+
+		eventRepo.On("CountByType", mock.Anything, from, to).Return([]domain.EventCount{
+			{Source: domain.SourceAWS, EventType: "EC2_STOPPED", Count: 2},
+		}, nil).Once()
+
+		digest, err := usecase.Build(context.Background(), domain.DigestDaily, from, to)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), digest.Total)
+		eventRepo.AssertNotCalled(t, "FindLatest", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestDigestUsecase_Send(t *testing.T) {
+	now := time.Date(2024, 5, 1, 13, 5, 0, 0, time.UTC)
+	from := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	to := from.Add(time.Hour)
+
+	newUsecase := func() (domain.DigestUsecase, *domain_mock.MockTransactionManager, *domain_mock.MockRepositories, *domain_mock.MockDigestSender) {
+		repos := &domain_mock.MockRepositories{EventRepo: new(domain_mock.MockEventRepository), DigestRepo: new(domain_mock.MockDigestRepository)}
+		txManager := &domain_mock.MockTransactionManager{Repositories: repos}
+		sender := new(domain_mock.MockDigestSender)
+		return NewDigestUsecase(nil, txManager, sender, DigestConfig{Period: domain.DigestHourly}), txManager, repos, sender
+	}
+
+	t.Run("Sends the digest of the last complete period", func(t *testing.T) {
+		usecase, txManager, repos, sender := newUsecase()
+
+		txManager.On("Transaction", mock.Anything).Return(nil).Once()
+		repos.DigestRepo.On("Claim", mock.Anything, domain.DigestHourly, from).Return(true, nil).Once()
+		repos.EventRepo.On("CountByType", mock.Anything, from, to).Return([]domain.EventCount{
+			{Source: domain.SourceAWS, EventType: "EC2_STOPPED", Count: 2},
+		}, nil).Once()
+		sender.On("SendDigest", mock.Anything, mock.MatchedBy(func(d domain.Digest) bool {
+			return d.Period == domain.DigestHourly && d.From.Equal(from) && d.To.Equal(to) && d.Total == 2
+		})).Return(nil).Once()
+
+		sent, err := usecase.Send(context.Background(), now)
+		require.NoError(t, err)
+		assert.True(t, sent)
+		sender.AssertExpectations(t)
+	})
+
+	t.Run("Skips a digest already sent", func(t *testing.T) {
+		usecase, txManager, repos, sender := newUsecase()
+
+		txManager.On("Transaction", mock.Anything).Return(nil).Once()
+		repos.DigestRepo.On("Claim", mock.Anything, domain.DigestHourly, from).Return(false, nil).Once()
+
+		sent, err := usecase.Send(context.Background(), now)
+		require.NoError(t, err)
+		assert.False(t, sent)
+		sender.AssertNotCalled(t, "SendDigest", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Rolls the claim back when sending fails", func(t *testing.T) {
+		usecase, txManager, repos, sender := newUsecase()
+		sendErr := errors.New("connection refused")
+
+		txManager.On("Transaction", mock.Anything).Return(nil).Maybe()
+		repos.DigestRepo.On("Claim", mock.Anything, domain.DigestHourly, from).Return(true, nil).Once()
+		repos.EventRepo.On("CountByType", mock.Anything, from, to).Return([]domain.EventCount{}, nil).Once()
+		sender.On("SendDigest", mock.Anything, mock.Anything).Return(sendErr).Once()
+
+		sent, err := usecase.Send(context.Background(), now)
+		assert.ErrorIs(t, err, sendErr)
+		assert.False(t, sent)
+	})
+}
+
+func TestDigestPeriod(t *testing.T) {
+	now := time.Date(2024, 5, 1, 13, 5, 0, 0, time.FixedZone("CEST", 2*3600))
+
+	from, to := domain.DigestPeriod(domain.DigestHourly, now)
+	assert.Equal(t, time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), from)
+	assert.Equal(t, time.Date(2024, 5, 1, 11, 0, 0, 0, time.UTC), to)
+
+	from, to = domain.DigestPeriod(domain.DigestDaily, now)
+	assert.Equal(t, time.Date(2024, 4, 30, 0, 0, 0, 0, time.UTC), from)
+	assert.Equal(t, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), to)
+}
@@ -0,0 +1,60 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/cvzm/go-web-project/domain"
+)
+
+type directEventIngester struct {
+	eventUsecase domain.EventUsecase
+}
+
+// NewDirectEventIngester creates a new event ingester saving the received events right away
+func NewDirectEventIngester(eventUsecase domain.EventUsecase) domain.EventIngester {
+	return &directEventIngester{eventUsecase: eventUsecase}
+}
+
+func (i *directEventIngester) Ingest(ctx context.Context, cloudEvent domain.CloudEvent) error {
+	return i.eventUsecase.Save(ctx, cloudEvent)
+}
+
+type queueEventIngester struct {
+	producer domain.MessageProducer
+}
+
+// NewQueueEventIngester creates a new event ingester buffering the received events in a queue, saved by the
+// workers consuming it
+func NewQueueEventIngester(producer domain.MessageProducer) domain.EventIngester {
+	return &queueEventIngester{producer: producer}
+}
+
+// Ingest enqueues the cloud event as is, the workers decoding it like the messages of the other queues
+func (i *queueEventIngester) Ingest(ctx context.Context, cloudEvent domain.CloudEvent) error {
+	// Events are parsed before being enqueued, so that the caller gets the errors of invalid events
+	if _, err := cloudEvent.Parse(ctx); err != nil {
+		return err
+	}
+	body, err := json.Marshal(cloudEvent)
+	if err != nil {
+		return err
+	}
+	return i.producer.Enqueue(ctx, body, "", deduplicationID(cloudEvent))
+}
+
+// deduplicationID returns the provider ID of the cloud event, so that an event delivered again while still
+// queued is enqueued once
+func deduplicationID(cloudEvent domain.CloudEvent) string {
+	switch event := cloudEvent.(type) {
+	case domain.AWSEvent:
+		if event.AWSEventID != "" {
+			return string(domain.SourceAWS) + ":" + event.AWSEventID
+		}
+	case domain.GCPEvent:
+		if event.GCPEventID != "" {
+			return string(domain.SourceGCP) + ":" + event.GCPEventID
+		}
+	}
+	return ""
+}
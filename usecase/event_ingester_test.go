@@ -0,0 +1,60 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cvzm/go-web-project/domain"
+	domain_mock "github.com/cvzm/go-web-project/domain/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestDirectEventIngester_Ingest(t *testing.T) {
+	mockUsecase := new(domain_mock.MockEventUsecase)
+	ingester := NewDirectEventIngester(mockUsecase)
+	event := domain.AWSEvent{AWSEventID: "aws-123", AWSEventType: "EC2_STOPPED"}
+
+	mockUsecase.On("Save", mock.Anything, event).Return(nil).Once()
+
+	assert.NoError(t, ingester.Ingest(context.Background(), event))
+	mockUsecase.AssertExpectations(t)
+}
+
+func TestQueueEventIngester_Ingest(t *testing.T) {
+	timestamp := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Enqueues the AWS event deduplicated by its ID", func(t *testing.T) {
+		mockProducer := new(domain_mock.MockMessageProducer)
+		ingester := NewQueueEventIngester(mockProducer)
+
+		mockProducer.On("Enqueue", mock.Anything,
+			[]byte(`{"aws_event_id":"aws-123","aws_event_type":"EC2_STOPPED","aws_message":"Stopped","aws_timestamp":"2024-05-01T12:00:00Z"}`),
+			"", "AWS:aws-123").Return(nil).Once()
+
+		err := ingester.Ingest(context.Background(), domain.AWSEvent{AWSEventID: "aws-123", AWSEventType: "EC2_STOPPED", AWSMessage: "Stopped", AWSTimestamp: timestamp})
+		assert.NoError(t, err)
+		mockProducer.AssertExpectations(t)
+	})
+
+	t.Run("Enqueues the GCP event without ID undeduplicated", func(t *testing.T) {
+		mockProducer := new(domain_mock.MockMessageProducer)
+		ingester := NewQueueEventIngester(mockProducer)
+
+		mockProducer.On("Enqueue", mock.Anything, mock.Anything, "", "").Return(nil).Once()
+
+		assert.NoError(t, ingester.Ingest(context.Background(), domain.GCPEvent{GCPEventType: "VM_STOPPED", GCPTimestamp: timestamp}))
+		mockProducer.AssertExpectations(t)
+	})
+
+	t.Run("Fails when the event can't be enqueued", func(t *testing.T) {
+		mockProducer := new(domain_mock.MockMessageProducer)
+		ingester := NewQueueEventIngester(mockProducer)
+
+		mockProducer.On("Enqueue", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(errors.New("database unavailable")).Once()
+
+		assert.EqualError(t, ingester.Ingest(context.Background(), domain.AWSEvent{AWSEventID: "aws-123"}), "database unavailable")
+	})
+}
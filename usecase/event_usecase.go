@@ -1,25 +1,155 @@
 package usecase
 
-import "github.com/cvzm/go-web-project/domain"
+import (
+	"context"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/cvzm/go-web-project/domain"
+)
 
 type eventUsecase struct {
-	eventRepo domain.EventRepository
+	eventRepo       domain.EventRepository
+	eventChangeRepo domain.EventChangeRepository
+	txManager       domain.TransactionManager
+	outboxUsecase   domain.OutboxUsecase
+	webhookUsecase  domain.WebhookUsecase
+	silenceUsecase  domain.SilenceUsecase
+	classifier      domain.EventClassifier
+	notifier        domain.Notifier
 }
 
-func NewEventUsecase(repo domain.EventRepository) domain.EventUsecase {
-	return &eventUsecase{eventRepo: repo}
+func NewEventUsecase(eventRepo domain.EventRepository, eventChangeRepo domain.EventChangeRepository, txManager domain.TransactionManager, outboxUsecase domain.OutboxUsecase, webhookUsecase domain.WebhookUsecase, silenceUsecase domain.SilenceUsecase, classifier domain.EventClassifier, notifier domain.Notifier) domain.EventUsecase {
+	return &eventUsecase{
+		eventRepo:       eventRepo,
+		eventChangeRepo: eventChangeRepo,
+		txManager:       txManager,
+		outboxUsecase:   outboxUsecase,
+		webhookUsecase:  webhookUsecase,
+		silenceUsecase:  silenceUsecase,
+		classifier:      classifier,
+		notifier:        notifier,
+	}
 }
 
-func (u *eventUsecase) Save(cloudEvent domain.CloudEvent) error {
-	event, err := cloudEvent.Parse()
+func (u *eventUsecase) Save(ctx context.Context, cloudEvent domain.CloudEvent) error {
+	event, err := cloudEvent.Parse(ctx)
 	if err != nil {
 		return err
 	}
 
 	// TODO: Check idempotence
 
-	// More business logic
-	// e.g slack notification
+	event.Status = domain.EventStatusOpen
+
+	// Events without a provider-native severity are classified, before being silenced or routed by severity
+	if event.Severity == "" {
+		event.Severity = u.classifier.Classify(event)
+	}
+
+	// Silenced events are stored, published and delivered to webhooks, and left to the notifier to skip. Events
+	// are notified rather than lost when the silences cannot be checked.
+	event.Silenced, err = u.silenceUsecase.Silenced(ctx, event)
+	if err != nil {
+		log.Printf("Error checking the silences of a %s %s event: %v", event.Source, event.EventType, err)
+	}
+
+	// The event, its outbox message and its webhook deliveries are saved together, so that the event is
+	// published and delivered if and only if it is saved
+	err = u.txManager.Transaction(ctx, func(ctx context.Context, repos domain.Repositories) error {
+		if err := repos.Events().Save(ctx, &event); err != nil {
+			return err
+		}
+		message, err := domain.NewOutboxMessage(domain.AggregateEvent, strconv.FormatUint(uint64(event.ID), 10), domain.OutboxEventCreated, event)
+		if err != nil {
+			return err
+		}
+		if err := u.outboxUsecase.Enqueue(ctx, repos, message); err != nil {
+			return err
+		}
+		return u.webhookUsecase.Enqueue(ctx, repos, event)
+	})
+	if err != nil {
+		return err
+	}
+
+	// Notifications are sent in the background, once the event is committed
+	u.notifier.Notify(ctx, event)
+	return nil
+}
+
+func (u *eventUsecase) Query(ctx context.Context, query domain.EventQuery) ([]domain.Event, error) {
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+	return u.eventRepo.Query(ctx, query)
+}
+
+func (u *eventUsecase) Get(ctx context.Context, id uint) (domain.Event, error) {
+	return u.eventRepo.Find(ctx, id)
+}
+
+func (u *eventUsecase) Acknowledge(ctx context.Context, id uint, request domain.EventChangeRequest) (domain.Event, error) {
+	return u.change(ctx, id, domain.EventActionAcknowledge, request, func(event *domain.Event, now time.Time) error {
+		return event.Acknowledge(now)
+	})
+}
+
+func (u *eventUsecase) Resolve(ctx context.Context, id uint, request domain.EventChangeRequest) (domain.Event, error) {
+	return u.change(ctx, id, domain.EventActionResolve, request, func(event *domain.Event, now time.Time) error {
+		return event.Resolve(now)
+	})
+}
+
+func (u *eventUsecase) Assign(ctx context.Context, id uint, request domain.EventChangeRequest) (domain.Event, error) {
+	return u.change(ctx, id, domain.EventActionAssign, request, func(event *domain.Event, now time.Time) error {
+		return event.Assign(request.Assignee)
+	})
+}
+
+func (u *eventUsecase) History(ctx context.Context, id uint) ([]domain.EventChange, error) {
+	// The history of an unknown event is not found rather than empty
+	if _, err := u.eventRepo.Find(ctx, id); err != nil {
+		return nil, err
+	}
+	return u.eventChangeRepo.FindByEvent(ctx, id)
+}
+
+// change applies the change to the event and records it in the history of the event, the event being locked so
+// that concurrent changes follow the lifecycle one after the other
+func (u *eventUsecase) change(ctx context.Context, id uint, action domain.EventAction, request domain.EventChangeRequest, apply func(event *domain.Event, now time.Time) error) (domain.Event, error) {
+	if request.Actor == "" {
+		return domain.Event{}, &domain.ValidationError{Message: "actor is required"}
+	}
+
+	var event domain.Event
+	err := u.txManager.Transaction(ctx, func(ctx context.Context, repos domain.Repositories) error {
+		var err error
+		event, err = repos.Events().Lock(ctx, id)
+		if err != nil {
+			return err
+		}
+		change := domain.EventChange{
+			EventID:      event.ID,
+			Action:       action,
+			Actor:        request.Actor,
+			FromStatus:   event.Status,
+			FromAssignee: event.Assignee,
+			Note:         request.Note,
+		}
+		if err := apply(&event, time.Now()); err != nil {
+			return err
+		}
+		change.ToStatus, change.ToAssignee = event.Status, event.Assignee
 
-	return u.eventRepo.Save(&event)
+		if err := repos.Events().UpdateStatus(ctx, &event); err != nil {
+			return err
+		}
+		return repos.EventChanges().Create(ctx, &change)
+	})
+	if err != nil {
+		return domain.Event{}, err
+	}
+	return event, nil
 }
@@ -1,7 +1,9 @@
 package usecase
 
 import (
+	"context"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
@@ -9,11 +11,24 @@ import (
 	domain_mock "github.com/cvzm/go-web-project/domain/mock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func TestEventUsecase_Save(t *testing.T) {
 	mockRepo := new(domain_mock.MockEventRepository)
-	usecase := NewEventUsecase(mockRepo)
+	mockTxManager := &domain_mock.MockTransactionManager{Repositories: &domain_mock.MockRepositories{EventRepo: mockRepo}}
+	mockTxManager.On("Transaction", mock.Anything).Return(nil)
+	mockOutbox := new(domain_mock.MockOutboxUsecase)
+	mockOutbox.On("Enqueue", mock.Anything, mockTxManager.Repositories, mock.AnythingOfType("*domain.OutboxMessage")).Return(nil)
+	mockWebhooks := new(domain_mock.MockWebhookUsecase)
+	mockWebhooks.On("Enqueue", mock.Anything, mockTxManager.Repositories, mock.AnythingOfType("domain.Event")).Return(nil)
+	mockSilences := new(domain_mock.MockSilenceUsecase)
+	mockSilences.On("Silenced", mock.Anything, mock.AnythingOfType("domain.Event")).Return(false, nil)
+	mockClassifier := new(domain_mock.MockEventClassifier)
+	mockClassifier.On("Classify", mock.AnythingOfType("domain.Event")).Return(domain.SeverityInfo)
+	mockNotifier := new(domain_mock.MockNotifier)
+	mockNotifier.On("Notify", mock.Anything, mock.AnythingOfType("domain.Event"))
+	usecase := NewEventUsecase(mockRepo, nil, mockTxManager, mockOutbox, mockWebhooks, mockSilences, mockClassifier, mockNotifier)
 
 	t.Run("Successfully save AWS event", func(t *testing.T) {
 		awsEvent := domain.AWSEvent{
@@ -27,15 +42,18 @@ func TestEventUsecase_Save(t *testing.T) {
 			Source:      domain.SourceAWS,
 			EventType:   awsEvent.AWSEventType,
 			Description: awsEvent.AWSMessage,
+			Severity:    domain.SeverityInfo,
+			Status:      domain.EventStatusOpen,
 			CreatedAt:   awsEvent.AWSTimestamp,
 		}
 
-		mockRepo.On("Save", mock.AnythingOfType("*domain.Event")).Return(nil).Once()
+		mockRepo.On("Save", mock.Anything, mock.AnythingOfType("*domain.Event")).Return(nil).Once()
 
-		err := usecase.Save(awsEvent)
+		err := usecase.Save(context.Background(), awsEvent)
 
 		assert.NoError(t, err)
-		mockRepo.AssertCalled(t, "Save", &expectedEvent)
+		mockRepo.AssertCalled(t, "Save", mock.Anything, &expectedEvent)
+		mockNotifier.AssertCalled(t, "Notify", mock.Anything, expectedEvent)
 	})
 
 	t.Run("Successfully save GCP event", func(t *testing.T) {
@@ -50,15 +68,17 @@ func TestEventUsecase_Save(t *testing.T) {
 			Source:      domain.SourceGCP,
 			EventType:   gcpEvent.GCPEventType,
 			Description: gcpEvent.GCPMessage,
+			Severity:    domain.SeverityInfo,
+			Status:      domain.EventStatusOpen,
 			CreatedAt:   gcpEvent.GCPTimestamp,
 		}
 
-		mockRepo.On("Save", mock.AnythingOfType("*domain.Event")).Return(nil).Once()
+		mockRepo.On("Save", mock.Anything, mock.AnythingOfType("*domain.Event")).Return(nil).Once()
 
-		err := usecase.Save(gcpEvent)
+		err := usecase.Save(context.Background(), gcpEvent)
 
 		assert.NoError(t, err)
-		mockRepo.AssertCalled(t, "Save", &expectedEvent)
+		mockRepo.AssertCalled(t, "Save", mock.Anything, &expectedEvent)
 	})
 
 	t.Run("Failed to save event", func(t *testing.T) {
@@ -69,11 +89,304 @@ func TestEventUsecase_Save(t *testing.T) {
 			AWSTimestamp: time.Now(),
 		}
 
-		mockRepo.On("Save", mock.AnythingOfType("*domain.Event")).Return(errors.New("save failed")).Once()
+		mockRepo.On("Save", mock.Anything, mock.AnythingOfType("*domain.Event")).Return(errors.New("save failed")).Once()
 
-		err := usecase.Save(awsEvent)
+		err := usecase.Save(context.Background(), awsEvent)
 
 		assert.Error(t, err)
 		assert.EqualError(t, err, "save failed")
+		mockNotifier.AssertNotCalled(t, "Notify", mock.Anything, mock.MatchedBy(func(event domain.Event) bool {
+			return event.EventType == "EC2_TERMINATED"
+		}))
 	})
+	t.Run("Records the saved event in the outbox", func(t *testing.T) {
+		awsEvent := domain.AWSEvent{
+			AWSEventID:   "aws-321",
+			AWSEventType: "EC2_STOPPED",
+			AWSMessage:   "EC2 instance stopped",
+			AWSTimestamp: time.Now(),
+		}
+
+		mockRepo.On("Save", mock.Anything, mock.AnythingOfType("*domain.Event")).Run(func(args mock.Arguments) {
+			args.Get(1).(*domain.Event).ID = 42
+		}).Return(nil).Once()
+
+		err := usecase.Save(context.Background(), awsEvent)
+
+		assert.NoError(t, err)
+		mockOutbox.AssertCalled(t, "Enqueue", mock.Anything, mockTxManager.Repositories, mock.MatchedBy(func(message *domain.OutboxMessage) bool {
+			return message.AggregateType == domain.AggregateEvent && message.AggregateID == "42" &&
+				message.EventType == domain.OutboxEventCreated && strings.Contains(string(message.Payload), `"event_type":"EC2_STOPPED"`)
+		}))
+		mockWebhooks.AssertCalled(t, "Enqueue", mock.Anything, mockTxManager.Repositories, mock.MatchedBy(func(event domain.Event) bool {
+			return event.ID == 42
+		}))
+	})
+
+	t.Run("Saves nothing when the outbox write fails", func(t *testing.T) {
+		mockRepo := new(domain_mock.MockEventRepository)
+		mockTxManager := &domain_mock.MockTransactionManager{Repositories: &domain_mock.MockRepositories{EventRepo: mockRepo}}
+		mockTxManager.On("Transaction", mock.Anything).Return(nil)
+		mockOutbox := new(domain_mock.MockOutboxUsecase)
+		mockWebhooks := new(domain_mock.MockWebhookUsecase)
+		mockSilences := new(domain_mock.MockSilenceUsecase)
+		mockSilences.On("Silenced", mock.Anything, mock.Anything).Return(false, nil)
+		mockClassifier := new(domain_mock.MockEventClassifier)
+		mockClassifier.On("Classify", mock.Anything).Return(domain.SeverityInfo)
+		mockNotifier := new(domain_mock.MockNotifier)
+		usecase := NewEventUsecase(mockRepo, nil, mockTxManager, mockOutbox, mockWebhooks, mockSilences, mockClassifier, mockNotifier)
+
+		mockRepo.On("Save", mock.Anything, mock.AnythingOfType("*domain.Event")).Return(nil).Once()
+		mockOutbox.On("Enqueue", mock.Anything, mock.Anything, mock.Anything).Return(errors.New("outbox write failed")).Once()
+
+		err := usecase.Save(context.Background(), domain.GCPEvent{GCPEventType: "VM_STARTED"})
+
+		// The error rolls back the transaction of the event
+		assert.EqualError(t, err, "outbox write failed")
+		mockTxManager.AssertNumberOfCalls(t, "Transaction", 1)
+		mockWebhooks.AssertNotCalled(t, "Enqueue", mock.Anything, mock.Anything, mock.Anything)
+		mockNotifier.AssertNotCalled(t, "Notify", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Stores silenced events and leaves them to the notifier to skip", func(t *testing.T) {
+		mockRepo := new(domain_mock.MockEventRepository)
+		mockTxManager := &domain_mock.MockTransactionManager{Repositories: &domain_mock.MockRepositories{EventRepo: mockRepo}}
+		mockTxManager.On("Transaction", mock.Anything).Return(nil)
+		mockOutbox := new(domain_mock.MockOutboxUsecase)
+		mockOutbox.On("Enqueue", mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+		mockWebhooks := new(domain_mock.MockWebhookUsecase)
+		mockWebhooks.On("Enqueue", mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+		mockSilences := new(domain_mock.MockSilenceUsecase)
+		mockSilences.On("Silenced", mock.Anything, mock.MatchedBy(func(event domain.Event) bool {
+			return event.EventType == "RDS_FAILOVER"
+		})).Return(true, nil).Once()
+		mockClassifier := new(domain_mock.MockEventClassifier)
+		mockClassifier.On("Classify", mock.Anything).Return(domain.SeverityInfo)
+		mockNotifier := new(domain_mock.MockNotifier)
+		mockNotifier.On("Notify", mock.Anything, mock.MatchedBy(func(event domain.Event) bool {
+			return event.Silenced
+		})).Once()
+		usecase := NewEventUsecase(mockRepo, nil, mockTxManager, mockOutbox, mockWebhooks, mockSilences, mockClassifier, mockNotifier)
+
+		mockRepo.On("Save", mock.Anything, mock.MatchedBy(func(event *domain.Event) bool {
+			return event.Silenced
+		})).Return(nil).Once()
+
+		err := usecase.Save(context.Background(), domain.AWSEvent{AWSEventType: "RDS_FAILOVER", AWSTimestamp: time.Now()})
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+		mockWebhooks.AssertExpectations(t)
+		mockNotifier.AssertExpectations(t)
+	})
+
+	t.Run("Notifies the event when the silences cannot be checked", func(t *testing.T) {
+		mockRepo := new(domain_mock.MockEventRepository)
+		mockTxManager := &domain_mock.MockTransactionManager{Repositories: &domain_mock.MockRepositories{EventRepo: mockRepo}}
+		mockTxManager.On("Transaction", mock.Anything).Return(nil)
+		mockOutbox := new(domain_mock.MockOutboxUsecase)
+		mockOutbox.On("Enqueue", mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+		mockWebhooks := new(domain_mock.MockWebhookUsecase)
+		mockWebhooks.On("Enqueue", mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+		mockSilences := new(domain_mock.MockSilenceUsecase)
+		mockSilences.On("Silenced", mock.Anything, mock.Anything).Return(false, errors.New("db down")).Once()
+		mockClassifier := new(domain_mock.MockEventClassifier)
+		mockClassifier.On("Classify", mock.Anything).Return(domain.SeverityInfo)
+		mockNotifier := new(domain_mock.MockNotifier)
+		mockNotifier.On("Notify", mock.Anything, mock.Anything).Once()
+		usecase := NewEventUsecase(mockRepo, nil, mockTxManager, mockOutbox, mockWebhooks, mockSilences, mockClassifier, mockNotifier)
+
+		mockRepo.On("Save", mock.Anything, mock.AnythingOfType("*domain.Event")).Return(nil).Once()
+
+		assert.NoError(t, usecase.Save(context.Background(), domain.AWSEvent{AWSEventType: "RDS_FAILOVER"}))
+		mockNotifier.AssertExpectations(t)
+	})
+
+	t.Run("Keeps the provider-native severity", func(t *testing.T) {
+		mockRepo.On("Save", mock.Anything, mock.AnythingOfType("*domain.Event")).Return(nil).Once()
+
+		err := usecase.Save(context.Background(), domain.GCPEvent{GCPEventType: "VM_CRASHED", GCPSeverity: "ALERT"})
+
+		assert.NoError(t, err)
+		mockRepo.AssertCalled(t, "Save", mock.Anything, mock.MatchedBy(func(event *domain.Event) bool {
+			return event.EventType == "VM_CRASHED" && event.Severity == domain.SeverityCritical
+		}))
+		mockClassifier.AssertNotCalled(t, "Classify", mock.MatchedBy(func(event domain.Event) bool {
+			return event.EventType == "VM_CRASHED"
+		}))
+	})
+}
+
+func TestEventUsecase_Query(t *testing.T) {
+	t.Run("Defaults the limit", func(t *testing.T) {
+		mockRepo := new(domain_mock.MockEventRepository)
+		usecase := NewEventUsecase(mockRepo, nil, nil, nil, nil, nil, nil, nil)
+		events := []domain.Event{{ID: 7, Severity: domain.SeverityCritical}}
+
+		mockRepo.On("Query", mock.Anything, domain.EventQuery{MinSeverity: domain.SeverityError, Limit: domain.DefaultEventQueryLimit}).Return(events, nil).Once()
+
+		result, err := usecase.Query(context.Background(), domain.EventQuery{MinSeverity: domain.SeverityError})
+		assert.NoError(t, err)
+		assert.Equal(t, events, result)
+		mockRepo.AssertExpectations(t)
+	})
+
+	invalidQueries := []struct {
+		name    string
+		query   domain.EventQuery
+		message string
+	}{
+		{"Invalid severity", domain.EventQuery{Severities: []domain.Severity{"fatal"}}, `invalid severity "fatal", expecting info, warning, error or critical`},
+		{"Invalid minimum severity", domain.EventQuery{MinSeverity: "high"}, `invalid min_severity "high", expecting info, warning, error or critical`},
+		{"Limit too high", domain.EventQuery{Limit: 5000}, "limit must be between 1 and 1000"},
+		{"Negative offset", domain.EventQuery{Offset: -1}, "offset must not be negative"},
+	}
+	for _, tc := range invalidQueries {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(domain_mock.MockEventRepository)
+			usecase := NewEventUsecase(mockRepo, nil, nil, nil, nil, nil, nil, nil)
+
+			_, err := usecase.Query(context.Background(), tc.query)
+			var validationErr *domain.ValidationError
+			require.ErrorAs(t, err, &validationErr)
+			assert.Equal(t, tc.message, validationErr.Message)
+			mockRepo.AssertNotCalled(t, "Query", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestEventUsecase_Lifecycle(t *testing.T) {
+	// newUsecase returns an event use case changing the events of the repository mocks in a transaction
+	newUsecase := func() (domain.EventUsecase, *domain_mock.MockEventRepository, *domain_mock.MockEventChangeRepository) {
+		mockRepo := new(domain_mock.MockEventRepository)
+		mockChangeRepo := new(domain_mock.MockEventChangeRepository)
+		mockTxManager := &domain_mock.MockTransactionManager{Repositories: &domain_mock.MockRepositories{EventRepo: mockRepo, EventChangeRepo: mockChangeRepo}}
+		mockTxManager.On("Transaction", mock.Anything).Return(nil)
+		return NewEventUsecase(mockRepo, mockChangeRepo, mockTxManager, nil, nil, nil, nil, nil), mockRepo, mockChangeRepo
+	}
+	request := domain.EventChangeRequest{Actor: "alice", Note: "Looking into it"}
+
+	t.Run("Acknowledges an open event", func(t *testing.T) {
+		usecase, mockRepo, mockChangeRepo := newUsecase()
+
+		mockRepo.On("Lock", mock.Anything, uint(7)).Return(domain.Event{ID: 7, Status: domain.EventStatusOpen}, nil).Once()
+		mockRepo.On("UpdateStatus", mock.Anything, mock.MatchedBy(func(event *domain.Event) bool {
+			return event.Status == domain.EventStatusAcknowledged && event.AcknowledgedAt != nil
+		})).Return(nil).Once()
+		mockChangeRepo.On("Create", mock.Anything, &domain.EventChange{
+			EventID: 7, Action: domain.EventActionAcknowledge, Actor: "alice", FromStatus: domain.EventStatusOpen,
+			ToStatus: domain.EventStatusAcknowledged, Note: "Looking into it",
+		}).Return(nil).Once()
+
+		event, err := usecase.Acknowledge(context.Background(), 7, request)
+		require.NoError(t, err)
+		assert.Equal(t, domain.EventStatusAcknowledged, event.Status)
+		mockRepo.AssertExpectations(t)
+		mockChangeRepo.AssertExpectations(t)
+	})
+
+	t.Run("Resolves an acknowledged event", func(t *testing.T) {
+		usecase, mockRepo, mockChangeRepo := newUsecase()
+
+		mockRepo.On("Lock", mock.Anything, uint(7)).Return(domain.Event{ID: 7, Status: domain.EventStatusAcknowledged, Assignee: "alice"}, nil).Once()
+		mockRepo.On("UpdateStatus", mock.Anything, mock.Anything).Return(nil).Once()
+		mockChangeRepo.On("Create", mock.Anything, mock.MatchedBy(func(change *domain.EventChange) bool {
+			return change.FromStatus == domain.EventStatusAcknowledged && change.ToStatus == domain.EventStatusResolved &&
+				change.FromAssignee == "alice" && change.ToAssignee == "alice"
+		})).Return(nil).Once()
+
+		event, err := usecase.Resolve(context.Background(), 7, request)
+		require.NoError(t, err)
+		assert.Equal(t, domain.EventStatusResolved, event.Status)
+		assert.NotNil(t, event.ResolvedAt)
+		mockChangeRepo.AssertExpectations(t)
+	})
+
+	t.Run("Assigns an open event", func(t *testing.T) {
+		usecase, mockRepo, mockChangeRepo := newUsecase()
+
+		mockRepo.On("Lock", mock.Anything, uint(7)).Return(domain.Event{ID: 7, Status: domain.EventStatusOpen}, nil).Once()
+		mockRepo.On("UpdateStatus", mock.Anything, mock.Anything).Return(nil).Once()
+		mockChangeRepo.On("Create", mock.Anything, mock.MatchedBy(func(change *domain.EventChange) bool {
+			return change.Action == domain.EventActionAssign && change.FromAssignee == "" && change.ToAssignee == "bob" &&
+				change.ToStatus == domain.EventStatusOpen
+		})).Return(nil).Once()
+
+		event, err := usecase.Assign(context.Background(), 7, domain.EventChangeRequest{Actor: "alice", Assignee: "bob"})
+		require.NoError(t, err)
+		assert.Equal(t, "bob", event.Assignee)
+		mockChangeRepo.AssertExpectations(t)
+	})
+
+	conflicts := []struct {
+		name    string
+		status  domain.EventStatus
+		change  func(usecase domain.EventUsecase) (domain.Event, error)
+		message string
+	}{
+		{"Acknowledging an acknowledged event", domain.EventStatusAcknowledged, func(usecase domain.EventUsecase) (domain.Event, error) {
+			return usecase.Acknowledge(context.Background(), 7, request)
+		}, "event 7 is acknowledged and cannot be acknowledged"},
+		{"Acknowledging a resolved event", domain.EventStatusResolved, func(usecase domain.EventUsecase) (domain.Event, error) {
+			return usecase.Acknowledge(context.Background(), 7, request)
+		}, "event 7 is resolved and cannot be acknowledged"},
+		{"Resolving a resolved event", domain.EventStatusResolved, func(usecase domain.EventUsecase) (domain.Event, error) {
+			return usecase.Resolve(context.Background(), 7, request)
+		}, "event 7 is resolved and cannot be resolved"},
+		{"Assigning a resolved event", domain.EventStatusResolved, func(usecase domain.EventUsecase) (domain.Event, error) {
+			return usecase.Assign(context.Background(), 7, domain.EventChangeRequest{Actor: "alice", Assignee: "bob"})
+		}, "event 7 is resolved and cannot be assigned"},
+	}
+	for _, tc := range conflicts {
+		t.Run(tc.name, func(t *testing.T) {
+			usecase, mockRepo, mockChangeRepo := newUsecase()
+
+			mockRepo.On("Lock", mock.Anything, uint(7)).Return(domain.Event{ID: 7, Status: tc.status}, nil).Once()
+
+			_, err := tc.change(usecase)
+			var conflictErr *domain.ConflictError
+			require.ErrorAs(t, err, &conflictErr)
+			assert.Equal(t, tc.message, conflictErr.Message)
+			mockRepo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything)
+			mockChangeRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		})
+	}
+
+	t.Run("Requires the actor", func(t *testing.T) {
+		usecase, mockRepo, _ := newUsecase()
+
+		_, err := usecase.Acknowledge(context.Background(), 7, domain.EventChangeRequest{})
+		var validationErr *domain.ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, "actor is required", validationErr.Message)
+		mockRepo.AssertNotCalled(t, "Lock", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Fails when the event is not found", func(t *testing.T) {
+		usecase, mockRepo, _ := newUsecase()
+
+		mockRepo.On("Lock", mock.Anything, uint(9)).Return(domain.Event{}, domain.ErrNotFound).Once()
+
+		_, err := usecase.Resolve(context.Background(), 9, request)
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+	})
+}
+
+func TestEventUsecase_History(t *testing.T) {
+	mockRepo := new(domain_mock.MockEventRepository)
+	mockChangeRepo := new(domain_mock.MockEventChangeRepository)
+	usecase := NewEventUsecase(mockRepo, mockChangeRepo, nil, nil, nil, nil, nil, nil)
+	changes := []domain.EventChange{{ID: 1, EventID: 7, Action: domain.EventActionAcknowledge, Actor: "alice"}}
+
+	mockRepo.On("Find", mock.Anything, uint(7)).Return(domain.Event{ID: 7}, nil).Once()
+	mockRepo.On("Find", mock.Anything, uint(9)).Return(domain.Event{}, domain.ErrNotFound).Once()
+	mockChangeRepo.On("FindByEvent", mock.Anything, uint(7)).Return(changes, nil).Once()
+
+	result, err := usecase.History(context.Background(), 7)
+	require.NoError(t, err)
+	assert.Equal(t, changes, result)
+
+	_, err = usecase.History(context.Background(), 9)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	mockChangeRepo.AssertNotCalled(t, "FindByEvent", mock.Anything, uint(9))
 }
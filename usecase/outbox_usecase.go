@@ -0,0 +1,107 @@
+package usecase
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/cvzm/go-web-project/domain"
+)
+
+// OutboxConfig is outbox relay related configuration
+type OutboxConfig struct {
+	BatchSize  int           // maximum number of messages published by a single relay
+	RetryDelay time.Duration // delay before retrying a failed message, doubled for each further attempt
+	MaxBackoff time.Duration // maximum delay between two attempts, unlimited if zero
+}
+
+type outboxUsecase struct {
+	outboxRepo domain.OutboxRepository
+	txManager  domain.TransactionManager
+	publisher  domain.OutboxPublisher
+	conf       OutboxConfig
+}
+
+// NewOutboxUsecase creates a new outbox use case publishing with the publisher, which may be nil if none is configured
+func NewOutboxUsecase(repo domain.OutboxRepository, txManager domain.TransactionManager, publisher domain.OutboxPublisher, conf OutboxConfig) domain.OutboxUsecase {
+	return &outboxUsecase{
+		outboxRepo: repo,
+		txManager:  txManager,
+		publisher:  publisher,
+		conf:       conf,
+	}
+}
+
+func (u *outboxUsecase) Enqueue(ctx context.Context, repos domain.Repositories, message *domain.OutboxMessage) error {
+	// Nothing would ever publish the message
+	if u.publisher == nil {
+		return nil
+	}
+	return repos.Outbox().Add(ctx, message)
+}
+
+// Relay publishes the messages in a transaction holding their locks, so that each message is marked as
+// published exactly once even with several relays. A message published but not marked because the
+// transaction failed is published again, hence downstream consumers deduplicate on the message ID.
+func (u *outboxUsecase) Relay(ctx context.Context) (domain.OutboxRelayResult, error) {
+	var result domain.OutboxRelayResult
+	if u.publisher == nil {
+		return result, nil
+	}
+
+	err := u.txManager.Transaction(ctx, func(ctx context.Context, repos domain.Repositories) error {
+		result = domain.OutboxRelayResult{}
+		messages, err := repos.Outbox().FindPublishable(ctx, time.Now(), u.conf.BatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, message := range messages {
+			if err := u.publisher.Publish(ctx, message); err != nil {
+				message.Attempts++
+				message.LastError = err.Error()
+				message.AvailableAt = time.Now().Add(u.backoff(message.Attempts))
+				if err := repos.Outbox().MarkFailed(ctx, message.ID, message.AvailableAt, message.LastError); err != nil {
+					return err
+				}
+				result.Failed = append(result.Failed, message)
+				continue
+			}
+
+			publishedAt := time.Now()
+			if err := repos.Outbox().MarkPublished(ctx, message.ID, publishedAt); err != nil {
+				return err
+			}
+			message.PublishedAt = &publishedAt
+			result.Published = append(result.Published, message)
+		}
+		return nil
+	})
+	if err != nil {
+		return domain.OutboxRelayResult{}, err
+	}
+	return result, nil
+}
+
+func (u *outboxUsecase) Stats(ctx context.Context) (domain.OutboxStats, error) {
+	return u.outboxRepo.Stats(ctx)
+}
+
+func (u *outboxUsecase) Purge(ctx context.Context, before time.Time) (int64, error) {
+	return u.outboxRepo.DeletePublished(ctx, before)
+}
+
+// backoff returns the delay before the next attempt
+func (u *outboxUsecase) backoff(attempts int) time.Duration {
+	return retryBackoff(u.conf.RetryDelay, u.conf.MaxBackoff, attempts)
+}
+
+// retryBackoff returns the delay before the next attempt, doubling the retry delay for each previous attempt
+// up to the maximum backoff, unlimited if zero
+func retryBackoff(retryDelay, maxBackoff time.Duration, attempts int) time.Duration {
+	backoff := float64(retryDelay) * math.Pow(2, float64(max(attempts-1, 0)))
+	if maxBackoff > 0 && backoff > float64(maxBackoff) {
+		return maxBackoff
+	}
+	return time.Duration(min(backoff, math.MaxInt64))
+}
@@ -0,0 +1,108 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cvzm/go-web-project/domain"
+	domain_mock "github.com/cvzm/go-web-project/domain/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutboxUsecase_Enqueue(t *testing.T) {
+	message := &domain.OutboxMessage{AggregateType: domain.AggregateEvent, AggregateID: "1", EventType: domain.OutboxEventCreated}
+
+	t.Run("Adds the message to the outbox", func(t *testing.T) {
+		outboxRepo := new(domain_mock.MockOutboxRepository)
+		repos := &domain_mock.MockRepositories{OutboxRepo: outboxRepo}
+		usecase := NewOutboxUsecase(nil, nil, new(domain_mock.MockOutboxPublisher), OutboxConfig{})
+
+		outboxRepo.On("Add", mock.Anything, []*domain.OutboxMessage{message}).Return(nil).Once()
+
+		assert.NoError(t, usecase.Enqueue(context.Background(), repos, message))
+		outboxRepo.AssertExpectations(t)
+	})
+
+	t.Run("Skips the outbox without publisher", func(t *testing.T) {
+		outboxRepo := new(domain_mock.MockOutboxRepository)
+		usecase := NewOutboxUsecase(nil, nil, nil, OutboxConfig{})
+
+		assert.NoError(t, usecase.Enqueue(context.Background(), &domain_mock.MockRepositories{OutboxRepo: outboxRepo}, message))
+		outboxRepo.AssertNotCalled(t, "Add", mock.Anything, mock.Anything)
+	})
+}
+
+func TestOutboxUsecase_Relay(t *testing.T) {
+	conf := OutboxConfig{BatchSize: 10, RetryDelay: time.Second, MaxBackoff: time.Minute}
+	messages := []domain.OutboxMessage{
+		{ID: 1, AggregateType: domain.AggregateEvent, AggregateID: "1", EventType: domain.OutboxEventCreated},
+		{ID: 2, AggregateType: domain.AggregateEvent, AggregateID: "2", EventType: domain.OutboxEventCreated, Attempts: 2},
+	}
+
+	t.Run("Marks published and failed messages", func(t *testing.T) {
+		outboxRepo := new(domain_mock.MockOutboxRepository)
+		txManager := &domain_mock.MockTransactionManager{Repositories: &domain_mock.MockRepositories{OutboxRepo: outboxRepo}}
+		publisher := new(domain_mock.MockOutboxPublisher)
+		usecase := NewOutboxUsecase(outboxRepo, txManager, publisher, conf)
+
+		txManager.On("Transaction", mock.Anything).Return(nil).Once()
+		outboxRepo.On("FindPublishable", mock.Anything, mock.AnythingOfType("time.Time"), 10).Return(messages, nil).Once()
+		publisher.On("Publish", mock.Anything, messages[0]).Return(nil).Once()
+		publisher.On("Publish", mock.Anything, messages[1]).Return(errors.New("broker unavailable")).Once()
+		outboxRepo.On("MarkPublished", mock.Anything, uint(1), mock.AnythingOfType("time.Time")).Return(nil).Once()
+		outboxRepo.On("MarkFailed", mock.Anything, uint(2), mock.AnythingOfType("time.Time"), "broker unavailable").Return(nil).Once()
+
+		start := time.Now()
+		result, err := usecase.Relay(context.Background())
+		require.NoError(t, err)
+		require.Len(t, result.Published, 1)
+		assert.Equal(t, uint(1), result.Published[0].ID)
+		assert.NotNil(t, result.Published[0].PublishedAt)
+		require.Len(t, result.Failed, 1)
+		assert.Equal(t, 3, result.Failed[0].Attempts)
+		assert.Equal(t, "broker unavailable", result.Failed[0].LastError)
+		// Third attempt, after twice the doubled retry delay
+		assert.WithinDuration(t, start.Add(4*time.Second), result.Failed[0].AvailableAt, time.Second)
+		outboxRepo.AssertExpectations(t)
+		publisher.AssertExpectations(t)
+	})
+
+	t.Run("Reports nothing when the transaction fails", func(t *testing.T) {
+		outboxRepo := new(domain_mock.MockOutboxRepository)
+		txManager := &domain_mock.MockTransactionManager{Repositories: &domain_mock.MockRepositories{OutboxRepo: outboxRepo}}
+		publisher := new(domain_mock.MockOutboxPublisher)
+		usecase := NewOutboxUsecase(outboxRepo, txManager, publisher, conf)
+
+		txManager.On("Transaction", mock.Anything).Return(errors.New("commit failed")).Once()
+		outboxRepo.On("FindPublishable", mock.Anything, mock.Anything, 10).Return(messages[:1], nil).Once()
+		publisher.On("Publish", mock.Anything, messages[0]).Return(nil).Once()
+		outboxRepo.On("MarkPublished", mock.Anything, uint(1), mock.Anything).Return(nil).Once()
+
+		result, err := usecase.Relay(context.Background())
+		assert.EqualError(t, err, "commit failed")
+		assert.Empty(t, result.Published)
+	})
+
+	t.Run("Does nothing without publisher", func(t *testing.T) {
+		txManager := new(domain_mock.MockTransactionManager)
+		usecase := NewOutboxUsecase(nil, txManager, nil, conf)
+
+		result, err := usecase.Relay(context.Background())
+		assert.NoError(t, err)
+		assert.Empty(t, result.Published)
+		txManager.AssertNotCalled(t, "Transaction", mock.Anything)
+	})
+}
+
+func TestOutboxUsecase_Backoff(t *testing.T) {
+	usecase := &outboxUsecase{conf: OutboxConfig{RetryDelay: time.Second, MaxBackoff: time.Minute}}
+
+	assert.Equal(t, time.Second, usecase.backoff(1))
+	assert.Equal(t, 8*time.Second, usecase.backoff(4))
+	assert.Equal(t, time.Minute, usecase.backoff(10))
+	assert.Equal(t, time.Minute, usecase.backoff(1000))
+}
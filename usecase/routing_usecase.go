@@ -0,0 +1,124 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/cvzm/go-web-project/domain"
+)
+
+type routingUsecase struct {
+	ruleRepo domain.RoutingRuleRepository
+	channels []string
+	rules    *ttlCache[domain.RoutingRule]
+}
+
+// NewRoutingUsecase creates a new routing use case for the named notification channels,
+// reloading the rules it routes with after the refresh interval
+func NewRoutingUsecase(repo domain.RoutingRuleRepository, channels []string, refreshInterval time.Duration) domain.RoutingUsecase {
+	return &routingUsecase{
+		ruleRepo: repo,
+		channels: channels,
+		rules: newTTLCache(refreshInterval, func(ctx context.Context) ([]domain.RoutingRule, error) {
+			rules, err := repo.FindAll(ctx)
+			if err != nil {
+				return nil, err
+			}
+			// Regexes are compiled once per load rather than for every event
+			for i := range rules {
+				_ = rules[i].Validate()
+			}
+			return rules, nil
+		}),
+	}
+}
+
+func (u *routingUsecase) List(ctx context.Context) ([]domain.RoutingRule, error) {
+	return u.ruleRepo.FindAll(ctx)
+}
+
+func (u *routingUsecase) Get(ctx context.Context, id uint) (domain.RoutingRule, error) {
+	return u.ruleRepo.Find(ctx, id)
+}
+
+func (u *routingUsecase) Create(ctx context.Context, rule *domain.RoutingRule) error {
+	if err := u.validate(rule); err != nil {
+		return err
+	}
+	if err := u.ruleRepo.Create(ctx, rule); err != nil {
+		return err
+	}
+	u.rules.invalidate()
+	return nil
+}
+
+func (u *routingUsecase) Update(ctx context.Context, rule *domain.RoutingRule) error {
+	if err := u.validate(rule); err != nil {
+		return err
+	}
+	if err := u.ruleRepo.Update(ctx, rule); err != nil {
+		return err
+	}
+	u.rules.invalidate()
+	return nil
+}
+
+func (u *routingUsecase) Delete(ctx context.Context, id uint) error {
+	if err := u.ruleRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	u.rules.invalidate()
+	return nil
+}
+
+func (u *routingUsecase) Route(ctx context.Context, event domain.Event) (domain.RoutingResult, error) {
+	rules, err := u.rules.get(ctx)
+	if err != nil {
+		return domain.RoutingResult{}, err
+	}
+	return route(rules, event), nil
+}
+
+func (u *routingUsecase) Test(ctx context.Context, event domain.Event) (domain.RoutingResult, error) {
+	rules, err := u.ruleRepo.FindAll(ctx)
+	if err != nil {
+		return domain.RoutingResult{}, err
+	}
+	return route(rules, event), nil
+}
+
+// validate checks the rule and that its channels are configured
+func (u *routingUsecase) validate(rule *domain.RoutingRule) error {
+	if err := rule.Validate(); err != nil {
+		return err
+	}
+	for _, channel := range rule.Channels {
+		if !slices.Contains(u.channels, channel) {
+			return &domain.ValidationError{Message: fmt.Sprintf("unknown channel %q", channel)}
+		}
+	}
+	return nil
+}
+
+// route evaluates the enabled rules in order until a matching stop rule
+func route(rules []domain.RoutingRule, event domain.Event) domain.RoutingResult {
+	result := domain.RoutingResult{Rules: []domain.RoutingRule{}, Channels: []string{}}
+	for i := range rules {
+		rule := &rules[i]
+		if rule.Disabled || !rule.Matches(event) {
+			continue
+		}
+		result.Rules = append(result.Rules, *rule)
+		for _, channel := range rule.Channels {
+			if !slices.Contains(result.Channels, channel) {
+				result.Channels = append(result.Channels, channel)
+			}
+		}
+		if rule.Stop {
+			break
+		}
+	}
+	return result
+}
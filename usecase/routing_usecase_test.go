@@ -0,0 +1,131 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cvzm/go-web-project/domain"
+	domain_mock "github.com/cvzm/go-web-project/domain/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var routingChannels = []string{"infra", "security", "oncall"}
+
+func TestRoutingUsecase_Create(t *testing.T) {
+	t.Run("Creates a valid rule", func(t *testing.T) {
+		ruleRepo := new(domain_mock.MockRoutingRuleRepository)
+		usecase := NewRoutingUsecase(ruleRepo, routingChannels, time.Minute)
+		rule := &domain.RoutingRule{Name: "EC2 to infra", EventTypes: []string{"EC2_*"}, Channels: []string{"infra"}}
+
+		ruleRepo.On("Create", mock.Anything, rule).Return(nil).Once()
+
+		assert.NoError(t, usecase.Create(context.Background(), rule))
+		ruleRepo.AssertExpectations(t)
+	})
+
+	invalidRules := []struct {
+		name    string
+		rule    domain.RoutingRule
+		message string
+	}{
+		{"Missing name", domain.RoutingRule{Channels: []string{"infra"}}, "name is required"},
+		{"Missing channels", domain.RoutingRule{Name: "rule"}, "at least one channel is required"},
+		{"Unknown channel", domain.RoutingRule{Name: "rule", Channels: []string{"finance"}}, `unknown channel "finance"`},
+		{"Invalid pattern", domain.RoutingRule{Name: "rule", Resources: []string{"arn:[a-"}, Channels: []string{"infra"}}, `invalid pattern "arn:[a-"`},
+		{"Invalid regex", domain.RoutingRule{Name: "rule", DescriptionRegex: "(", Channels: []string{"infra"}}, "invalid description regex: error parsing regexp: missing closing ): `(`"},
+		{"Invalid severity", domain.RoutingRule{Name: "rule", Severities: []string{"high"}, Channels: []string{"infra"}}, `invalid severity "high", expecting info, warning, error or critical`},
+	}
+	for _, tc := range invalidRules {
+		t.Run(tc.name, func(t *testing.T) {
+			ruleRepo := new(domain_mock.MockRoutingRuleRepository)
+			usecase := NewRoutingUsecase(ruleRepo, routingChannels, time.Minute)
+
+			err := usecase.Create(context.Background(), &tc.rule)
+			var validationErr *domain.ValidationError
+			require.ErrorAs(t, err, &validationErr)
+			assert.Equal(t, tc.message, validationErr.Message)
+			ruleRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestRoutingUsecase_Route(t *testing.T) {
+	rules := []domain.RoutingRule{
+		{ID: 1, Name: "Disabled", Disabled: true, Channels: []string{"oncall"}},
+		{ID: 2, Name: "Prod pages", Resources: []string{"arn:aws:*:prod-*"}, Channels: []string{"oncall", "infra"}},
+		{ID: 6, Name: "Critical pages", Severities: []string{"error", "critical"}, Channels: []string{"oncall"}},
+		{ID: 3, Name: "IAM to security", Sources: []string{"AWS"}, EventTypes: []string{"IAM_*"}, Channels: []string{"security"}, Stop: true},
+		{ID: 4, Name: "EC2 to infra", EventTypes: []string{"EC2_*"}, DescriptionRegex: "(?i)failed", Channels: []string{"infra"}},
+		{ID: 5, Name: "Catch-all", Channels: []string{"infra"}},
+	}
+
+	testCases := []struct {
+		name     string
+		event    domain.Event
+		rules    []uint
+		channels []string
+	}{
+		{
+			name:     "Matches by resource and continues",
+			event:    domain.Event{Source: domain.SourceAWS, EventType: "EC2_FAILED", Description: "Instance Failed", AffectedResources: []string{"arn:aws:ec2:prod-web"}},
+			rules:    []uint{2, 4, 5},
+			channels: []string{"oncall", "infra"},
+		},
+		{
+			name:     "Stops at a matching stop rule",
+			event:    domain.Event{Source: domain.SourceAWS, EventType: "IAM_KEY_CREATED", AffectedResources: []string{"arn:aws:iam:prod-user"}},
+			rules:    []uint{2, 3},
+			channels: []string{"oncall", "infra", "security"},
+		},
+		{
+			name:     "Matches by severity",
+			event:    domain.Event{Source: domain.SourceGCP, EventType: "VM_CRASHED", Severity: domain.SeverityCritical},
+			rules:    []uint{6, 5},
+			channels: []string{"oncall", "infra"},
+		},
+		{
+			name:     "Requires every criteria",
+			event:    domain.Event{Source: domain.SourceGCP, EventType: "IAM_KEY_CREATED", Description: "Instance started"},
+			rules:    []uint{5},
+			channels: []string{"infra"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ruleRepo := new(domain_mock.MockRoutingRuleRepository)
+			usecase := NewRoutingUsecase(ruleRepo, routingChannels, time.Minute)
+
+			ruleRepo.On("FindAll", mock.Anything).Return(rules, nil).Once()
+
+			result, err := usecase.Route(context.Background(), tc.event)
+			require.NoError(t, err)
+			ids := []uint{}
+			for _, rule := range result.Rules {
+				ids = append(ids, rule.ID)
+			}
+			assert.Equal(t, tc.rules, ids)
+			assert.Equal(t, tc.channels, result.Channels)
+		})
+	}
+
+	t.Run("Caches the rules until changed", func(t *testing.T) {
+		ruleRepo := new(domain_mock.MockRoutingRuleRepository)
+		usecase := NewRoutingUsecase(ruleRepo, routingChannels, time.Minute)
+		event := domain.Event{Source: domain.SourceAWS, EventType: "EC2_STARTED"}
+
+		ruleRepo.On("FindAll", mock.Anything).Return(rules, nil).Twice()
+		ruleRepo.On("Delete", mock.Anything, uint(5)).Return(nil).Once()
+
+		for i := 0; i < 2; i++ {
+			_, err := usecase.Route(context.Background(), event)
+			require.NoError(t, err)
+		}
+		require.NoError(t, usecase.Delete(context.Background(), 5))
+		_, err := usecase.Route(context.Background(), event)
+		require.NoError(t, err)
+		ruleRepo.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,36 @@
+package usecase
+
+import (
+	"fmt"
+
+	"github.com/cvzm/go-web-project/domain"
+)
+
+type severityClassifier struct {
+	rules           []domain.SeverityRule
+	defaultSeverity domain.Severity
+}
+
+// NewSeverityClassifier creates a new classifier assigning the severity of the first matching rule to the events,
+// and the default severity to those no rule matches
+func NewSeverityClassifier(rules []domain.SeverityRule, defaultSeverity domain.Severity) (domain.EventClassifier, error) {
+	if !defaultSeverity.Valid() {
+		return nil, fmt.Errorf("invalid default severity %q", defaultSeverity)
+	}
+	rules = append([]domain.SeverityRule{}, rules...)
+	for i := range rules {
+		if err := rules[i].Validate(); err != nil {
+			return nil, fmt.Errorf("severity rule %d: %w", i+1, err)
+		}
+	}
+	return &severityClassifier{rules: rules, defaultSeverity: defaultSeverity}, nil
+}
+
+func (c *severityClassifier) Classify(event domain.Event) domain.Severity {
+	for i := range c.rules {
+		if c.rules[i].Matches(event) {
+			return c.rules[i].Severity
+		}
+	}
+	return c.defaultSeverity
+}
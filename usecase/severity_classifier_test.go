@@ -0,0 +1,45 @@
+package usecase
+
+import (
+	"testing"
+
+	"github.com/cvzm/go-web-project/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeverityClassifier_Classify(t *testing.T) {
+	classifier, err := NewSeverityClassifier([]domain.SeverityRule{
+		{Severity: domain.SeverityCritical, EventTypes: []string{"RDS_*"}, DescriptionRegex: "(?i)failover failed"},
+		{Severity: domain.SeverityError, EventTypes: []string{"*_FAILED", "*_TERMINATED"}},
+		{Severity: domain.SeverityWarning, DescriptionRegex: "(?i)degraded"},
+	}, domain.SeverityInfo)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name     string
+		event    domain.Event
+		severity domain.Severity
+	}{
+		{"First matching rule", domain.Event{EventType: "RDS_FAILOVER", Description: "Failover failed on prod-db"}, domain.SeverityCritical},
+		{"Type only rule", domain.Event{EventType: "EC2_TERMINATED"}, domain.SeverityError},
+		{"Description only rule", domain.Event{EventType: "VM_STARTED", Description: "Performance degraded"}, domain.SeverityWarning},
+		{"Default severity", domain.Event{EventType: "RDS_FAILOVER", Description: "Failover completed"}, domain.SeverityInfo},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.severity, classifier.Classify(tc.event))
+		})
+	}
+}
+
+func TestNewSeverityClassifier(t *testing.T) {
+	_, err := NewSeverityClassifier([]domain.SeverityRule{{Severity: "fatal"}}, domain.SeverityInfo)
+	assert.EqualError(t, err, `severity rule 1: invalid severity "fatal", expecting info, warning, error or critical`)
+
+	_, err = NewSeverityClassifier([]domain.SeverityRule{{Severity: domain.SeverityError, DescriptionRegex: "("}}, domain.SeverityInfo)
+	assert.EqualError(t, err, "severity rule 1: invalid description regex: error parsing regexp: missing closing ): `(`")
+
+	_, err = NewSeverityClassifier(nil, "")
+	assert.EqualError(t, err, `invalid default severity ""`)
+}
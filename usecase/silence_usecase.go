@@ -0,0 +1,96 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/cvzm/go-web-project/domain"
+)
+
+type silenceUsecase struct {
+	silenceRepo domain.SilenceRepository
+	silences    *ttlCache[domain.Silence] // silences not ended
+}
+
+// NewSilenceUsecase creates a new silence use case, reloading the silences it checks events with after the
+// refresh interval
+func NewSilenceUsecase(repo domain.SilenceRepository, refreshInterval time.Duration) domain.SilenceUsecase {
+	return &silenceUsecase{
+		silenceRepo: repo,
+		silences: newTTLCache(refreshInterval, func(ctx context.Context) ([]domain.Silence, error) {
+			return repo.FindCurrent(ctx, time.Now())
+		}),
+	}
+}
+
+func (u *silenceUsecase) List(ctx context.Context) ([]domain.Silence, error) {
+	silences, err := u.silenceRepo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for i := range silences {
+		silences[i].Active = silences[i].ActiveAt(now)
+	}
+	return silences, nil
+}
+
+func (u *silenceUsecase) Get(ctx context.Context, id uint) (domain.Silence, error) {
+	silence, err := u.silenceRepo.Find(ctx, id)
+	if err != nil {
+		return silence, err
+	}
+	silence.Active = silence.ActiveAt(time.Now())
+	return silence, nil
+}
+
+func (u *silenceUsecase) Create(ctx context.Context, silence *domain.Silence) error {
+	if err := silence.Validate(); err != nil {
+		return err
+	}
+	if err := u.silenceRepo.Create(ctx, silence); err != nil {
+		return err
+	}
+	u.silences.invalidate()
+	silence.Active = silence.ActiveAt(time.Now())
+	return nil
+}
+
+func (u *silenceUsecase) Update(ctx context.Context, silence *domain.Silence) error {
+	if err := silence.Validate(); err != nil {
+		return err
+	}
+	if err := u.silenceRepo.Update(ctx, silence); err != nil {
+		return err
+	}
+	u.silences.invalidate()
+	return nil
+}
+
+func (u *silenceUsecase) Delete(ctx context.Context, id uint) error {
+	if err := u.silenceRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	u.silences.invalidate()
+	return nil
+}
+
+// Silenced checks the event against the silences active when it is received, rather than when it occurred,
+// so that a maintenance window does not silence events delivered late
+func (u *silenceUsecase) Silenced(ctx context.Context, event domain.Event) (bool, error) {
+	silences, err := u.silences.get(ctx)
+	if err != nil {
+		return false, err
+	}
+	return silenced(silences, event, time.Now()), nil
+}
+
+// silenced reports whether any silence active at the given time matches the event
+func silenced(silences []domain.Silence, event domain.Event, now time.Time) bool {
+	for i := range silences {
+		if silences[i].ActiveAt(now) && silences[i].Matches(event) {
+			return true
+		}
+	}
+	return false
+}
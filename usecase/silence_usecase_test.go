@@ -0,0 +1,152 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cvzm/go-web-project/domain"
+	domain_mock "github.com/cvzm/go-web-project/domain/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSilenceUsecase_Create(t *testing.T) {
+	startsAt := time.Now().Add(-time.Hour)
+	endsAt := startsAt.Add(2 * time.Hour)
+
+	t.Run("Creates a valid silence", func(t *testing.T) {
+		silenceRepo := new(domain_mock.MockSilenceRepository)
+		usecase := NewSilenceUsecase(silenceRepo, time.Minute)
+		silence := &domain.Silence{Sources: []string{"AWS"}, StartsAt: startsAt, EndsAt: &endsAt, CreatedBy: "alice", Comment: "RDS upgrade"}
+
+		silenceRepo.On("Create", mock.Anything, silence).Return(nil).Once()
+
+		assert.NoError(t, usecase.Create(context.Background(), silence))
+		assert.True(t, silence.Active)
+		silenceRepo.AssertExpectations(t)
+	})
+
+	t.Run("Creates a recurring maintenance window", func(t *testing.T) {
+		silenceRepo := new(domain_mock.MockSilenceRepository)
+		usecase := NewSilenceUsecase(silenceRepo, time.Minute)
+		silence := &domain.Silence{
+			Resources:  []string{"arn:aws:rds:*"},
+			StartsAt:   startsAt,
+			Recurrence: domain.SilenceRecurrence{Days: []string{"sun"}, Start: "02:00", Duration: 120, Timezone: "Europe/Paris"},
+			CreatedBy:  "alice",
+		}
+
+		silenceRepo.On("Create", mock.Anything, silence).Return(nil).Once()
+
+		assert.NoError(t, usecase.Create(context.Background(), silence))
+		assert.Equal(t, []string{"SUN"}, []string(silence.Recurrence.Days))
+		silenceRepo.AssertExpectations(t)
+	})
+
+	invalidSilences := []struct {
+		name    string
+		silence domain.Silence
+		message string
+	}{
+		{"Missing creator", domain.Silence{Sources: []string{"AWS"}, StartsAt: startsAt, EndsAt: &endsAt}, "created_by is required"},
+		{"Missing matchers", domain.Silence{StartsAt: startsAt, EndsAt: &endsAt, CreatedBy: "alice"}, "at least one matcher of sources, event_types or resources is required"},
+		{"Invalid pattern", domain.Silence{Resources: []string{"arn:[a-"}, StartsAt: startsAt, EndsAt: &endsAt, CreatedBy: "alice"}, `invalid pattern "arn:[a-"`},
+		{"Missing end", domain.Silence{Sources: []string{"AWS"}, StartsAt: startsAt, CreatedBy: "alice"}, "ends_at is required unless the silence has a recurrence"},
+		{"End before start", domain.Silence{Sources: []string{"AWS"}, StartsAt: endsAt, EndsAt: &startsAt, CreatedBy: "alice"}, "ends_at must be after starts_at"},
+		{"Recurrence without day", domain.Silence{Sources: []string{"AWS"}, StartsAt: startsAt, EndsAt: &endsAt, Recurrence: domain.SilenceRecurrence{Start: "02:00", Duration: 60}, CreatedBy: "alice"}, "recurrence requires at least one day"},
+		{"Invalid day", domain.Silence{Sources: []string{"AWS"}, StartsAt: startsAt, Recurrence: domain.SilenceRecurrence{Days: []string{"SUNDAY"}, Start: "02:00", Duration: 60}, CreatedBy: "alice"}, `invalid recurrence day "SUNDAY", expecting SUN to SAT`},
+		{"Invalid start", domain.Silence{Sources: []string{"AWS"}, StartsAt: startsAt, Recurrence: domain.SilenceRecurrence{Days: []string{"SUN"}, Start: "2am", Duration: 60}, CreatedBy: "alice"}, "recurrence start must be a time of day, e.g. 02:00"},
+		{"Invalid duration", domain.Silence{Sources: []string{"AWS"}, StartsAt: startsAt, Recurrence: domain.SilenceRecurrence{Days: []string{"SUN"}, Start: "02:00"}, CreatedBy: "alice"}, "recurrence duration must be between 1 and 10080 minutes"},
+		{"Invalid timezone", domain.Silence{Sources: []string{"AWS"}, StartsAt: startsAt, Recurrence: domain.SilenceRecurrence{Days: []string{"SUN"}, Start: "02:00", Duration: 60, Timezone: "Mars/Olympus"}, CreatedBy: "alice"}, `invalid recurrence timezone "Mars/Olympus"`},
+	}
+	for _, tc := range invalidSilences {
+		t.Run(tc.name, func(t *testing.T) {
+			silenceRepo := new(domain_mock.MockSilenceRepository)
+			usecase := NewSilenceUsecase(silenceRepo, time.Minute)
+
+			err := usecase.Create(context.Background(), &tc.silence)
+			var validationErr *domain.ValidationError
+			require.ErrorAs(t, err, &validationErr)
+			assert.Equal(t, tc.message, validationErr.Message)
+			silenceRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestSilenceUsecase_Silenced(t *testing.T) {
+	startsAt := time.Now().Add(-time.Hour)
+	endsAt := startsAt.Add(2 * time.Hour)
+	silences := []domain.Silence{
+		{ID: 1, Sources: []string{"AWS"}, EventTypes: []string{"EC2_*"}, StartsAt: startsAt, EndsAt: &endsAt, CreatedBy: "alice"},
+	}
+	ec2Event := domain.Event{Source: domain.SourceAWS, EventType: "EC2_STOPPED"}
+	rdsEvent := domain.Event{Source: domain.SourceAWS, EventType: "RDS_FAILOVER"}
+
+	t.Run("Caches the silences until invalidated", func(t *testing.T) {
+		silenceRepo := new(domain_mock.MockSilenceRepository)
+		usecase := NewSilenceUsecase(silenceRepo, time.Minute)
+
+		silenceRepo.On("FindCurrent", mock.Anything, mock.AnythingOfType("time.Time")).Return(silences, nil).Twice()
+		silenceRepo.On("Delete", mock.Anything, uint(1)).Return(nil).Once()
+
+		isSilenced, err := usecase.Silenced(context.Background(), ec2Event)
+		require.NoError(t, err)
+		assert.True(t, isSilenced)
+		isSilenced, err = usecase.Silenced(context.Background(), rdsEvent)
+		require.NoError(t, err)
+		assert.False(t, isSilenced)
+
+		require.NoError(t, usecase.Delete(context.Background(), 1))
+		_, err = usecase.Silenced(context.Background(), ec2Event)
+		require.NoError(t, err)
+		silenceRepo.AssertExpectations(t)
+	})
+
+	t.Run("Fails when the silences cannot be loaded", func(t *testing.T) {
+		silenceRepo := new(domain_mock.MockSilenceRepository)
+		usecase := NewSilenceUsecase(silenceRepo, time.Minute)
+
+		silenceRepo.On("FindCurrent", mock.Anything, mock.Anything).Return([]domain.Silence(nil), errors.New("db down")).Once()
+
+		_, err := usecase.Silenced(context.Background(), ec2Event)
+		assert.EqualError(t, err, "db down")
+	})
+}
+
+func TestSilenced(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	require.NoError(t, err)
+	startsAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	endsAt := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	prodEvent := domain.Event{Source: domain.SourceAWS, EventType: "RDS_FAILOVER", AffectedResources: []string{"arn:aws:rds:prod-db"}}
+	// Saturday 23:00 to Sunday 01:00 in Paris, every week from January to May
+	window := domain.Silence{
+		Resources:  []string{"arn:aws:rds:prod-*"},
+		StartsAt:   startsAt,
+		EndsAt:     &endsAt,
+		Recurrence: domain.SilenceRecurrence{Days: []string{"SAT"}, Start: "23:00", Duration: 120, Timezone: "Europe/Paris"},
+	}
+
+	testCases := []struct {
+		name     string
+		event    domain.Event
+		now      time.Time
+		silenced bool
+	}{
+		{"Within the window", prodEvent, time.Date(2024, 3, 2, 23, 30, 0, 0, paris), true},
+		{"Within the window past midnight", prodEvent, time.Date(2024, 3, 3, 0, 59, 0, 0, paris), true},
+		{"At the end of the window", prodEvent, time.Date(2024, 3, 3, 1, 0, 0, 0, paris), false},
+		{"Before the window", prodEvent, time.Date(2024, 3, 2, 22, 59, 0, 0, paris), false},
+		{"Another day", prodEvent, time.Date(2024, 3, 5, 23, 30, 0, 0, paris), false},
+		{"After the last occurrence", prodEvent, time.Date(2024, 6, 1, 23, 30, 0, 0, paris), false},
+		{"Unmatched resource", domain.Event{Source: domain.SourceAWS, AffectedResources: []string{"arn:aws:rds:staging-db"}}, time.Date(2024, 3, 2, 23, 30, 0, 0, paris), false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.silenced, silenced([]domain.Silence{window}, tc.event, tc.now))
+		})
+	}
+}
@@ -0,0 +1,244 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/cvzm/go-web-project/domain"
+)
+
+// WebhookConfig is webhook delivery related configuration
+type WebhookConfig struct {
+	BatchSize    int           // maximum number of deliveries sent by a single dispatch
+	MaxAttempts  int           // number of attempts after which a delivery is given up
+	RetryDelay   time.Duration // delay before retrying a failed delivery, doubled for each further attempt
+	MaxBackoff   time.Duration // maximum delay between two attempts, unlimited if zero
+	DisableAfter int           // consecutive failures after which a subscription is disabled, never if zero
+}
+
+type webhookUsecase struct {
+	subscriptionRepo domain.WebhookSubscriptionRepository
+	deliveryRepo     domain.WebhookDeliveryRepository
+	txManager        domain.TransactionManager
+	sender           domain.WebhookSender
+	conf             WebhookConfig
+}
+
+// NewWebhookUsecase creates a new webhook use case sending the deliveries with the sender
+func NewWebhookUsecase(subscriptionRepo domain.WebhookSubscriptionRepository, deliveryRepo domain.WebhookDeliveryRepository, txManager domain.TransactionManager, sender domain.WebhookSender, conf WebhookConfig) domain.WebhookUsecase {
+	return &webhookUsecase{
+		subscriptionRepo: subscriptionRepo,
+		deliveryRepo:     deliveryRepo,
+		txManager:        txManager,
+		sender:           sender,
+		conf:             conf,
+	}
+}
+
+func (u *webhookUsecase) List(ctx context.Context) ([]domain.WebhookSubscription, error) {
+	subscriptions, err := u.subscriptionRepo.FindAll(ctx)
+	for i := range subscriptions {
+		subscriptions[i].Secret = ""
+	}
+	return subscriptions, err
+}
+
+func (u *webhookUsecase) Get(ctx context.Context, id uint) (domain.WebhookSubscription, error) {
+	subscription, err := u.subscriptionRepo.Find(ctx, id)
+	subscription.Secret = ""
+	return subscription, err
+}
+
+func (u *webhookUsecase) Create(ctx context.Context, subscription *domain.WebhookSubscription) error {
+	if err := subscription.Validate(); err != nil {
+		return err
+	}
+	if subscription.Secret == "" {
+		secret, err := newWebhookSecret()
+		if err != nil {
+			return err
+		}
+		subscription.Secret = secret
+	}
+	subscription.ConsecutiveFailures = 0
+	subscription.DisabledReason = ""
+	return u.subscriptionRepo.Create(ctx, subscription)
+}
+
+func (u *webhookUsecase) Update(ctx context.Context, subscription *domain.WebhookSubscription) error {
+	if err := subscription.Validate(); err != nil {
+		return err
+	}
+	existing, err := u.subscriptionRepo.Find(ctx, subscription.ID)
+	if err != nil {
+		return err
+	}
+	if subscription.Secret == "" {
+		subscription.Secret = existing.Secret
+	}
+	subscription.ConsecutiveFailures = existing.ConsecutiveFailures
+	subscription.DisabledReason = ""
+	if subscription.Disabled {
+		subscription.DisabledReason = existing.DisabledReason
+	} else if existing.Disabled {
+		subscription.ConsecutiveFailures = 0
+	}
+	if err := u.subscriptionRepo.Update(ctx, subscription); err != nil {
+		return err
+	}
+	subscription.Secret = ""
+	return nil
+}
+
+func (u *webhookUsecase) Delete(ctx context.Context, id uint) error {
+	return u.subscriptionRepo.Delete(ctx, id)
+}
+
+func (u *webhookUsecase) Enqueue(ctx context.Context, repos domain.Repositories, event domain.Event) error {
+	subscriptions, err := repos.WebhookSubscriptions().FindEnabled(ctx)
+	if err != nil {
+		return err
+	}
+
+	deliveries := []*domain.WebhookDelivery{}
+	for _, subscription := range subscriptions {
+		if !subscription.Matches(event) {
+			continue
+		}
+		delivery, err := domain.NewWebhookDelivery(subscription.ID, event)
+		if err != nil {
+			return err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return repos.WebhookDeliveries().Add(ctx, deliveries...)
+}
+
+func (u *webhookUsecase) Deliveries(ctx context.Context, subscriptionID uint, query domain.WebhookDeliveryQuery) ([]domain.WebhookDelivery, error) {
+	if _, err := u.subscriptionRepo.Find(ctx, subscriptionID); err != nil {
+		return nil, err
+	}
+	return u.deliveryRepo.FindBySubscription(ctx, subscriptionID, query)
+}
+
+func (u *webhookUsecase) Redeliver(ctx context.Context, subscriptionID, deliveryID uint) (domain.WebhookDelivery, error) {
+	original, err := u.deliveryRepo.Find(ctx, deliveryID)
+	if err != nil {
+		return domain.WebhookDelivery{}, err
+	}
+	if original.SubscriptionID != subscriptionID {
+		return domain.WebhookDelivery{}, domain.ErrNotFound
+	}
+
+	// The original delivery is left as is in the delivery log
+	delivery := domain.WebhookDelivery{
+		SubscriptionID: original.SubscriptionID,
+		EventID:        original.EventID,
+		EventType:      original.EventType,
+		Payload:        original.Payload,
+		Status:         domain.WebhookDeliveryPending,
+		NextAttemptAt:  time.Now(),
+	}
+	if err := u.deliveryRepo.Add(ctx, &delivery); err != nil {
+		return domain.WebhookDelivery{}, err
+	}
+	return delivery, nil
+}
+
+// Dispatch sends the due deliveries with the same locking and delivery semantics as outboxUsecase.Relay,
+// subscribers deduplicating on the event ID.
+func (u *webhookUsecase) Dispatch(ctx context.Context) (domain.WebhookDispatchResult, error) {
+	var result domain.WebhookDispatchResult
+	err := u.txManager.Transaction(ctx, func(ctx context.Context, repos domain.Repositories) error {
+		result = domain.WebhookDispatchResult{}
+		deliveries, err := repos.WebhookDeliveries().FindDue(ctx, time.Now(), u.conf.BatchSize)
+		if err != nil || len(deliveries) == 0 {
+			return err
+		}
+		enabled, err := repos.WebhookSubscriptions().FindEnabled(ctx)
+		if err != nil {
+			return err
+		}
+		subscriptions := make(map[uint]domain.WebhookSubscription, len(enabled))
+		for _, subscription := range enabled {
+			subscriptions[subscription.ID] = subscription
+		}
+
+		for _, delivery := range deliveries {
+			// The subscription may have been disabled by a previous delivery of the batch
+			subscription, ok := subscriptions[delivery.SubscriptionID]
+			if !ok {
+				continue
+			}
+
+			status, sendErr := u.sender.Send(ctx, subscription, delivery)
+			delivery.Attempts++
+			delivery.ResponseStatus = status
+			if sendErr == nil {
+				deliveredAt := time.Now()
+				delivery.Status = domain.WebhookDeliverySucceeded
+				delivery.DeliveredAt = &deliveredAt
+				delivery.LastError = ""
+				if err := repos.WebhookDeliveries().Update(ctx, &delivery); err != nil {
+					return err
+				}
+				if subscription.ConsecutiveFailures > 0 {
+					if err := repos.WebhookSubscriptions().RecordSuccess(ctx, subscription.ID); err != nil {
+						return err
+					}
+					subscription.ConsecutiveFailures = 0
+					subscriptions[subscription.ID] = subscription
+				}
+				result.Succeeded = append(result.Succeeded, delivery)
+				continue
+			}
+
+			delivery.LastError = sendErr.Error()
+			if delivery.Attempts >= u.conf.MaxAttempts {
+				delivery.Status = domain.WebhookDeliveryFailed
+			} else {
+				delivery.NextAttemptAt = time.Now().Add(retryBackoff(u.conf.RetryDelay, u.conf.MaxBackoff, delivery.Attempts))
+			}
+			if err := repos.WebhookDeliveries().Update(ctx, &delivery); err != nil {
+				return err
+			}
+			result.Failed = append(result.Failed, delivery)
+
+			reason := fmt.Sprintf("disabled after %d consecutive failures, last: %s", u.conf.DisableAfter, delivery.LastError)
+			disabled, err := repos.WebhookSubscriptions().RecordFailure(ctx, subscription.ID, u.conf.DisableAfter, reason)
+			if err != nil {
+				return err
+			}
+			subscription.ConsecutiveFailures++
+			subscriptions[subscription.ID] = subscription
+			if disabled {
+				delete(subscriptions, subscription.ID)
+				subscription.Disabled = true
+				subscription.DisabledReason = reason
+				subscription.Secret = ""
+				result.Disabled = append(result.Disabled, subscription)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return domain.WebhookDispatchResult{}, err
+	}
+	return result, nil
+}
+
+func (u *webhookUsecase) Purge(ctx context.Context, before time.Time) (int64, error) {
+	return u.deliveryRepo.DeleteFinished(ctx, before)
+}
+
+// newWebhookSecret returns a random secret for signing deliveries
+func newWebhookSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(secret), nil
+}
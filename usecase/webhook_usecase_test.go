@@ -0,0 +1,182 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cvzm/go-web-project/domain"
+	domain_mock "github.com/cvzm/go-web-project/domain/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var webhookConfig = WebhookConfig{BatchSize: 10, MaxAttempts: 3, RetryDelay: time.Second, MaxBackoff: time.Minute, DisableAfter: 5}
+
+func TestWebhookUsecase_Create(t *testing.T) {
+	t.Run("Generates the secret", func(t *testing.T) {
+		subscriptionRepo := new(domain_mock.MockWebhookSubscriptionRepository)
+		usecase := NewWebhookUsecase(subscriptionRepo, nil, nil, nil, webhookConfig)
+		subscription := &domain.WebhookSubscription{Name: "billing", URL: "https://billing.internal/hooks"}
+
+		subscriptionRepo.On("Create", mock.Anything, subscription).Return(nil).Once()
+
+		require.NoError(t, usecase.Create(context.Background(), subscription))
+		assert.True(t, strings.HasPrefix(subscription.Secret, "whsec_"))
+		assert.Len(t, subscription.Secret, len("whsec_")+64)
+		subscriptionRepo.AssertExpectations(t)
+	})
+
+	t.Run("Rejects an invalid URL", func(t *testing.T) {
+		subscriptionRepo := new(domain_mock.MockWebhookSubscriptionRepository)
+		usecase := NewWebhookUsecase(subscriptionRepo, nil, nil, nil, webhookConfig)
+
+		err := usecase.Create(context.Background(), &domain.WebhookSubscription{Name: "billing", URL: "billing.internal/hooks"})
+		var validationErr *domain.ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, "url must be an absolute http or https URL", validationErr.Message)
+		subscriptionRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+}
+
+func TestWebhookUsecase_Update(t *testing.T) {
+	subscriptionRepo := new(domain_mock.MockWebhookSubscriptionRepository)
+	usecase := NewWebhookUsecase(subscriptionRepo, nil, nil, nil, webhookConfig)
+	existing := domain.WebhookSubscription{ID: 3, Name: "billing", URL: "https://billing.internal/hooks", Secret: "whsec_1",
+		Disabled: true, DisabledReason: "disabled after 5 consecutive failures", ConsecutiveFailures: 5}
+
+	subscriptionRepo.On("Find", mock.Anything, uint(3)).Return(existing, nil).Once()
+	subscriptionRepo.On("Update", mock.Anything, mock.MatchedBy(func(s *domain.WebhookSubscription) bool {
+		return s.Secret == "whsec_1" && !s.Disabled && s.ConsecutiveFailures == 0 && s.DisabledReason == ""
+	})).Return(nil).Once()
+
+	// Enabling the subscription again keeps its secret and resets its failures
+	subscription := &domain.WebhookSubscription{ID: 3, Name: "billing", URL: "https://billing.internal/v2/hooks"}
+	require.NoError(t, usecase.Update(context.Background(), subscription))
+	assert.Empty(t, subscription.Secret)
+	subscriptionRepo.AssertExpectations(t)
+}
+
+func TestWebhookUsecase_Enqueue(t *testing.T) {
+	subscriptionRepo := new(domain_mock.MockWebhookSubscriptionRepository)
+	deliveryRepo := new(domain_mock.MockWebhookDeliveryRepository)
+	repos := &domain_mock.MockRepositories{WebhookSubscriptionRepo: subscriptionRepo, WebhookDeliveryRepo: deliveryRepo}
+	usecase := NewWebhookUsecase(nil, nil, nil, nil, webhookConfig)
+	event := domain.Event{ID: 42, Source: domain.SourceAWS, EventType: "EC2_STARTED"}
+
+	subscriptionRepo.On("FindEnabled", mock.Anything).Return([]domain.WebhookSubscription{
+		{ID: 1, EventTypes: []string{"EC2_*"}},
+		{ID: 2, Sources: []string{"GCP"}},
+		{ID: 3},
+	}, nil).Once()
+	deliveryRepo.On("Add", mock.Anything, mock.MatchedBy(func(deliveries []*domain.WebhookDelivery) bool {
+		return len(deliveries) == 2 && deliveries[0].SubscriptionID == 1 && deliveries[1].SubscriptionID == 3 &&
+			deliveries[0].EventID == 42 && deliveries[0].Status == domain.WebhookDeliveryPending
+	})).Return(nil).Once()
+
+	require.NoError(t, usecase.Enqueue(context.Background(), repos, event))
+	deliveryRepo.AssertExpectations(t)
+}
+
+func TestWebhookUsecase_Redeliver(t *testing.T) {
+	t.Run("Records a new delivery", func(t *testing.T) {
+		deliveryRepo := new(domain_mock.MockWebhookDeliveryRepository)
+		usecase := NewWebhookUsecase(nil, deliveryRepo, nil, nil, webhookConfig)
+		original := domain.WebhookDelivery{ID: 7, SubscriptionID: 3, EventID: 42, EventType: domain.OutboxEventCreated,
+			Payload: []byte(`{"id":42}`), Status: domain.WebhookDeliveryFailed, Attempts: 3}
+
+		deliveryRepo.On("Find", mock.Anything, uint(7)).Return(original, nil).Once()
+		deliveryRepo.On("Add", mock.Anything, mock.MatchedBy(func(deliveries []*domain.WebhookDelivery) bool {
+			return deliveries[0].ID == 0 && deliveries[0].Attempts == 0 && deliveries[0].Status == domain.WebhookDeliveryPending &&
+				string(deliveries[0].Payload) == `{"id":42}`
+		})).Return(nil).Once()
+
+		delivery, err := usecase.Redeliver(context.Background(), 3, 7)
+		require.NoError(t, err)
+		assert.Equal(t, uint(42), delivery.EventID)
+		deliveryRepo.AssertExpectations(t)
+	})
+
+	t.Run("Fails for a delivery of another subscription", func(t *testing.T) {
+		deliveryRepo := new(domain_mock.MockWebhookDeliveryRepository)
+		usecase := NewWebhookUsecase(nil, deliveryRepo, nil, nil, webhookConfig)
+
+		deliveryRepo.On("Find", mock.Anything, uint(7)).Return(domain.WebhookDelivery{ID: 7, SubscriptionID: 4}, nil).Once()
+
+		_, err := usecase.Redeliver(context.Background(), 3, 7)
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+	})
+}
+
+func TestWebhookUsecase_Dispatch(t *testing.T) {
+	subscriptions := []domain.WebhookSubscription{
+		{ID: 1, URL: "https://billing.internal/hooks", ConsecutiveFailures: 2},
+		{ID: 2, URL: "https://audit.internal/hooks", ConsecutiveFailures: 4},
+	}
+	deliveries := []domain.WebhookDelivery{
+		{ID: 10, SubscriptionID: 1, Status: domain.WebhookDeliveryPending},
+		{ID: 11, SubscriptionID: 2, Status: domain.WebhookDeliveryPending, Attempts: 2},
+		{ID: 12, SubscriptionID: 2, Status: domain.WebhookDeliveryPending},
+	}
+
+	subscriptionRepo := new(domain_mock.MockWebhookSubscriptionRepository)
+	deliveryRepo := new(domain_mock.MockWebhookDeliveryRepository)
+	txManager := &domain_mock.MockTransactionManager{Repositories: &domain_mock.MockRepositories{WebhookSubscriptionRepo: subscriptionRepo, WebhookDeliveryRepo: deliveryRepo}}
+	sender := new(domain_mock.MockWebhookSender)
+	usecase := NewWebhookUsecase(subscriptionRepo, deliveryRepo, txManager, sender, webhookConfig)
+
+	txManager.On("Transaction", mock.Anything).Return(nil).Once()
+	deliveryRepo.On("FindDue", mock.Anything, mock.AnythingOfType("time.Time"), 10).Return(deliveries, nil).Once()
+	subscriptionRepo.On("FindEnabled", mock.Anything).Return(subscriptions, nil).Once()
+	sender.On("Send", mock.Anything, subscriptions[0], deliveries[0]).Return(200, nil).Once()
+	sender.On("Send", mock.Anything, subscriptions[1], deliveries[1]).Return(500, errors.New("subscriber responded 500")).Once()
+	deliveryRepo.On("Update", mock.Anything, mock.MatchedBy(func(d *domain.WebhookDelivery) bool {
+		return d.ID == 10 && d.Status == domain.WebhookDeliverySucceeded && d.DeliveredAt != nil && d.Attempts == 1
+	})).Return(nil).Once()
+	subscriptionRepo.On("RecordSuccess", mock.Anything, uint(1)).Return(nil).Once()
+	// The third attempt gives up the delivery
+	deliveryRepo.On("Update", mock.Anything, mock.MatchedBy(func(d *domain.WebhookDelivery) bool {
+		return d.ID == 11 && d.Status == domain.WebhookDeliveryFailed && d.ResponseStatus == 500 && d.Attempts == 3
+	})).Return(nil).Once()
+	subscriptionRepo.On("RecordFailure", mock.Anything, uint(2), 5, "disabled after 5 consecutive failures, last: subscriber responded 500").Return(true, nil).Once()
+
+	result, err := usecase.Dispatch(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, result.Succeeded, 1)
+	assert.Len(t, result.Failed, 1)
+	// The last delivery is left pending once its subscription is disabled
+	require.Len(t, result.Disabled, 1)
+	assert.Equal(t, uint(2), result.Disabled[0].ID)
+	sender.AssertNotCalled(t, "Send", mock.Anything, mock.Anything, deliveries[2])
+	mock.AssertExpectationsForObjects(t, txManager, subscriptionRepo, deliveryRepo, sender)
+}
+
+func TestWebhookUsecase_Dispatch_Retry(t *testing.T) {
+	subscriptionRepo := new(domain_mock.MockWebhookSubscriptionRepository)
+	deliveryRepo := new(domain_mock.MockWebhookDeliveryRepository)
+	txManager := &domain_mock.MockTransactionManager{Repositories: &domain_mock.MockRepositories{WebhookSubscriptionRepo: subscriptionRepo, WebhookDeliveryRepo: deliveryRepo}}
+	sender := new(domain_mock.MockWebhookSender)
+	usecase := NewWebhookUsecase(subscriptionRepo, deliveryRepo, txManager, sender, webhookConfig)
+	subscription := domain.WebhookSubscription{ID: 1}
+	delivery := domain.WebhookDelivery{ID: 10, SubscriptionID: 1, Status: domain.WebhookDeliveryPending, Attempts: 1}
+
+	txManager.On("Transaction", mock.Anything).Return(nil).Once()
+	deliveryRepo.On("FindDue", mock.Anything, mock.AnythingOfType("time.Time"), 10).Return([]domain.WebhookDelivery{delivery}, nil).Once()
+	subscriptionRepo.On("FindEnabled", mock.Anything).Return([]domain.WebhookSubscription{subscription}, nil).Once()
+	sender.On("Send", mock.Anything, subscription, delivery).Return(0, errors.New("connection refused")).Once()
+	start := time.Now()
+	deliveryRepo.On("Update", mock.Anything, mock.MatchedBy(func(d *domain.WebhookDelivery) bool {
+		// The second attempt is retried after twice the retry delay
+		return d.Status == domain.WebhookDeliveryPending && d.Attempts == 2 && !d.NextAttemptAt.Before(start.Add(2*time.Second))
+	})).Return(nil).Once()
+	subscriptionRepo.On("RecordFailure", mock.Anything, uint(1), 5, mock.Anything).Return(false, nil).Once()
+
+	result, err := usecase.Dispatch(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, result.Failed, 1)
+	assert.Empty(t, result.Disabled)
+	mock.AssertExpectationsForObjects(t, deliveryRepo, subscriptionRepo)
+}